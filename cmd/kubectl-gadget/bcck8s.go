@@ -1,32 +1,49 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 
-	"github.com/kinvolk/inspektor-gadget/pkg/k8sutil"
+	"github.com/kinvolk/inspektor-gadget/pkg/commfilter"
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+	"github.com/kinvolk/inspektor-gadget/pkg/eventseq"
+	"github.com/kinvolk/inspektor-gadget/pkg/fieldmap"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnsconnect"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/execsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/hostpathsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/restartsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpping"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpsubnet"
+	"github.com/kinvolk/inspektor-gadget/pkg/histogram"
+	"github.com/kinvolk/inspektor-gadget/pkg/logfmt"
+	"github.com/kinvolk/inspektor-gadget/pkg/peerfilter"
+	"github.com/kinvolk/inspektor-gadget/pkg/podinformer"
 )
 
 var execsnoopCmd = &cobra.Command{
 	Use:               "execsnoop",
 	Short:             "Trace new processes",
-	Run:               bccCmd("execsnoop", "/usr/share/bcc/tools/execsnoop"),
+	Run:               bccCmd("execsnoop", "/opt/bcck8s/execsnoop"),
 	PersistentPreRunE: doesKubeconfigExist,
 }
 
@@ -68,13 +85,134 @@ var tcpconnectCmd = &cobra.Command{
 var tcptracerCmd = &cobra.Command{
 	Use:               "tcptracer",
 	Short:             "trace tcp connect, accept and close",
-	Run:               bccCmd("tcptracer", "/usr/share/bcc/tools/tcptracer"),
+	Run:               bccCmd("tcptracer", "/bin/tcptracer"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var tcpconnlatCmd = &cobra.Command{
+	Use:               "tcpconnlat",
+	Short:             "Trace TCP connection latency",
+	Run:               bccCmd("tcpconnlat", "/opt/bcck8s/tcpconnlat"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var ugidsnoopCmd = &cobra.Command{
+	Use:               "ugidsnoop",
+	Short:             "Trace credential changes (setuid, setgid, capset...)",
+	Run:               bccCmd("ugidsnoop", "/opt/bcck8s/ugidsnoop"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var restartsnoopCmd = &cobra.Command{
+	Use:               "restartsnoop",
+	Short:             "Explain why containers restart",
+	Run:               bccCmd("restartsnoop", "/opt/bcck8s/restartsnoop"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var cachestatCmd = &cobra.Command{
+	Use:               "cachestat",
+	Short:             "Show page cache hits and misses",
+	Run:               bccCmd("cachestat", "/opt/bcck8s/cachestat"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var tcpsubnetCmd = &cobra.Command{
+	Use:               "tcpsubnet",
+	Short:             "Show the TCP traffic by destination subnet",
+	Run:               bccCmd("tcpsubnet", "/opt/bcck8s/tcpsubnet"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var swapinCmd = &cobra.Command{
+	Use:               "swapin",
+	Short:             "Trace page faults served from swap",
+	Run:               bccCmd("swapin", "/opt/bcck8s/swapin"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var tcppingCmd = &cobra.Command{
+	Use:               "tcpping",
+	Short:             "Estimate the application latency of TCP connections",
+	Run:               bccCmd("tcpping", "/opt/bcck8s/tcpping"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var killsnoopCmd = &cobra.Command{
+	Use:               "killsnoop",
+	Short:             "Trace SIGKILL and SIGTERM sent to containers",
+	Run:               bccCmd("killsnoop", "/opt/bcck8s/killsnoop"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var hostpathsnoopCmd = &cobra.Command{
+	Use:               "hostpathsnoop",
+	Short:             "Trace the files opened by containers under paths of the host",
+	Run:               bccCmd("hostpathsnoop", "/opt/bcck8s/hostpathsnoop"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var dnsconnectCmd = &cobra.Command{
+	Use:               "dnsconnect",
+	Short:             "Trace TCP connections with the DNS names resolved by the processes",
+	Run:               bccCmd("dnsconnect", "/opt/bcck8s/dnsconnect"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var dnssnoopCmd = &cobra.Command{
+	Use:               "dnssnoop",
+	Aliases:           []string{"dns"},
+	Short:             "Trace DNS queries and responses over UDP and TCP, with their flags and EDNS options",
+	Run:               bccCmd("dnssnoop", "/opt/bcck8s/dnssnoop"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var solistenCmd = &cobra.Command{
+	Use:               "solisten",
+	Short:             "Trace TCP sockets starting to listen",
+	Run:               bccCmd("solisten", "/opt/bcck8s/solisten"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var biosnoopCmd = &cobra.Command{
+	Use:               "biosnoop",
+	Short:             "Trace block device I/O",
+	Run:               bccCmd("biosnoop", "/opt/bcck8s/biosnoop"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var ttysnoopCmd = &cobra.Command{
+	Use:               "ttysnoop",
+	Short:             "Mirror the output written to terminals by containers, needs --confirm-capture",
+	Run:               bccCmd("ttysnoop", "/opt/bcck8s/ttysnoop"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var nfsslowerCmd = &cobra.Command{
+	Use:               "nfsslower",
+	Short:             "Trace the NFS operations slower than --min-latency",
+	Run:               bccCmd("nfsslower", "/opt/bcck8s/nfsslower"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var bpfmetricsCmd = &cobra.Command{
+	Use:               "bpfmetrics",
+	Short:             "Show the run count and run time of the BPF programs of the gadgets",
+	Run:               bccCmd("bpfmetrics", "/opt/bcck8s/bpfmetrics"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var netqtopCmd = &cobra.Command{
+	Use:               "netqtop",
+	Short:             "Show the packets and bytes of each queue of the network devices of the nodes",
+	Run:               bccCmd("netqtop", "/opt/bcck8s/netqtop"),
 	PersistentPreRunE: doesKubeconfigExist,
 }
 
 var capabilitiesCmd = &cobra.Command{
 	Use:               "capabilities",
 	Short:             "Suggest Security Capabilities for securityContext",
+	PreRun:            deprecateCapabilitiesVerbose,
 	Run:               bccCmd("capabilities", "/usr/share/bcc/tools/capable"),
 	PersistentPreRunE: doesKubeconfigExist,
 }
@@ -84,13 +222,26 @@ var (
 	nodeParam      string
 	namespaceParam string
 	podnameParam   string
+	podUIDParam    string
+	outputDirParam string
 
-	stackFlag   bool
-	uniqueFlag  bool
-	verboseFlag bool
+	stackFlag           bool
+	uniqueFlag          bool
+	includeNonAuditFlag bool
 
 	profileKernel bool
 	profileUser   bool
+
+	jsonOutput     bool
+	heartbeatParam time.Duration
+	podStatusFlag  bool
+	showAgeFlag    bool
+	showCgroupFlag bool
+
+	emitPartialFlag   bool
+	noEmitPartialFlag bool
+
+	includeSelfFlag bool
 )
 
 func init() {
@@ -102,32 +253,177 @@ func init() {
 		tcptopCmd,
 		tcpconnectCmd,
 		tcptracerCmd,
+		tcpconnlatCmd,
+		ugidsnoopCmd,
+		cachestatCmd,
+		tcpsubnetCmd,
+		swapinCmd,
+		tcppingCmd,
+		killsnoopCmd,
+		hostpathsnoopCmd,
+		solistenCmd,
+		dnsconnectCmd,
+		dnssnoopCmd,
+		biosnoopCmd,
+		ttysnoopCmd,
+		nfsslowerCmd,
+		restartsnoopCmd,
+		bpfmetricsCmd,
+		netqtopCmd,
 		capabilitiesCmd,
 	}
-	args := []string{"label", "node", "namespace", "podname"}
-	vars := []*string{&labelParam, &nodeParam, &namespaceParam, &podnameParam}
+	args := []string{"label", "node", "podname"}
+	vars := []*string{&labelParam, &nodeParam, &podnameParam}
+	// -l like kubectl and traceloop list
+	shorthands := []string{"l", "", ""}
 	for _, command := range commands {
 		rootCmd.AddCommand(command)
 		for i, _ := range args {
-			command.PersistentFlags().StringVar(
+			command.PersistentFlags().StringVarP(
 				vars[i],
 				args[i],
+				shorthands[i],
 				"",
 				fmt.Sprintf("Kubernetes %s selector", args[i]))
 		}
+		command.PersistentFlags().VarP(
+			namespaceListValue{&namespaceParam},
+			"namespace",
+			"n",
+			"Kubernetes namespace selector, a comma-separated list or repeated for several namespaces")
+		command.PersistentFlags().BoolVarP(
+			&allNamespacesFlag,
+			"all-namespaces",
+			"A",
+			false,
+			"Trace all namespaces, the default without --namespace when the current context has no namespace")
+		command.PersistentFlags().StringVar(
+			&podUIDParam,
+			"pod-uid",
+			"",
+			"Kubernetes pod UID selector (takes precedence over --podname)")
+		command.PersistentFlags().StringVar(
+			&outputDirParam,
+			"output-dir",
+			"",
+			"Write the output of each node to a separate file in this directory")
+		command.PersistentFlags().BoolVar(
+			&includeSelfFlag,
+			"include-self",
+			false,
+			"Also trace the gadget pods, for debugging Inspektor Gadget")
 	}
 	capabilitiesCmd.PersistentFlags().BoolVarP(&stackFlag, "print-stack", "", false, "Print kernel and userspace call stack of cap_capable()")
 	capabilitiesCmd.PersistentFlags().BoolVarP(&uniqueFlag, "unique", "", false, "Don't print duplicate capability checks")
-	capabilitiesCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "", false, "Include non-audit")
+	capabilitiesCmd.PersistentFlags().BoolVarP(&includeNonAuditFlag, "include-non-audit", "", false, "Include non-audit")
 
 	profileCmd.PersistentFlags().BoolVarP(&profileUser, "user", "U", false, "Show stacks from user space only (no kernel space stacks)")
 	profileCmd.PersistentFlags().BoolVarP(&profileKernel, "kernel", "K", false, "Show stacks from kernel space only (no user space stacks)")
+
+	tcptracerCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	tcptracerCmd.PersistentFlags().BoolVarP(&showAgeFlag, "show-age", "", false,
+		"Add the start time of the process and the time it has been running for to events")
+	tcptracerCmd.PersistentFlags().BoolVarP(&showCgroupFlag, "show-cgroup", "", false,
+		"Add the cgroup path of the process to events, like /kubepods/burstable/pod<uid>/<container id>")
+	ugidsnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	tcpconnlatCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	tcpconnlatCmd.PersistentFlags().BoolVarP(&tcpconnlatHistogram, "histogram", "", false, "Print a histogram of the latencies when terminating instead of the connections")
+	tcpconnlatCmd.PersistentFlags().StringVarP(&outputParam, "output", "o", "",
+		"With prometheus-exposition, print the latencies per container in the Prometheus text format when terminating. With protobuf, write the events as protocol buffers. With json, like --json. With logfmt, output events as key=value lines")
+
+	cachestatCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output summaries in JSON, one per line")
+	restartsnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output restarts in JSON, one per line")
+	cachestatCmd.PersistentFlags().IntVarP(&cachestatInterval, "interval", "", 1, "Interval between two summaries, in seconds")
+	tcpsubnetCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output summaries in JSON, one per line")
+	swapinCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	tcppingCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	killsnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	hostpathsnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	solistenCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	dnsconnectCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	dnssnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output messages in JSON, one per line")
+	biosnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output requests in JSON, one per line")
+	ttysnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output writes in JSON, one per line")
+	ttysnoopCmd.PersistentFlags().BoolVarP(&ttysnoopConfirm, "confirm-capture", "", false,
+		"Confirm capturing the output of the terminals of the containers, that can contain secrets")
+	nfsslowerCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output operations in JSON, one per line")
+	biosnoopCmd.PersistentFlags().DurationVarP(&biosnoopMinLatency, "min-latency", "", 0,
+		"Only print the requests taking at least this long (e.g. 10ms)")
+	nfsslowerCmd.PersistentFlags().DurationVarP(&nfsslowerMinLatency, "min-latency", "", 10*time.Millisecond,
+		"Only print the operations taking at least this long, 0 for all of them")
+	dnsconnectCmd.PersistentFlags().DurationVarP(&dnsconnectMaxAge, "max-age", "", dnsconnect.DefaultMaxAge,
+		"How long the addresses resolved by a process are kept to name its connections")
+	hostpathsnoopCmd.PersistentFlags().StringVarP(&hostpathsnoopPaths, "paths", "", "",
+		"Comma-separated list of the absolute paths on the nodes to watch, like /etc/kubernetes")
+	execsnoopCmd.PersistentFlags().StringVarP(&execsnoopEnv, "env", "", "",
+		"Comma-separated patterns of the environment variables to capture, like JAVA_* or * for all. None by default")
+	execsnoopCmd.PersistentFlags().StringVarP(&execsnoopEnvDeny, "env-deny", "", execsnoop.DefaultDeny,
+		"Comma-separated patterns of the environment variables whose value is redacted")
+	execsnoopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	execsnoopCmd.PersistentFlags().IntVarP(&execsnoopMaxArgs, "max-args", "", execsnoop.DefaultMaxArgs,
+		fmt.Sprintf("Maximum number of arguments captured, the file name included, at most %d", execsnoop.MaxArgsLimit))
+	execsnoopCmd.PersistentFlags().IntVarP(&execsnoopMaxArgLen, "max-arg-len", "", execsnoop.DefaultMaxArgLen,
+		fmt.Sprintf("Maximum length of the arguments and variables captured, in bytes, at most %d", execsnoop.MaxArgLenLimit))
+	execsnoopCmd.PersistentFlags().BoolVarP(&execsnoopEntrypoints, "entrypoints", "", false,
+		"Only print the entrypoints of the containers started, the first program executed in their pid namespace")
+	swapinCmd.PersistentFlags().BoolVarP(&swapinHistogram, "histogram", "", false, "Print a histogram of the latencies when terminating instead of the page faults")
+	tcppingCmd.PersistentFlags().BoolVarP(&tcppingHistogram, "histogram", "", false, "Print histograms of the latencies of the clients and of the servers when terminating instead of the requests")
+	tcppingCmd.PersistentFlags().StringVarP(&tcppingTraceHeader, "trace-header", "", "",
+		"Add the trace id carried by this header of the plaintext HTTP/1.x requests, like traceparent. Copies the beginning of each request")
+	tcpsubnetCmd.PersistentFlags().IntVarP(&tcpsubnetInterval, "interval", "", 1, "Interval between two summaries, in seconds")
+	bpfmetricsCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output summaries in JSON, one per line")
+	bpfmetricsCmd.PersistentFlags().IntVarP(&bpfmetricsInterval, "interval", "", 5, "Interval between two summaries, in seconds")
+	netqtopCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output summaries in JSON, one per line")
+	netqtopCmd.PersistentFlags().IntVarP(&netqtopInterval, "interval", "", 1, "Interval between two summaries, in seconds")
+	netqtopCmd.PersistentFlags().StringVarP(&netqtopDevice, "device", "", "",
+		"Comma-separated names of the network devices to report, like eth0, all by default")
+	tcpsubnetCmd.PersistentFlags().StringVarP(&tcpsubnetSubnets, "subnets", "", "0.0.0.0/0",
+		"Comma-separated list of IPv4 subnets, the traffic is counted for the first one containing the remote address")
+
+	for _, command := range []*cobra.Command{tcptracerCmd, tcpconnlatCmd} {
+		command.PersistentFlags().BoolVarP(&podStatusFlag, "pod-status", "", false,
+			"Add the pod phase and the readiness of the container to events")
+	}
+
+	for _, command := range []*cobra.Command{tcptracerCmd, dnssnoopCmd} {
+		command.PersistentFlags().StringVarP(&outputParam, "output", "o", "",
+			"With json, output events in JSON, one per line, like --json. With logfmt, output events as key=value lines")
+	}
+
+	// Gadgets with typed events, see pkg/eventpb
+	for _, command := range []*cobra.Command{ugidsnoopCmd, cachestatCmd, tcpsubnetCmd, swapinCmd} {
+		command.PersistentFlags().StringVarP(&outputParam, "output", "o", "",
+			"With protobuf, write the events as protocol buffers, see Documentation/protobuf-output.md. With json, like --json. With logfmt, output events as key=value lines")
+	}
+
+	// Gadgets printing events as they happen
+	for _, command := range []*cobra.Command{execsnoopCmd, opensnoopCmd, bindsnoopCmd, tcpconnectCmd, tcptracerCmd, tcpconnlatCmd, ugidsnoopCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd, capabilitiesCmd} {
+		command.PersistentFlags().BoolVarP(&oneShotFlag, "one-shot", "", false,
+			"Collect the events for --duration, then print them sorted by time")
+		command.PersistentFlags().DurationVar(&oneShotDuration, "duration", 10*time.Second,
+			"With --one-shot, how long to collect the events")
+	}
+
+	// Gadgets printing summaries over an interval
+	for _, command := range []*cobra.Command{cachestatCmd, tcpsubnetCmd, bpfmetricsCmd, netqtopCmd} {
+		command.PersistentFlags().BoolVarP(&emitPartialFlag, "emit-partial", "", true,
+			"When terminating, print the summary of the incomplete last interval, marked as partial")
+		command.PersistentFlags().BoolVarP(&noEmitPartialFlag, "no-emit-partial", "", false,
+			"When terminating, don't print the summary of the incomplete last interval")
+	}
+
+	for _, command := range []*cobra.Command{tcptracerCmd, tcpconnlatCmd, ugidsnoopCmd, cachestatCmd, restartsnoopCmd, tcpsubnetCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd} {
+		command.PersistentFlags().DurationVar(&heartbeatParam, "heartbeat", 0,
+			"With --json, print a heartbeat record when no events were printed for this interval (e.g. 30s)")
+		command.PersistentFlags().StringVar(&fieldMapParam, "field-map", "",
+			"With --json, rename the fields of the events as in this YAML file (e.g. pod: k8s.pod.name)")
+	}
 }
 
 type postProcess struct {
 	firstLinePrinted uint64
-	outStreams []*postProcessSingle
-	errStreams []*postProcessSingle
+	outStreams       []*postProcessSingle
+	errStreams       []*postProcessSingle
 }
 
 type postProcessSingle struct {
@@ -135,21 +431,32 @@ type postProcessSingle struct {
 	orig             io.Writer
 	firstLine        bool
 	firstLinePrinted *uint64
-	buffer           string  // buffer to save incomplete strings
+	buffer           string                            // buffer to save incomplete strings
+	raw              bool                              // don't add the node prefix
+	transform        func(line string) (string, error) // optional, see setTransform
+	header           string                            // header printed instead of the gadget's one
+	collector        *oneShotCollector                 // optional, see setOneShot
+	diagnostics      *eventDiagnostics                 // optional, see setDiagnostics
+	seq              *eventseq.Tracker                 // optional, see setSeq
+	dedup            *flowDedup                        // optional, see setDedup
+	counter          *eventCounter                     // optional, see setCountBy
+	peerFilter       func(line string) bool            // optional, see setPeerFilter
+	gate             *readyGate                        // optional, see setReadyGate
+	index            int                               // of the node, for gate
 }
 
 func newPostProcess(n int, outStream io.Writer, errStream io.Writer) *postProcess {
 	p := &postProcess{
 		firstLinePrinted: 0,
-		outStreams: make([]*postProcessSingle, n),
-		errStreams: make([]*postProcessSingle, n),
+		outStreams:       make([]*postProcessSingle, n),
+		errStreams:       make([]*postProcessSingle, n),
 	}
 
 	for i := 0; i < n; i++ {
 		p.outStreams[i] = &postProcessSingle{
 			nodeShort:        " " + strconv.Itoa(i),
 			orig:             outStream,
-			firstLine:         true,
+			firstLine:        true,
 			firstLinePrinted: &p.firstLinePrinted,
 			buffer:           "",
 		}
@@ -157,7 +464,7 @@ func newPostProcess(n int, outStream io.Writer, errStream io.Writer) *postProces
 		p.errStreams[i] = &postProcessSingle{
 			nodeShort:        "E" + strconv.Itoa(i),
 			orig:             errStream,
-			firstLine:         false,
+			firstLine:        false,
 			firstLinePrinted: &p.firstLinePrinted,
 			buffer:           "",
 		}
@@ -166,8 +473,116 @@ func newPostProcess(n int, outStream io.Writer, errStream io.Writer) *postProces
 	return p
 }
 
+// newPostProcessRaw is like newPostProcess but does not add the node prefix
+// and does not expect a header line on outStream. It is used for output
+// formats where each line is self-contained, like JSON.
+func newPostProcessRaw(n int, outStream io.Writer, errStream io.Writer) *postProcess {
+	p := newPostProcess(n, outStream, errStream)
+	for i := 0; i < n; i++ {
+		p.outStreams[i].firstLine = false
+		p.outStreams[i].raw = true
+	}
+	return p
+}
+
+// newPostProcessJSON is like newPostProcessRaw but the lines printed by the
+// gadgets on their error stream are printed as error records on outStream,
+// so that consumers of a JSON stream can parse the errors as well.
+func newPostProcessJSON(nodeNames []string, outStream io.Writer) *postProcess {
+	p := newPostProcessRaw(len(nodeNames), outStream, outStream)
+	for i, nodeName := range nodeNames {
+		p.errStreams[i].raw = true
+		p.errStreams[i].transform = jsonErrorTransform(nodeName)
+	}
+	return p
+}
+
+// errorRecord is an error in a JSON stream
+type errorRecord struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Node    string `json:"node,omitempty"`
+}
+
+func newErrorRecord(node, message string) errorRecord {
+	return errorRecord{Type: "error", Message: message, Node: node}
+}
+
+func (r errorRecord) String() string {
+	buf, _ := json.Marshal(r)
+	return string(buf)
+}
+
+func jsonErrorTransform(node string) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		if strings.TrimSpace(line) == "" {
+			return "", errSkipLine
+		}
+		return newErrorRecord(node, line).String(), nil
+	}
+}
+
+// errSkipLine is returned by a transform function for lines that must not be
+// printed
+var errSkipLine = errors.New("skip line")
+
+// setTransform sets a function applied on each line printed by the gadget on
+// outStream, for gadgets printing a machine-readable format that needs to be
+// rendered. Lines that cannot be transformed are printed unchanged. Such
+// gadgets don't print a header line: header is printed instead, unless empty.
+func (p *postProcess) setTransform(header string, transform func(line string) (string, error)) {
+	for _, s := range p.outStreams {
+		s.transform = transform
+		s.header = header
+	}
+}
+
+// fileStream returns the stream writing the output of the gadget of the node
+// index to f, for --output-dir. The lines are transformed as on stdout, without
+// the node prefix since f only has the events of this node, and the header is
+// printed at the top of f.
+func (p *postProcess) fileStream(index int, f io.Writer) *postProcessSingle {
+	s := p.outStreams[index]
+	return &postProcessSingle{
+		orig:             f,
+		firstLine:        s.firstLine,
+		firstLinePrinted: new(uint64),
+		raw:              true,
+		transform:        s.transform,
+		header:           s.header,
+	}
+}
+
+// setFieldMap renames the fields of the JSON events printed on outStreams
+func (p *postProcess) setFieldMap(m fieldmap.FieldMap) {
+	for _, s := range p.outStreams {
+		s.orig = fieldmap.NewWriter(s.orig, m)
+	}
+}
+
+// setOneShot buffers the lines printed on outStreams in c instead of writing
+// them, except the header
+func (p *postProcess) setOneShot(c *oneShotCollector) {
+	for _, s := range p.outStreams {
+		s.collector = c
+	}
+}
+
+// print writes line, or buffers it with --one-shot. raw is the line as
+// printed by the gadget.
+func (post *postProcessSingle) print(raw, line string) {
+	if post.collector != nil {
+		post.collector.add(post.orig, raw, line)
+		return
+	}
+	fmt.Fprintf(post.orig, "%s\n", line)
+}
+
 func (post *postProcessSingle) Write(p []byte) (n int, err error) {
 	prefix := "[" + post.nodeShort + "] "
+	if post.raw {
+		prefix = ""
+	}
 	asStr := post.buffer + string(p)
 
 	lines := strings.Split(asStr, "\n")
@@ -176,16 +591,69 @@ func (post *postProcessSingle) Write(p []byte) (n int, err error) {
 	}
 
 	// Print lines with prefix but the last one
-	for _, line := range lines[0:len(lines)-1] {
+	for _, line := range lines[0 : len(lines)-1] {
+		if isReadyRecord(line) {
+			// Without gate, with --output-dir, not an event either
+			if post.gate != nil {
+				post.gate.ready(post.index)
+				post.gate.wait()
+			}
+			continue
+		}
+		if post.transform != nil {
+			if post.firstLine {
+				post.firstLine = false
+				if atomic.AddUint64(post.firstLinePrinted, 1) == 1 && post.header != "" {
+					header := post.header
+					if post.seq != nil {
+						header = seqHeader + header
+					}
+					if !post.raw {
+						header = "NODE " + header
+					}
+					fmt.Fprintf(post.orig, "%s\n", header)
+				}
+			}
+			var received time.Time
+			if post.diagnostics != nil {
+				received = post.diagnostics.received(line)
+			}
+			column := ""
+			if post.seq != nil {
+				column = post.seqColumn(line)
+			}
+			// Before the transform, that can aggregate the events
+			if post.peerFilter != nil && !post.peerFilter(line) {
+				continue
+			}
+			transformed, err := post.transform(line)
+			if err == errSkipLine {
+				continue
+			}
+			if err == nil {
+				if post.counter != nil && post.counter.add(transformed) {
+					continue
+				}
+				if post.dedup != nil && !post.dedupEvent(line, transformed, prefix+column+transformed) {
+					continue
+				}
+				post.print(line, prefix+column+transformed)
+			} else {
+				post.print(line, prefix+line)
+			}
+			if post.diagnostics != nil {
+				post.diagnostics.printed(received)
+			}
+			continue
+		}
 		if post.firstLine {
 			post.firstLine = false
 			if atomic.AddUint64(post.firstLinePrinted, 1) == 1 {
-				prefix = "NODE "
-			} else {
-				continue // ignore this line, somebody else already printed it
+				fmt.Fprintf(post.orig, "%s\n", "NODE "+line)
 			}
+			continue // or ignore this line, somebody else already printed it
 		}
-		fmt.Fprintf(post.orig, "%s\n", prefix + line)
+		post.print(line, prefix+line)
 	}
 
 	post.buffer = lines[len(lines)-1] // Buffer last line to print in next iteration
@@ -193,6 +661,115 @@ func (post *postProcessSingle) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+// createNodeOutputFiles creates the directory dir if needed and one file per
+// node in it, named after the node.
+func createNodeOutputFiles(dir string, nodeNames []string, ext string) (map[string]*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	files := map[string]*os.File{}
+	for _, nodeName := range nodeNames {
+		f, err := os.Create(filepath.Join(dir, nodeName+ext))
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, err
+		}
+		files[nodeName] = f
+	}
+	return files, nil
+}
+
+// heartbeatWriter writes a heartbeat record on w when nothing was written for
+// interval, so that consumers of a JSON stream can tell a quiet stream from a
+// stalled one. Writes must be complete lines.
+type heartbeatWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	interval time.Duration
+	last     time.Time
+	now      func() time.Time // can be replaced in tests
+	done     chan struct{}
+}
+
+type heartbeatRecord struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+}
+
+// newHeartbeatWriter returns a heartbeatWriter. Heartbeats are only written
+// once start is called.
+func newHeartbeatWriter(w io.Writer, interval time.Duration) *heartbeatWriter {
+	return &heartbeatWriter{
+		w:        w,
+		interval: interval,
+		last:     time.Now(),
+		now:      time.Now,
+		done:     make(chan struct{}),
+	}
+}
+
+func (h *heartbeatWriter) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = h.now()
+	return h.w.Write(p)
+}
+
+// beat writes a heartbeat if nothing was written since interval before now,
+// and returns how long to wait before the next call.
+func (h *heartbeatWriter) beat(now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	idle := now.Sub(h.last)
+	if idle < h.interval {
+		return h.interval - idle
+	}
+	buf, _ := json.Marshal(heartbeatRecord{
+		Type:      "heartbeat",
+		Timestamp: now.UTC().Format(time.RFC3339),
+	})
+	fmt.Fprintf(h.w, "%s\n", buf)
+	h.last = now
+	return h.interval
+}
+
+func (h *heartbeatWriter) start() {
+	go func() {
+		wait := h.interval
+		for {
+			select {
+			case <-h.done:
+				return
+			case <-time.After(wait):
+				wait = h.beat(h.now())
+			}
+		}
+	}()
+}
+
+func (h *heartbeatWriter) stop() {
+	close(h.done)
+}
+
+// gadgetOutputTimeout is how long to wait for the last output of the gadgets
+// once they are stopped
+const gadgetOutputTimeout = 2 * time.Second
+
+// waitTimeout waits for wg, at most for timeout
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
 func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 	return func(cmd *cobra.Command, args []string) {
 		contextLogger := log.WithFields(log.Fields{
@@ -200,15 +777,39 @@ func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 			"args":    args,
 		})
 
-		client, err := k8sutil.NewClientset(viper.GetString("kubeconfig"))
+		client, err := newClientset()
 		if err != nil {
 			contextLogger.Fatalf("Error in creating setting up Kubernetes client: %q", err)
 		}
 
+		namespaceParam, err = resolveNamespace(namespaceParam, allNamespacesFlag, kubeconfigNamespace)
+		if err != nil {
+			contextLogger.Fatalf("%s", err)
+		}
+
+		// -o json is the same as --json, like with kubectl
+		if outputParam == "json" && cmd.Flags().Lookup("json") != nil {
+			jsonOutput = true
+			outputParam = ""
+		}
+		// -o logfmt prints the events of --json as logfmt
+		if outputParam == "logfmt" {
+			if _, ok := jsonEvents[subCommand]; !ok || cmd.Flags().Lookup("json") == nil {
+				contextLogger.Fatalf("-o logfmt is not supported by %s", subCommand)
+			}
+			if jsonOutput || outputDirParam != "" || heartbeatParam != 0 || fieldMapParam != "" {
+				contextLogger.Fatalf("-o logfmt cannot be used with --json, --output-dir, --heartbeat or --field-map")
+			}
+			jsonOutput = true
+		}
+
 		// tcptop only works on one pod at a time
 		if subCommand == "tcptop" {
-			if nodeParam == "" || namespaceParam == "" || podnameParam == "" {
-				contextLogger.Fatalf("tcptop only works with --node, --namespace and --podname")
+			if nodeParam == "" || namespaceParam == "" || (podnameParam == "" && podUIDParam == "") {
+				contextLogger.Fatalf("tcptop only works with --node, --namespace and --podname or --pod-uid")
+			}
+			if strings.Contains(namespaceParam, ",") {
+				contextLogger.Fatalf("tcptop only works with a single namespace")
 			}
 		}
 
@@ -230,11 +831,132 @@ func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 		}
 
 		podnameFilter := ""
-		if podnameParam != "" {
+		if podUIDParam != "" {
+			// A pod name can be reused by a new pod after deletion, the UID
+			// can't. So --pod-uid takes precedence over --podname.
+			pods, err := client.CoreV1().Pods(listNamespace(namespaceParam)).List(metaV1.ListOptions{})
+			if err != nil {
+				contextLogger.Fatalf("Error in listing pods: %q", err)
+			}
+			pod, err := findPodByUID(pods.Items, podUIDParam)
+			if err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			if !namespaceSelected(namespaceParam, pod.Namespace) {
+				contextLogger.Fatalf("Pod with UID %q is in namespace %q, not selected", podUIDParam, pod.Namespace)
+			}
+			if podnameParam != "" && podnameParam != pod.Name {
+				contextLogger.Warnf("Ignoring --podname %q: pod with UID %q is %s/%s",
+					podnameParam, podUIDParam, pod.Namespace, pod.Name)
+			}
+			if nodeParam == "" {
+				nodeParam = pod.Spec.NodeName
+			}
+			podnameFilter = fmt.Sprintf("--poduid %q", podUIDParam)
+		} else if podnameParam != "" {
 			podnameFilter = fmt.Sprintf("--podname %q", podnameParam)
 		}
 
+		if heartbeatParam != 0 && (!jsonOutput || outputDirParam != "") {
+			contextLogger.Fatalf("--heartbeat only works with --json, without --output-dir")
+		}
+		var fieldMap fieldmap.FieldMap
+		if fieldMapParam != "" {
+			if !jsonOutput || outputDirParam != "" {
+				contextLogger.Fatalf("--field-map only works with --json, without --output-dir")
+			}
+			fieldMap, err = loadFieldMap(subCommand, fieldMapParam)
+			if err != nil {
+				contextLogger.Fatalf("Error in loading the field map: %s", err)
+			}
+		}
+		if oneShotFlag {
+			if outputDirParam != "" || heartbeatParam != 0 {
+				contextLogger.Fatalf("--one-shot cannot be used with --output-dir or --heartbeat")
+			}
+			if oneShotDuration <= 0 {
+				contextLogger.Fatalf("--duration must be positive")
+			}
+		} else if cmd.Flags().Changed("duration") {
+			contextLogger.Fatalf("--duration only works with --one-shot")
+		}
+		if diagnosticsFlag && outputDirParam != "" {
+			contextLogger.Fatalf("--diagnostics cannot be used with --output-dir")
+		}
+		if seqFlag && outputDirParam != "" {
+			contextLogger.Fatalf("--seq cannot be used with --output-dir")
+		}
+		if dedupWindowParam < 0 {
+			contextLogger.Fatalf("--dedup-window cannot be negative")
+		}
+		if dedupWindowParam != 0 && (outputDirParam != "" || outputParam != "") {
+			contextLogger.Fatalf("--dedup-window cannot be used with --output-dir or -o")
+		}
+		var countFields []string
+		countByText := false
+		if countByParam != "" {
+			if oneShotFlag || outputDirParam != "" || outputParam != "" || dedupWindowParam != 0 || heartbeatParam != 0 || fieldMapParam != "" {
+				contextLogger.Fatalf("--count-by cannot be used with --one-shot, --output-dir, -o, --dedup-window, --heartbeat or --field-map")
+			}
+			if cmd.Flags().Changed("histogram") {
+				contextLogger.Fatalf("--count-by cannot be used with --histogram")
+			}
+			if countIntervalParam < 0 {
+				contextLogger.Fatalf("--count-interval cannot be negative")
+			}
+			countFields, err = countByFields(subCommand, countByParam)
+			if err != nil {
+				contextLogger.Fatalf("Invalid --count-by: %s", err)
+			}
+			// The events are counted as rendered in JSON: without
+			// --json, only the counts are printed as a table
+			if !jsonOutput {
+				countByText = true
+				jsonOutput = true
+			}
+		} else if cmd.Flags().Changed("count-interval") {
+			contextLogger.Fatalf("--count-interval only works with --count-by")
+		}
+		if internalOnlyFlag && externalOnlyFlag {
+			contextLogger.Fatalf("--internal-only and --external-only cannot be used together")
+		}
+		if (internalOnlyFlag || externalOnlyFlag) && outputDirParam != "" {
+			contextLogger.Fatalf("--internal-only and --external-only cannot be used with --output-dir")
+		}
+		if len(clusterCIDRParam) != 0 && !internalOnlyFlag && !externalOnlyFlag {
+			contextLogger.Fatalf("--cluster-cidr only works with --internal-only or --external-only")
+		}
+		switch {
+		case outputParam == "protobuf":
+			if jsonOutput || outputDirParam != "" || oneShotFlag {
+				contextLogger.Fatalf("-o protobuf cannot be used with --json, --output-dir or --one-shot")
+			}
+			protobufWriter = eventpb.NewWriter(os.Stdout)
+		case outputParam == "logfmt":
+		case outputParam != "" && subCommand != "tcpconnlat":
+			contextLogger.Fatalf("Unknown output %q, only json, logfmt and protobuf are supported", outputParam)
+		}
+		if noEmitPartialFlag {
+			if cmd.Flags().Changed("emit-partial") {
+				contextLogger.Fatalf("--emit-partial and --no-emit-partial cannot be used together")
+			}
+			emitPartialFlag = false
+		}
+		if probeOnlyFlag {
+			if outputDirParam != "" || oneShotFlag || outputParam != "" || heartbeatParam != 0 {
+				contextLogger.Fatalf("--probe-only cannot be used with --output-dir, --one-shot, -o or --heartbeat")
+			}
+			if probeTimeout <= 0 {
+				contextLogger.Fatalf("--probe-timeout must be positive")
+			}
+		} else if cmd.Flags().Changed("probe-timeout") {
+			contextLogger.Fatalf("--probe-timeout only works with --probe-only")
+		}
+
+		wrapperParams := ""
 		gadgetParams := ""
+		var envPolicy execsnoop.EnvPolicy
+		var gadget *externalGadget
 		switch subCommand {
 		case "capabilities":
 			if stackFlag {
@@ -243,7 +965,7 @@ func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 			if uniqueFlag {
 				gadgetParams += " --unique"
 			}
-			if verboseFlag {
+			if includeNonAuditFlag {
 				gadgetParams += " -v"
 			}
 		case "profile":
@@ -253,6 +975,172 @@ func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 			} else if profileKernel {
 				gadgetParams += " -K "
 			}
+		case "tcptracer":
+			// tcptracer selects the pods itself instead of using the
+			// gadget tracer manager
+			wrapperParams = "--nomanager --probecleanup"
+			gadgetParams = fmt.Sprintf(" %s %s %s", labelFilter, namespaceFilter, podnameFilter)
+			labelFilter, namespaceFilter, podnameFilter = "", "", ""
+			if jsonOutput {
+				gadgetParams += " --json"
+			}
+			if podStatusFlag {
+				gadgetParams += " --podstatus"
+			}
+			if showAgeFlag {
+				gadgetParams += " --showage"
+			}
+			if showCgroupFlag {
+				gadgetParams += " --showcgroup"
+			}
+		case "execsnoop":
+			if err := execsnoop.CheckLimits(execsnoopMaxArgs, execsnoopMaxArgLen); err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			gadgetParams = fmt.Sprintf(" --max-args %d --max-arg-len %d", execsnoopMaxArgs, execsnoopMaxArgLen)
+			if execsnoopEntrypoints {
+				gadgetParams += " --entrypoints"
+			}
+			if execsnoopEnv == "" {
+				if cmd.Flags().Changed("env-deny") {
+					contextLogger.Fatalf("--env-deny only works with --env")
+				}
+				break
+			}
+			envPolicy, err = execsnoop.ParseEnvPolicy(execsnoopEnv, execsnoopEnvDeny)
+			if err != nil {
+				contextLogger.Fatalf("Invalid --env or --env-deny: %s", err)
+			}
+			gadgetParams += fmt.Sprintf(" --env %q --env-deny %q",
+				strings.Join(envPolicy.Allow, ","), strings.Join(envPolicy.Deny, ","))
+		case "cachestat":
+			if cachestatInterval < 1 {
+				contextLogger.Fatalf("--interval must be at least 1 second")
+			}
+			gadgetParams = fmt.Sprintf(" --interval %d", cachestatInterval)
+		case "tcpsubnet":
+			if tcpsubnetInterval < 1 {
+				contextLogger.Fatalf("--interval must be at least 1 second")
+			}
+			subnets, err := tcpsubnet.ParseSubnets(tcpsubnetSubnets)
+			if err != nil {
+				contextLogger.Fatalf("Invalid --subnets: %s", err)
+			}
+			if historySince != 0 && strings.Join(subnets, ",") != "0.0.0.0/0" {
+				// Only the default subnets are recorded
+				contextLogger.Fatalf("--history cannot be used with --subnets")
+			}
+			gadgetParams = fmt.Sprintf(" --interval %d --subnets %s", tcpsubnetInterval, strings.Join(subnets, ","))
+		case "hostpathsnoop":
+			paths, err := hostpathsnoop.ParsePaths(hostpathsnoopPaths)
+			if err != nil {
+				contextLogger.Fatalf("Invalid --paths: %s", err)
+			}
+			gadgetParams = fmt.Sprintf(" --paths %q", strings.Join(paths, ","))
+		case "dnsconnect":
+			if err := dnsconnect.CheckMaxAge(dnsconnectMaxAge); err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			gadgetParams = fmt.Sprintf(" --max-age %d", int(dnsconnectMaxAge.Seconds()))
+		case "tcpping":
+			if tcppingTraceHeader != "" {
+				if tcppingHistogram {
+					contextLogger.Fatalf("--trace-header cannot be used with --histogram")
+				}
+				tcppingTraceHeader, err = tcpping.ParseTraceHeader(tcppingTraceHeader)
+				if err != nil {
+					contextLogger.Fatalf("Invalid --trace-header: %s", err)
+				}
+				gadgetParams = " --trace-header " + tcppingTraceHeader
+			}
+		case "ttysnoop":
+			if !ttysnoopConfirm {
+				contextLogger.Fatalf("ttysnoop captures the output of the terminals of the containers, that can contain secrets: confirm with --confirm-capture")
+			}
+		case "biosnoop":
+			param, err := biosnoopMinLatencyParam(biosnoopMinLatency)
+			if err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			gadgetParams = param
+		case "nfsslower":
+			param, err := biosnoopMinLatencyParam(nfsslowerMinLatency)
+			if err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			gadgetParams = param
+		case "bpfmetrics":
+			// bpfmetrics reports the programs of all the gadgets of the
+			// node, whatever the pods they trace
+			wrapperParams = "--nomanager"
+			if bpfmetricsInterval < 1 {
+				contextLogger.Fatalf("--interval must be at least 1 second")
+			}
+			gadgetParams = fmt.Sprintf(" --interval %d", bpfmetricsInterval)
+		case "netqtop":
+			// netqtop reports the network devices of the node, whatever
+			// the pods they carry the traffic of
+			wrapperParams = "--nomanager"
+			if netqtopInterval < 1 {
+				contextLogger.Fatalf("--interval must be at least 1 second")
+			}
+			devices, err := parseNetqtopDevices(netqtopDevice)
+			if err != nil {
+				contextLogger.Fatalf("Invalid --device: %s", err)
+			}
+			gadgetParams = fmt.Sprintf(" --interval %d", netqtopInterval)
+			if len(devices) != 0 {
+				gadgetParams += fmt.Sprintf(" --device %q", strings.Join(devices, ","))
+			}
+		case "run-gadget":
+			// External gadgets are not given the set of containers of the
+			// gadget tracer manager and trace the whole node
+			wrapperParams = "--nomanager"
+			gadget, err = readExternalGadget()
+			if err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+		}
+
+		param, truncated, err := commFilterParam(commParam)
+		if err != nil {
+			contextLogger.Fatalf("%s", err)
+		}
+		for _, name := range truncated {
+			contextLogger.Warnf("--comm %q matches all the processes whose name starts with %q: the kernel truncates the names to %d bytes",
+				name, commfilter.Truncate(name), commfilter.MaxLen)
+		}
+		gadgetParams += param
+
+		param, err = cpuBudgetGadgetParam(cpuBudgetParam)
+		if err != nil {
+			contextLogger.Fatalf("%s", err)
+		}
+		gadgetParams += param
+
+		historyCmd := ""
+		if historySince != 0 {
+			historyCmd, err = historyCommand(subCommand, historySince)
+			if err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+		}
+
+		if perfBufferPages != 0 {
+			param, err := perfBufferParam(perfBufferPages)
+			if err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			wrapperParams += param
+		}
+
+		// The gadget pods are excluded on the nodes by default
+		if includeSelfFlag {
+			if subCommand == "tcptracer" {
+				gadgetParams += " --includeself"
+			} else {
+				wrapperParams += " --includeself"
+			}
 		}
 
 		tracerId := time.Now().Format("20060102150405")
@@ -261,6 +1149,12 @@ func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 		if err == nil {
 			tracerId = fmt.Sprintf("%s-%x", tracerId, b)
 		}
+		if gadget != nil {
+			gadgetParams = gadget.params(tracerId)
+			if jsonOutput {
+				gadgetParams += " --json"
+			}
+		}
 
 		var listOptions = metaV1.ListOptions{
 			LabelSelector: labels.Everything().String(),
@@ -272,40 +1166,352 @@ func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 			contextLogger.Fatalf("Error in listing nodes: %q", err)
 		}
 
+		if probeOnlyFlag {
+			var nodeNames []string
+			for _, node := range nodes.Items {
+				if nodeParam == "" || node.Name == nodeParam {
+					nodeNames = append(nodeNames, node.Name)
+				}
+			}
+			load := func(nodeName string, stdout, stderr io.Writer) error {
+				cmd := fmt.Sprintf("exec /opt/bcck8s/bcc-wrapper.sh --tracerid %s %s --gadget %s %s %s %s -- %s",
+					tracerId, wrapperParams, bccScript, labelFilter, namespaceFilter, podnameFilter, gadgetParams)
+				return execPod(client, nodeName, cmd, stdout, stderr)
+			}
+			stop := func(nodeName string) {
+				// ignore errors, like when removing the tracers below
+				cmd := fmt.Sprintf("exec /opt/bcck8s/bcc-wrapper.sh --tracerid %s %s --stop", tracerId, wrapperParams)
+				execPodCapture(client, nodeName, cmd)
+			}
+			failed := false
+			for _, result := range probeNodes(nodeNames, load, stop, probeTimeout) {
+				fmt.Println(result)
+				failed = failed || !result.Ready
+			}
+			if failed {
+				os.Exit(1)
+			}
+			return
+		}
+
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-		failure := make(chan string)
+		// Buffered: the gadgets can still fail after termination was
+		// requested, when nobody is reading failures anymore
+		failure := make(chan errorRecord, len(nodes.Items))
+		var running sync.WaitGroup
+
+		// Keep stdout for the events when they are meant to be parsed
+		info := io.Writer(os.Stdout)
+		if outputParam != "" {
+			info = os.Stderr
+		}
+		var postProcess *postProcess
+		var heartbeat *heartbeatWriter
+		var pause *pauseWriter
+		out := io.Writer(os.Stdout)
+		if jsonOutput {
+			info = os.Stderr
+			if heartbeatParam != 0 {
+				heartbeat = newHeartbeatWriter(os.Stdout, heartbeatParam)
+				heartbeat.start()
+				out = heartbeat
+			}
+			if outputParam == "logfmt" {
+				out = logfmt.NewWriter(out, jsonEvents[subCommand])
+			}
+			var nodeNames []string
+			for _, node := range nodes.Items {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			if countByText {
+				// The errors are printed as without --json
+				postProcess = newPostProcessRaw(len(nodes.Items), out, os.Stderr)
+			} else {
+				postProcess = newPostProcessJSON(nodeNames, out)
+			}
+		} else if protobufWriter != nil {
+			// The events are written by protobufWriter, only the lines
+			// that are not events are printed
+			postProcess = newPostProcess(len(nodes.Items), os.Stderr, os.Stderr)
+		} else if outputDirParam == "" && !oneShotFlag && subCommand != "tcptop" &&
+			isTerminal(os.Stdin.Fd()) && isTerminal(os.Stdout.Fd()) {
+			// The output can be paused when watched interactively
+			pause = newPauseWriter(os.Stdout, os.Stderr, pausedEventsMax)
+			postProcess = newPostProcess(len(nodes.Items), pause, os.Stderr)
+		} else {
+			postProcess = newPostProcess(len(nodes.Items), os.Stdout, os.Stderr)
+		}
+		containers := containercache.New(lookupContainerByID(client, namespaceParam), containercache.DefaultConfig)
+		history := newHistorySelector(namespaceParam, podnameParam, podUIDParam, labelParam)
+		var aggregate *tcpconnlatAggregate
+		var swapinHist *histogram.Histogram
+		var tcppingHists *tcppingHistograms
+		switch subCommand {
+		case "bpfmetrics":
+			postProcess.setTransform(bpfmetricsHeader, bpfmetricsTransform(emitPartialFlag))
+		case "netqtop":
+			postProcess.setTransform(netqtopHeader, netqtopTransform(emitPartialFlag))
+		case "ugidsnoop":
+			postProcess.setTransform(ugidsnoopHeader, ugidsnoopTransform)
+		case "cachestat":
+			postProcess.setTransform(cachestatHeader, cachestatTransform(containers, emitPartialFlag, history))
+		case "tcpsubnet":
+			postProcess.setTransform(tcpsubnetHeader, tcpsubnetTransform(containers, emitPartialFlag, history))
+		case "restartsnoop":
+			if outputDirParam != "" {
+				contextLogger.Fatalf("restartsnoop cannot be used with --output-dir")
+			}
+			correlator := restartsnoop.NewCorrelator()
+			stop := make(chan struct{})
+			defer close(stop)
+			pods, err := podinformer.NewStore(client, listNamespace(namespaceParam), nodeParam, stop)
+			if err != nil {
+				contextLogger.Fatalf("Error in watching pods: %q", err)
+			}
+			events := out
+			if fieldMap != nil {
+				events = fieldmap.NewWriter(out, fieldMap)
+			}
+			pods.OnUpdate(restartsnoopHandler(correlator, events, restartsnoopDelay))
+			postProcess.setTransform("", restartsnoopTransform(correlator))
+		case "tcpconnlat":
+			switch outputParam {
+			case "", "logfmt":
+			case "protobuf":
+				if tcpconnlatHistogram {
+					contextLogger.Fatalf("-o protobuf cannot be used with --histogram")
+				}
+			case "prometheus-exposition":
+				if tcpconnlatHistogram || jsonOutput || outputDirParam != "" {
+					contextLogger.Fatalf("--output=prometheus-exposition cannot be used with --histogram, --json or --output-dir")
+				}
+				aggregate = newTcpconnlatAggregate(true)
+			default:
+				contextLogger.Fatalf("Unknown output %q, only json, logfmt, prometheus-exposition and protobuf are supported", outputParam)
+			}
+			var pods *podinformer.Store
+			if podStatusFlag && aggregate == nil && !tcpconnlatHistogram {
+				stop := make(chan struct{})
+				defer close(stop)
+				pods, err = podinformer.NewStore(client, listNamespace(namespaceParam), "", stop)
+				if err != nil {
+					contextLogger.Fatalf("Error in watching pods: %q", err)
+				}
+			}
+			header := tcpconnlatHeader(pods)
+			if tcpconnlatHistogram {
+				if outputDirParam != "" {
+					contextLogger.Fatalf("--histogram cannot be used with --output-dir")
+				}
+				aggregate = newTcpconnlatAggregate(false)
+			}
+			if aggregate != nil {
+				header = ""
+			}
+			postProcess.setTransform(header, tcpconnlatTransform(containers, pods, aggregate))
+		case "execsnoop":
+			postProcess.setTransform(execsnoopHeader, execsnoopTransform(containers, envPolicy))
+		case "killsnoop":
+			// The sender can be in any namespace
+			senders := containercache.New(lookupContainerByID(client, ""), containercache.DefaultConfig)
+			postProcess.setTransform(killsnoopHeader, killsnoopTransform(senders, namespaceParam, podnameParam))
+		case "dnsconnect":
+			postProcess.setTransform(dnsconnectHeader, dnsconnectTransform(containers))
+		case "dnssnoop":
+			postProcess.setTransform(dnssnoopHeader, dnssnoopTransform(containers))
+		case "solisten":
+			postProcess.setTransform(solistenHeader, solistenTransform(containers))
+		case "ttysnoop":
+			postProcess.setTransform(ttysnoopHeader, ttysnoopTransform(containers))
+		case "biosnoop":
+			postProcess.setTransform(biosnoopHeader, biosnoopTransform(containers))
+		case "nfsslower":
+			postProcess.setTransform(nfsslowerHeader, nfsslowerTransform(containers))
+		case "hostpathsnoop":
+			postProcess.setTransform(hostpathsnoopHeader, hostpathsnoopTransform(containers))
+		case "swapin":
+			header := swapinHeader
+			if swapinHistogram {
+				if outputDirParam != "" || protobufWriter != nil {
+					contextLogger.Fatalf("--histogram cannot be used with --output-dir or -o protobuf")
+				}
+				swapinHist = &histogram.Histogram{}
+				header = ""
+			}
+			postProcess.setTransform(header, swapinTransform(containers, swapinHist))
+		case "tcpping":
+			header := tcppingHeader
+			if tcppingTraceHeader != "" {
+				header = tcppingTraceIDHeader
+			}
+			if tcppingHistogram {
+				if outputDirParam != "" {
+					contextLogger.Fatalf("--histogram cannot be used with --output-dir")
+				}
+				tcppingHists = &tcppingHistograms{}
+				header = ""
+			}
+			postProcess.setTransform(header, tcppingTransform(containers, tcppingHists))
+		}
+		if protobufWriter != nil {
+			for _, s := range postProcess.outStreams {
+				s.header = ""
+			}
+		}
+		if fieldMap != nil {
+			postProcess.setFieldMap(fieldMap)
+		}
+		var diagnostics *eventDiagnostics
+		if diagnosticsFlag {
+			diagnostics = newEventDiagnostics()
+			postProcess.setDiagnostics(diagnostics)
+		}
+		if seqFlag {
+			postProcess.setSeq()
+		}
+		if internalOnlyFlag || externalOnlyFlag {
+			classifier, err := clusterClassifier(client, nodes.Items, clusterCIDRParam)
+			if err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			keep := peerfilter.Internal
+			if externalOnlyFlag {
+				keep = peerfilter.External
+			}
+			postProcess.setPeerFilter(classifier, keep, peerAddrs[subCommand])
+		}
+		var stopDedup func()
+		if dedupWindowParam != 0 {
+			postProcess.setDedup(dedupWindowParam, dedupFlowKeys[subCommand])
+			stopDedup = postProcess.startDedup()
+		}
+		var counter *eventCounter
+		var stopCount func() error
+		if countFields != nil {
+			counter = newEventCounter(countFields, os.Stdout, !countByText)
+			postProcess.setCountBy(counter)
+			if countIntervalParam != 0 {
+				stopCount = counter.start(countIntervalParam)
+			}
+		}
+		var collector *oneShotCollector
+		var oneShotTimeout <-chan time.Time
+		if oneShotFlag {
+			collector = newOneShotCollector()
+			postProcess.setOneShot(collector)
+			oneShotTimeout = time.After(oneShotDuration)
+		}
 
-		postProcess := newPostProcess(len(nodes.Items), os.Stdout, os.Stderr)
+		var outputFiles map[string]*os.File
+		if outputDirParam != "" {
+			var nodeNames []string
+			for _, node := range nodes.Items {
+				if nodeParam != "" && node.Name != nodeParam {
+					continue
+				}
+				nodeNames = append(nodeNames, node.Name)
+			}
+			ext := ".txt"
+			if jsonOutput {
+				ext = ".json"
+			}
+			outputFiles, err = createNodeOutputFiles(outputDirParam, nodeNames, ext)
+			if err != nil {
+				contextLogger.Fatalf("Error in creating output files: %q", err)
+			}
+		}
 
-		fmt.Printf("Node numbers:")
+		var gate *readyGate
+		if readyGadgets[subCommand] && outputFiles == nil {
+			var indexes []int
+			for i, node := range nodes.Items {
+				if nodeParam == "" || node.Name == nodeParam {
+					indexes = append(indexes, i)
+				}
+			}
+			if jsonOutput && !countByText {
+				gate = newReadyGate(indexes, printReady(out))
+			} else {
+				gate = newReadyGate(indexes, printReady(os.Stderr))
+			}
+			postProcess.setReadyGate(gate)
+		}
+
+		fmt.Fprintf(info, "Node numbers:")
 		for i, node := range nodes.Items {
 			if nodeParam != "" && node.Name != nodeParam {
 				continue
 			}
-			fmt.Printf(" %d = %s", i, node.Name)
+			fmt.Fprintf(info, " %d = %s", i, node.Name)
+			running.Add(1)
 			go func(nodeName string, index int) {
-				cmd := fmt.Sprintf("exec /opt/bcck8s/bcc-wrapper.sh --tracerid %s --gadget %s %s %s %s -- %s",
-					tracerId, bccScript, labelFilter, namespaceFilter, podnameFilter, gadgetParams)
+				defer running.Done()
+				if gate != nil {
+					// Not waited for anymore
+					defer gate.ready(index)
+				}
+				if gadget != nil {
+					if err := gadget.upload(client, nodeName, tracerId); err != nil {
+						failure <- newErrorRecord(nodeName, fmt.Sprintf("Error running command: %v", err))
+						return
+					}
+				}
+				cmd := fmt.Sprintf("%sexec /opt/bcck8s/bcc-wrapper.sh --tracerid %s %s --gadget %s %s %s %s -- %s",
+					historyCmd, tracerId, wrapperParams, bccScript, labelFilter, namespaceFilter, podnameFilter, gadgetParams)
 				var err error
-				if subCommand != "tcptop" {
+				if outputFiles != nil && postProcess.outStreams[index].transform != nil {
+					err = execPod(client, nodeName, cmd,
+						postProcess.fileStream(index, outputFiles[nodeName]), postProcess.errStreams[index])
+				} else if outputFiles != nil {
+					err = execPod(client, nodeName, cmd,
+						outputFiles[nodeName], postProcess.errStreams[index])
+				} else if subCommand != "tcptop" {
 					err = execPod(client, nodeName, cmd,
 						postProcess.outStreams[index], postProcess.errStreams[index])
 				} else {
 					err = execPod(client, nodeName, cmd, os.Stdout, os.Stderr)
 				}
 				if fmt.Sprintf("%s", err) != "command terminated with exit code 137" {
-					failure <- fmt.Sprintf("Error running command: %v\n", err)
+					failure <- newErrorRecord(nodeName, fmt.Sprintf("Error running command: %v", err))
 				}
 			}(node.Name, i) // node.Name is invalidated by the above for loop, causes races
 		}
-		fmt.Println()
+		fmt.Fprintln(info)
+		if pause != nil {
+			if err := pause.start(os.Stdin); err != nil {
+				pause = nil
+			} else {
+				fmt.Fprintln(info, "Press space to pause and resume the output.")
+			}
+		}
+		if collector != nil {
+			fmt.Fprintf(info, "Collecting events for %s...\n", oneShotDuration)
+		}
+		if counter != nil {
+			fmt.Fprintf(info, "Counting the events by %s...\n", strings.Join(countFields, ", "))
+		}
+		if subCommand == "restartsnoop" && !jsonOutput {
+			fmt.Fprintln(out, restartsnoopHeader)
+		}
 
 		select {
 		case <-sigs:
-			fmt.Println("\nTerminating...")
+			fmt.Fprintln(info, "\nTerminating...")
+		case <-oneShotTimeout:
 		case e := <-failure:
-			fmt.Printf("\n%s\n", e)
+			if jsonOutput && !countByText {
+				fmt.Fprintf(out, "%s\n", e)
+			} else {
+				fmt.Fprintf(info, "\n%s\n\n", e.Message)
+			}
+		}
+
+		if heartbeat != nil {
+			heartbeat.stop()
+		}
+		if gate != nil {
+			gate.open()
 		}
 
 		// remove tracers from the nodes
@@ -314,9 +1520,75 @@ func bccCmd(subCommand, bccScript string) func(*cobra.Command, []string) {
 				continue
 			}
 			// ignore errors, there is nothing the user can do about it
-			execPodCapture(client, node.Name,
-				fmt.Sprintf("exec /opt/bcck8s/bcc-wrapper.sh --tracerid %s --stop", tracerId))
+			cmd := fmt.Sprintf("exec /opt/bcck8s/bcc-wrapper.sh --tracerid %s %s --stop", tracerId, wrapperParams)
+			if gadget != nil {
+				cmd = fmt.Sprintf("rm -rf %s ; %s", externalGadgetDir(tracerId), cmd)
+			}
+			execPodCapture(client, node.Name, cmd)
+		}
+		// The gadgets can print a last output when stopped, like the
+		// summary of an incomplete interval
+		waitTimeout(&running, gadgetOutputTimeout)
+		if stopDedup != nil {
+			stopDedup()
+		}
+		if pause != nil {
+			pause.stop()
+		}
+		if collector != nil {
+			if err := collector.flush(); err != nil {
+				contextLogger.Errorf("Error in printing events: %q", err)
+			}
+		}
+		if counter != nil {
+			if stopCount != nil {
+				err = stopCount()
+			} else {
+				err = counter.print()
+			}
+			if err != nil {
+				contextLogger.Errorf("Error in printing counts: %q", err)
+			}
+		}
+		if aggregate != nil {
+			if aggregate.perContainer {
+				err = aggregate.writeExposition(os.Stdout)
+			} else {
+				err = printTcpconnlatHistogram(os.Stdout, aggregate)
+			}
+			if err != nil {
+				contextLogger.Errorf("Error in printing latencies: %q", err)
+			}
+		}
+		if swapinHist != nil {
+			if err := printHistogram(os.Stdout, swapinHist); err != nil {
+				contextLogger.Errorf("Error in printing latencies: %q", err)
+			}
+		}
+		if tcppingHists != nil {
+			if err := printTcppingHistograms(os.Stdout, tcppingHists); err != nil {
+				contextLogger.Errorf("Error in printing latencies: %q", err)
+			}
+		}
+		if diagnostics != nil {
+			if err := diagnostics.writeExposition(os.Stderr); err != nil {
+				contextLogger.Errorf("Error in printing diagnostics: %q", err)
+			}
+		}
+		if seqFlag {
+			var nodeNames []string
+			for _, node := range nodes.Items {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			if err := postProcess.writeSeqReport(os.Stderr, nodeNames); err != nil {
+				contextLogger.Errorf("Error in printing lost events: %q", err)
+			}
+		}
+		for nodeName, f := range outputFiles {
+			if err := f.Close(); err != nil {
+				contextLogger.Errorf("Error in closing output file for node %s: %q", nodeName, err)
+			}
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(info, "\n")
 	}
 }
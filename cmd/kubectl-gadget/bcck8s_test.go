@@ -1,7 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
 )
 
 type mockWriter struct {
@@ -13,6 +23,44 @@ func (mock *mockWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+// testContainers returns a cache of the containers resolving the container
+// "abc" to pod and container in the namespace demo, and no other container
+func testContainers(pod, container string) *containercache.Cache {
+	return containercache.New(func(id string) (*containercache.Metadata, error) {
+		if id != "abc" {
+			return nil, nil
+		}
+		return &containercache.Metadata{Namespace: "demo", Pod: pod, Container: container}, nil
+	}, containercache.DefaultConfig)
+}
+
+// noContainers returns a cache of the containers resolving none
+func noContainers() *containercache.Cache {
+	return containercache.New(func(id string) (*containercache.Metadata, error) {
+		return nil, nil
+	}, containercache.DefaultConfig)
+}
+
+// runTransform returns the output of lines printed by the gadget of a single
+// node, rendered with header and transform
+func runTransform(header string, transform func(line string) (string, error), lines string) string {
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(1, mock, mock)
+	postProcess.setTransform(header, transform)
+	postProcess.outStreams[0].Write([]byte(lines))
+	return string(mock.output)
+}
+
+// runTransformRaw is runTransform without the prefixes of the nodes, as with
+// --json
+func runTransformRaw(header string, transform func(line string) (string, error), lines string) string {
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessRaw(1, mock, mock)
+	postProcess.setTransform(header, transform)
+	postProcess.outStreams[0].Write([]byte(lines))
+	return string(mock.output)
+}
+
 // TestPostProcessFirstLineOutStream tests that the first line is printed
 // only once among the different nodes using out stream
 func TestPostProcessFirstLineOutStream(t *testing.T) {
@@ -102,3 +150,177 @@ NODE PCOMM  PID    PPID   RET ARGS
 		t.Fatalf("%v != %v", string(mock.output), expected)
 	}
 }
+
+// TestPostProcessJSON tests that the errors of the gadgets are printed as
+// JSON records in the stream of events
+func TestPostProcessJSON(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessJSON([]string{"node0", "node1"}, mock)
+
+	postProcess.outStreams[0].Write([]byte(`{"type":"connect"}` + "\n"))
+	postProcess.errStreams[1].Write([]byte("cannot attach kprobe: \"tcp_v4_connect\"\n\n"))
+	postProcess.outStreams[1].Write([]byte(`{"type":"accept"}` + "\n"))
+	fmt.Fprintf(mock, "%s\n", newErrorRecord("node0", "Error running command: command terminated with exit code 1"))
+
+	expected := `
+{"type":"connect"}
+{"type":"error","message":"cannot attach kprobe: \"tcp_v4_connect\"","node":"node1"}
+{"type":"accept"}
+{"type":"error","message":"Error running command: command terminated with exit code 1","node":"node0"}
+`
+	if "\n"+string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(mock.output)), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %s", line, err)
+		}
+	}
+}
+
+// TestPostProcessRaw tests that lines are printed without node prefix and
+// without header handling
+func TestPostProcessRaw(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessRaw(2, mock, mock)
+
+	postProcess.outStreams[0].Write([]byte(`{"type":"connect","node":"node0"}` + "\n"))
+	postProcess.outStreams[1].Write([]byte(`{"type":"accept",`))
+	postProcess.outStreams[1].Write([]byte(`"node":"node1"}` + "\n"))
+	postProcess.errStreams[1].Write([]byte("error in node1\n"))
+
+	expected := `
+{"type":"connect","node":"node0"}
+{"type":"accept","node":"node1"}
+[E1] error in node1
+`
+	if "\n"+string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+}
+
+func TestCreateNodeOutputFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubectl-gadget-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outputDir := filepath.Join(dir, "output")
+	nodeNames := []string{"ip-10-0-23-52", "ip-10-0-30-247"}
+	files, err := createNodeOutputFiles(outputDir, nodeNames, ".txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, nodeName := range nodeNames {
+		fmt.Fprintf(files[nodeName], "output of %s\n", nodeName)
+		files[nodeName].Close()
+	}
+
+	entries, err := ioutil.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(nodeNames) {
+		t.Fatalf("%d files created, expected %d", len(entries), len(nodeNames))
+	}
+	for _, nodeName := range nodeNames {
+		content, err := ioutil.ReadFile(filepath.Join(outputDir, nodeName+".txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "output of "+nodeName+"\n" {
+			t.Fatalf("unexpected content for %s: %q", nodeName, string(content))
+		}
+	}
+}
+
+func TestPostProcessTransform(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(2, mock, mock)
+	postProcess.setTransform("UPPER", func(line string) (string, error) {
+		if line == "unchanged" {
+			return "", fmt.Errorf("cannot transform %q", line)
+		}
+		return strings.ToUpper(line), nil
+	})
+
+	postProcess.outStreams[1].Write([]byte("foo\nunchanged\n"))
+	postProcess.outStreams[0].Write([]byte("bar\n"))
+
+	expected := `
+NODE UPPER
+[ 1] FOO
+[ 1] unchanged
+[ 0] BAR
+`
+	if "\n"+string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+}
+
+// TestPostProcessFileStream tests that with --output-dir, the lines of each
+// node are transformed in its own file, with the header but without prefix
+func TestPostProcessFileStream(t *testing.T) {
+	stdout := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(2, stdout, stdout)
+	postProcess.setTransform("UPPER", func(line string) (string, error) {
+		return strings.ToUpper(line), nil
+	})
+
+	files := []*mockWriter{{[]byte{}}, {[]byte{}}}
+	postProcess.fileStream(0, files[0]).Write([]byte("{\"type\":\"ready\"}\nfoo\n"))
+	postProcess.fileStream(1, files[1]).Write([]byte("bar\n"))
+
+	for i, expected := range []string{"UPPER\nFOO\n", "UPPER\nBAR\n"} {
+		if string(files[i].output) != expected {
+			t.Errorf("node %d: %q != %q", i, string(files[i].output), expected)
+		}
+	}
+	if len(stdout.output) != 0 {
+		t.Errorf("Unexpected output on stdout: %q", string(stdout.output))
+	}
+}
+
+func TestHeartbeatWriter(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	h := newHeartbeatWriter(&out, 10*time.Second)
+	h.now = func() time.Time { return now }
+	h.last = now
+
+	// Events flowing: no heartbeat
+	for i := 0; i < 5; i++ {
+		now = now.Add(4 * time.Second)
+		fmt.Fprintf(h, "{\"pid\":%d}\n", i)
+		if wait := h.beat(now); wait != 10*time.Second {
+			t.Fatalf("wait %s after an event, expected 10s", wait)
+		}
+	}
+	now = now.Add(6 * time.Second)
+	if wait := h.beat(now); wait != 4*time.Second {
+		t.Fatalf("wait %s, expected 4s", wait)
+	}
+
+	// Idle: one heartbeat per interval
+	now = now.Add(4 * time.Second)
+	h.beat(now)
+	now = now.Add(10 * time.Second)
+	h.beat(now)
+	now = now.Add(3 * time.Second)
+	fmt.Fprintf(h, "{\"pid\":5}\n")
+
+	expected := `{"pid":0}
+{"pid":1}
+{"pid":2}
+{"pid":3}
+{"pid":4}
+{"type":"heartbeat","timestamp":"2020-06-01T12:00:30Z"}
+{"type":"heartbeat","timestamp":"2020-06-01T12:00:40Z"}
+{"pid":5}
+`
+	if out.String() != expected {
+		t.Fatalf("output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
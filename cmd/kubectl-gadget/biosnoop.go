@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/biosnoop"
+)
+
+var biosnoopMinLatency time.Duration
+
+var biosnoopHeader = fmt.Sprintf("%-27s %-6s %-16s %-8s %-7s %12s %8s %10s %s",
+	"TIME", "PID", "COMM", "DISK", "OP", "SECTOR", "BYTES", "LAT(us)", "POD")
+
+// biosnoopMinLatencyParam returns the parameter of the biosnoop and
+// nfsslower gadgets for --min-latency, in microseconds
+func biosnoopMinLatencyParam(d time.Duration) (string, error) {
+	if d < 0 {
+		return "", fmt.Errorf("invalid --min-latency %s: must not be negative", d)
+	}
+	return fmt.Sprintf(" --min-latency-us %d", d/time.Microsecond), nil
+}
+
+// biosnoopTransform returns the transform function rendering the requests
+// printed by the biosnoop gadget with the pod of their issuer
+func biosnoopTransform(containers *containercache.Cache) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := biosnoop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-8s %-7s %12d %8d %10d %s",
+			event.Timestamp, event.Pid, event.Comm, event.Disk, event.Operation, event.Sector, event.Bytes, event.LatencyUs, pod), " "), nil
+	}
+}
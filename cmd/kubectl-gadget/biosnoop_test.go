@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/biosnoop"
+)
+
+func TestBiosnoopTransform(t *testing.T) {
+	containers := testContainers("postgres-0", "postgres")
+
+	lines := `{"seq":1,"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"postgres","containerid":"abc","disk":"nvme1n1","operation":"write","sector":18874368,"bytes":8192,"latency_us":24310}
+{"seq":2,"timestamp":"2020-06-01T12:00:01.000456Z","pid":312,"comm":"kworker/u8:2","disk":"nvme0n1","operation":"read","sector":2048,"bytes":4096,"latency_us":95}
+`
+	output := runTransform(biosnoopHeader, biosnoopTransform(containers), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             DISK     OP            SECTOR    BYTES    LAT(us) POD
+[ 0] 2020-06-01T12:00:01.000123Z 4242   postgres         nvme1n1  write       18874368     8192      24310 demo/postgres-0/postgres
+[ 0] 2020-06-01T12:00:01.000456Z 312    kworker/u8:2     nvme0n1  read            2048     4096         95
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	output = runTransformRaw("", biosnoopTransform(containers), strings.SplitAfter(lines, "\n")[0])
+	event := biosnoop.Event{}
+	if err := json.Unmarshal([]byte(output), &event); err != nil {
+		t.Fatal(err)
+	}
+	expectedEvent := biosnoop.Event{
+		Timestamp:   "2020-06-01T12:00:01.000123Z",
+		Pid:         4242,
+		Comm:        "postgres",
+		ContainerID: "abc",
+		Namespace:   "demo",
+		Pod:         "postgres-0",
+		Container:   "postgres",
+		Disk:        "nvme1n1",
+		Operation:   "write",
+		Sector:      18874368,
+		Bytes:       8192,
+		LatencyUs:   24310,
+		Seq:         1,
+	}
+	if event != expectedEvent {
+		t.Fatalf("got %+v, expected %+v", event, expectedEvent)
+	}
+}
+
+func TestBiosnoopMinLatencyParam(t *testing.T) {
+	param, err := biosnoopMinLatencyParam(10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param != " --min-latency-us 10000" {
+		t.Errorf("got %q", param)
+	}
+	if _, err := biosnoopMinLatencyParam(-time.Millisecond); err == nil {
+		t.Errorf("negative latency accepted")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/bpfmetrics"
+)
+
+var bpfmetricsInterval int
+
+var bpfmetricsHeader = fmt.Sprintf("%-20s %-16s %-7s %-6s %-15s %-16s %10s %10s %9s %6s",
+	"TIME", "GADGET", "PID", "PROGID", "TYPE", "NAME", "RUNS", "RUNTIME", "AVG", "CPU")
+
+// bpfmetricsTransform returns the transform function rendering the run
+// counts and run times printed by the bpfmetrics gadget. The summaries of the
+// incomplete last interval are dropped unless emitPartial is set.
+func bpfmetricsTransform(emitPartial bool) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := bpfmetrics.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if event.Partial && !emitPartial {
+			return "", errSkipLine
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		name := event.Name
+		if event.Partial {
+			name += partialMarker
+		}
+		return fmt.Sprintf("%-20s %-16s %-7d %-6d %-15s %-16s %10d %10s %9s %5.2f%%",
+			event.Timestamp, event.Gadget, event.PID, event.ProgID, event.Type,
+			name, event.RunCount,
+			time.Duration(event.RunTimeNs).Round(time.Microsecond),
+			time.Duration(event.AverageNs()),
+			event.CPUShare()*100), nil
+	}
+}
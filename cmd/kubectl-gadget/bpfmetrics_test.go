@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBpfmetricsTransform(t *testing.T) {
+	lines := `{"timestamp":"2020-06-01T12:00:05Z","pid":4242,"gadget":"execsnoop","tracerid":"20200601115950-0a1b2c3d4e5f","progid":31,"type":"kprobe","name":"syscall__execve","runcount":1200,"runtimens":2400000,"interval":5.0}
+{"timestamp":"2020-06-01T12:00:05Z","pid":4242,"gadget":"execsnoop","tracerid":"20200601115950-0a1b2c3d4e5f","progid":32,"type":"kprobe","name":"do_ret_sys_exec","runcount":0,"runtimens":0,"interval":5.0}
+{"timestamp":"2020-06-01T12:00:07Z","pid":77,"gadget":"traceloop","progid":12,"type":"tracepoint","name":"sys_enter","runcount":500000,"runtimens":100000000,"interval":2.0,"partial":true}
+`
+	output := runTransform(bpfmetricsHeader, bpfmetricsTransform(true), lines)
+
+	expected := `
+NODE TIME                 GADGET           PID     PROGID TYPE            NAME                   RUNS    RUNTIME       AVG    CPU
+[ 0] 2020-06-01T12:00:05Z execsnoop        4242    31     kprobe          syscall__execve        1200      2.4ms       2µs  0.05%
+[ 0] 2020-06-01T12:00:05Z execsnoop        4242    32     kprobe          do_ret_sys_exec           0         0s        0s  0.00%
+[ 0] 2020-06-01T12:00:07Z traceloop        77      12     tracepoint      sys_enter (partial)     500000      100ms     200ns  5.00%
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	// The partial summary is dropped without --emit-partial
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessRaw(1, mock, mock)
+	postProcess.setTransform(bpfmetricsHeader, bpfmetricsTransform(false))
+	postProcess.outStreams[0].Write([]byte(strings.SplitAfter(lines, "\n")[2]))
+	if len(mock.output) != 0 {
+		t.Fatalf("partial summary printed: %s", string(mock.output))
+	}
+	postProcess.outStreams[0].Write([]byte(strings.SplitAfter(lines, "\n")[0]))
+	event := map[string]interface{}{}
+	if err := json.Unmarshal(mock.output, &event); err != nil {
+		t.Fatalf("invalid JSON %q: %s", string(mock.output), err)
+	}
+	if event["name"] != "syscall__execve" || event["runtimens"] != float64(2400000) {
+		t.Fatalf("unexpected event: %s", string(mock.output))
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/cachestat"
+)
+
+var cachestatInterval int
+
+// partialMarker is appended to the summaries of the incomplete last interval
+const partialMarker = " (partial)"
+
+var cachestatHeader = fmt.Sprintf("%-20s %10s %10s %10s %8s %s",
+	"TIME", "HITS", "MISSES", "DIRTIES", "HITRATIO", "POD")
+
+// cachestatTransform returns the transform function rendering the summaries
+// printed by the cachestat gadget with the pod of the container. The summaries
+// of the incomplete last interval are dropped unless emitPartial is set, and
+// the summaries of the history of the node unless selected by history.
+func cachestatTransform(containers *containercache.Cache, emitPartial bool, history historySelector) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := cachestat.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if event.Partial && !emitPartial {
+			return "", errSkipLine
+		}
+		m := lookupContainer(containers, event.ContainerID)
+		if m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if event.History && !history.match(m) {
+			return "", errSkipLine
+		}
+		if protobufWriter != nil {
+			return writeProtobuf(eventpb.FromCachestat(event))
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		var pod string
+		switch {
+		case event.Scope == cachestat.ScopeNode:
+			pod = "(node)"
+		case event.Pod != "":
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		default:
+			// Container not found in the selected namespace
+			pod = "container " + shortContainerID(event.ContainerID)
+		}
+		if event.Partial {
+			pod += partialMarker
+		}
+		return fmt.Sprintf("%-20s %10d %10d %10d %7.2f%% %s",
+			event.Timestamp, event.Hits, event.Misses, event.Dirties,
+			event.HitRatio*100, pod), nil
+	}
+}
+
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
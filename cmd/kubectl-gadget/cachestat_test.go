@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCachestatTransform(t *testing.T) {
+	containers := testContainers("db-0", "postgres")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01Z","scope":"node","hits":900,"misses":100,"dirties":12,"hitratio":0.9}
+{"timestamp":"2020-06-01T12:00:01Z","scope":"container","containerid":"abc","hits":300,"misses":100,"dirties":10,"hitratio":0.75}
+{"timestamp":"2020-06-01T12:00:01Z","scope":"container","containerid":"0123456789abcdef","hits":600,"misses":0,"dirties":2,"hitratio":1}
+`
+	output := runTransform(cachestatHeader, cachestatTransform(containers, true, historySelector{}), lines)
+
+	expected := `
+NODE TIME                       HITS     MISSES    DIRTIES HITRATIO POD
+[ 0] 2020-06-01T12:00:01Z        900        100         12   90.00% (node)
+[ 0] 2020-06-01T12:00:01Z        300        100         10   75.00% demo/db-0/postgres
+[ 0] 2020-06-01T12:00:01Z        600          0          2  100.00% container 0123456789ab
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
+
+func TestCachestatPartialWindow(t *testing.T) {
+	containers := noContainers()
+
+	// Stopped 2 seconds into the second interval
+	lines := `{"timestamp":"2020-06-01T12:00:05Z","scope":"node","hits":900,"misses":100,"dirties":12,"hitratio":0.9}
+{"timestamp":"2020-06-01T12:00:07Z","scope":"node","hits":300,"misses":100,"dirties":10,"hitratio":0.75,"partial":true}
+`
+	table := []struct {
+		emitPartial bool
+		expected    string
+	}{
+		{
+			emitPartial: true,
+			expected: `
+NODE TIME                       HITS     MISSES    DIRTIES HITRATIO POD
+[ 0] 2020-06-01T12:00:05Z        900        100         12   90.00% (node)
+[ 0] 2020-06-01T12:00:07Z        300        100         10   75.00% (node) (partial)
+`,
+		},
+		{
+			emitPartial: false,
+			expected: `
+NODE TIME                       HITS     MISSES    DIRTIES HITRATIO POD
+[ 0] 2020-06-01T12:00:05Z        900        100         12   90.00% (node)
+`,
+		},
+	}
+
+	for _, entry := range table {
+		output := runTransform(cachestatHeader, cachestatTransform(containers, entry.emitPartial, historySelector{}), lines)
+		if "\n"+output != entry.expected {
+			t.Errorf("emitPartial=%t: %v != %v", entry.emitPartial, output, entry.expected)
+		}
+	}
+
+	// The marker is kept in JSON
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessJSON([]string{"node-a"}, mock)
+	postProcess.setTransform(cachestatHeader, cachestatTransform(containers, true, historySelector{}))
+	postProcess.outStreams[0].Write([]byte(lines))
+	if !strings.Contains(string(mock.output), `"hits":300,"misses":100,"dirties":10,"hitratio":0.75,"partial":true}`) {
+		t.Fatalf("partial summary not marked: %s", string(mock.output))
+	}
+}
+
+func TestCachestatHistory(t *testing.T) {
+	containers := testContainers("db-0", "postgres")
+
+	// The history is recorded for the whole node
+	lines := `{"history":true,"hitratio":0.9,"hits":900,"misses":100,"dirties":12,"scope":"node","timestamp":"2020-06-01T11:58:00Z"}
+{"containerid":"abc","history":true,"hitratio":0.75,"hits":300,"misses":100,"dirties":10,"scope":"container","timestamp":"2020-06-01T11:58:00Z"}
+{"containerid":"0123456789abcdef","history":true,"hitratio":1,"hits":600,"misses":0,"dirties":2,"scope":"container","timestamp":"2020-06-01T11:58:00Z"}
+{"timestamp":"2020-06-01T12:00:01Z","scope":"node","hits":50,"misses":50,"dirties":0,"hitratio":0.5}
+{"timestamp":"2020-06-01T12:00:01Z","scope":"container","containerid":"abc","hits":50,"misses":50,"dirties":0,"hitratio":0.5}
+`
+	table := []struct {
+		history  historySelector
+		expected string
+	}{
+		{
+			history: newHistorySelector("", "", "", ""),
+			expected: `
+NODE TIME                       HITS     MISSES    DIRTIES HITRATIO POD
+[ 0] 2020-06-01T11:58:00Z        900        100         12   90.00% (node)
+[ 0] 2020-06-01T11:58:00Z        300        100         10   75.00% demo/db-0/postgres
+[ 0] 2020-06-01T11:58:00Z        600          0          2  100.00% container 0123456789ab
+[ 0] 2020-06-01T12:00:01Z         50         50          0   50.00% (node)
+[ 0] 2020-06-01T12:00:01Z         50         50          0   50.00% demo/db-0/postgres
+`,
+		},
+		{
+			history: newHistorySelector("demo", "", "", ""),
+			expected: `
+NODE TIME                       HITS     MISSES    DIRTIES HITRATIO POD
+[ 0] 2020-06-01T11:58:00Z        300        100         10   75.00% demo/db-0/postgres
+[ 0] 2020-06-01T12:00:01Z         50         50          0   50.00% (node)
+[ 0] 2020-06-01T12:00:01Z         50         50          0   50.00% demo/db-0/postgres
+`,
+		},
+		{
+			history: newHistorySelector("demo", "web-1", "", ""),
+			expected: `
+NODE TIME                       HITS     MISSES    DIRTIES HITRATIO POD
+[ 0] 2020-06-01T12:00:01Z         50         50          0   50.00% (node)
+[ 0] 2020-06-01T12:00:01Z         50         50          0   50.00% demo/db-0/postgres
+`,
+		},
+	}
+
+	for _, entry := range table {
+		output := runTransform(cachestatHeader, cachestatTransform(containers, true, entry.history), lines)
+		if "\n"+output != entry.expected {
+			t.Errorf("%+v: %v != %v", entry.history, output, entry.expected)
+		}
+	}
+}
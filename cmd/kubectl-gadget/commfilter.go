@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/commfilter"
+)
+
+var commParam []string
+
+func init() {
+	for _, command := range []*cobra.Command{execsnoopCmd, tcpconnlatCmd, ugidsnoopCmd, swapinCmd, tcppingCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd} {
+		command.PersistentFlags().StringArrayVar(&commParam, "comm", nil,
+			fmt.Sprintf("Only trace the processes with this name, compared on its first %d bytes as the kernel truncates it (can be repeated)", commfilter.MaxLen))
+	}
+}
+
+// commFilterParam returns the parameter of the gadgets tracing only the
+// processes named names, none without names, and the names that are
+// truncated
+func commFilterParam(names []string) (string, []string, error) {
+	if len(names) == 0 {
+		return "", nil, nil
+	}
+	truncated, err := commfilter.Check(names)
+	if err != nil {
+		return "", nil, fmt.Errorf("Invalid --comm: %s", err)
+	}
+	return fmt.Sprintf(" --comm %q", strings.Join(names, ",")), truncated, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommFilterParam(t *testing.T) {
+	table := []struct {
+		names     []string
+		expected  string
+		truncated []string
+		err       bool
+	}{
+		{names: nil, expected: ""},
+		{names: []string{"nginx", "java"}, expected: ` --comm "nginx,java"`},
+		{
+			names:     []string{"kube-controller-manager"},
+			expected:  ` --comm "kube-controller-manager"`,
+			truncated: []string{"kube-controller-manager"},
+		},
+		{names: []string{"nginx,java"}, err: true},
+	}
+	for _, entry := range table {
+		param, truncated, err := commFilterParam(entry.names)
+		if entry.err {
+			if err == nil {
+				t.Errorf("%q: expected an error", entry.names)
+			}
+			continue
+		}
+		if err != nil || param != entry.expected || !reflect.DeepEqual(truncated, entry.truncated) {
+			t.Errorf("%q: got %q, %q, %v, expected %q, %q", entry.names, param, truncated, err, entry.expected, entry.truncated)
+		}
+	}
+}
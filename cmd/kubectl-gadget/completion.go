@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// completionTimeout bounds the requests to the cluster of the dynamic
+// completions, so that pressing tab doesn't hang when it is unreachable
+const completionTimeout = 3 * time.Second
+
+var completionCmd = &cobra.Command{
+	Use:   "completion SHELL",
+	Short: "Output the shell completion code for bash or zsh",
+	Long: `Output the shell completion code for bash or zsh.
+
+With bash, the namespaces, the labels of the pods and the IDs of the traces
+are completed from the cluster:
+
+  source <(kubectl-gadget completion bash)
+
+With zsh, only the commands are completed:
+
+  kubectl-gadget completion zsh > "${fpath[1]}/_kubectl-gadget"
+
+The completion is the one of kubectl-gadget: kubectl doesn't complete the
+commands of its plugins.`,
+	ValidArgs: []string{"bash", "zsh"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Usage: kubectl-gadget completion [bash|zsh]")
+		}
+		return writeCompletion(os.Stdout, rootCmd, args[0])
+	},
+}
+
+// completeNamesCmd prints the names completed by the functions of
+// completionBashFunctions. It prints nothing when the cluster cannot be
+// queried, so that the completion degrades to no suggestion.
+var completeNamesCmd = &cobra.Command{
+	Use:    "__complete-names [namespaces|labels|traces]",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			return
+		}
+		names, err := completeNames(args[0], completeNamespace, completeAllNamespaces)
+		if err != nil {
+			return
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	},
+}
+
+var (
+	completeNamespace     string
+	completeAllNamespaces bool
+)
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(completeNamesCmd)
+	rootCmd.BashCompletionFunction = fmt.Sprintf(completionBashFunctions, rootCmd.Name())
+
+	completeNamesCmd.Flags().VarP(namespaceListValue{&completeNamespace}, "namespace", "n", "")
+	completeNamesCmd.Flags().BoolVarP(&completeAllNamespaces, "all-namespaces", "A", false, "")
+}
+
+// completionBashFunctions are the functions of the bash completion calling
+// "kubectl-gadget __complete-names", with the flags selecting the cluster and
+// the namespace typed before the word completed. __custom_func is called by
+// the completion generated by cobra for the arguments it cannot complete.
+const completionBashFunctions = `__%[1]s_override_flags()
+{
+    local w prev_flag=""
+    for w in "${words[@]:0:${cword}}"; do
+        if [[ -n ${prev_flag} ]]; then
+            echo -n "${prev_flag}=${w} "
+            prev_flag=""
+            continue
+        fi
+        case "${w}" in
+            --kubeconfig|--context|--gadget-namespace|--namespace|-n)
+                prev_flag=${w}
+                ;;
+            --kubeconfig=*|--context=*|--gadget-namespace=*|--namespace=*|-n=*|--all-namespaces|-A)
+                echo -n "${w} "
+                ;;
+        esac
+    done
+}
+
+__%[1]s_complete_names()
+{
+    local out
+    if out=$(%[1]s $(__%[1]s_override_flags) __complete-names "$1" 2>/dev/null); then
+        COMPREPLY=( $( compgen -W "${out[*]}" -- "$cur" ) )
+    fi
+}
+
+__%[1]s_complete_namespaces()
+{
+    __%[1]s_complete_names namespaces
+}
+
+__%[1]s_complete_labels()
+{
+    __%[1]s_complete_names labels
+}
+
+__custom_func() {
+    case ${last_command} in
+        %[1]s_traceloop_show | %[1]s_traceloop_close)
+            __%[1]s_complete_names traces
+            return
+            ;;
+        *)
+            ;;
+    esac
+}
+`
+
+// completionFlagFunctions are the bash functions completing the values of
+// the flags of all the commands, by flag name
+var completionFlagFunctions = map[string]string{
+	"namespace": "__%s_complete_namespaces",
+	"label":     "__%s_complete_labels",
+	"selector":  "__%s_complete_labels",
+}
+
+// writeCompletion writes the completion code of root for shell
+func writeCompletion(out io.Writer, root *cobra.Command, shell string) error {
+	switch shell {
+	case "bash":
+		annotateCompletionFlags(root)
+		return root.GenBashCompletion(out)
+	case "zsh":
+		return root.GenZshCompletion(out)
+	default:
+		return fmt.Errorf("Unsupported shell %q, only bash and zsh are supported", shell)
+	}
+}
+
+// annotateCompletionFlags marks the flags of cmd and of its subcommands
+// listed in completionFlagFunctions to be completed by their function. The
+// flags are only annotated when the completion code is generated, once all
+// the commands have their flags.
+func annotateCompletionFlags(cmd *cobra.Command) {
+	flags := cmd.LocalFlags()
+	for name, f := range completionFlagFunctions {
+		if flags.Lookup(name) != nil {
+			flags.SetAnnotation(name, cobra.BashCompCustom, []string{fmt.Sprintf(f, cmd.Root().Name())})
+		}
+	}
+	for _, c := range cmd.Commands() {
+		annotateCompletionFlags(c)
+	}
+}
+
+// completeNames returns the names of kind in the cluster: the namespaces,
+// the labels of the pods of namespace as key=value, or the IDs of the traces
+// of traceloop, of namespace if not empty
+func completeNames(kind, namespace string, allNamespaces bool) ([]string, error) {
+	if err := doesKubeconfigExist(nil, nil); err != nil {
+		return nil, err
+	}
+	config, err := kubeClientConfig().ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = completionTimeout
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	switch kind {
+	case "namespaces":
+		namespaces, err := client.CoreV1().Namespaces().List(metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range namespaces.Items {
+			names = append(names, ns.Name)
+		}
+	case "labels":
+		namespace, err := resolveNamespace(namespace, allNamespaces, getDefaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+		pods, err := client.CoreV1().Pods(listNamespace(namespace)).List(metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		labels := []map[string]string{}
+		for _, pod := range pods.Items {
+			if namespaceSelected(namespace, pod.Namespace) {
+				labels = append(labels, pod.Labels)
+			}
+		}
+		names = labelPairs(labels)
+	case "traces":
+		tracesPerNode, err := getTracesListPerNode(client)
+		if err != nil {
+			return nil, err
+		}
+		for _, tm := range tracesPerNode {
+			for _, trace := range selectTraces(tm, namespace, nil) {
+				names = append(names, trace.TraceID)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+	return uniqueSorted(names), nil
+}
+
+// labelPairs returns the labels as key=value, sorted and without
+// duplicates
+func labelPairs(labels []map[string]string) []string {
+	pairs := []string{}
+	for _, l := range labels {
+		for key, value := range l {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return uniqueSorted(pairs)
+}
+
+// uniqueSorted returns names sorted, without duplicates
+func uniqueSorted(names []string) []string {
+	sort.Strings(names)
+	out := []string{}
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeCompletion(&out, rootCmd, "bash"); err != nil {
+		t.Fatal(err)
+	}
+	script := out.String()
+	for _, expected := range []string{
+		// The trace IDs of traceloop show
+		"kubectl-gadget_traceloop_show | kubectl-gadget_traceloop_close)",
+		// The flags of the gadgets and of traceloop
+		`flags_completion+=("__kubectl-gadget_complete_namespaces")`,
+		`flags_completion+=("__kubectl-gadget_complete_labels")`,
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("%q not found in the bash completion", expected)
+		}
+	}
+	if strings.Contains(script, `commands+=("__complete-names")`) {
+		t.Errorf("hidden command __complete-names completed")
+	}
+
+	if err := writeCompletion(&out, rootCmd, "fish"); err == nil {
+		t.Errorf("no error for fish")
+	}
+}
+
+func TestLabelPairs(t *testing.T) {
+	labels := []map[string]string{
+		{"app": "web", "tier": "frontend"},
+		{"app": "web"},
+		{"app": "db"},
+		nil,
+	}
+	expected := []string{"app=db", "app=web", "tier=frontend"}
+	if got := labelPairs(labels); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
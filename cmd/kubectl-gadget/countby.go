@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/eventcount"
+	"github.com/kinvolk/inspektor-gadget/pkg/fieldmap"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/biosnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnsconnect"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnssnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/execsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/hostpathsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/killsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/nfsslower"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/solisten"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/swapin"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpconnlat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpping"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ttysnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ugidsnoop"
+)
+
+var (
+	countByParam       string
+	countIntervalParam time.Duration
+)
+
+func init() {
+	for _, command := range []*cobra.Command{execsnoopCmd, tcpconnlatCmd, ugidsnoopCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd} {
+		command.PersistentFlags().StringVar(&countByParam, "count-by", "",
+			"Instead of printing the events, count them by the values of these fields of their JSON output, a comma-separated list (e.g. pod,comm), and print the counts when terminating")
+		command.PersistentFlags().DurationVar(&countIntervalParam, "count-interval", 0,
+			"With --count-by, print the counts of each interval (e.g. 10s) instead of the counts of the whole run")
+	}
+}
+
+// countByEvents are the events of the gadgets, as printed with --json, whose
+// fields can be counted by with --count-by
+var countByEvents = map[string]interface{}{
+	"execsnoop":     execsnoop.Event{},
+	"tcpconnlat":    tcpconnlat.Event{},
+	"ugidsnoop":     ugidsnoop.DecodedEvent{},
+	"swapin":        swapin.Event{},
+	"tcpping":       tcpping.Event{},
+	"killsnoop":     killsnoop.Event{},
+	"hostpathsnoop": hostpathsnoop.Event{},
+	"solisten":      solisten.Event{},
+	"dnsconnect":    dnsconnect.Event{},
+	"dnssnoop":      dnssnoop.Event{},
+	"biosnoop":      biosnoop.Event{},
+	"ttysnoop":      ttysnoop.Event{},
+	"nfsslower":     nfsslower.Event{},
+}
+
+// countByFields returns the fields of --count-by, checked against the
+// fields of the events of subCommand
+func countByFields(subCommand, list string) ([]string, error) {
+	event, ok := countByEvents[subCommand]
+	if !ok {
+		return nil, fmt.Errorf("--count-by is not supported by %s", subCommand)
+	}
+	return eventcount.ParseFields(list, fieldmap.JSONFields(event))
+}
+
+// countRecord is the count of the events with the same values of the fields
+// of --count-by, printed with --json
+type countRecord struct {
+	Type      string                     `json:"type"`
+	Timestamp string                     `json:"timestamp"`
+	Fields    map[string]json.RawMessage `json:"fields"`
+	Count     uint64                     `json:"count"`
+}
+
+// eventCounter counts the events of all the nodes for --count-by. The events
+// are counted as rendered in JSON by the transform of the gadget, so that
+// the fields added by kubectl-gadget, like the pod, can be counted by too.
+type eventCounter struct {
+	mu      sync.Mutex
+	counter *eventcount.Counter
+	out     io.Writer
+	json    bool
+	now     func() time.Time // can be replaced in tests
+}
+
+func newEventCounter(fields []string, out io.Writer, json bool) *eventCounter {
+	return &eventCounter{
+		counter: eventcount.New(fields),
+		out:     out,
+		json:    json,
+		now:     time.Now,
+	}
+}
+
+// setCountBy counts the events transformed on outStreams with c instead of
+// printing them
+func (p *postProcess) setCountBy(c *eventCounter) {
+	for _, s := range p.outStreams {
+		s.counter = c
+		s.header = ""
+	}
+}
+
+// add counts an event rendered in JSON. It returns false for the lines that
+// are not events, like the errors, to be printed as usual.
+func (c *eventCounter) add(transformed string) bool {
+	if !strings.HasPrefix(transformed, "{") {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counter.Add([]byte(transformed)) == nil
+}
+
+// print prints the counts since the last call, as a table or as count
+// records with --json, and resets them
+func (c *eventCounter) print() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	groups := c.counter.Flush()
+	fields := c.counter.Fields()
+	if c.json {
+		timestamp := c.now().UTC().Format(time.RFC3339)
+		for _, g := range groups {
+			record := countRecord{
+				Type:      "count",
+				Timestamp: timestamp,
+				Fields:    map[string]json.RawMessage{},
+				Count:     g.Count,
+			}
+			for i, f := range fields {
+				record.Fields[f] = g.Values[i]
+			}
+			buf, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(c.out, "%s\n", buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	header := []string{}
+	for _, f := range fields {
+		header = append(header, strings.ToUpper(f))
+	}
+	header = append(header, "COUNT")
+	rows := [][]string{}
+	for _, g := range groups {
+		row := []string{}
+		for _, v := range g.Values {
+			value := eventcount.FormatValue(v)
+			if value == "" {
+				value = emptyField
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, append(row, fmt.Sprint(g.Count)))
+	}
+	writeTable(c.out, header, rows, "")
+	_, err := fmt.Fprintln(c.out)
+	return err
+}
+
+// start prints the counts every interval. It returns a function stopping it
+// and printing the counts of the last interval.
+func (c *eventCounter) start(interval time.Duration) (stop func() error) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.print()
+			}
+		}
+	}()
+	return func() error {
+		close(done)
+		<-stopped
+		return c.print()
+	}
+}
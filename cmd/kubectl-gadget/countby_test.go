@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+)
+
+func TestCountBy(t *testing.T) {
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		if id == "" {
+			return nil, nil
+		}
+		return &containercache.Metadata{Namespace: "demo", Pod: "nginx-6db4", Container: "nginx"}, nil
+	}, containercache.DefaultConfig)
+
+	retry := func(containerID string, ret int) string {
+		return fmt.Sprintf(`{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"comm":"nginx","containerid":"%s","proto":"TCP","ipversion":4,"addr":"0.0.0.0","port":80,"backlog":511,"ret":%d}`+"\n", containerID, ret)
+	}
+	lines := retry("abc", -98) + retry("abc", 0) + retry("abc", -98) + retry("", -98) + "not an event\n"
+
+	if _, err := countByFields("solisten", "pod,syscall"); err == nil {
+		t.Fatalf("no error for unknown field")
+	}
+	fields, err := countByFields("solisten", "pod,ret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The events are rendered in JSON to be counted, as with --count-by
+	// without --json
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	mock := &mockWriter{[]byte{}}
+	counts := &mockWriter{[]byte{}}
+	postProcess := newPostProcessRaw(1, mock, mock)
+	postProcess.setTransform(solistenHeader, solistenTransform(containers))
+	counter := newEventCounter(fields, counts, false)
+	postProcess.setCountBy(counter)
+	postProcess.outStreams[0].Write([]byte(lines))
+	if err := counter.print(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the lines that are not events are printed
+	if string(mock.output) != "not an event\n" {
+		t.Fatalf("unexpected output %q", mock.output)
+	}
+	expected := `
+POD           RET    COUNT
+nginx-6db4    -98    2
+nginx-6db4    0      1
+-             -98    1
+
+`
+	if "\n"+string(counts.output) != expected {
+		t.Fatalf("%v != %v", string(counts.output), expected)
+	}
+
+	counts = &mockWriter{[]byte{}}
+	counter = newEventCounter(fields, counts, true)
+	counter.now = func() time.Time { return time.Date(2020, 6, 1, 12, 0, 10, 0, time.UTC) }
+	postProcess.setCountBy(counter)
+	postProcess.outStreams[0].Write([]byte(retry("abc", 0)))
+	if err := counter.print(); err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"type":"count","timestamp":"2020-06-01T12:00:10Z","fields":{"pod":"nginx-6db4","ret":0},"count":1}
+`
+	if string(counts.output) != expected {
+		t.Fatalf("%v != %v", string(counts.output), expected)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/cpubudget"
+)
+
+var cpuBudgetParam string
+
+func init() {
+	for _, command := range []*cobra.Command{tcpconnlatCmd, ugidsnoopCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd} {
+		command.PersistentFlags().StringVar(&cpuBudgetParam, "cpu-budget", "",
+			"Percentage of one CPU the BPF programs of the gadget can run on each node, like 5%. Above it, the gadget samples the events, reporting it on stderr. Requires Linux 5.1")
+	}
+}
+
+// cpuBudgetGadgetParam returns the parameter of the gadgets sampling their
+// events above budget, a percentage of one CPU, none without budget
+func cpuBudgetGadgetParam(budget string) (string, error) {
+	if budget == "" {
+		return "", nil
+	}
+	share, err := cpubudget.Parse(budget)
+	if err != nil {
+		return "", fmt.Errorf("Invalid --cpu-budget: %s", err)
+	}
+	return fmt.Sprintf(" --cpu-budget %g", share), nil
+}
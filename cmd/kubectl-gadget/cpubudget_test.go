@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestCPUBudgetGadgetParam(t *testing.T) {
+	for budget, expected := range map[string]string{
+		"":     "",
+		"5%":   " --cpu-budget 0.05",
+		"0.5%": " --cpu-budget 0.005",
+	} {
+		param, err := cpuBudgetGadgetParam(budget)
+		if err != nil || param != expected {
+			t.Errorf("%q: got %q, %v, expected %q", budget, param, err, expected)
+		}
+	}
+	if _, err := cpuBudgetGadgetParam("5"); err == nil {
+		t.Errorf("expected an error without %%")
+	}
+}
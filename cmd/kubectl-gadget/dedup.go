@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/eventdedup"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnsconnect"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnssnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/solisten"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpconnlat"
+)
+
+var dedupWindowParam time.Duration
+
+func init() {
+	for _, command := range []*cobra.Command{tcpconnlatCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd} {
+		command.PersistentFlags().DurationVar(&dedupWindowParam, "dedup-window", 0,
+			"Print the events repeating a flow printed less than this duration ago, like 5s, only as a count when the window closes. 0 to print all the events")
+	}
+}
+
+// dedupFlowKeys return the flow of an event line of the network gadgets,
+// for --dedup-window
+var dedupFlowKeys = map[string]func(line string) (string, error){
+	"tcpconnlat": func(line string) (string, error) {
+		event := tcpconnlat.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return tcpconnlat.FlowKey(event), err
+	},
+	"solisten": func(line string) (string, error) {
+		event := solisten.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return solisten.FlowKey(event), err
+	},
+	"dnsconnect": func(line string) (string, error) {
+		event := dnsconnect.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return dnsconnect.FlowKey(event), err
+	},
+	"dnssnoop": func(line string) (string, error) {
+		event := dnssnoop.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return dnssnoop.FlowKey(event), err
+	},
+}
+
+// repeatRecord reports in a JSON stream the events of a flow suppressed by
+// --dedup-window
+type repeatRecord struct {
+	Type   string `json:"type"`
+	Count  int    `json:"count"`
+	Window string `json:"window"`
+	// Last event suppressed
+	Event json.RawMessage `json:"event"`
+}
+
+// flowDedup suppresses the events of a node repeating a flow. The windows
+// are closed by the events and by a ticker, hence the lock.
+type flowDedup struct {
+	mu     sync.Mutex
+	window *eventdedup.Window
+	d      time.Duration
+	key    func(line string) (string, error)
+	now    func() time.Time // can be replaced in tests
+}
+
+// setDedup suppresses the events repeating a flow within d on outStreams, one
+// node each, key returning the flow of an event line
+func (p *postProcess) setDedup(d time.Duration, key func(line string) (string, error)) {
+	for _, s := range p.outStreams {
+		s.dedup = &flowDedup{
+			window: eventdedup.New(d),
+			d:      d,
+			key:    key,
+			now:    time.Now,
+		}
+	}
+}
+
+// suppressedEvent is an event suppressed by --dedup-window
+type suppressedEvent struct {
+	// Line as printed by the gadget
+	line string
+	// Event as transformed, and as printed with its node and columns
+	transformed string
+	printed     string
+}
+
+// dedupEvent returns whether the event line must be printed, after printing
+// the repeats of the windows closed since the last event. The lines that are
+// not events are always printed.
+func (post *postProcessSingle) dedupEvent(line, transformed, printed string) bool {
+	key, err := post.dedup.key(line)
+	if err != nil {
+		return true
+	}
+	post.dedup.mu.Lock()
+	defer post.dedup.mu.Unlock()
+	now := post.dedup.now()
+	post.printRepeats(post.dedup.window.Expire(now))
+	return post.dedup.window.Add(key, suppressedEvent{line, transformed, printed}, now)
+}
+
+// expireDedup prints the repeats of the windows closed by now, or of all the
+// windows with all, when terminating
+func (post *postProcessSingle) expireDedup(all bool) {
+	post.dedup.mu.Lock()
+	defer post.dedup.mu.Unlock()
+	if all {
+		post.printRepeats(post.dedup.window.Flush())
+		return
+	}
+	post.printRepeats(post.dedup.window.Expire(post.dedup.now()))
+}
+
+// printRepeats prints the last event suppressed of each flow with the number
+// of events suppressed, or a repeat record with --json
+func (post *postProcessSingle) printRepeats(repeats []eventdedup.Repeat) {
+	for _, r := range repeats {
+		last := r.Last.(suppressedEvent)
+		if jsonOutput {
+			buf, err := json.Marshal(repeatRecord{
+				Type:   "repeated",
+				Count:  r.Count,
+				Window: post.dedup.d.String(),
+				Event:  json.RawMessage(last.transformed),
+			})
+			if err == nil {
+				post.print(last.line, string(buf))
+			}
+			continue
+		}
+		post.print(last.line, fmt.Sprintf("%s (repeated %d times within %s)", last.printed, r.Count, post.dedup.d))
+	}
+}
+
+// startDedup closes the windows of --dedup-window every second, so that the
+// repeats of a flow are printed even when no more events are received. It
+// returns a function stopping it and printing the repeats of all the windows.
+func (p *postProcess) startDedup() (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Second):
+				for _, s := range p.outStreams {
+					s.expireDedup(false)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		for _, s := range p.outStreams {
+			s.expireDedup(true)
+		}
+	}
+}
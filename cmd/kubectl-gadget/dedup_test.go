@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+)
+
+func TestDedupWindow(t *testing.T) {
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		return &containercache.Metadata{Namespace: "demo", Pod: "nginx-6db4", Container: "nginx"}, nil
+	}, containercache.DefaultConfig)
+
+	retry := func(ts string, pid int) string {
+		return fmt.Sprintf(`{"timestamp":"%s","pid":%d,"comm":"nginx","containerid":"abc","proto":"TCP","ipversion":4,"addr":"0.0.0.0","port":80,"backlog":511,"ret":-98}`+"\n", ts, pid)
+	}
+
+	now := time.Date(2020, 6, 1, 12, 0, 1, 0, time.UTC)
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(1, mock, mock)
+	postProcess.setTransform(solistenHeader, solistenTransform(containers))
+	postProcess.setDedup(5*time.Second, dedupFlowKeys["solisten"])
+	post := postProcess.outStreams[0]
+	post.dedup.now = func() time.Time { return now }
+
+	post.Write([]byte(retry("2020-06-01T12:00:01.000001Z", 4242)))
+	now = now.Add(time.Second)
+	post.Write([]byte(retry("2020-06-01T12:00:02.000001Z", 4243)))
+	now = now.Add(time.Second)
+	post.Write([]byte(retry("2020-06-01T12:00:03.000001Z", 4244)))
+
+	// The window closes: the count is printed, and the next retry is
+	// printed again
+	now = now.Add(3 * time.Second)
+	post.expireDedup(false)
+	now = now.Add(time.Second)
+	post.Write([]byte(retry("2020-06-01T12:00:07.000001Z", 4245)))
+	post.expireDedup(true)
+
+	expected := `
+NODE TIME                        PID    COMM             PROTO ADDR                     BACKLOG RESULT     POD
+[ 0] 2020-06-01T12:00:01.000001Z 4242   nginx            TCPv4 0.0.0.0:80               511     EADDRINUSE demo/nginx-6db4/nginx
+[ 0] 2020-06-01T12:00:03.000001Z 4244   nginx            TCPv4 0.0.0.0:80               511     EADDRINUSE demo/nginx-6db4/nginx (repeated 2 times within 5s)
+[ 0] 2020-06-01T12:00:07.000001Z 4245   nginx            TCPv4 0.0.0.0:80               511     EADDRINUSE demo/nginx-6db4/nginx
+`
+	if "\n"+string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	mock = &mockWriter{[]byte{}}
+	postProcess = newPostProcessRaw(1, mock, mock)
+	postProcess.setTransform(solistenHeader, solistenTransform(containers))
+	postProcess.setDedup(5*time.Second, dedupFlowKeys["solisten"])
+	post = postProcess.outStreams[0]
+	post.dedup.now = func() time.Time { return now }
+	post.Write([]byte(retry("2020-06-01T12:00:01.000001Z", 4242) + retry("2020-06-01T12:00:02.000001Z", 4243)))
+	post.expireDedup(true)
+
+	expected = `{"type":"repeated","count":1,"window":"5s","event":{"timestamp":"2020-06-01T12:00:02.000001Z","pid":4243,"comm":"nginx","containerid":"abc","namespace":"demo","pod":"nginx-6db4","container":"nginx","proto":"TCP","ipversion":4,"addr":"0.0.0.0","port":80,"backlog":511,"ret":-98,"error":"address already in use"}}
+`
+	if !strings.HasSuffix(string(mock.output), expected) {
+		t.Fatalf("%v doesn't end with %v", string(mock.output), expected)
+	}
+}
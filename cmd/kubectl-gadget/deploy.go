@@ -2,10 +2,18 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/template"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
 )
 
 var deployCmd = &cobra.Command{
@@ -19,9 +27,37 @@ var deployCmd = &cobra.Command{
 var gadgetimage = "undefined"
 
 var (
-	image         string
-	traceloop     bool
-	runcHooksMode string
+	image            string
+	imagePullPolicy  string
+	imagePullSecrets []string
+	traceloop        bool
+	runcHooksMode    string
+	readOnlyRoot     bool
+
+	allowedNamespaces string
+	tenantNamespace   string
+
+	serviceAccount       string
+	createServiceAccount bool
+
+	aggregateTo string
+
+	readinessInitialDelay time.Duration
+	readinessPeriod       time.Duration
+	livenessInitialDelay  time.Duration
+	livenessPeriod        time.Duration
+
+	terminationGracePeriod time.Duration
+
+	cpuRequest    string
+	memoryRequest string
+	cpuLimit      string
+	memoryLimit   string
+
+	historyDuration time.Duration
+
+	nodeSelector string
+	tolerations  []string
 )
 
 func init() {
@@ -30,6 +66,16 @@ func init() {
 		"image", "",
 		gadgetimage,
 		"container image")
+	deployCmd.PersistentFlags().StringVarP(
+		&imagePullPolicy,
+		"image-pull-policy", "",
+		"Always",
+		"pull policy of the container image (Always, IfNotPresent, Never)")
+	deployCmd.PersistentFlags().StringArrayVarP(
+		&imagePullSecrets,
+		"image-pull-secret", "",
+		nil,
+		"name of a secret to pull the container image with, in the namespace of the gadget pods (can be repeated)")
 	deployCmd.PersistentFlags().BoolVarP(
 		&traceloop,
 		"traceloop", "",
@@ -40,35 +86,192 @@ func init() {
 		"runc-hooks-mode", "",
 		"auto",
 		"how to attach runc hooks (auto, crio, flatcar_edge, ldpreload)")
+	deployCmd.PersistentFlags().BoolVarP(
+		&readOnlyRoot,
+		"read-only-root", "",
+		false,
+		"run the gadget container with a read-only root filesystem, with writable volumes for the directories the gadgets write to")
+	deployCmd.PersistentFlags().StringVarP(
+		&allowedNamespaces,
+		"allowed-namespaces", "",
+		"",
+		"comma-separated list of the only namespaces the gadgets can trace, all namespaces if empty")
+	deployCmd.PersistentFlags().StringVarP(
+		&tenantNamespace,
+		"tenant-namespace", "",
+		"",
+		"only allow the gadgets to trace this namespace, and create the gadget-tenant roles to grant access to them instead of the gadget-user cluster role")
+	deployCmd.PersistentFlags().StringVarP(
+		&serviceAccount,
+		"service-account", "",
+		"gadget",
+		"name of the service account of the gadget pods, in their namespace")
+	deployCmd.PersistentFlags().BoolVarP(
+		&createServiceAccount,
+		"create-service-account", "",
+		true,
+		"create the service account, instead of using an existing one (e.g. bound to a cloud identity)")
+	deployCmd.PersistentFlags().StringVarP(
+		&aggregateTo,
+		"aggregate-to", "",
+		"",
+		"comma-separated list of the default roles (admin, edit) the gadget-user cluster role is aggregated to")
+	deployCmd.PersistentFlags().DurationVarP(
+		&readinessInitialDelay,
+		"readiness-initial-delay", "",
+		5*time.Second,
+		"delay before the first readiness check of the gadget pods")
+	deployCmd.PersistentFlags().DurationVarP(
+		&readinessPeriod,
+		"readiness-period", "",
+		10*time.Second,
+		"interval between two readiness checks of the gadget pods")
+	deployCmd.PersistentFlags().DurationVarP(
+		&livenessInitialDelay,
+		"liveness-initial-delay", "",
+		60*time.Second,
+		"delay before the first liveness check of the gadget pods, increase it on nodes where starting the gadgets is slow")
+	deployCmd.PersistentFlags().DurationVarP(
+		&livenessPeriod,
+		"liveness-period", "",
+		30*time.Second,
+		"interval between two liveness checks of the gadget pods")
+	deployCmd.PersistentFlags().DurationVarP(
+		&terminationGracePeriod,
+		"termination-grace-period", "",
+		30*time.Second,
+		"time given to the gadget pods to stop the gadgets and unload their BPF programs when terminating")
+	deployCmd.PersistentFlags().StringVarP(
+		&cpuRequest,
+		"cpu-request", "",
+		"",
+		"CPU request of the gadget container (e.g. 100m), none if empty")
+	deployCmd.PersistentFlags().StringVarP(
+		&memoryRequest,
+		"memory-request", "",
+		"",
+		"memory request of the gadget container (e.g. 256Mi), none if empty")
+	deployCmd.PersistentFlags().StringVarP(
+		&cpuLimit,
+		"cpu-limit", "",
+		"",
+		"CPU limit of the gadget container (e.g. 1), none if empty")
+	deployCmd.PersistentFlags().StringVarP(
+		&memoryLimit,
+		"memory-limit", "",
+		"",
+		"memory limit of the gadget container (e.g. 1Gi), none if empty")
+	deployCmd.PersistentFlags().DurationVarP(
+		&historyDuration,
+		"history", "",
+		0,
+		"record the aggregate gadgets (cachestat, tcpsubnet) on every node and keep their summaries of this last duration (e.g. 5m), 0 to disable")
+	deployCmd.PersistentFlags().StringVarP(
+		&nodeSelector,
+		"node-selector", "",
+		"",
+		"comma-separated list of key=value labels of the only nodes the gadget pods run on, all nodes if empty")
+	deployCmd.PersistentFlags().StringArrayVarP(
+		&tolerations,
+		"toleration", "",
+		nil,
+		"taint tolerated by the gadget pods, as key=value:Effect or key:Effect, instead of all the NoSchedule and NoExecute taints (can be repeated)")
 
 	rootCmd.AddCommand(deployCmd)
 }
 
 const deployYamlTmpl string = `
+{{- if .CreateServiceAccount}}
 apiVersion: v1
 kind: ServiceAccount
 metadata:
-  name: gadget
-  namespace: kube-system
+  name: {{.ServiceAccount}}
+  namespace: {{.Namespace}}
 ---
+{{- end}}
 kind: ClusterRoleBinding
 apiVersion: rbac.authorization.k8s.io/v1
 metadata:
   name: gadget
 subjects:
 - kind: ServiceAccount
-  name: gadget
-  namespace: kube-system
+  name: {{.ServiceAccount}}
+  namespace: {{.Namespace}}
 roleRef:
   kind: ClusterRole
   name: cluster-admin
   apiGroup: rbac.authorization.k8s.io
 ---
+{{- if .TenantNamespace}}
+# Running the gadgets: finding the gadget pods, executing commands in them
+# and reading their logs
+kind: Role
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-tenant
+  namespace: {{.Namespace}}
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+- apiGroups: [""]
+  resources: ["pods/exec"]
+  verbs: ["create"]
+- apiGroups: [""]
+  resources: ["pods/log"]
+  verbs: ["get"]
+---
+# Selecting the pods to trace
+kind: Role
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-tenant
+  namespace: {{.TenantNamespace}}
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+---
+# Listing the nodes to run the gadgets on
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-tenant
+rules:
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["list"]
+{{- else}}
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-user
+{{- if .AggregateTo}}
+  labels:
+{{- range .AggregateTo}}
+    rbac.authorization.k8s.io/aggregate-to-{{.}}: "true"
+{{- end}}
+{{- end}}
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+- apiGroups: [""]
+  resources: ["pods/exec"]
+  verbs: ["create"]
+- apiGroups: [""]
+  resources: ["pods/log"]
+  verbs: ["get"]
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["list"]
+{{- end}}
+---
 apiVersion: apps/v1
 kind: DaemonSet
 metadata:
   name: gadget
-  namespace: kube-system
+  namespace: {{.Namespace}}
   labels:
     k8s-app: gadget
 spec:
@@ -83,14 +286,56 @@ spec:
         inspektor-gadget.kinvolk.io/option-traceloop: "{{.Traceloop}}"
         inspektor-gadget.kinvolk.io/option-runc-hooks: "{{.RuncHooksMode}}"
     spec:
-      serviceAccount: gadget
+      serviceAccount: {{.ServiceAccount}}
+{{- if .ImagePullSecrets}}
+      imagePullSecrets:
+{{- range .ImagePullSecrets}}
+      - name: {{.}}
+{{- end}}
+{{- end}}
       hostPID: true
       hostNetwork: true
+      # The gadgets still running are stopped on termination
+      terminationGracePeriodSeconds: {{.TerminationGracePeriodSeconds}}
       containers:
       - name: gadget
         image: {{.Image}}
-        imagePullPolicy: Always
+        imagePullPolicy: {{.ImagePullPolicy}}
         command: [ "/entrypoint.sh" ]
+{{- with .Resources}}
+{{- if or .CPURequest .MemoryRequest .CPULimit .MemoryLimit}}
+        resources:
+{{- if or .CPURequest .MemoryRequest}}
+          requests:
+{{- if .CPURequest}}
+            cpu: "{{.CPURequest}}"
+{{- end}}
+{{- if .MemoryRequest}}
+            memory: "{{.MemoryRequest}}"
+{{- end}}
+{{- end}}
+{{- if or .CPULimit .MemoryLimit}}
+          limits:
+{{- if .CPULimit}}
+            cpu: "{{.CPULimit}}"
+{{- end}}
+{{- if .MemoryLimit}}
+            memory: "{{.MemoryLimit}}"
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+        # The gadget tracer manager answers once the gadget pod is set up
+        readinessProbe:
+          exec:
+            command: [ "/bin/gadgettracermanager", "-dump" ]
+          initialDelaySeconds: {{.Readiness.InitialDelaySeconds}}
+          periodSeconds: {{.Readiness.PeriodSeconds}}
+        livenessProbe:
+          exec:
+            command: [ "/bin/gadgettracermanager", "-dump" ]
+          initialDelaySeconds: {{.Liveness.InitialDelaySeconds}}
+          periodSeconds: {{.Liveness.PeriodSeconds}}
         lifecycle:
           preStop:
             exec:
@@ -121,8 +366,19 @@ spec:
             value: "{{.Traceloop}}"
           - name: INSPEKTOR_GADGET_OPTION_RUNC_HOOKS_MODE
             value: "{{.RuncHooksMode}}"
+          - name: INSPEKTOR_GADGET_OPTION_ALLOWED_NAMESPACES
+            value: "{{.AllowedNamespaces}}"
+          - name: INSPEKTOR_GADGET_OPTION_PERF_BUFFER_PAGES
+            value: "{{.PerfBufferPages}}"
+          - name: INSPEKTOR_GADGET_OPTION_HISTORY
+            value: "{{.History}}"
+          - name: INSPEKTOR_GADGET_OPTION_READ_ONLY_ROOT
+            value: "{{.ReadOnlyRoot}}"
         securityContext:
           privileged: true
+{{- if .ReadOnlyRoot}}
+          readOnlyRootFilesystem: true
+{{- end}}
         volumeMounts:
         - name: host
           mountPath: /host
@@ -139,11 +395,32 @@ spec:
           mountPath: /sys/fs/bpf
         - name: localtime
           mountPath: /etc/localtime
+{{- if .ReadOnlyRoot}}
+        # bcc extracts the kernel headers of the node there when they are
+        # built in the kernel
+        - name: tmp
+          mountPath: /tmp
+        # The kernel headers fetched on RHCOS are mounted there
+        - name: kernels
+          mountPath: /usr/src/kernels
+{{- end}}
+{{- if .NodeSelector}}
+      nodeSelector:
+{{- range $key, $value := .NodeSelector}}
+        {{$key}}: "{{$value}}"
+{{- end}}
+{{- end}}
       tolerations:
-      - effect: NoSchedule
-        operator: Exists
-      - effect: NoExecute
-        operator: Exists
+{{- range .Tolerations}}
+      - effect: {{.Effect}}
+{{- if .Key}}
+        key: {{.Key}}
+{{- end}}
+        operator: {{.Operator}}
+{{- if .Value}}
+        value: "{{.Value}}"
+{{- end}}
+{{- end}}
       volumes:
       - name: host
         hostPath:
@@ -166,13 +443,150 @@ spec:
       - name: localtime
         hostPath:
           path: /etc/localtime
+{{- if .ReadOnlyRoot}}
+      - name: tmp
+        emptyDir: {}
+      - name: kernels
+        emptyDir: {}
+{{- end}}
 `
 
 type parameters struct {
-	Image         string
-	Version       string
-	Traceloop     bool
-	RuncHooksMode string
+	Image            string
+	ImagePullPolicy  string
+	ImagePullSecrets []string
+	Version          string
+	Traceloop        bool
+	RuncHooksMode    string
+	ReadOnlyRoot     bool
+
+	Namespace string
+
+	AllowedNamespaces string
+	TenantNamespace   string
+
+	ServiceAccount       string
+	CreateServiceAccount bool
+
+	PerfBufferPages string
+
+	AggregateTo []string
+
+	Readiness probeTimings
+	Liveness  probeTimings
+
+	TerminationGracePeriodSeconds int
+
+	Resources containerResources
+
+	History string
+
+	NodeSelector map[string]string
+	Tolerations  []toleration
+}
+
+// probeTimings are the timings of a probe of the gadget container
+type probeTimings struct {
+	InitialDelaySeconds int
+	PeriodSeconds       int
+}
+
+// newProbeTimings checks the timings of the probe name, given by the flags
+// --name-initial-delay and --name-period. The probes are configured in
+// seconds.
+func newProbeTimings(name string, initialDelay, period time.Duration) (probeTimings, error) {
+	if initialDelay < 0 || initialDelay%time.Second != 0 {
+		return probeTimings{}, fmt.Errorf("invalid --%s-initial-delay %s: must be a number of seconds, at least 0s", name, initialDelay)
+	}
+	if period < time.Second || period%time.Second != 0 {
+		return probeTimings{}, fmt.Errorf("invalid --%s-period %s: must be a number of seconds, at least 1s", name, period)
+	}
+	return probeTimings{
+		InitialDelaySeconds: int(initialDelay / time.Second),
+		PeriodSeconds:       int(period / time.Second),
+	}, nil
+}
+
+// containerResources are the resource requests and limits of the gadget
+// container, as Kubernetes quantities. Empty ones are not set.
+type containerResources struct {
+	CPURequest    string
+	MemoryRequest string
+	CPULimit      string
+	MemoryLimit   string
+}
+
+// newContainerResources checks the requests and limits given by
+// --cpu-request, --memory-request, --cpu-limit and --memory-limit
+func newContainerResources(cpuRequest, memoryRequest, cpuLimit, memoryLimit string) (containerResources, error) {
+	r := containerResources{}
+	for _, q := range []struct {
+		flag  string
+		value string
+		field *string
+	}{
+		{"cpu-request", cpuRequest, &r.CPURequest},
+		{"memory-request", memoryRequest, &r.MemoryRequest},
+		{"cpu-limit", cpuLimit, &r.CPULimit},
+		{"memory-limit", memoryLimit, &r.MemoryLimit},
+	} {
+		if q.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(q.value)
+		if err != nil || quantity.Sign() <= 0 {
+			return containerResources{}, fmt.Errorf("invalid --%s %q: must be a positive quantity, like 500m or 1 for CPU, 256Mi or 1Gi for memory", q.flag, q.value)
+		}
+		*q.field = quantity.String()
+	}
+	for _, pair := range []struct {
+		name    string
+		request string
+		limit   string
+	}{
+		{"cpu", r.CPURequest, r.CPULimit},
+		{"memory", r.MemoryRequest, r.MemoryLimit},
+	} {
+		if pair.request == "" || pair.limit == "" {
+			continue
+		}
+		request := resource.MustParse(pair.request)
+		if request.Cmp(resource.MustParse(pair.limit)) > 0 {
+			return containerResources{}, fmt.Errorf("invalid --%s-request %s: must not exceed --%s-limit %s", pair.name, pair.request, pair.name, pair.limit)
+		}
+	}
+	return r, nil
+}
+
+// minTerminationGracePeriod is the minimum termination grace period of the
+// gadget pods: stopping a gadget can take up to 5 seconds before it is
+// killed, and the gadget tracer manager has to remove the maps of the
+// tracers afterwards.
+const minTerminationGracePeriod = 10 * time.Second
+
+// terminationGracePeriodSeconds checks the termination grace period given
+// by --termination-grace-period, configured in seconds
+func terminationGracePeriodSeconds(period time.Duration) (int, error) {
+	if period < minTerminationGracePeriod || period%time.Second != 0 {
+		return 0, fmt.Errorf("invalid --termination-grace-period %s: must be a number of seconds, at least %s", period, minTerminationGracePeriod)
+	}
+	return int(period / time.Second), nil
+}
+
+// maxHistory is the maximum duration of the history of the aggregate gadgets.
+// The memory used is bounded by the gadget history as well.
+const maxHistory = time.Hour
+
+// historyParam checks the duration of the history given by --history. It
+// returns an empty string when the history is disabled.
+func historyParam(d time.Duration) (string, error) {
+	if d == 0 {
+		return "", nil
+	}
+	if d < time.Minute || d > maxHistory || d%time.Second != 0 {
+		return "", fmt.Errorf("invalid --history %s: must be a number of seconds between 1m and %s, or 0 to disable", d, maxHistory)
+	}
+	return d.String(), nil
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
@@ -183,19 +597,270 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid argument %q for --runc-hooks=[auto,crio,flatcar_edge,ldpreload]", runcHooksMode)
 	}
 
-	t, err := template.New("deploy.yaml").Parse(deployYamlTmpl)
+	allowlist, err := tenantAllowlist(tenantNamespace, nsallowlist.Parse(allowedNamespaces), aggregateTo)
 	if err != nil {
-		return fmt.Errorf("failed to parse template %w", err)
+		return err
+	}
+	if allowlist.Enabled() && traceloop {
+		// traceloop traces all the pods of the node
+		return fmt.Errorf("--allowed-namespaces cannot be used with the traceloop gadget, use --traceloop=false")
+	}
+
+	if err := validateImagePull(imagePullPolicy, imagePullSecrets); err != nil {
+		return err
+	}
+
+	namespace := gadgetNamespace()
+	if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+		return fmt.Errorf("invalid --gadget-namespace %q: %s", namespace, strings.Join(errs, ", "))
+	}
+
+	if err := validateServiceAccount(serviceAccount); err != nil {
+		return err
+	}
+
+	perfBufferPages, err := perfBufferEnv(perfBufferPagesDefaults)
+	if err != nil {
+		return err
+	}
+
+	roles, err := parseAggregateTo(aggregateTo)
+	if err != nil {
+		return err
+	}
+
+	readiness, err := newProbeTimings("readiness", readinessInitialDelay, readinessPeriod)
+	if err != nil {
+		return err
+	}
+	liveness, err := newProbeTimings("liveness", livenessInitialDelay, livenessPeriod)
+	if err != nil {
+		return err
+	}
+
+	gracePeriod, err := terminationGracePeriodSeconds(terminationGracePeriod)
+	if err != nil {
+		return err
+	}
+
+	resources, err := newContainerResources(cpuRequest, memoryRequest, cpuLimit, memoryLimit)
+	if err != nil {
+		return err
+	}
+
+	history, err := historyParam(historyDuration)
+	if err != nil {
+		return err
+	}
+
+	selector, err := parseNodeSelector(nodeSelector)
+	if err != nil {
+		return err
+	}
+	tols, err := parseTolerations(tolerations)
+	if err != nil {
+		return err
 	}
 
 	p := parameters{
 		image,
+		imagePullPolicy,
+		imagePullSecrets,
 		version,
 		traceloop,
 		runcHooksMode,
+		readOnlyRoot,
+		namespace,
+		allowlist.String(),
+		tenantNamespace,
+		serviceAccount,
+		createServiceAccount,
+		perfBufferPages,
+		roles,
+		readiness,
+		liveness,
+		gracePeriod,
+		resources,
+		history,
+		selector,
+		tols,
+	}
+
+	log.Debugf("Generating the deployment of image %s in namespace %s, traceloop %t, runc hooks mode %s",
+		image, namespace, traceloop, runcHooksMode)
+	return generateDeploy(os.Stdout, p)
+}
+
+// validateServiceAccount checks that name is a valid service account name,
+// i.e. a DNS subdomain
+func validateServiceAccount(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+		return fmt.Errorf("invalid service account name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// imagePullPolicies are the pull policies of a container image
+var imagePullPolicies = []string{"Always", "IfNotPresent", "Never"}
+
+// validateImagePull checks the pull policy given by --image-pull-policy and
+// the names of the secrets given by --image-pull-secret
+func validateImagePull(policy string, secrets []string) error {
+	valid := false
+	for _, p := range imagePullPolicies {
+		if policy == p {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid argument %q for --image-pull-policy=[%s]", policy, strings.Join(imagePullPolicies, ","))
+	}
+	for _, secret := range secrets {
+		if errs := validation.IsDNS1123Subdomain(secret); len(errs) != 0 {
+			return fmt.Errorf("invalid --image-pull-secret %q: %s", secret, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// tenantAllowlist returns the allowlist of the gadgets for the namespace of
+// the tenant given by --tenant-namespace, or allowlist without. The
+// allowlist is enforced by the gadget pods whoever queries them: the other
+// namespaces can't be allowed, nor the gadgets be given to other users by
+// aggregating the cluster role.
+func tenantAllowlist(tenant string, allowlist nsallowlist.Allowlist, aggregateTo string) (nsallowlist.Allowlist, error) {
+	if tenant == "" {
+		return allowlist, nil
+	}
+	if errs := validation.IsDNS1123Label(tenant); len(errs) != 0 {
+		return nil, fmt.Errorf("invalid --tenant-namespace %q: %s", tenant, strings.Join(errs, ", "))
+	}
+	if allowlist.Enabled() && allowlist.String() != tenant {
+		return nil, fmt.Errorf("--allowed-namespaces=%s cannot be used with --tenant-namespace=%s: the tenant could trace the other namespaces", allowlist, tenant)
+	}
+	if aggregateTo != "" {
+		return nil, fmt.Errorf("--aggregate-to cannot be used with --tenant-namespace")
+	}
+	return nsallowlist.Parse(tenant), nil
+}
+
+// aggregationRoles are the default cluster roles the gadget-user cluster
+// role can be aggregated to. The view role is not one of them: running the
+// gadgets executes commands in the privileged gadget pods.
+var aggregationRoles = []string{"admin", "edit"}
+
+// parseAggregateTo parses the comma-separated list of roles of
+// --aggregate-to
+func parseAggregateTo(list string) ([]string, error) {
+	var roles []string
+	seen := map[string]bool{}
+	for _, role := range strings.Split(list, ",") {
+		role = strings.TrimSpace(role)
+		if role == "" || seen[role] {
+			continue
+		}
+		valid := false
+		for _, r := range aggregationRoles {
+			if role == r {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid role %q for --aggregate-to, expected %s", role, strings.Join(aggregationRoles, " or "))
+		}
+		seen[role] = true
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// parseNodeSelector parses the comma-separated list of key=value labels of
+// --node-selector
+func parseNodeSelector(list string) (map[string]string, error) {
+	selector := map[string]string{}
+	for _, label := range strings.Split(list, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid --node-selector %q: expected key=value", label)
+		}
+		if errs := validation.IsQualifiedName(parts[0]); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid --node-selector key %q: %s", parts[0], strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(parts[1]); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid --node-selector value %q: %s", parts[1], strings.Join(errs, ", "))
+		}
+		selector[parts[0]] = parts[1]
+	}
+	return selector, nil
+}
+
+// toleration is a toleration of the gadget pods
+type toleration struct {
+	Key      string
+	Operator string
+	Value    string
+	Effect   string
+}
+
+// defaultTolerations let the gadget pods run on all the nodes, whatever
+// their taints
+var defaultTolerations = []toleration{
+	{Operator: "Exists", Effect: "NoSchedule"},
+	{Operator: "Exists", Effect: "NoExecute"},
+}
+
+// taintEffects are the effects of a taint
+var taintEffects = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+// parseTolerations parses the tolerations of --toleration, with the syntax
+// of the taints of kubectl taint: key=value:Effect tolerates the taint with
+// this value, key:Effect the taints with this key whatever their value.
+// Without, the default tolerations are returned.
+func parseTolerations(specs []string) ([]toleration, error) {
+	if len(specs) == 0 {
+		return defaultTolerations, nil
+	}
+	var tols []toleration
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, ":")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid --toleration %q: expected key=value:Effect or key:Effect", spec)
+		}
+		t := toleration{Key: spec[:i], Operator: "Exists", Effect: spec[i+1:]}
+		if parts := strings.SplitN(t.Key, "=", 2); len(parts) == 2 {
+			t.Key, t.Operator, t.Value = parts[0], "Equal", parts[1]
+			if errs := validation.IsValidLabelValue(t.Value); len(errs) != 0 {
+				return nil, fmt.Errorf("invalid --toleration value %q: %s", t.Value, strings.Join(errs, ", "))
+			}
+		}
+		if errs := validation.IsQualifiedName(t.Key); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid --toleration key %q: %s", t.Key, strings.Join(errs, ", "))
+		}
+		valid := false
+		for _, e := range taintEffects {
+			if t.Effect == e {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid --toleration effect %q, expected %s", t.Effect, strings.Join(taintEffects, ", "))
+		}
+		tols = append(tols, t)
+	}
+	return tols, nil
+}
+
+func generateDeploy(w io.Writer, p parameters) error {
+	t, err := template.New("deploy.yaml").Parse(deployYamlTmpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse template %w", err)
 	}
 
-	err = t.Execute(os.Stdout, p)
+	err = t.Execute(w, p)
 	if err != nil {
 		return fmt.Errorf("failed to generate deploy template %w", err)
 	}
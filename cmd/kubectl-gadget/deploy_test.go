@@ -0,0 +1,656 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
+)
+
+func TestGenerateDeployServiceAccount(t *testing.T) {
+	table := []struct {
+		description   string
+		create        bool
+		expectAccount bool
+	}{
+		{
+			description:   "created service account",
+			create:        true,
+			expectAccount: true,
+		},
+		{
+			description:   "existing service account",
+			create:        false,
+			expectAccount: false,
+		},
+	}
+
+	for _, entry := range table {
+		p := parameters{
+			Image:                "docker.io/kinvolk/gadget:test",
+			RuncHooksMode:        "auto",
+			Namespace:            "kube-system",
+			ServiceAccount:       "gadget-irsa",
+			CreateServiceAccount: entry.create,
+		}
+		var buf bytes.Buffer
+		if err := generateDeploy(&buf, p); err != nil {
+			t.Fatalf("%s: %v", entry.description, err)
+		}
+		yaml := buf.String()
+
+		createdAccount := strings.Contains(yaml, "kind: ServiceAccount\nmetadata:\n  name: gadget-irsa\n  namespace: kube-system\n")
+		if createdAccount != entry.expectAccount {
+			t.Errorf("%s: service account created: %t, expected %t", entry.description, createdAccount, entry.expectAccount)
+		}
+		if !strings.Contains(yaml, "- kind: ServiceAccount\n  name: gadget-irsa\n  namespace: kube-system\n") {
+			t.Errorf("%s: cluster role not bound to the service account:\n%s", entry.description, yaml)
+		}
+		if !strings.Contains(yaml, "      serviceAccount: gadget-irsa\n") {
+			t.Errorf("%s: service account not used by the DaemonSet:\n%s", entry.description, yaml)
+		}
+		if strings.Contains(yaml, "name: gadget\n  namespace: kube-system\n---\nkind: ClusterRoleBinding") {
+			t.Errorf("%s: default service account still created:\n%s", entry.description, yaml)
+		}
+	}
+}
+
+func TestGenerateDeployNamespace(t *testing.T) {
+	p := parameters{
+		Image:                "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:        "auto",
+		Namespace:            "gadget-system",
+		ServiceAccount:       "gadget",
+		CreateServiceAccount: true,
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	yaml := buf.String()
+
+	for _, expected := range []string{
+		"kind: ServiceAccount\nmetadata:\n  name: gadget\n  namespace: gadget-system\n",
+		"- kind: ServiceAccount\n  name: gadget\n  namespace: gadget-system\n",
+		"kind: DaemonSet\nmetadata:\n  name: gadget\n  namespace: gadget-system\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("%q not found in:\n%s", expected, yaml)
+		}
+	}
+	if strings.Contains(yaml, "kube-system") {
+		t.Errorf("kube-system still used:\n%s", yaml)
+	}
+}
+
+func TestGenerateDeployImagePull(t *testing.T) {
+	p := parameters{
+		Image:            "registry.example.com/kinvolk/gadget:test",
+		ImagePullPolicy:  "IfNotPresent",
+		ImagePullSecrets: []string{"mirror", "mirror-backup"},
+		RuncHooksMode:    "auto",
+		ServiceAccount:   "gadget",
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"      serviceAccount: gadget\n      imagePullSecrets:\n      - name: mirror\n      - name: mirror-backup\n      hostPID: true\n",
+		"        image: registry.example.com/kinvolk/gadget:test\n        imagePullPolicy: IfNotPresent\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("%q not found in:\n%s", expected, buf.String())
+		}
+	}
+
+	// Without --image-pull-secret, the pod spec has no pull secrets
+	p.ImagePullSecrets = nil
+	buf.Reset()
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "imagePullSecrets") {
+		t.Fatalf("unexpected pull secrets:\n%s", buf.String())
+	}
+}
+
+func TestValidateImagePull(t *testing.T) {
+	for _, policy := range []string{"Always", "IfNotPresent", "Never"} {
+		if err := validateImagePull(policy, []string{"mirror"}); err != nil {
+			t.Errorf("%q: unexpected error: %v", policy, err)
+		}
+	}
+	if err := validateImagePull("always", nil); err == nil {
+		t.Errorf("invalid pull policy accepted")
+	}
+	if err := validateImagePull("Always", []string{"Mirror_Secret"}); err == nil {
+		t.Errorf("invalid secret name accepted")
+	}
+}
+
+func TestGenerateDeployReadOnlyRoot(t *testing.T) {
+	p := parameters{
+		Image:          "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:  "auto",
+		ReadOnlyRoot:   true,
+		ServiceAccount: "gadget",
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	yaml := buf.String()
+	for _, expected := range []string{
+		"        securityContext:\n          privileged: true\n          readOnlyRootFilesystem: true\n",
+		"        - name: tmp\n          mountPath: /tmp\n",
+		"        - name: kernels\n          mountPath: /usr/src/kernels\n",
+		"      - name: tmp\n        emptyDir: {}\n",
+		"      - name: kernels\n        emptyDir: {}\n",
+		"          - name: INSPEKTOR_GADGET_OPTION_READ_ONLY_ROOT\n            value: \"true\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("%q not found in:\n%s", expected, yaml)
+		}
+	}
+
+	// By default, the root filesystem is writable and needs no volumes
+	p.ReadOnlyRoot = false
+	buf.Reset()
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	yaml = buf.String()
+	for _, unexpected := range []string{"readOnlyRootFilesystem", "emptyDir", "mountPath: /tmp"} {
+		if strings.Contains(yaml, unexpected) {
+			t.Errorf("%q found in:\n%s", unexpected, yaml)
+		}
+	}
+}
+
+func TestValidateServiceAccount(t *testing.T) {
+	for _, name := range []string{"gadget", "gadget-irsa", "gadget.team-a"} {
+		if err := validateServiceAccount(name); err != nil {
+			t.Errorf("%q: unexpected error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Gadget", "gadget_irsa", "-gadget", strings.Repeat("a", 254)} {
+		if err := validateServiceAccount(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestGenerateDeployPerfBufferPages(t *testing.T) {
+	env, err := perfBufferEnv("swapin=256, run-gadget=32,execsnoop=128")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The gadgets are named after their program in the gadget pod
+	if env != "execsnoop=128,rungadget=32,swapin=256" {
+		t.Fatalf("unexpected value %q", env)
+	}
+	p := parameters{
+		Image:           "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:   "auto",
+		ServiceAccount:  "gadget",
+		PerfBufferPages: env,
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "- name: INSPEKTOR_GADGET_OPTION_PERF_BUFFER_PAGES\n            value: \"execsnoop=128,rungadget=32,swapin=256\"\n") {
+		t.Fatalf("perf buffer sizes not set:\n%s", buf.String())
+	}
+
+	for _, list := range []string{"opensnoop=64", "execsnoop=100", "execsnoop=0"} {
+		if _, err := perfBufferEnv(list); err == nil {
+			t.Errorf("%q: expected an error", list)
+		}
+	}
+}
+
+func TestPerfBufferParam(t *testing.T) {
+	table := []struct {
+		pages    int
+		expected string
+		err      bool
+	}{
+		{pages: 0, expected: ""},
+		{pages: 128, expected: " --perfbufferpages 128"},
+		{pages: 100, err: true},
+		{pages: -8, err: true},
+		{pages: 4096, err: true},
+	}
+	for _, entry := range table {
+		param, err := perfBufferParam(entry.pages)
+		if entry.err {
+			if err == nil {
+				t.Errorf("%d: expected an error", entry.pages)
+			}
+			continue
+		}
+		if err != nil || param != entry.expected {
+			t.Errorf("%d: got %q, %v, expected %q", entry.pages, param, err, entry.expected)
+		}
+	}
+}
+
+func TestGenerateDeployAggregateTo(t *testing.T) {
+	roles, err := parseAggregateTo("admin, edit,admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parameters{
+		Image:          "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:  "auto",
+		ServiceAccount: "gadget",
+		AggregateTo:    roles,
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	expected := `kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-user
+  labels:
+    rbac.authorization.k8s.io/aggregate-to-admin: "true"
+    rbac.authorization.k8s.io/aggregate-to-edit: "true"
+rules:
+`
+	if !strings.Contains(buf.String(), expected) {
+		t.Fatalf("aggregation labels not found:\n%s", buf.String())
+	}
+
+	// Without --aggregate-to, the cluster role is not aggregated
+	p.AggregateTo = nil
+	buf.Reset()
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "  name: gadget-user\nrules:\n") || strings.Contains(buf.String(), "aggregate-to") {
+		t.Fatalf("unexpected cluster role:\n%s", buf.String())
+	}
+
+	for _, list := range []string{"view", "admin,cluster-admin"} {
+		if _, err := parseAggregateTo(list); err == nil {
+			t.Errorf("%q: expected an error", list)
+		}
+	}
+}
+
+func TestGenerateDeployProbes(t *testing.T) {
+	readiness, err := newProbeTimings("readiness", 15*time.Second, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveness, err := newProbeTimings("liveness", 3*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parameters{
+		Image:          "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:  "auto",
+		ServiceAccount: "gadget",
+		Readiness:      readiness,
+		Liveness:       liveness,
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`        readinessProbe:
+          exec:
+            command: [ "/bin/gadgettracermanager", "-dump" ]
+          initialDelaySeconds: 15
+          periodSeconds: 5
+`,
+		`        livenessProbe:
+          exec:
+            command: [ "/bin/gadgettracermanager", "-dump" ]
+          initialDelaySeconds: 180
+          periodSeconds: 60
+`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Fatalf("%s not found in:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestNewProbeTimings(t *testing.T) {
+	table := []struct {
+		initialDelay time.Duration
+		period       time.Duration
+		err          string
+	}{
+		{0, time.Second, ""},
+		{-time.Second, time.Second, "invalid --liveness-initial-delay -1s: must be a number of seconds, at least 0s"},
+		{1500 * time.Millisecond, time.Second, "invalid --liveness-initial-delay 1.5s: must be a number of seconds, at least 0s"},
+		{time.Second, 0, "invalid --liveness-period 0s: must be a number of seconds, at least 1s"},
+		{time.Second, 2500 * time.Millisecond, "invalid --liveness-period 2.5s: must be a number of seconds, at least 1s"},
+	}
+	for _, entry := range table {
+		_, err := newProbeTimings("liveness", entry.initialDelay, entry.period)
+		if entry.err == "" {
+			if err != nil {
+				t.Errorf("%s/%s: unexpected error %s", entry.initialDelay, entry.period, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != entry.err {
+			t.Errorf("%s/%s: expected error %q, got %v", entry.initialDelay, entry.period, entry.err, err)
+		}
+	}
+}
+
+func TestGenerateDeployTerminationGracePeriod(t *testing.T) {
+	gracePeriod, err := terminationGracePeriodSeconds(2 * time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parameters{
+		Image:                         "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:                 "auto",
+		ServiceAccount:                "gadget",
+		TerminationGracePeriodSeconds: gracePeriod,
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	expected := "      terminationGracePeriodSeconds: 120\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Fatalf("%s not found in:\n%s", expected, buf.String())
+	}
+}
+
+func TestTerminationGracePeriodSeconds(t *testing.T) {
+	table := []struct {
+		period time.Duration
+		err    string
+	}{
+		{10 * time.Second, ""},
+		{time.Minute, ""},
+		{5 * time.Second, "invalid --termination-grace-period 5s: must be a number of seconds, at least 10s"},
+		{12500 * time.Millisecond, "invalid --termination-grace-period 12.5s: must be a number of seconds, at least 10s"},
+	}
+	for _, entry := range table {
+		_, err := terminationGracePeriodSeconds(entry.period)
+		if entry.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", entry.period, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != entry.err {
+			t.Errorf("%s: expected error %q, got %v", entry.period, entry.err, err)
+		}
+	}
+}
+
+func TestHistoryParam(t *testing.T) {
+	table := []struct {
+		history  time.Duration
+		expected string
+		err      string
+	}{
+		{0, "", ""},
+		{5 * time.Minute, "5m0s", ""},
+		{time.Hour, "1h0m0s", ""},
+		{30 * time.Second, "", "invalid --history 30s: must be a number of seconds between 1m and 1h0m0s, or 0 to disable"},
+		{2 * time.Hour, "", "invalid --history 2h0m0s: must be a number of seconds between 1m and 1h0m0s, or 0 to disable"},
+		{90500 * time.Millisecond, "", "invalid --history 1m30.5s: must be a number of seconds between 1m and 1h0m0s, or 0 to disable"},
+	}
+	for _, entry := range table {
+		history, err := historyParam(entry.history)
+		if entry.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", entry.history, err)
+			} else if history != entry.expected {
+				t.Errorf("%s: expected %q, got %q", entry.history, entry.expected, history)
+			}
+			continue
+		}
+		if err == nil || err.Error() != entry.err {
+			t.Errorf("%s: expected error %q, got %v", entry.history, entry.err, err)
+		}
+	}
+}
+
+func TestGenerateDeployScheduling(t *testing.T) {
+	tols, err := parseTolerations(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parameters{
+		Image:          "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:  "auto",
+		ServiceAccount: "gadget",
+		Tolerations:    tols,
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	// Without the flags, the gadget pods tolerate all the taints
+	expected := "      tolerations:\n      - effect: NoSchedule\n        operator: Exists\n      - effect: NoExecute\n        operator: Exists\n      volumes:\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q not found in:\n%s", expected, buf.String())
+	}
+	if strings.Contains(buf.String(), "nodeSelector") {
+		t.Errorf("unexpected node selector:\n%s", buf.String())
+	}
+
+	p.NodeSelector, err = parseNodeSelector("kubernetes.io/os=linux, gadget=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Tolerations, err = parseTolerations([]string{"dedicated=gpu:NoSchedule", "node-role.kubernetes.io/master:NoSchedule"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"      nodeSelector:\n        gadget: \"\"\n        kubernetes.io/os: \"linux\"\n      tolerations:\n",
+		"      tolerations:\n      - effect: NoSchedule\n        key: dedicated\n        operator: Equal\n        value: \"gpu\"\n" +
+			"      - effect: NoSchedule\n        key: node-role.kubernetes.io/master\n        operator: Exists\n      volumes:\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("%q not found in:\n%s", expected, buf.String())
+		}
+	}
+}
+
+func TestParseNodeSelectorInvalid(t *testing.T) {
+	for _, list := range []string{"linux", "kubernetes.io/os=linux,gadget", "-os=linux", "os=linux os"} {
+		if _, err := parseNodeSelector(list); err == nil {
+			t.Errorf("%q: invalid node selector accepted", list)
+		}
+	}
+}
+
+func TestParseTolerationsInvalid(t *testing.T) {
+	for _, spec := range []string{"dedicated=gpu", "dedicated", "dedicated:NoRun", "dedicated=gpu:noschedule", ":NoSchedule", "=gpu:NoSchedule", "dedicated=g pu:NoSchedule"} {
+		if _, err := parseTolerations([]string{spec}); err == nil {
+			t.Errorf("%q: invalid toleration accepted", spec)
+		}
+	}
+}
+
+func TestGenerateDeployTenantNamespace(t *testing.T) {
+	p := parameters{
+		Image:             "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:     "auto",
+		Namespace:         "gadget-system",
+		AllowedNamespaces: "team-a",
+		TenantNamespace:   "team-a",
+		ServiceAccount:    "gadget",
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	yaml := buf.String()
+	for _, expected := range []string{
+		`kind: Role
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-tenant
+  namespace: gadget-system
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+- apiGroups: [""]
+  resources: ["pods/exec"]
+  verbs: ["create"]
+- apiGroups: [""]
+  resources: ["pods/log"]
+  verbs: ["get"]
+---
+`,
+		`kind: Role
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-tenant
+  namespace: team-a
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+---
+`,
+		`kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: gadget-tenant
+rules:
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["list"]
+---
+apiVersion: apps/v1
+kind: DaemonSet
+`,
+		"          - name: INSPEKTOR_GADGET_OPTION_ALLOWED_NAMESPACES\n            value: \"team-a\"\n",
+	} {
+		if !strings.Contains(yaml, expected) {
+			t.Errorf("%q not found in:\n%s", expected, yaml)
+		}
+	}
+	// The cluster role giving access to the pods of all the namespaces is
+	// not created
+	if strings.Contains(yaml, "gadget-user") {
+		t.Errorf("unexpected gadget-user cluster role:\n%s", yaml)
+	}
+}
+
+func TestTenantAllowlist(t *testing.T) {
+	allowlist, err := tenantAllowlist("team-a", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowlist.String() != "team-a" {
+		t.Errorf("got allowlist %q, expected team-a", allowlist)
+	}
+	if _, err := tenantAllowlist("team-a", nsallowlist.Parse("team-a"), ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	allowlist, err = tenantAllowlist("", nsallowlist.Parse("team-a,team-b"), "admin")
+	if err != nil || allowlist.String() != "team-a,team-b" {
+		t.Errorf("allowlist changed without tenant: %q, %v", allowlist, err)
+	}
+
+	for _, entry := range []struct {
+		tenant     string
+		allowed    string
+		aggregates string
+	}{
+		{"Team_A", "", ""},
+		{"team-a", "team-a,team-b", ""},
+		{"team-a", "team-b", ""},
+		{"team-a", "", "edit"},
+	} {
+		if _, err := tenantAllowlist(entry.tenant, nsallowlist.Parse(entry.allowed), entry.aggregates); err == nil {
+			t.Errorf("%+v: expected an error", entry)
+		}
+	}
+}
+
+func TestGenerateDeployResources(t *testing.T) {
+	p := parameters{
+		Image:          "docker.io/kinvolk/gadget:test",
+		RuncHooksMode:  "auto",
+		ServiceAccount: "gadget",
+	}
+	var buf bytes.Buffer
+	if err := generateDeploy(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	// Without the flags, the gadget container has no resources
+	if strings.Contains(buf.String(), "        resources:") {
+		t.Errorf("unexpected resources:\n%s", buf.String())
+	}
+
+	table := []struct {
+		resources containerResources
+		expected  string
+	}{
+		{
+			containerResources{CPURequest: "100m", MemoryRequest: "256Mi", CPULimit: "1", MemoryLimit: "1Gi"},
+			"        resources:\n          requests:\n            cpu: \"100m\"\n            memory: \"256Mi\"\n          limits:\n            cpu: \"1\"\n            memory: \"1Gi\"\n        # The gadget tracer manager",
+		},
+		{
+			containerResources{MemoryLimit: "1Gi"},
+			"        command: [ \"/entrypoint.sh\" ]\n        resources:\n          limits:\n            memory: \"1Gi\"\n        # The gadget tracer manager",
+		},
+		{
+			containerResources{CPURequest: "100m"},
+			"        resources:\n          requests:\n            cpu: \"100m\"\n        # The gadget tracer manager",
+		},
+	}
+	for _, entry := range table {
+		p.Resources = entry.resources
+		buf.Reset()
+		if err := generateDeploy(&buf, p); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), entry.expected) {
+			t.Errorf("%q not found in:\n%s", entry.expected, buf.String())
+		}
+	}
+}
+
+func TestNewContainerResources(t *testing.T) {
+	r, err := newContainerResources("0.5", "", "2000m", "1G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := containerResources{CPURequest: "500m", CPULimit: "2", MemoryLimit: "1G"}
+	if r != expected {
+		t.Errorf("got %+v, expected %+v", r, expected)
+	}
+
+	for _, entry := range [][4]string{
+		{"one", "", "", ""},
+		{"", "256MB", "", ""},
+		{"", "", "-1", ""},
+		{"", "", "", "0"},
+		{"2", "", "1", ""},
+		{"", "2Gi", "", "1Gi"},
+	} {
+		if _, err := newContainerResources(entry[0], entry[1], entry[2], entry[3]); err == nil {
+			t.Errorf("%q: expected an error", entry)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/eventlatency"
+	"github.com/kinvolk/inspektor-gadget/pkg/exposition"
+)
+
+var diagnosticsFlag bool
+
+func init() {
+	for _, command := range []*cobra.Command{execsnoopCmd, tcpconnlatCmd, ugidsnoopCmd, cachestatCmd, restartsnoopCmd, tcpsubnetCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd} {
+		command.PersistentFlags().BoolVarP(&diagnosticsFlag, "diagnostics", "", false,
+			"When terminating, print on stderr the percentiles of the latencies of the events, from the node to the output")
+	}
+}
+
+// diagnosticsQuantiles are the quantiles printed with --diagnostics
+var diagnosticsQuantiles = []float64{0.5, 0.9, 0.99}
+
+// eventDiagnostics records the latencies of the events printed by the
+// gadgets, in two stages: transport, from the timestamp of the event on its
+// node to its reception by kubectl-gadget, and enrichment, from its
+// reception to its output, including the lookup of the pod.
+type eventDiagnostics struct {
+	transport  eventlatency.Recorder
+	enrichment eventlatency.Recorder
+	now        func() time.Time // can be replaced in tests
+}
+
+func newEventDiagnostics() *eventDiagnostics {
+	return &eventDiagnostics{now: time.Now}
+}
+
+// received records the transport latency of an event line and returns the
+// time it was received, to be given to printed
+func (d *eventDiagnostics) received(line string) time.Time {
+	now := d.now()
+	// Events without timestamp, like those of tcpconnlat, only have their
+	// enrichment recorded
+	if ts, ok := eventlatency.EventTime(line); ok {
+		d.transport.Record(now.Sub(ts))
+	}
+	return now
+}
+
+// printed records the enrichment latency of an event received at received
+func (d *eventDiagnostics) printed(received time.Time) {
+	d.enrichment.Record(d.now().Sub(received))
+}
+
+// writeExposition writes the latencies as a summary metric
+func (d *eventDiagnostics) writeExposition(w io.Writer) error {
+	var samples []exposition.SummarySample
+	for _, stage := range []struct {
+		name     string
+		recorder *eventlatency.Recorder
+	}{
+		{"transport", &d.transport},
+		{"enrichment", &d.enrichment},
+	} {
+		sample := exposition.SummarySample{
+			Labels: exposition.Labels{"stage": stage.name},
+			Sum:    stage.recorder.Sum().Seconds(),
+			Count:  stage.recorder.Count(),
+		}
+		for _, q := range diagnosticsQuantiles {
+			sample.Quantiles = append(sample.Quantiles, exposition.Quantile{
+				Quantile: q,
+				Value:    stage.recorder.Percentile(q).Seconds(),
+			})
+		}
+		samples = append(samples, sample)
+	}
+	return exposition.WriteSummary(w, "gadget_event_latency_seconds",
+		"Latency of the events of the gadget, by stage, over the last events", samples)
+}
+
+// setDiagnostics records the latencies of the lines printed on outStreams
+// in d. Only the lines of gadgets with a transform are recorded.
+func (p *postProcess) setDiagnostics(d *eventDiagnostics) {
+	for _, s := range p.outStreams {
+		s.diagnostics = d
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventDiagnostics(t *testing.T) {
+	// The clock advances by 10ms at each reading
+	now := time.Date(2020, 6, 1, 12, 0, 1, 0, time.UTC)
+	diagnostics := newEventDiagnostics()
+	diagnostics.now = func() time.Time {
+		now = now.Add(10 * time.Millisecond)
+		return now
+	}
+
+	lines := `{"timestamp":"2020-06-01T12:00:00.5Z","pid":4242}
+{"pid":4243}
+skipped
+`
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(1, mock, mock)
+	postProcess.setTransform("PID", func(line string) (string, error) {
+		if line == "skipped" {
+			return "", errSkipLine
+		}
+		return line, nil
+	})
+	postProcess.setDiagnostics(diagnostics)
+	postProcess.outStreams[0].Write([]byte(lines))
+
+	// Only the first event has a timestamp: received 510ms after it
+	if diagnostics.transport.Count() != 1 {
+		t.Fatalf("transport latencies: %d != 1", diagnostics.transport.Count())
+	}
+	if p := diagnostics.transport.Percentile(0.5); p != 510*time.Millisecond {
+		t.Errorf("transport latency: %s != 510ms", p)
+	}
+	// The skipped line is not printed
+	if diagnostics.enrichment.Count() != 2 {
+		t.Fatalf("enrichment latencies: %d != 2", diagnostics.enrichment.Count())
+	}
+	if p := diagnostics.enrichment.Percentile(0.99); p != 10*time.Millisecond {
+		t.Errorf("enrichment latency: %s != 10ms", p)
+	}
+
+	var out bytes.Buffer
+	if err := diagnostics.writeExposition(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `gadget_event_latency_seconds{stage="transport",quantile="0.9"} 0.51`+"\n") {
+		t.Errorf("transport latency not found in:\n%s", out.String())
+	}
+}
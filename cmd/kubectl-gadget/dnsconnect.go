@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnsconnect"
+)
+
+var dnsconnectMaxAge = dnsconnect.DefaultMaxAge
+
+var dnsconnectHeader = fmt.Sprintf("%-27s %-6s %-16s %-40s %s",
+	"TIME", "PID", "COMM", "POD", "DESTINATION")
+
+// dnsconnectTransform returns the transform function rendering the
+// connections printed by the dnsconnect gadget with their pod
+func dnsconnectTransform(containers *containercache.Cache) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := dnsconnect.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-40s %s",
+			event.Timestamp, event.Pid, event.Comm, pod, dnsconnect.Destination(event)), " "), nil
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestDnsconnectTransform(t *testing.T) {
+	containers := testContainers("checkout-5d8f", "app")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"comm":"java","containerid":"abc","ipversion":4,"daddr":"203.0.113.10","dport":443,"name":"api.example.com","resolved_ms":3}
+{"timestamp":"2020-06-01T12:00:02.000002Z","pid":4242,"comm":"java","containerid":"abc","ipversion":4,"daddr":"10.96.0.1","dport":443}
+`
+	output := runTransform(dnsconnectHeader, dnsconnectTransform(containers), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             POD                                      DESTINATION
+[ 0] 2020-06-01T12:00:01.000001Z 4242   java             demo/checkout-5d8f/app                   api.example.com -> 203.0.113.10:443
+[ 0] 2020-06-01T12:00:02.000002Z 4242   java             demo/checkout-5d8f/app                   10.96.0.1:443
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnssnoop"
+)
+
+var dnssnoopHeader = fmt.Sprintf("%-27s %-6s %-16s %-5s %-8s %-8s %-30s %-6s %-9s %-6s %-14s %-16s %s",
+	"TIME", "PID", "COMM", "PROTO", "DIR", "QR", "NAME", "TYPE", "RCODE", "SIZE", "FLAGS", "EDNS", "POD")
+
+// dnssnoopTransform returns the transform function rendering the DNS
+// messages printed by the dnssnoop gadget with their pod
+func dnssnoopTransform(containers *containercache.Cache) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := dnssnoop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+			event.HostNetwork = m.HostNetwork
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+			if event.HostNetwork {
+				pod = "node:" + pod
+			}
+		}
+		rcode := event.Rcode
+		if rcode == "" {
+			rcode = "-"
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-5s %-8s %-8s %-30s %-6s %-9s %-6d %-14s %-16s %s",
+			event.Timestamp, event.Pid, event.Comm, strings.ToUpper(event.Transport), event.Direction,
+			event.QR, event.Name, event.QType, rcode, event.Size, dnssnoop.FlagsString(event),
+			dnssnoop.EDNSString(event), pod), " "), nil
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+)
+
+func TestDnssnoopTransform(t *testing.T) {
+	containers := testContainers("checkout-5d8f", "app")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"comm":"java","containerid":"abc","transport":"udp","direction":"sent","size":44,"ipversion":4,"raddr":"10.96.0.10","rport":53,"id":4660,"qr":"query","name":"api.example.com","qtype":"A","flags":["rd"],"truncated":false,"answers":0,"edns":{"udpsize":1232,"version":0,"do":false,"options":["cookie"]}}
+{"timestamp":"2020-06-01T12:00:01.000812Z","pid":4242,"comm":"java","containerid":"abc","transport":"udp","direction":"received","size":1232,"ipversion":4,"raddr":"10.96.0.10","rport":53,"id":4660,"qr":"response","name":"api.example.com","qtype":"A","flags":["tc","rd","ra","ad"],"truncated":true,"answers":40,"rcode":"NOERROR","edns":{"udpsize":1232,"version":0,"do":true}}
+{"timestamp":"2020-06-01T12:00:01.001020Z","pid":4242,"comm":"java","containerid":"abc","transport":"tcp","direction":"received","size":2712,"ipversion":4,"raddr":"10.96.0.10","rport":53,"id":4661,"qr":"response","name":"api.example.com","qtype":"A","flags":["rd","ra","ad"],"truncated":false,"answers":90,"rcode":"NOERROR","partial":true}
+`
+	output := runTransform(dnssnoopHeader, dnssnoopTransform(containers), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             PROTO DIR      QR       NAME                           TYPE   RCODE     SIZE   FLAGS          EDNS             POD
+[ 0] 2020-06-01T12:00:01.000001Z 4242   java             UDP   sent     query    api.example.com                A      -         44     rd             1232,cookie      demo/checkout-5d8f/app
+[ 0] 2020-06-01T12:00:01.000812Z 4242   java             UDP   received response api.example.com                A      NOERROR   1232   tc,rd,ra,ad    1232,do          demo/checkout-5d8f/app
+[ 0] 2020-06-01T12:00:01.001020Z 4242   java             TCP   received response api.example.com                A      NOERROR   2712   rd,ra,ad       ?                demo/checkout-5d8f/app
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	output = runTransform(dnssnoopHeader, dnssnoopTransform(containers), strings.SplitAfter(lines, "\n")[1])
+
+	expected = `[ 0] {"timestamp":"2020-06-01T12:00:01.000812Z","pid":4242,"comm":"java","containerid":"abc","namespace":"demo","pod":"checkout-5d8f","container":"app","transport":"udp","direction":"received","size":1232,"ipversion":4,"raddr":"10.96.0.10","rport":53,"id":4660,"qr":"response","name":"api.example.com","qtype":"A","flags":["tc","rd","ra","ad"],"truncated":true,"answers":40,"rcode":"NOERROR","edns":{"udpsize":1232,"version":0,"do":true}}
+`
+	if !strings.HasSuffix(output, expected) {
+		t.Fatalf("%v doesn't end with %v", output, expected)
+	}
+}
+
+func TestDnssnoopTransformHostNetwork(t *testing.T) {
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		return &containercache.Metadata{Namespace: "kube-system", Pod: "node-agent-x7k2p", Container: "agent", HostNetwork: true}, nil
+	}, containercache.DefaultConfig)
+	line := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":812,"comm":"agent","containerid":"def","transport":"udp","direction":"sent","size":40,"ipversion":4,"raddr":"169.254.169.253","rport":53,"id":17,"qr":"query","name":"s3.amazonaws.com","qtype":"AAAA","flags":["rd"],"truncated":false,"answers":0}` + "\n"
+
+	output := runTransform(dnssnoopHeader, dnssnoopTransform(containers), line)
+
+	expected := "[ 0] 2020-06-01T12:00:01.000001Z 812    agent            UDP   sent     query    s3.amazonaws.com               AAAA   -         40     rd             -                node:kube-system/node-agent-x7k2p/agent\n"
+	if !strings.HasSuffix(output, expected) {
+		t.Fatalf("%v doesn't end with %v", output, expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	output = runTransform(dnssnoopHeader, dnssnoopTransform(containers), line)
+	if !strings.Contains(output, `"container":"agent","hostnetwork":true,`) {
+		t.Fatalf("hostnetwork not set: %v", output)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/execsnoop"
+)
+
+var (
+	execsnoopEnv         string
+	execsnoopEnvDeny     string
+	execsnoopMaxArgs     int
+	execsnoopMaxArgLen   int
+	execsnoopEntrypoints bool
+)
+
+var execsnoopHeader = fmt.Sprintf("%-16s %-6s %-6s %3s %s", "PCOMM", "PID", "PPID", "RET", "ARGS")
+
+// execsnoopTransform returns the transform function rendering the processes
+// printed by the execsnoop gadget with their pod, marking the truncated
+// arguments and the entrypoints of the containers. The gadget already
+// filtered the variables with policy: it is applied again in case the gadget
+// is older than kubectl-gadget.
+func execsnoopTransform(containers *containercache.Cache, policy execsnoop.EnvPolicy) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := execsnoop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		event.Env = policy.Filter(event.Env)
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if jsonOutput {
+			if event.Args == nil {
+				event.Args = []string{}
+			}
+			if event.Env == nil {
+				event.Env = []string{}
+			}
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		s := fmt.Sprintf("%-16s %-6d %-6d %3d %s", event.Comm, event.Pid, event.Ppid, event.Ret, execsnoop.FormatArgs(event))
+		if event.IsEntrypoint {
+			s += "  [entrypoint]"
+		}
+		if len(event.Env) != 0 {
+			s += "  env: " + strings.Join(event.Env, " ")
+		}
+		return s, nil
+	}
+}
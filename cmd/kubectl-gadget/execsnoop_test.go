@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/execsnoop"
+)
+
+func TestExecsnoopTransform(t *testing.T) {
+	containers := noContainers()
+	policy, err := execsnoop.ParseEnvPolicy("JAVA_*,DB_*", execsnoop.DefaultDeny)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An older gadget not filtering the variables
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"ppid":1,"comm":"java","ret":0,"args":["/usr/bin/java","-jar","app.jar"],"env":["PATH=/usr/bin","JAVA_OPTS=-Xmx512m","DB_PASSWORD=hunter2"]}
+{"timestamp":"2020-06-01T12:00:02.000002Z","pid":4243,"ppid":4242,"comm":"sh","ret":0,"args":["/bin/sh"],"env":[]}
+{"timestamp":"2020-06-01T12:00:03.000003Z","pid":4244,"ppid":4243,"comm":"sh","ret":0,"args":["/bin/sh","-c","echo aaaa"],"args_truncated":true,"truncated_args":[2],"env":[]}
+`
+	output := runTransform(execsnoopHeader, execsnoopTransform(containers, policy), lines)
+
+	expected := `
+NODE PCOMM            PID    PPID   RET ARGS
+[ 0] java             4242   1        0 /usr/bin/java -jar app.jar  env: JAVA_OPTS=-Xmx512m DB_PASSWORD=<redacted>
+[ 0] sh               4243   4242     0 /bin/sh
+[ 0] sh               4244   4243     0 /bin/sh -c echo aaaa... ...
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
+
+func TestExecsnoopTransformJSONArgs(t *testing.T) {
+	containers := noContainers()
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	transformed, err := execsnoopTransform(containers, execsnoop.EnvPolicy{})(`{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"ppid":1,"comm":"sh","ret":0,"args":["/bin/sh","-c","echo a b","/w=="],"base64_args":[3],"env":[]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(transformed), &event); err != nil {
+		t.Fatal(err)
+	}
+	args, ok := event["args"].([]interface{})
+	if !ok || len(args) != 4 || args[2] != "echo a b" || args[3] != "/w==" {
+		t.Fatalf("args not kept as an array in %s", transformed)
+	}
+	if base64Args, ok := event["base64_args"].([]interface{}); !ok || len(base64Args) != 1 || base64Args[0] != 3.0 {
+		t.Fatalf("base64_args not kept in %s", transformed)
+	}
+
+	transformed, err = execsnoopTransform(containers, execsnoop.EnvPolicy{})(`{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"ppid":1,"comm":"sh","ret":-2,"env":[]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(transformed, `"args":[]`) {
+		t.Fatalf("args not an empty array in %s", transformed)
+	}
+}
+
+func TestExecsnoopTransformEntrypoint(t *testing.T) {
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		return &containercache.Metadata{Namespace: "demo", Pod: "web-1", Container: "frontend"}, nil
+	}, containercache.DefaultConfig)
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":5120,"ppid":5101,"comm":"entrypoint.sh","ret":0,"args":["/entrypoint.sh","nginx"],"env":[],"containerid":"abc","isEntrypoint":true}
+{"timestamp":"2020-06-01T12:00:01.000002Z","pid":5120,"ppid":5101,"comm":"nginx","ret":0,"args":["/usr/sbin/nginx"],"env":[],"containerid":"abc"}
+{"timestamp":"2020-06-01T12:00:05.000001Z","pid":5204,"ppid":5190,"comm":"runc:[2:INIT]","ret":-2,"args":["/app"],"env":[],"containerid":"def","isEntrypoint":true}
+`
+	output := runTransform(execsnoopHeader, execsnoopTransform(containers, execsnoop.EnvPolicy{}), lines)
+
+	expected := `
+NODE PCOMM            PID    PPID   RET ARGS
+[ 0] entrypoint.sh    5120   5101     0 /entrypoint.sh nginx  [entrypoint]
+[ 0] nginx            5120   5101     0 /usr/sbin/nginx
+[ 0] runc:[2:INIT]    5204   5190    -2 /app  [entrypoint]
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	transformed, err := execsnoopTransform(containers, execsnoop.EnvPolicy{})(strings.SplitN(lines, "\n", 2)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := execsnoop.Event{}
+	if err := json.Unmarshal([]byte(transformed), &event); err != nil {
+		t.Fatal(err)
+	}
+	if !event.IsEntrypoint || event.Pod != "web-1" {
+		t.Fatalf("entrypoint not kept in %s", transformed)
+	}
+}
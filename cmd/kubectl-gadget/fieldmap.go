@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/fieldmap"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/biosnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/cachestat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnsconnect"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnssnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/hostpathsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/killsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/nfsslower"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/restartsnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/solisten"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/swapin"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpconnlat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpsubnet"
+	tcptracer "github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcptracer/types"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ttysnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ugidsnoop"
+)
+
+var fieldMapParam string
+
+// jsonEvents are the events printed by the gadgets with --json, whose fields
+// can be renamed with --field-map
+var jsonEvents = map[string]interface{}{
+	"tcptracer":     tcptracer.Event{},
+	"tcpconnlat":    tcpconnlat.Event{},
+	"ugidsnoop":     ugidsnoop.DecodedEvent{},
+	"cachestat":     cachestat.Event{},
+	"tcpsubnet":     tcpsubnet.Event{},
+	"restartsnoop":  restartsnoop.Restart{},
+	"swapin":        swapin.Event{},
+	"killsnoop":     killsnoop.Event{},
+	"hostpathsnoop": hostpathsnoop.Event{},
+	"solisten":      solisten.Event{},
+	"dnsconnect":    dnsconnect.Event{},
+	"dnssnoop":      dnssnoop.Event{},
+	"biosnoop":      biosnoop.Event{},
+	"ttysnoop":      ttysnoop.Event{},
+	"nfsslower":     nfsslower.Event{},
+}
+
+// loadFieldMap loads the field map of --field-map and checks that it only
+// renames fields of the events of the gadget
+func loadFieldMap(subCommand, path string) (fieldmap.FieldMap, error) {
+	event, ok := jsonEvents[subCommand]
+	if !ok {
+		return nil, fmt.Errorf("--field-map is not supported by %s", subCommand)
+	}
+	m, err := fieldmap.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Check(fieldmap.JSONFields(event)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
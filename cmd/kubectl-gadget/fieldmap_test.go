@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/fieldmap"
+)
+
+func TestPostProcessFieldMap(t *testing.T) {
+	m, err := fieldmap.Parse([]byte("pod: k8s.pod.name\nnamespace: k8s.namespace.name\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessJSON([]string{"node-a"}, mock)
+	postProcess.setFieldMap(m)
+	postProcess.outStreams[0].Write([]byte(`{"type":"connect","namespace":"demo","pod":"web-1","pid":4242}` + "\n"))
+	postProcess.errStreams[0].Write([]byte("lost 3 events\n"))
+
+	expected := `{"type":"connect","k8s.namespace.name":"demo","k8s.pod.name":"web-1","pid":4242}
+{"type":"error","message":"lost 3 events","node":"node-a"}
+`
+	if string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+)
+
+var historySince time.Duration
+
+func init() {
+	for _, command := range []*cobra.Command{cachestatCmd, tcpsubnetCmd} {
+		command.PersistentFlags().DurationVar(&historySince, "history", 0,
+			"First print the summaries recorded on the nodes during this last duration (e.g. 5m), when deployed with --history")
+	}
+}
+
+// historyCommand returns the command printing the summaries of the gadget
+// recorded on the node during the last since, to run before the gadget
+func historyCommand(gadget string, since time.Duration) (string, error) {
+	if since < time.Second || since%time.Second != 0 {
+		return "", fmt.Errorf("Invalid --history %s: must be a number of seconds, at least 1s", since)
+	}
+	return fmt.Sprintf("/bin/gadgethistory -dump %s -since %s ; ", gadget, since), nil
+}
+
+// historySelector selects the summaries of the history: they are recorded
+// for the whole node, while the summaries of the gadget only cover the
+// selected containers
+type historySelector struct {
+	namespace string
+	podname   string
+	podUID    string
+	labels    map[string]string
+}
+
+// newHistorySelector returns the selector of the containers selected by
+// the flags of the gadget. labels is a comma-separated list of key=value
+// pairs.
+func newHistorySelector(namespace, podname, podUID, labels string) historySelector {
+	s := historySelector{
+		namespace: namespace,
+		podname:   podname,
+		podUID:    podUID,
+	}
+	if labels != "" {
+		s.labels = map[string]string{}
+		for _, pair := range strings.Split(labels, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 {
+				s.labels[kv[0]] = kv[1]
+			}
+		}
+	}
+	return s
+}
+
+// all returns whether all the containers of the node are selected
+func (s historySelector) all() bool {
+	return s.namespace == "" && s.podname == "" && s.podUID == "" && len(s.labels) == 0
+}
+
+// match returns whether the summary of the container m is selected. The
+// summaries of the whole node and of the processes that are not in a
+// container, with a nil m, are only selected with all the containers.
+func (s historySelector) match(m *containercache.Metadata) bool {
+	if s.all() {
+		return true
+	}
+	if m == nil {
+		return false
+	}
+	if !namespaceSelected(s.namespace, m.Namespace) {
+		return false
+	}
+	if s.podUID != "" {
+		if m.PodUID != s.podUID {
+			return false
+		}
+	} else if s.podname != "" && m.Pod != s.podname {
+		return false
+	}
+	for k, v := range s.labels {
+		if m.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
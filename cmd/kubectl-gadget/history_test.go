@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+)
+
+func TestHistorySelector(t *testing.T) {
+	m := &containercache.Metadata{
+		Namespace: "demo",
+		Pod:       "web-1",
+		PodUID:    "7f8c1a3e-0d2b-4c55-9e1a-3b6f2d0c9a10",
+		Labels:    map[string]string{"app": "web", "tier": "frontend"},
+		Container: "nginx",
+	}
+	table := []struct {
+		history  historySelector
+		expected bool
+	}{
+		{newHistorySelector("", "", "", ""), true},
+		{newHistorySelector("demo", "", "", ""), true},
+		{newHistorySelector("other", "", "", ""), false},
+		{newHistorySelector("demo,other", "", "", ""), true},
+		{newHistorySelector("demo", "web-1", "", ""), true},
+		{newHistorySelector("demo", "web-2", "", ""), false},
+		{newHistorySelector("demo", "web-2", "7f8c1a3e-0d2b-4c55-9e1a-3b6f2d0c9a10", ""), true},
+		{newHistorySelector("demo", "", "0d2b7f8c-1a3e-4c55-9e1a-3b6f2d0c9a10", ""), false},
+		{newHistorySelector("", "", "", "app=web,tier=frontend"), true},
+		{newHistorySelector("", "", "", "app=db"), false},
+	}
+	for _, entry := range table {
+		if entry.history.match(m) != entry.expected {
+			t.Errorf("%+v: expected %t", entry.history, entry.expected)
+		}
+	}
+
+	if newHistorySelector("demo", "", "", "").match(nil) {
+		t.Errorf("the summaries of the node should only be selected with all the containers")
+	}
+	if !newHistorySelector("", "", "", "").match(nil) {
+		t.Errorf("the summaries of the node should be selected with all the containers")
+	}
+}
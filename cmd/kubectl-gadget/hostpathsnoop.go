@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/hostpathsnoop"
+)
+
+var hostpathsnoopPaths string
+
+var hostpathsnoopHeader = fmt.Sprintf("%-27s %-6s %-16s %-6s %-10s %-40s %s",
+	"TIME", "PID", "COMM", "UID", "ACCESS", "POD", "PATH")
+
+// hostpathsnoopTransform returns the transform function rendering the
+// accesses printed by the hostpathsnoop gadget with their pod
+func hostpathsnoopTransform(containers *containercache.Cache) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := hostpathsnoop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		event.Access = hostpathsnoop.Access(event.Flags)
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-6d %-10s %-40s %s",
+			event.Timestamp, event.Pid, event.Comm, event.Uid, event.Access, pod, event.Path), " "), nil
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestHostpathsnoopTransform(t *testing.T) {
+	containers := testContainers("debug-7f9c", "shell")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"comm":"cat","uid":0,"containerid":"abc","watched":"/etc/kubernetes","path":"/etc/kubernetes/pki/ca.key","flags":32768}
+{"timestamp":"2020-06-01T12:00:02.000002Z","pid":4250,"comm":"sh","uid":1000,"containerid":"unknown","watched":"/etc/kubernetes","path":"/etc/kubernetes/kubelet.conf","flags":33345}
+`
+	output := runTransform(hostpathsnoopHeader, hostpathsnoopTransform(containers), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             UID    ACCESS     POD                                      PATH
+[ 0] 2020-06-01T12:00:01.000001Z 4242   cat              0      read       demo/debug-7f9c/shell                    /etc/kubernetes/pki/ca.key
+[ 0] 2020-06-01T12:00:02.000002Z 4250   sh               1000   write                                               /etc/kubernetes/kubelet.conf
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
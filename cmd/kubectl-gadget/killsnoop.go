@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/killsnoop"
+)
+
+var killsnoopHeader = fmt.Sprintf("%-27s %-7s %-15s %-6s %-16s %-10s %-6s %-16s %s",
+	"TIME", "SIGNAL", "RESULT", "PID", "COMM", "ORIGIN", "SPID", "SCOMM", "POD")
+
+// killsnoopTransform returns the transform function rendering the signals
+// printed by the killsnoop gadget with the pods of the sender and of the
+// receiver, and where the signal came from. The gadget doesn't filter the
+// receivers on cgroup-v2 nodes: the signals received outside of namespace and
+// podname are dropped here, when they are set.
+func killsnoopTransform(containers *containercache.Cache, namespace, podname string) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := killsnoop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if !namespaceSelected(namespace, event.Namespace) || (podname != "" && event.Pod != podname) {
+			return "", errSkipLine
+		}
+		if m := lookupContainer(containers, event.SenderContainerID); m != nil {
+			event.SenderNamespace = m.Namespace
+			event.SenderPod = m.Pod
+			event.SenderContainer = m.Container
+		}
+		killsnoop.Classify(&event)
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		if event.External && event.SenderPod != "" {
+			pod += fmt.Sprintf(" <- %s/%s/%s", event.SenderNamespace, event.SenderPod, event.SenderContainer)
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-7s %-15s %-6d %-16s %-10s %-6d %-16s %s",
+			event.Timestamp, killsnoop.SignalName(event.Signal), event.Result, event.Pid, event.Comm,
+			event.Origin, event.SenderPid, event.SenderComm, pod), " "), nil
+	}
+}
+
+// lookupContainer returns the pod and container of a container id, or nil
+// if unknown
+func lookupContainer(containers *containercache.Cache, containerID string) *containercache.Metadata {
+	if containerID == "" {
+		return nil
+	}
+	m, err := containers.Get(containerID)
+	if err != nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+)
+
+func TestKillsnoopTransform(t *testing.T) {
+	pods := map[string]*containercache.Metadata{
+		"web":     {Namespace: "demo", Pod: "web-1", Container: "nginx"},
+		"sidecar": {Namespace: "demo", Pod: "web-1", Container: "envoy"},
+		"chaos":   {Namespace: "ops", Pod: "chaos-1", Container: "chaos"},
+	}
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		return pods[id], nil
+	}, containercache.DefaultConfig)
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","signal":15,"result":"delivered","pid":4242,"comm":"nginx","containerid":"web","sender_pid":812,"sender_comm":"kubelet","sender_containerid":""}
+{"timestamp":"2020-06-01T12:00:02.000002Z","signal":9,"result":"delivered","pid":4242,"comm":"nginx","containerid":"web","sender_pid":4250,"sender_comm":"nginx","sender_containerid":"web"}
+{"timestamp":"2020-06-01T12:00:03.000003Z","signal":9,"result":"delivered","pid":4242,"comm":"nginx","containerid":"web","sender_pid":7001,"sender_comm":"chaos","sender_containerid":"chaos"}
+{"timestamp":"2020-06-01T12:00:04.000004Z","signal":9,"result":"delivered","pid":4242,"comm":"nginx","containerid":"web","sender_pid":4300,"sender_comm":"envoy","sender_containerid":"sidecar","kernel":true,"oomkill":true}
+{"timestamp":"2020-06-01T12:00:05.000005Z","signal":9,"result":"delivered","pid":7001,"comm":"chaos","containerid":"chaos","sender_pid":1,"sender_comm":"systemd","sender_containerid":""}
+`
+	output := runTransform(killsnoopHeader, killsnoopTransform(containers, "demo", ""), lines)
+
+	expected := `
+NODE TIME                        SIGNAL  RESULT          PID    COMM             ORIGIN     SPID   SCOMM            POD
+[ 0] 2020-06-01T12:00:01.000001Z SIGTERM delivered       4242   nginx            host       812    kubelet          demo/web-1/nginx
+[ 0] 2020-06-01T12:00:02.000002Z SIGKILL delivered       4242   nginx            container  4250   nginx            demo/web-1/nginx
+[ 0] 2020-06-01T12:00:03.000003Z SIGKILL delivered       4242   nginx            other-pod  7001   chaos            demo/web-1/nginx <- ops/chaos-1/chaos
+[ 0] 2020-06-01T12:00:04.000004Z SIGKILL delivered       4242   nginx            oom-killer 4300   envoy            demo/web-1/nginx <- demo/web-1/envoy
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
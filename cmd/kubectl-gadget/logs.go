@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var logsCmd = &cobra.Command{
+	Use:               "logs",
+	Short:             "Print the logs of the gadget pods",
+	Run:               runLogs,
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var (
+	logsFollow bool
+	logsTail   int64
+)
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	// -n is --namespace in the other commands: --node has no shorthand
+	logsCmd.PersistentFlags().StringVar(&nodeParam, "node", "", "Only print the logs of the gadget pod of this node")
+	logsCmd.PersistentFlags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing the logs as they are written")
+	logsCmd.PersistentFlags().Int64Var(&logsTail, "tail", -1, "Number of lines to print from the end of the logs of each pod, -1 for all")
+}
+
+// gadgetPods returns the gadget pods, sorted by node, only the one of node
+// if not empty
+func gadgetPods(client kubernetes.Interface, node string) ([]corev1.Pod, error) {
+	listOptions := metaV1.ListOptions{LabelSelector: "k8s-app=gadget"}
+	if node != "" {
+		listOptions.FieldSelector = "spec.nodeName=" + node
+	}
+	pods, err := client.CoreV1().Pods(gadgetNamespace()).List(listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot find gadget pods: %q", err)
+	}
+	var result []corev1.Pod
+	for _, pod := range pods.Items {
+		if node == "" || pod.Spec.NodeName == node {
+			result = append(result, pod)
+		}
+	}
+	if len(result) == 0 {
+		if node != "" {
+			return nil, fmt.Errorf("No gadget pod found on node %q", node)
+		}
+		return nil, fmt.Errorf("No gadget pods found")
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Spec.NodeName < result[j].Spec.NodeName
+	})
+	return result, nil
+}
+
+// podLogs opens the logs of the gadget container of a pod
+type podLogs func(pod corev1.Pod) (io.ReadCloser, error)
+
+func gadgetPodLogs(client kubernetes.Interface, follow bool, tail int64) podLogs {
+	return func(pod corev1.Pod) (io.ReadCloser, error) {
+		options := &corev1.PodLogOptions{Container: "gadget", Follow: follow}
+		if tail >= 0 {
+			options.TailLines = &tail
+		}
+		return client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, options).Stream()
+	}
+}
+
+// printLogs prints the logs of pods on out, each line prefixed with the
+// number of the node of the pod, as the tracers do. With follow, the logs of
+// all the pods are streamed at the same time, otherwise one pod after the
+// other. The errors of a pod are printed on errOut, and the logs of the
+// other pods are still printed.
+func printLogs(pods []corev1.Pod, open podLogs, follow bool, out, errOut io.Writer) error {
+	fmt.Fprintf(out, "Node numbers:")
+	for i, pod := range pods {
+		fmt.Fprintf(out, " %d = %s", i, pod.Spec.NodeName)
+	}
+	fmt.Fprintln(out)
+
+	postProcess := newPostProcess(len(pods), out, errOut)
+	errs := make([]error, len(pods))
+	printPod := func(i int) {
+		stream := postProcess.outStreams[i]
+		stream.firstLine = false
+		r, err := open(pods[i])
+		if err != nil {
+			errs[i] = err
+			fmt.Fprintf(postProcess.errStreams[i], "Error reading the logs of %s: %s\n", pods[i].Name, err)
+			return
+		}
+		defer r.Close()
+		_, err = io.Copy(stream, r)
+		// The last line might not end with a newline
+		if stream.buffer != "" {
+			stream.Write([]byte("\n"))
+		}
+		if err != nil {
+			errs[i] = err
+			fmt.Fprintf(postProcess.errStreams[i], "Error reading the logs of %s: %s\n", pods[i].Name, err)
+		}
+	}
+	if follow {
+		var wg sync.WaitGroup
+		for i := range pods {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				printPod(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range pods {
+			printPod(i)
+		}
+	}
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("Cannot read the logs of %d of %d gadget pods", failed, len(pods))
+	}
+	return nil
+}
+
+func runLogs(cmd *cobra.Command, args []string) {
+	contextLogger := log.WithFields(log.Fields{
+		"command": "kubectl-gadget logs",
+		"args":    args,
+	})
+
+	client, err := newClientset()
+	if err != nil {
+		contextLogger.Fatalf("Error in creating setting up Kubernetes client: %q", err)
+	}
+	pods, err := gadgetPods(client, nodeParam)
+	if err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
+	if err := printLogs(pods, gadgetPodLogs(client, logsFollow, logsTail), logsFollow, os.Stdout, os.Stderr); err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func gadgetPod(name, node string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kube-system",
+			Labels:    map[string]string{"k8s-app": "gadget"},
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+	}
+}
+
+// lockedWriter is a mockWriter that can be used by several goroutines
+type lockedWriter struct {
+	mu sync.Mutex
+	mockWriter
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.mockWriter.Write(p)
+}
+
+func TestGadgetPods(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		gadgetPod("gadget-b2x7q", "node-b"),
+		gadgetPod("gadget-9kd2f", "node-a"),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "coredns-1", Namespace: "kube-system"}},
+	)
+
+	pods, err := gadgetPods(client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 2 || pods[0].Name != "gadget-9kd2f" || pods[1].Name != "gadget-b2x7q" {
+		t.Fatalf("unexpected pods %v", pods)
+	}
+
+	pods, err = gadgetPods(client, "node-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].Name != "gadget-b2x7q" {
+		t.Fatalf("unexpected pods %v", pods)
+	}
+
+	if _, err := gadgetPods(client, "node-c"); err == nil || err.Error() != `No gadget pod found on node "node-c"` {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestPrintLogs(t *testing.T) {
+	pods := []corev1.Pod{*gadgetPod("gadget-9kd2f", "node-a"), *gadgetPod("gadget-b2x7q", "node-b"), *gadgetPod("gadget-x8w3c", "node-c")}
+	logs := map[string]string{
+		"gadget-9kd2f": "Starting the gadget tracer manager...\nReady\n",
+		// The last line doesn't end with a newline
+		"gadget-b2x7q": "Starting the gadget tracer manager...\nError: cannot pin map",
+	}
+	open := func(pod corev1.Pod) (io.ReadCloser, error) {
+		l, ok := logs[pod.Name]
+		if !ok {
+			return nil, errors.New("container \"gadget\" is waiting to start: ContainerCreating")
+		}
+		return ioutil.NopCloser(strings.NewReader(l)), nil
+	}
+
+	for _, follow := range []bool{false, true} {
+		out := &lockedWriter{}
+		errOut := &lockedWriter{}
+		err := printLogs(pods, open, follow, out, errOut)
+		if err == nil || err.Error() != "Cannot read the logs of 1 of 3 gadget pods" {
+			t.Fatalf("follow %t: unexpected error %v", follow, err)
+		}
+
+		expected := `Node numbers: 0 = node-a 1 = node-b 2 = node-c
+[ 0] Starting the gadget tracer manager...
+[ 0] Ready
+[ 1] Starting the gadget tracer manager...
+[ 1] Error: cannot pin map
+`
+		if follow {
+			// The lines of the different pods can be interleaved
+			for _, line := range strings.Split(expected, "\n") {
+				if !strings.Contains(string(out.output), line+"\n") {
+					t.Fatalf("follow: line %q missing in %q", line, out.output)
+				}
+			}
+		} else if string(out.output) != expected {
+			t.Fatalf("%q != %q", out.output, expected)
+		}
+		expectedErr := "[E2] Error reading the logs of gadget-x8w3c: container \"gadget\" is waiting to start: ContainerCreating\n"
+		if string(errOut.output) != expectedErr {
+			t.Fatalf("follow %t: %q != %q", follow, errOut.output, expectedErr)
+		}
+	}
+}
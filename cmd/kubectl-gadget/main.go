@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -21,10 +22,23 @@ func init() {
 		os.ExpandEnv("$HOME/.kube/config"),
 		"Path to kubeconfig file")
 	viper.BindPFlag("kubeconfig", rootCmd.PersistentFlags().Lookup("kubeconfig"))
+
+	rootCmd.PersistentFlags().String(
+		"context",
+		"",
+		"Name of the kubeconfig context to use, instead of its current context")
+	viper.BindPFlag("context", rootCmd.PersistentFlags().Lookup("context"))
+
+	rootCmd.PersistentFlags().String(
+		"gadget-namespace",
+		"kube-system",
+		"Namespace of the gadget pods, to deploy them in and to find them")
+	viper.BindPFlag("gadget-namespace", rootCmd.PersistentFlags().Lookup("gadget-namespace"))
 }
 
 func cobraInit() {
 	viper.AutomaticEnv()
+	log.SetLevel(verbosityLevel(verbosity))
 }
 
 func main() {
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var (
+	allNamespacesFlag bool
+
+	errNamespaceConflict = errors.New("-A/--all-namespaces cannot be used with a namespace")
+)
+
+// resolveNamespace returns the namespace selected with -n/--namespace and
+// -A/--all-namespaces, "" for all namespaces. Giving both is an error.
+// Without either, the namespace is the one returned by defaultNamespace.
+//
+// namespace can be a comma-separated list, returned sorted without
+// duplicates, and an error if one of them is not a valid namespace name.
+func resolveNamespace(namespace string, all bool, defaultNamespace func() string) (string, error) {
+	switch {
+	case all && namespace != "":
+		return "", errNamespaceConflict
+	case all:
+		return "", nil
+	case namespace != "":
+		namespaces, err := parseNamespaces(namespace)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(namespaces, ","), nil
+	default:
+		return defaultNamespace(), nil
+	}
+}
+
+// parseNamespaces parses a comma-separated list of namespaces
+func parseNamespaces(list string) ([]string, error) {
+	set := map[string]bool{}
+	var namespaces []string
+	for _, ns := range strings.Split(list, ",") {
+		ns = strings.TrimSpace(ns)
+		if errs := validation.IsDNS1123Label(ns); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid namespace %q: %s", ns, strings.Join(errs, ", "))
+		}
+		if !set[ns] {
+			set[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// namespaceSelected returns whether namespace is in the comma-separated list
+// of namespaces returned by resolveNamespace, "" selecting all namespaces
+func namespaceSelected(namespaces, namespace string) bool {
+	if namespaces == "" {
+		return true
+	}
+	for _, ns := range strings.Split(namespaces, ",") {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// listNamespace returns the namespace in which to list the pods of the
+// namespaces returned by resolveNamespace: all namespaces when several are
+// selected, the API selecting one or all of them only
+func listNamespace(namespaces string) string {
+	if strings.Contains(namespaces, ",") {
+		return ""
+	}
+	return namespaces
+}
+
+// namespaceListValue is the value of -n/--namespace: a comma-separated list
+// of namespaces, the namespaces of the repeated flags being appended
+type namespaceListValue struct {
+	list *string
+}
+
+func (v namespaceListValue) String() string {
+	return *v.list
+}
+
+func (v namespaceListValue) Set(s string) error {
+	if *v.list != "" {
+		s = *v.list + "," + s
+	}
+	*v.list = s
+	return nil
+}
+
+func (v namespaceListValue) Type() string {
+	return "strings"
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestResolveNamespace(t *testing.T) {
+	current := func() string { return "current" }
+	table := []struct {
+		description string
+		namespace   string
+		all         bool
+		def         func() string
+		expected    string
+		err         error
+	}{
+		{"-n", "demo", false, current, "demo", nil},
+		{"-A", "", true, current, "", nil},
+		{"-n and -A", "demo", true, current, "", errNamespaceConflict},
+		{"default namespace", "", false, current, "current", nil},
+		{"all namespaces by default", "", false, func() string { return "" }, "", nil},
+		{"several namespaces", "shop, demo,shop", false, current, "demo,shop", nil},
+		{"several namespaces and -A", "demo,shop", true, current, "", errNamespaceConflict},
+	}
+	for _, entry := range table {
+		namespace, err := resolveNamespace(entry.namespace, entry.all, entry.def)
+		if namespace != entry.expected || err != entry.err {
+			t.Errorf("%s: %q, %v, expected %q, %v",
+				entry.description, namespace, err, entry.expected, entry.err)
+		}
+	}
+
+	for _, invalid := range []string{"Demo", "demo,", "demo,,shop", "demo/shop", "demo_shop"} {
+		if _, err := resolveNamespace(invalid, false, current); err == nil {
+			t.Errorf("expected an error for namespaces %q", invalid)
+		}
+	}
+}
+
+// TestNamespaceList tests the repeated -n flags and the selection of several
+// namespaces
+func TestNamespaceList(t *testing.T) {
+	var namespaces string
+	command := &cobra.Command{}
+	command.Flags().VarP(namespaceListValue{&namespaces}, "namespace", "n", "")
+	if err := command.ParseFlags([]string{"-n", "shop", "--namespace", "demo,web", "-n", "shop"}); err != nil {
+		t.Fatal(err)
+	}
+	if namespaces != "shop,demo,web,shop" {
+		t.Fatalf("unexpected namespaces %q", namespaces)
+	}
+	namespaces, err := resolveNamespace(namespaces, false, kubeconfigNamespace)
+	if err != nil || namespaces != "demo,shop,web" {
+		t.Fatalf("unexpected namespaces %q, %v", namespaces, err)
+	}
+
+	table := []struct {
+		namespaces string
+		namespace  string
+		selected   bool
+		list       string
+	}{
+		{"", "demo", true, ""},
+		{"demo", "demo", true, "demo"},
+		{"demo", "shop", false, "demo"},
+		{"demo,shop", "shop", true, ""},
+		{"demo,shop", "sho", false, ""},
+		{"demo,shop", "web", false, ""},
+	}
+	for _, entry := range table {
+		if selected := namespaceSelected(entry.namespaces, entry.namespace); selected != entry.selected {
+			t.Errorf("%q selected in %q: %v, expected %v", entry.namespace, entry.namespaces, selected, entry.selected)
+		}
+		if list := listNamespace(entry.namespaces); list != entry.list {
+			t.Errorf("pods of %q listed in %q, expected %q", entry.namespaces, list, entry.list)
+		}
+	}
+}
+
+// TestNamespaceFlags checks that all the commands selecting namespaces have
+// both -n and -A
+func TestNamespaceFlags(t *testing.T) {
+	var check func(command *cobra.Command)
+	check = func(command *cobra.Command) {
+		flags := command.PersistentFlags()
+		namespace := flags.Lookup("namespace")
+		all := flags.Lookup("all-namespaces")
+		if namespace != nil && namespace.Shorthand != "n" {
+			t.Errorf("%s: --namespace without -n", command.CommandPath())
+		}
+		if (namespace != nil || flags.Lookup("namespaces") != nil) && (all == nil || all.Shorthand != "A") {
+			t.Errorf("%s: --namespace without -A", command.CommandPath())
+		}
+		if all != nil && all.Value.Type() != "bool" {
+			t.Errorf("%s: unexpected --all-namespaces", command.CommandPath())
+		}
+		for _, c := range command.Commands() {
+			check(c)
+		}
+	}
+	check(rootCmd)
+}
+
+func TestContextNamespace(t *testing.T) {
+	config := &clientcmdapi.Config{
+		Contexts: map[string]*clientcmdapi.Context{
+			"team-a":  {Cluster: "prod", Namespace: "team-a"},
+			"cluster": {Cluster: "prod"},
+		},
+	}
+	table := []struct {
+		context  string
+		expected string
+	}{
+		{"team-a", "team-a"},
+		{"cluster", ""},
+		{"missing", ""},
+		{"", ""},
+	}
+	for _, entry := range table {
+		config.CurrentContext = entry.context
+		if namespace := contextNamespace(config); namespace != entry.expected {
+			t.Errorf("context %q: namespace %q, expected %q", entry.context, namespace, entry.expected)
+		}
+	}
+}
+
+// TestContextNamespaceDefault tests that the namespace of the current context
+// of the kubeconfig is used without -n, and that -n and -A override it
+func TestContextNamespaceDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubectl-gadget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "config")
+	err = ioutil.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: alice
+  user:
+    token: secret
+contexts:
+- name: team-a
+  context:
+    cluster: prod
+    user: alice
+    namespace: team-a
+- name: cluster
+  context:
+    cluster: prod
+    user: alice
+current-context: team-a
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := viper.GetString("kubeconfig")
+	viper.Set("kubeconfig", kubeconfig)
+	defer viper.Set("kubeconfig", previous)
+
+	table := []struct {
+		description string
+		namespace   string
+		all         bool
+		def         func() string
+		expected    string
+	}{
+		{"gadget without -n", "", false, kubeconfigNamespace, "team-a"},
+		{"gadget with -n", "demo", false, kubeconfigNamespace, "demo"},
+		{"gadget with -A", "", true, kubeconfigNamespace, ""},
+		{"traceloop list without -n", "", false, getDefaultNamespace, "team-a"},
+	}
+	for _, entry := range table {
+		namespace, err := resolveNamespace(entry.namespace, entry.all, entry.def)
+		if err != nil || namespace != entry.expected {
+			t.Errorf("%s: %q, %v, expected %q", entry.description, namespace, err, entry.expected)
+		}
+	}
+
+	// Without namespace in the context
+	err = ioutil.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: cluster
+  context:
+    cluster: prod
+current-context: cluster
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace := kubeconfigNamespace(); namespace != "" {
+		t.Errorf("gadgets should trace all namespaces without namespace in the context, got %q", namespace)
+	}
+	if namespace := getDefaultNamespace(); namespace != "default" {
+		t.Errorf("traceloop list should use the default namespace without namespace in the context, got %q", namespace)
+	}
+}
+
+// TestKubeconfigContext tests that --context selects the cluster and the
+// namespace, and that KUBECONFIG can list several files, like kubectl
+func TestKubeconfigContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubectl-gadget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	prod := filepath.Join(dir, "prod")
+	err = ioutil.WriteFile(prod, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    namespace: shop
+current-context: prod
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging := filepath.Join(dir, "staging")
+	err = ioutil.WriteFile(staging, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: staging
+  cluster:
+    server: https://staging.example.com
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    namespace: qa
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := viper.GetString("kubeconfig")
+	defer viper.Set("kubeconfig", previous)
+	defer viper.Set("context", "")
+
+	table := []struct {
+		kubeconfig string
+		context    string
+		host       string
+		namespace  string
+	}{
+		{prod, "", "https://prod.example.com", "shop"},
+		{prod + string(filepath.ListSeparator) + staging, "", "https://prod.example.com", "shop"},
+		{prod + string(filepath.ListSeparator) + staging, "staging", "https://staging.example.com", "qa"},
+	}
+	for _, entry := range table {
+		viper.Set("kubeconfig", entry.kubeconfig)
+		viper.Set("context", entry.context)
+		if err := doesKubeconfigExist(nil, nil); err != nil {
+			t.Errorf("%q: %s", entry.kubeconfig, err)
+		}
+		config, err := kubeClientConfig().ClientConfig()
+		if err != nil {
+			t.Errorf("%q, context %q: %s", entry.kubeconfig, entry.context, err)
+			continue
+		}
+		if config.Host != entry.host {
+			t.Errorf("%q, context %q: host %q, expected %q", entry.kubeconfig, entry.context, config.Host, entry.host)
+		}
+		if namespace := kubeconfigNamespace(); namespace != entry.namespace {
+			t.Errorf("%q, context %q: namespace %q, expected %q", entry.kubeconfig, entry.context, namespace, entry.namespace)
+		}
+	}
+
+	viper.Set("context", "missing")
+	if _, err := kubeClientConfig().ClientConfig(); err == nil {
+		t.Errorf("expected an error for a missing context")
+	}
+	viper.Set("kubeconfig", filepath.Join(dir, "missing"))
+	if err := doesKubeconfigExist(nil, nil); err == nil {
+		t.Errorf("expected an error for a missing kubeconfig")
+	}
+}
+
+// TestKubeconfigFlagOverridesEnv tests that --kubeconfig takes precedence
+// over KUBECONFIG
+func TestKubeconfigFlagOverridesEnv(t *testing.T) {
+	previous, set := os.LookupEnv("KUBECONFIG")
+	os.Setenv("KUBECONFIG", "/from/env")
+	defer func() {
+		if set {
+			os.Setenv("KUBECONFIG", previous)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+	}()
+	v := viper.New()
+	v.AutomaticEnv()
+	flags := rootCmd.PersistentFlags()
+	v.BindPFlag("kubeconfig", flags.Lookup("kubeconfig"))
+	if kubeconfig := v.GetString("kubeconfig"); kubeconfig != "/from/env" {
+		t.Errorf("without --kubeconfig: %q, expected KUBECONFIG", kubeconfig)
+	}
+
+	flag := flags.Lookup("kubeconfig")
+	defaultValue := flag.Value.String()
+	defer func() {
+		flag.Value.Set(defaultValue)
+		flag.Changed = false
+	}()
+	if err := flags.Set("kubeconfig", "/from/flag"); err != nil {
+		t.Fatal(err)
+	}
+	if kubeconfig := v.GetString("kubeconfig"); kubeconfig != "/from/flag" {
+		t.Errorf("with --kubeconfig: %q, expected the flag", kubeconfig)
+	}
+}
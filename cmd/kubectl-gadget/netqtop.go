@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/netqtop"
+)
+
+var (
+	netqtopInterval int
+	netqtopDevice   string
+)
+
+var netqtopHeader = fmt.Sprintf("%-20s %-16s %5s %10s %12s %10s %12s",
+	"TIME", "DEVICE", "QUEUE", "RXPKT/S", "RXKB/S", "TXPKT/S", "TXKB/S")
+
+// netqtopTransform returns the transform function rendering the rates of the
+// queues printed by the netqtop gadget. The summaries of the incomplete last
+// interval are dropped unless emitPartial is set.
+func netqtopTransform(emitPartial bool) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := netqtop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if event.Partial && !emitPartial {
+			return "", errSkipLine
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		device := event.Device
+		if event.Partial {
+			device += partialMarker
+		}
+		return fmt.Sprintf("%-20s %-16s %5d %10.0f %12.1f %10.0f %12.1f",
+			event.Timestamp, device, event.Queue,
+			event.Rate(event.RxPackets), event.Rate(event.RxBytes)/1000,
+			event.Rate(event.TxPackets), event.Rate(event.TxBytes)/1000), nil
+	}
+}
+
+// parseNetqtopDevices parses the comma-separated names of network devices
+// given to --device, valid as checked by dev_valid_name() in the kernel:
+// not longer than 15 bytes, without slash, colon nor whitespace
+func parseNetqtopDevices(list string) ([]string, error) {
+	var devices []string
+	for _, name := range strings.Split(list, ",") {
+		if name == "" {
+			continue
+		}
+		if len(name) > 15 || name == "." || name == ".." ||
+			strings.IndexFunc(name, func(r rune) bool { return r == '/' || r == ':' || unicode.IsSpace(r) }) != -1 {
+			return nil, fmt.Errorf("invalid network device name %q", name)
+		}
+		devices = append(devices, name)
+	}
+	return devices, nil
+}
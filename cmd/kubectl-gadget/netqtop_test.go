@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNetqtopTransform(t *testing.T) {
+	lines := `{"timestamp":"2020-06-01T12:00:01Z","device":"eth0","queue":0,"rxpackets":90000,"rxbytes":120000000,"txpackets":1200,"txbytes":96000,"interval":1.0}
+{"timestamp":"2020-06-01T12:00:01Z","device":"eth0","queue":1,"rxpackets":310,"rxbytes":52000,"txpackets":0,"txbytes":0,"interval":1.0}
+{"timestamp":"2020-06-01T12:00:01Z","device":"eth0","queue":2,"rxpackets":100,"rxbytes":6000,"txpackets":50,"txbytes":4000,"interval":0.5,"partial":true}
+`
+	output := runTransform(netqtopHeader, netqtopTransform(true), lines)
+
+	expected := `
+NODE TIME                 DEVICE           QUEUE    RXPKT/S       RXKB/S    TXPKT/S       TXKB/S
+[ 0] 2020-06-01T12:00:01Z eth0                 0      90000     120000.0       1200         96.0
+[ 0] 2020-06-01T12:00:01Z eth0                 1        310         52.0          0          0.0
+[ 0] 2020-06-01T12:00:01Z eth0 (partial)       2        200         12.0        100          8.0
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	// The partial summary is dropped without --emit-partial
+	output = runTransformRaw(netqtopHeader, netqtopTransform(false), strings.SplitAfter(lines, "\n")[2])
+	if len(output) != 0 {
+		t.Fatalf("partial summary printed: %s", output)
+	}
+}
+
+func TestParseNetqtopDevices(t *testing.T) {
+	devices, err := parseNetqtopDevices("eth0,,ens5f1np1")
+	if err != nil || !reflect.DeepEqual(devices, []string{"eth0", "ens5f1np1"}) {
+		t.Fatalf("unexpected devices %v, %v", devices, err)
+	}
+	if devices, err := parseNetqtopDevices(""); err != nil || len(devices) != 0 {
+		t.Fatalf("unexpected devices %v, %v", devices, err)
+	}
+	for _, invalid := range []string{"eth0:1", "a/b", "eth 0", "..", "averyveryverylongname"} {
+		if _, err := parseNetqtopDevices(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
@@ -1,12 +1,12 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
 	"syscall"
 
@@ -20,7 +20,7 @@ import (
 
 	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/networkpolicy"
 	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/networkpolicy/types"
-	"github.com/kinvolk/inspektor-gadget/pkg/k8sutil"
+	"github.com/kinvolk/inspektor-gadget/pkg/logfmt"
 )
 
 var networkPolicyCmd = &cobra.Command{
@@ -43,6 +43,7 @@ var networkPolicyReportCmd = &cobra.Command{
 var (
 	inputFileName  string
 	outputFileName string
+	outputFormat   string
 	namespaces     string
 )
 
@@ -57,7 +58,9 @@ func init() {
 
 	networkPolicyCmd.AddCommand(networkPolicyMonitorCmd)
 	networkPolicyMonitorCmd.PersistentFlags().StringVarP(&outputFileName, "output", "", "-", "File name output")
-	networkPolicyMonitorCmd.PersistentFlags().StringVarP(&namespaces, "namespaces", "", "default", "Comma-separated list of namespaces to monitor")
+	networkPolicyMonitorCmd.PersistentFlags().StringVarP(&outputFormat, "output-format", "o", "json", "Output format (json, logfmt)")
+	networkPolicyMonitorCmd.PersistentFlags().StringVarP(&namespaces, "namespaces", "", "", "Comma-separated list of namespaces to monitor (default: the namespace of the current context, or \"default\")")
+	networkPolicyMonitorCmd.PersistentFlags().BoolVarP(&allNamespacesFlag, "all-namespaces", "A", false, "Monitor all namespaces")
 
 	networkPolicyCmd.AddCommand(networkPolicyReportCmd)
 	networkPolicyReportCmd.PersistentFlags().StringVarP(&inputFileName, "input", "", "-", "File name input")
@@ -65,29 +68,31 @@ func init() {
 
 type traceCollector struct {
 	m      *sync.Mutex
-	writer *bufio.Writer
+	writer io.Writer
 	node   string
+	buffer []byte // incomplete line
 }
 
-func (t traceCollector) Write(p []byte) (n int, err error) {
+func (t *traceCollector) Write(p []byte) (n int, err error) {
 	t.m.Lock()
 	defer t.m.Unlock()
 
-	event := types.KubernetesConnectionEvent{}
-	text := strings.TrimSpace(string(p))
-	if len(text) != 0 {
-		err := json.Unmarshal([]byte(text), &event)
-		if err == nil && event.Type == "ready" {
+	t.buffer = append(t.buffer, p...)
+	for {
+		i := bytes.IndexByte(t.buffer, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := t.buffer[:i+1]
+		event := types.KubernetesConnectionEvent{}
+		if err := json.Unmarshal(line, &event); err == nil && event.Type == "ready" {
 			fmt.Printf("Node %s ready.\n", t.node)
 		}
+		if _, err := t.writer.Write(line); err != nil {
+			return 0, err
+		}
+		t.buffer = t.buffer[i+1:]
 	}
-
-	n, err = t.writer.Write(p)
-	if err != nil {
-		return
-	}
-	err = t.writer.Flush()
-	return
 }
 
 func runNetworkPolicyMonitor(cmd *cobra.Command, args []string) {
@@ -96,19 +101,24 @@ func runNetworkPolicyMonitor(cmd *cobra.Command, args []string) {
 		"args":    args,
 	})
 
-	var w *bufio.Writer
-	if outputFileName == "-" {
-		w = bufio.NewWriter(os.Stdout)
-	} else {
+	if outputFormat != "json" && outputFormat != "logfmt" {
+		contextLogger.Fatalf("Invalid argument %q for --output-format=[json,logfmt]", outputFormat)
+	}
+
+	w := io.Writer(os.Stdout)
+	if outputFileName != "-" {
 		outputFile, err := os.Create(outputFileName)
 		if err != nil {
 			contextLogger.Fatalf("Error creating file %q: %q", outputFileName, err)
 		}
 		defer outputFile.Close()
-		w = bufio.NewWriter(outputFile)
+		w = outputFile
+	}
+	if outputFormat == "logfmt" {
+		w = logfmt.NewWriter(w, types.KubernetesConnectionEvent{})
 	}
 
-	client, err := k8sutil.NewClientset(viper.GetString("kubeconfig"))
+	client, err := newClientset()
 	if err != nil {
 		contextLogger.Fatalf("Error setting up Kubernetes client: %q", err)
 	}
@@ -122,6 +132,10 @@ func runNetworkPolicyMonitor(cmd *cobra.Command, args []string) {
 		contextLogger.Fatalf("Error listing nodes: %q", err)
 	}
 
+	namespaces, err = resolveNamespace(namespaces, allNamespacesFlag, getDefaultNamespace)
+	if err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
 	namespaceFilter := fmt.Sprintf("--namespace %q", namespaces)
 
 	sigs := make(chan os.Signal, 1)
@@ -131,7 +145,7 @@ func runNetworkPolicyMonitor(cmd *cobra.Command, args []string) {
 	var m sync.Mutex
 	for _, node := range nodes.Items {
 		go func(nodeName string) {
-			collector := traceCollector{&m, w, nodeName}
+			collector := &traceCollector{m: &m, writer: w, node: nodeName}
 			cmd := fmt.Sprintf("exec /opt/bcck8s/bcc-wrapper.sh --tracerid networkpolicyadvisor --nomanager --probecleanup --gadget /bin/networkpolicyadvisor -- %s",
 				namespaceFilter)
 			err := execPod(client, nodeName, cmd, collector, os.Stderr)
@@ -145,14 +159,14 @@ func runNetworkPolicyMonitor(cmd *cobra.Command, args []string) {
 	case <-sigs:
 		fmt.Printf("\nStopping...\n")
 	case e := <-failure:
-		fmt.Printf("Error detected: %q\n", e)
+		stderrLog.Errorf("Error detected: %q", e)
 	}
 
 	for _, node := range nodes.Items {
 		_, _, err := execPodCapture(client, node.Name,
 			fmt.Sprintf("exec /opt/bcck8s/bcc-wrapper.sh --tracerid networkpolicyadvisor --stop"))
 		if err != nil {
-			fmt.Printf("Error running command: %q\n", err)
+			stderrLog.Errorf("Error running command: %q", err)
 		}
 	}
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/networkpolicy/types"
+	"github.com/kinvolk/inspektor-gadget/pkg/logfmt"
+)
+
+func TestTraceCollectorLogfmt(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	var m sync.Mutex
+	collector := &traceCollector{m: &m, writer: logfmt.NewWriter(mock, types.KubernetesConnectionEvent{}), node: "node1"}
+
+	// The chunks of the remote command hold several lines, or half a line
+	lines := `{"type":"connect","remote_kind":"svc","port":6379,"local_pod_namespace":"demo","local_pod_name":"cart","local_pod_labels":{"app":"cart"}}
+{"type":"accept","remote_kind":"other","port":80,"local_pod_namespace":"demo","local_pod_name":"web","local_pod_labels":null,"remote_other":"10.0.0.1"}
+`
+	for _, chunk := range []string{lines[:20], lines[20:140], lines[140:]} {
+		if n, err := collector.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write returned %d, %v", n, err)
+		}
+	}
+
+	expected := `type=connect remote_kind=svc port=6379 local_pod_namespace=demo local_pod_name=cart local_pod_labels.app=cart
+type=accept remote_kind=other port=80 local_pod_namespace=demo local_pod_name=web remote_other=10.0.0.1
+`
+	if string(mock.output) != expected {
+		t.Fatalf("%q != %q", string(mock.output), expected)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/nfsslower"
+)
+
+// nfsslowerMinLatency is set with --min-latency, 10ms by default as in
+// bcc/tools/nfsslower.py
+var nfsslowerMinLatency time.Duration
+
+var nfsslowerHeader = fmt.Sprintf("%-27s %-6s %-16s %-7s %8s %10s %10s %-24s %s",
+	"TIME", "PID", "COMM", "OP", "BYTES", "OFFSET", "LAT(us)", "PATH", "POD")
+
+// nfsslowerTransform returns the transform function rendering the slow NFS
+// operations printed by the nfsslower gadget with their pod
+func nfsslowerTransform(containers *containercache.Cache) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := nfsslower.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		bytes, offset := "-", "-"
+		if event.Bytes != nil {
+			bytes = fmt.Sprintf("%d", *event.Bytes)
+		}
+		if event.Offset != nil {
+			offset = fmt.Sprintf("%d", *event.Offset)
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-7s %8s %10s %10d %-24s %s",
+			event.Timestamp, event.Pid, event.Comm, event.Operation, bytes, offset, event.LatencyUs, event.Path, pod), " "), nil
+	}
+}
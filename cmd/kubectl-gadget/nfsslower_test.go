@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNfsslowerTransform(t *testing.T) {
+	containers := testContainers("build-0", "builder")
+
+	lines := `{"seq":1,"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"make","containerid":"abc","operation":"read","path":"Makefile","latency_us":24310,"bytes":8192,"offset":4096}
+{"seq":2,"timestamp":"2020-06-01T12:00:01.000456Z","pid":4250,"comm":"ls","containerid":"abc","operation":"getattr","path":"cache","latency_us":12050}
+{"seq":3,"timestamp":"2020-06-01T12:00:01.000789Z","pid":312,"comm":"rsync","operation":"write","path":"backup.tar","latency_us":10995,"bytes":-5,"offset":0}
+`
+	output := runTransform(nfsslowerHeader, nfsslowerTransform(containers), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             OP         BYTES     OFFSET    LAT(us) PATH                     POD
+[ 0] 2020-06-01T12:00:01.000123Z 4242   make             read        8192       4096      24310 Makefile                 demo/build-0/builder
+[ 0] 2020-06-01T12:00:01.000456Z 4250   ls               getattr        -          -      12050 cache                    demo/build-0/builder
+[ 0] 2020-06-01T12:00:01.000789Z 312    rsync            write         -5          0      10995 backup.tar
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	output = runTransformRaw("", nfsslowerTransform(containers), strings.SplitAfter(lines, "\n")[1])
+	expected = `{"timestamp":"2020-06-01T12:00:01.000456Z","pid":4250,"comm":"ls","containerid":"abc","namespace":"demo","pod":"build-0","container":"builder","operation":"getattr","path":"cache","latency_us":12050,"seq":2}` + "\n"
+	if output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	oneShotFlag     bool
+	oneShotDuration time.Duration
+)
+
+// oneShotCollector buffers the lines printed by the gadgets during the
+// --one-shot window, to print them sorted by time once it ends. The lines of
+// the different nodes are interleaved in the order they are received, which
+// is not the order of the events.
+type oneShotCollector struct {
+	mu    sync.Mutex
+	lines []collectedLine
+	now   func() time.Time // can be replaced in tests
+}
+
+type collectedLine struct {
+	time time.Time
+	w    io.Writer
+	text string
+}
+
+func newOneShotCollector() *oneShotCollector {
+	return &oneShotCollector{now: time.Now}
+}
+
+// eventTime returns the timestamp of an event printed by a gadget as JSON,
+// if it has one
+func eventTime(raw string) (time.Time, bool) {
+	if !strings.HasPrefix(raw, "{") {
+		return time.Time{}, false
+	}
+	event := struct {
+		Timestamp string `json:"timestamp"`
+	}{}
+	if err := json.Unmarshal([]byte(raw), &event); err != nil || event.Timestamp == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// add buffers line, to be written on w. raw is the line as printed by the
+// gadget: the line is sorted by the timestamp of its event, or by the time it
+// was received for gadgets whose events don't have one.
+func (c *oneShotCollector) add(w io.Writer, raw, line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := eventTime(raw)
+	if !ok {
+		t = c.now()
+	}
+	c.lines = append(c.lines, collectedLine{t, w, line})
+}
+
+// flush writes the buffered lines sorted by time. Lines with the same time
+// keep the order in which they were received.
+func (c *oneShotCollector) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sort.SliceStable(c.lines, func(i, j int) bool {
+		return c.lines[i].time.Before(c.lines[j].time)
+	})
+	for _, l := range c.lines {
+		if _, err := fmt.Fprintf(l.w, "%s\n", l.text); err != nil {
+			return err
+		}
+	}
+	c.lines = nil
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPostProcessOneShot(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessRaw(2, mock, mock)
+	collector := newOneShotCollector()
+	postProcess.setOneShot(collector)
+
+	// Node 1 is late, and node 0 prints its events out of order
+	postProcess.outStreams[0].Write([]byte(`{"timestamp":"2020-06-01T12:00:03Z","pid":3}
+{"timestamp":"2020-06-01T12:00:01.5Z","pid":2}
+`))
+	postProcess.outStreams[1].Write([]byte(`{"timestamp":"2020-06-01T12:00:01Z","pid":1}
+{"timestamp":"2020-06-01T12:00:03Z","pid":4}
+`))
+	if len(mock.output) != 0 {
+		t.Fatalf("unexpected output %q before flush", mock.output)
+	}
+	if err := collector.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"timestamp":"2020-06-01T12:00:01Z","pid":1}
+{"timestamp":"2020-06-01T12:00:01.5Z","pid":2}
+{"timestamp":"2020-06-01T12:00:03Z","pid":3}
+{"timestamp":"2020-06-01T12:00:03Z","pid":4}
+`
+	if string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+}
+
+func TestPostProcessOneShotWithoutTimestamp(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(2, mock, mock)
+	collector := newOneShotCollector()
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	collector.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	postProcess.setOneShot(collector)
+
+	postProcess.outStreams[1].Write([]byte("PCOMM PID\nsh 1\n"))
+	postProcess.outStreams[0].Write([]byte("PCOMM PID\nls 2\n"))
+	postProcess.outStreams[1].Write([]byte("cat 3\n"))
+
+	// The header is not buffered
+	if string(mock.output) != "NODE PCOMM PID\n" {
+		t.Fatalf("unexpected output %q before flush", mock.output)
+	}
+	if err := collector.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `NODE PCOMM PID
+[ 1] sh 1
+[ 0] ls 2
+[ 1] cat 3
+`
+	if string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+}
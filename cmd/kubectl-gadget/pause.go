@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pausedEventsMax is the maximum number of events buffered while the output
+// is paused. The next ones are dropped and counted.
+const pausedEventsMax = 100000
+
+// pauseStatusInterval is the minimum interval between two updates of the
+// paused indicator, to not flood the terminal with fast streams
+const pauseStatusInterval = 100 * time.Millisecond
+
+// pauseKey pauses and resumes the output
+const pauseKey = ' '
+
+// pauseWriter writes on w, or buffers the writes while paused. It shows the
+// paused indicator and the number of buffered events on status. Writes must
+// be complete lines.
+type pauseWriter struct {
+	mu         sync.Mutex
+	w          io.Writer
+	status     io.Writer
+	max        int
+	paused     bool
+	stopped    bool
+	buffered   [][]byte
+	dropped    int
+	lastStatus time.Time
+	now        func() time.Time // can be replaced in tests
+	restore    func()
+}
+
+func newPauseWriter(w, status io.Writer, max int) *pauseWriter {
+	return &pauseWriter{
+		w:      w,
+		status: status,
+		max:    max,
+		now:    time.Now,
+	}
+}
+
+func (p *pauseWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused {
+		return p.w.Write(b)
+	}
+	if len(p.buffered) < p.max {
+		p.buffered = append(p.buffered, append([]byte(nil), b...))
+	} else {
+		p.dropped++
+	}
+	if now := p.now(); now.Sub(p.lastStatus) >= pauseStatusInterval {
+		p.printStatus(now)
+	}
+	return len(b), nil
+}
+
+// printStatus rewrites the line of the paused indicator
+func (p *pauseWriter) printStatus(now time.Time) {
+	dropped := ""
+	if p.dropped != 0 {
+		dropped = fmt.Sprintf(", %d dropped", p.dropped)
+	}
+	fmt.Fprintf(p.status, "\r\033[KPAUSED: %d events buffered%s, press space to resume", len(p.buffered), dropped)
+	p.lastStatus = now
+}
+
+// toggle pauses the output, or resumes it and writes the buffered events
+func (p *pauseWriter) toggle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	if !p.paused {
+		p.paused = true
+		p.printStatus(p.now())
+		return
+	}
+	p.resume()
+}
+
+func (p *pauseWriter) resume() {
+	fmt.Fprint(p.status, "\r\033[K")
+	for _, b := range p.buffered {
+		p.w.Write(b)
+	}
+	if p.dropped != 0 {
+		fmt.Fprintf(p.status, "%d events dropped while paused, more than %d\n", p.dropped, p.max)
+	}
+	p.paused = false
+	p.buffered = nil
+	p.dropped = 0
+}
+
+// start reads the keys pressed on the terminal in, pausing and resuming the
+// output on space. The terminal is restored by stop, or when exiting on a
+// fatal error.
+func (p *pauseWriter) start(in *os.File) error {
+	restore, err := enableCbreak(in.Fd())
+	if err != nil {
+		return err
+	}
+	p.restore = restore
+	log.RegisterExitHandler(restore)
+	go func() {
+		r := bufio.NewReader(in)
+		for {
+			key, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			if key == pauseKey {
+				p.toggle()
+			}
+		}
+	}()
+	return nil
+}
+
+// stop writes the events buffered if paused, and restores the terminal
+func (p *pauseWriter) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		p.resume()
+	}
+	p.stopped = true
+	if p.restore != nil {
+		p.restore()
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPauseWriter(t *testing.T) {
+	var out, status bytes.Buffer
+	p := newPauseWriter(&out, &status, 2)
+	now := time.Unix(0, 0)
+	p.now = func() time.Time { return now }
+
+	fmt.Fprintln(p, "event 1")
+	p.toggle()
+	if !strings.Contains(status.String(), "PAUSED: 0 events buffered") {
+		t.Errorf("no paused indicator: %q", status.String())
+	}
+	fmt.Fprintln(p, "event 2")
+	now = now.Add(pauseStatusInterval)
+	fmt.Fprintln(p, "event 3")
+	if !strings.HasSuffix(status.String(), "PAUSED: 2 events buffered, press space to resume") {
+		t.Errorf("buffered events not counted: %q", status.String())
+	}
+	// Over the cap
+	fmt.Fprintln(p, "event 4")
+	if out.String() != "event 1\n" {
+		t.Fatalf("events printed while paused: %q", out.String())
+	}
+
+	status.Reset()
+	p.toggle()
+	if out.String() != "event 1\nevent 2\nevent 3\n" {
+		t.Errorf("buffered events not flushed: %q", out.String())
+	}
+	if !strings.Contains(status.String(), "1 events dropped while paused") {
+		t.Errorf("dropped events not reported: %q", status.String())
+	}
+	fmt.Fprintln(p, "event 5")
+	if !strings.HasSuffix(out.String(), "event 3\nevent 5\n") {
+		t.Errorf("events not printed after resuming: %q", out.String())
+	}
+}
+
+func TestPauseWriterStop(t *testing.T) {
+	var out, status bytes.Buffer
+	p := newPauseWriter(&out, &status, 10)
+	p.toggle()
+	fmt.Fprintln(p, "event 1")
+	p.stop()
+	if out.String() != "event 1\n" {
+		t.Errorf("buffered events not flushed on stop: %q", out.String())
+	}
+	// Keys pressed after stopping don't pause anymore
+	p.toggle()
+	fmt.Fprintln(p, "event 2")
+	if out.String() != "event 1\nevent 2\n" {
+		t.Errorf("events not printed after stop: %q", out.String())
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnsconnect"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/dnssnoop"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpconnlat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpping"
+	"github.com/kinvolk/inspektor-gadget/pkg/peerfilter"
+)
+
+var (
+	internalOnlyFlag bool
+	externalOnlyFlag bool
+	clusterCIDRParam []string
+)
+
+func init() {
+	for _, command := range []*cobra.Command{tcpconnlatCmd, tcppingCmd, dnsconnectCmd, dnssnoopCmd} {
+		command.PersistentFlags().BoolVar(&internalOnlyFlag, "internal-only", false,
+			"Only print the connections whose peer is a pod or a service of the cluster")
+		command.PersistentFlags().BoolVar(&externalOnlyFlag, "external-only", false,
+			"Only print the connections whose peer is outside of the cluster")
+		command.PersistentFlags().StringArrayVar(&clusterCIDRParam, "cluster-cidr", nil,
+			"Pod or service CIDR of the cluster, like 10.96.0.0/12, for --internal-only and --external-only. By default, the pod CIDRs of the nodes and the cluster IPs of the services (can be repeated)")
+	}
+}
+
+// peerAddrs return the address of the peer of an event line of the network
+// gadgets, for --internal-only and --external-only
+var peerAddrs = map[string]func(line string) (string, error){
+	"tcpconnlat": func(line string) (string, error) {
+		event := tcpconnlat.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return event.Daddr, err
+	},
+	"tcpping": func(line string) (string, error) {
+		event := tcpping.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return event.Daddr, err
+	},
+	"dnsconnect": func(line string) (string, error) {
+		event := dnsconnect.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return event.Daddr, err
+	},
+	"dnssnoop": func(line string) (string, error) {
+		event := dnssnoop.Event{}
+		err := json.Unmarshal([]byte(line), &event)
+		return event.Raddr, err
+	},
+}
+
+// clusterClassifier returns the classifier of the peers for the CIDRs given
+// with --cluster-cidr or, without, for the pod CIDRs of nodes and the cluster
+// IPs of the services. The service CIDR can't be read from the API: the
+// services created after this are classified as external.
+func clusterClassifier(client *kubernetes.Clientset, nodes []corev1.Node, cidrs []string) (*peerfilter.Classifier, error) {
+	if len(cidrs) != 0 {
+		c, err := peerfilter.New(cidrs, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --cluster-cidr: %s", err)
+		}
+		return c, nil
+	}
+	for _, node := range nodes {
+		if len(node.Spec.PodCIDRs) != 0 {
+			cidrs = append(cidrs, node.Spec.PodCIDRs...)
+		} else if node.Spec.PodCIDR != "" {
+			cidrs = append(cidrs, node.Spec.PodCIDR)
+		}
+	}
+	if len(cidrs) == 0 {
+		return nil, fmt.Errorf("The nodes have no pod CIDR, give the pod and service CIDRs of the cluster with --cluster-cidr")
+	}
+	services, err := client.CoreV1().Services("").List(metaV1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Error in listing services, give the service CIDR of the cluster with --cluster-cidr: %s", err)
+	}
+	var ips []string
+	for _, service := range services.Items {
+		if ip := service.Spec.ClusterIP; ip != "" && ip != corev1.ClusterIPNone {
+			ips = append(ips, ip)
+		}
+	}
+	return peerfilter.New(cidrs, ips)
+}
+
+// setPeerFilter prints on outStreams only the events whose peer, as returned
+// by addr, is of the class keep. The lines that are not events are always
+// printed.
+func (p *postProcess) setPeerFilter(c *peerfilter.Classifier, keep peerfilter.Class, addr func(line string) (string, error)) {
+	for _, s := range p.outStreams {
+		s.peerFilter = func(line string) bool {
+			peer, err := addr(line)
+			if err != nil {
+				return true
+			}
+			return c.Classify(peer) == keep
+		}
+	}
+}
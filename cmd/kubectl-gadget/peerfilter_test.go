@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/peerfilter"
+)
+
+func TestPeerFilter(t *testing.T) {
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		return &containercache.Metadata{Namespace: "demo", Pod: "checkout-5d8f", Container: "app"}, nil
+	}, containercache.DefaultConfig)
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"comm":"java","containerid":"abc","ipversion":4,"daddr":"203.0.113.10","dport":443}
+{"timestamp":"2020-06-01T12:00:02.000002Z","pid":4242,"comm":"java","containerid":"abc","ipversion":4,"daddr":"10.96.0.1","dport":443}
+{"timestamp":"2020-06-01T12:00:03.000003Z","pid":4242,"comm":"java","containerid":"abc","ipversion":4,"daddr":"127.0.0.1","dport":8080}
+`
+	classifier, err := peerfilter.New([]string{"10.2.0.0/16", "10.96.0.0/12"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for keep, expected := range map[peerfilter.Class]string{
+		peerfilter.Internal: `
+NODE TIME                        PID    COMM             POD                                      DESTINATION
+[ 0] 2020-06-01T12:00:02.000002Z 4242   java             demo/checkout-5d8f/app                   10.96.0.1:443
+`,
+		peerfilter.External: `
+NODE TIME                        PID    COMM             POD                                      DESTINATION
+[ 0] 2020-06-01T12:00:01.000001Z 4242   java             demo/checkout-5d8f/app                   203.0.113.10:443
+`,
+	} {
+		mock := &mockWriter{[]byte{}}
+		postProcess := newPostProcess(1, mock, mock)
+		postProcess.setTransform(dnsconnectHeader, dnsconnectTransform(containers))
+		postProcess.setPeerFilter(classifier, keep, peerAddrs["dnsconnect"])
+		postProcess.outStreams[0].Write([]byte(lines))
+		if "\n"+string(mock.output) != expected {
+			t.Fatalf("%s: %v != %v", keep, string(mock.output), expected)
+		}
+	}
+}
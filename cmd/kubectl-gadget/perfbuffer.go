@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/perfbuffer"
+)
+
+var (
+	perfBufferPages         int
+	perfBufferPagesDefaults string
+)
+
+// perfBufferGadgets are the gadgets whose perf buffers can be sized, with
+// the name of their program in the gadget pod. The other gadgets are bcc
+// tools without this option, or don't use perf buffers.
+var perfBufferGadgets = map[string]string{
+	"execsnoop":     "execsnoop",
+	"tcpconnlat":    "tcpconnlat",
+	"ugidsnoop":     "ugidsnoop",
+	"restartsnoop":  "restartsnoop",
+	"swapin":        "swapin",
+	"tcpping":       "tcpping",
+	"killsnoop":     "killsnoop",
+	"hostpathsnoop": "hostpathsnoop",
+	"solisten":      "solisten",
+	"dnsconnect":    "dnsconnect",
+	"dnssnoop":      "dnssnoop",
+	"biosnoop":      "biosnoop",
+	"ttysnoop":      "ttysnoop",
+	"nfsslower":     "nfsslower",
+	"run-gadget":    "rungadget",
+}
+
+func init() {
+	for _, command := range []*cobra.Command{execsnoopCmd, tcpconnlatCmd, ugidsnoopCmd, restartsnoopCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd, runGadgetCmd} {
+		command.PersistentFlags().IntVar(&perfBufferPages, "perf-buffer-pages", 0,
+			"Size of the perf buffer of each CPU, in pages (a power of 2). Larger buffers lose fewer events. 0 for the default of the deployment")
+	}
+	deployCmd.PersistentFlags().StringVar(&perfBufferPagesDefaults, "perf-buffer-pages", "",
+		"comma-separated list of gadget=pages, the default size of the perf buffers of these gadgets (e.g. execsnoop=128,swapin=256)")
+}
+
+// perfBufferParam returns the parameter of bcc-wrapper.sh setting the size
+// of the perf buffers, none for 0: the gadget then uses the default of the
+// deployment, or its own.
+func perfBufferParam(pages int) (string, error) {
+	if pages == 0 {
+		return "", nil
+	}
+	if err := perfbuffer.CheckPages(pages); err != nil {
+		return "", fmt.Errorf("Invalid --perf-buffer-pages: %s", err)
+	}
+	return fmt.Sprintf(" --perfbufferpages %d", pages), nil
+}
+
+// perfBufferEnv returns the value of perfbuffer.EnvVar for the defaults of
+// "deploy --perf-buffer-pages", given by gadget
+func perfBufferEnv(list string) (string, error) {
+	var known []string
+	for gadget := range perfBufferGadgets {
+		known = append(known, gadget)
+	}
+	sort.Strings(known)
+	defaults, err := perfbuffer.ParseDefaults(list, known)
+	if err != nil {
+		return "", fmt.Errorf("invalid --perf-buffer-pages: %s", err)
+	}
+	programs := map[string]int{}
+	for gadget, pages := range defaults {
+		programs[perfBufferGadgets[gadget]] = pages
+	}
+	return perfbuffer.FormatDefaults(programs), nil
+}
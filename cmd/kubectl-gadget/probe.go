@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	probeOnlyFlag bool
+	probeTimeout  time.Duration
+)
+
+func init() {
+	for _, command := range []*cobra.Command{execsnoopCmd, tcpconnlatCmd, ugidsnoopCmd, restartsnoopCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd} {
+		command.PersistentFlags().BoolVarP(&probeOnlyFlag, "probe-only", "", false,
+			"Load the gadget on the nodes, print whether it loaded on each of them, and stop it without printing events. Exits with 1 if it failed on a node")
+		command.PersistentFlags().DurationVarP(&probeTimeout, "probe-timeout", "", 2*time.Minute,
+			"With --probe-only, the time given to the gadget to load on each node")
+	}
+}
+
+// probeResult is whether the gadget loaded on a node, printed as one line
+// per node
+type probeResult struct {
+	Node  string `json:"node"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+func (r probeResult) String() string {
+	if jsonOutput {
+		buf, _ := json.Marshal(r)
+		return string(buf)
+	}
+	if r.Ready {
+		return fmt.Sprintf("%-20s ready", r.Node)
+	}
+	return fmt.Sprintf("%-20s failed: %s", r.Node, r.Error)
+}
+
+// readyWriter calls ready when the ready record of a gadget is written, and
+// discards the events
+type readyWriter struct {
+	buf   []byte
+	ready func()
+	once  sync.Once
+}
+
+func (w *readyWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if isReadyRecord(string(w.buf[:i])) {
+			w.once.Do(w.ready)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// probeNode runs the gadget on node with load until it prints its ready
+// record, terminates, or timeout elapses, and stops it with stop. load
+// writes the output of the gadget on stdout and stderr, and returns once the
+// gadget terminated.
+func probeNode(node string, load func(node string, stdout, stderr io.Writer) error, stop func(node string), timeout time.Duration) probeResult {
+	ready := make(chan struct{})
+	terminated := make(chan error, 1)
+	var stderr lockedBuffer
+	go func() {
+		terminated <- load(node, &readyWriter{ready: func() { close(ready) }}, &stderr)
+	}()
+	// Stopped even when terminated, to remove the tracer from the node
+	defer stop(node)
+
+	result := probeResult{Node: node}
+	select {
+	case <-ready:
+		result.Ready = true
+	case err := <-terminated:
+		result.Error = loadError(err, stderr.String())
+	case <-time.After(timeout):
+		result.Error = fmt.Sprintf("not loaded after %s", timeout)
+	}
+	return result
+}
+
+// loadError describes why a gadget terminated before being ready, with the
+// last line it printed on stderr, usually the error of BCC
+func loadError(err error, stderr string) string {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	switch {
+	case err != nil && last != "":
+		return fmt.Sprintf("%s: %s", err, last)
+	case err != nil:
+		return err.Error()
+	case last != "":
+		return last
+	}
+	return "terminated before loading"
+}
+
+// probeNodes probes the gadget on the nodes in parallel, returning the
+// results in the order of the nodes
+func probeNodes(nodes []string, load func(node string, stdout, stderr io.Writer) error, stop func(node string), timeout time.Duration) []probeResult {
+	results := make([]probeResult, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node string) {
+			defer wg.Done()
+			results[i] = probeNode(node, load, stop, timeout)
+		}(i, node)
+	}
+	wg.Wait()
+	return results
+}
+
+// lockedBuffer is a bytes.Buffer that can be written while being read
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLoader loads a gadget printing the ready record, failing to load, or
+// never ready, depending on the node
+type fakeLoader struct {
+	mu      sync.Mutex
+	stopped map[string]bool
+	done    map[string]chan struct{}
+}
+
+func newFakeLoader(nodes ...string) *fakeLoader {
+	l := &fakeLoader{stopped: map[string]bool{}, done: map[string]chan struct{}{}}
+	for _, node := range nodes {
+		l.done[node] = make(chan struct{})
+	}
+	return l
+}
+
+func (l *fakeLoader) load(node string, stdout, stderr io.Writer) error {
+	switch node {
+	case "ready":
+		// The record split between writes, after an event
+		fmt.Fprint(stdout, `{"pid":1}`+"\n"+`{"type":`)
+		fmt.Fprint(stdout, `"ready"}`+"\n")
+	case "unsupported":
+		fmt.Fprintln(stderr, "Traceback (most recent call last):")
+		fmt.Fprintln(stderr, "Exception: Failed to attach BPF program trace_pid_start to kprobe blk_account_io_start")
+		return errors.New("command terminated with exit code 1")
+	}
+	<-l.done[node]
+	return nil
+}
+
+func (l *fakeLoader) stop(node string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.stopped[node] {
+		l.stopped[node] = true
+		close(l.done[node])
+	}
+}
+
+func TestProbeNodes(t *testing.T) {
+	loader := newFakeLoader("ready", "unsupported", "stuck")
+	results := probeNodes([]string{"ready", "unsupported", "stuck"}, loader.load, loader.stop, 50*time.Millisecond)
+
+	expected := []probeResult{
+		{Node: "ready", Ready: true},
+		{Node: "unsupported", Error: "command terminated with exit code 1: Exception: Failed to attach BPF program trace_pid_start to kprobe blk_account_io_start"},
+		{Node: "stuck", Error: "not loaded after 50ms"},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("%v != %v", results, expected)
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Fatalf("%v != %v", results[i], expected[i])
+		}
+		if !loader.stopped[expected[i].Node] {
+			t.Fatalf("gadget not stopped on %s", expected[i].Node)
+		}
+	}
+}
+
+func TestProbeResultString(t *testing.T) {
+	ready := probeResult{Node: "node-1", Ready: true}
+	failed := probeResult{Node: "node-2", Error: "not loaded after 2m0s"}
+	if s := ready.String(); s != "node-1               ready" {
+		t.Fatalf("unexpected %q", s)
+	}
+	if s := failed.String(); s != "node-2               failed: not loaded after 2m0s" {
+		t.Fatalf("unexpected %q", s)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	if s := ready.String(); s != `{"node":"node-1","ready":true}` {
+		t.Fatalf("unexpected %q", s)
+	}
+	if s := failed.String(); s != `{"node":"node-2","ready":false,"error":"not loaded after 2m0s"}` {
+		t.Fatalf("unexpected %q", s)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+)
+
+// outputParam is the output format given with -o, like protobuf
+var outputParam string
+
+// protobufWriter writes the events on stdout with -o protobuf, nil otherwise
+var protobufWriter *eventpb.Writer
+
+// writeProtobuf writes an event with -o protobuf. The line of the gadget is
+// always skipped: printing it would corrupt the binary stream.
+func writeProtobuf(event *eventpb.Event) (string, error) {
+	if err := protobufWriter.Write(event); err != nil {
+		log.Errorf("Error in writing event: %s", err)
+	}
+	return "", errSkipLine
+}
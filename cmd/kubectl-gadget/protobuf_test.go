@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/swapin"
+)
+
+func TestProtobufOutput(t *testing.T) {
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		return &containercache.Metadata{Namespace: "demo", Pod: "redis-0", Container: "redis"}, nil
+	}, containercache.DefaultConfig)
+
+	var events bytes.Buffer
+	protobufWriter = eventpb.NewWriter(&events)
+	defer func() { protobufWriter = nil }()
+
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(1, mock, mock)
+	postProcess.setTransform("", swapinTransform(containers, nil))
+	postProcess.outStreams[0].Write([]byte(`{"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"redis-server","containerid":"abc","major":true,"latency_us":1800}
+not an event
+`))
+	if string(mock.output) != "[ 0] not an event\n" {
+		t.Fatalf("unexpected output %q", mock.output)
+	}
+
+	m, err := eventpb.NewReader(&events).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := swapin.Event{
+		Timestamp: "2020-06-01T12:00:01.000123Z", Pid: 4242, Comm: "redis-server", ContainerID: "abc",
+		Namespace: "demo", Pod: "redis-0", Container: "redis", Major: true, LatencyUs: 1800,
+	}
+	if event := eventpb.ToSwapin(m.GetSwapin()); event != expected {
+		t.Fatalf("%+v != %+v", event, expected)
+	}
+}
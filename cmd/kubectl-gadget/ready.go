@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// readyGadgets are the gadgets printing a ready record once capturing, see
+// gadget-container/gadgets/bcck8s/ready.py
+var readyGadgets = map[string]bool{
+	"execsnoop":     true,
+	"tcpconnlat":    true,
+	"ugidsnoop":     true,
+	"swapin":        true,
+	"tcpping":       true,
+	"killsnoop":     true,
+	"hostpathsnoop": true,
+	"restartsnoop":  true,
+	"solisten":      true,
+	"dnsconnect":    true,
+	"dnssnoop":      true,
+	"biosnoop":      true,
+	"ttysnoop":      true,
+	"nfsslower":     true,
+}
+
+// readyRecord is printed in a JSON stream once the gadgets of all the nodes
+// are ready, before their events. It is also the record printed by each
+// gadget.
+type readyRecord struct {
+	Type string `json:"type"`
+}
+
+func (r readyRecord) String() string {
+	buf, _ := json.Marshal(r)
+	return string(buf)
+}
+
+// readyMarker is printed on stderr instead of the ready record without
+// --json
+const readyMarker = "Ready"
+
+// isReadyRecord returns whether a line printed by a gadget is its ready
+// record
+func isReadyRecord(line string) bool {
+	if !strings.HasPrefix(line, `{"type"`) {
+		return false
+	}
+	r := readyRecord{}
+	return json.Unmarshal([]byte(line), &r) == nil && r.Type == "ready"
+}
+
+// printReady returns the function printing the ready signal on w, as a
+// record with --json
+func printReady(w io.Writer) func() {
+	return func() {
+		if jsonOutput {
+			fmt.Fprintf(w, "%s\n", readyRecord{Type: "ready"})
+			return
+		}
+		fmt.Fprintln(w, readyMarker)
+	}
+}
+
+// readyGate holds the events of the nodes whose gadget is ready until the
+// gadgets of all the nodes are, and prints the ready signal then, so that it
+// precedes all the events. A node whose gadget failed before being ready is
+// not waited for.
+type readyGate struct {
+	mu      sync.Mutex
+	pending map[int]bool
+	signal  func()
+	done    chan struct{}
+}
+
+// newReadyGate returns a readyGate waiting for the gadgets of the nodes with
+// the given indexes, and calling signal once they are all ready
+func newReadyGate(indexes []int, signal func()) *readyGate {
+	g := &readyGate{
+		pending: map[int]bool{},
+		signal:  signal,
+		done:    make(chan struct{}),
+	}
+	for _, i := range indexes {
+		g.pending[i] = true
+	}
+	return g
+}
+
+// ready marks the gadget of the node index as ready, or as terminated. The
+// last one calls the signal and opens the gate.
+func (g *readyGate) ready(index int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.pending[index] {
+		return
+	}
+	delete(g.pending, index)
+	if len(g.pending) == 0 {
+		g.signal()
+		close(g.done)
+	}
+}
+
+// wait blocks until the gate is open
+func (g *readyGate) wait() {
+	<-g.done
+}
+
+// open opens the gate without signal, when terminating before the gadgets of
+// all the nodes are ready
+func (g *readyGate) open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.pending) == 0 {
+		return
+	}
+	g.pending = map[int]bool{}
+	close(g.done)
+}
+
+// setReadyGate makes the outStreams of the nodes wait for the gate after
+// receiving the ready record of their gadget
+func (p *postProcess) setReadyGate(g *readyGate) {
+	for i, s := range p.outStreams {
+		s.gate = g
+		s.index = i
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReadyGate tests that the ready record is printed once the gadgets of
+// all the nodes are ready, before their events
+func TestReadyGate(t *testing.T) {
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcessJSON([]string{"node0", "node1", "node2"}, mock)
+	// Node 2 is not selected
+	gate := newReadyGate([]int{0, 1}, printReady(mock))
+	postProcess.setReadyGate(gate)
+
+	written := make(chan struct{})
+	go func() {
+		postProcess.outStreams[0].Write([]byte(`{"type": "ready"}` + "\n" + `{"pid":1}` + "\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatalf("event printed before node1 is ready: %q", string(mock.output))
+	case <-time.After(100 * time.Millisecond):
+	}
+	if len(mock.output) != 0 {
+		t.Fatalf("unexpected output %q", string(mock.output))
+	}
+
+	postProcess.outStreams[1].Write([]byte(`{"type": "ready"}` + "\n"))
+	<-written
+	postProcess.outStreams[1].Write([]byte(`{"pid":2}` + "\n"))
+
+	expected := `
+{"type":"ready"}
+{"pid":1}
+{"pid":2}
+`
+	if "\n"+string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+}
+
+// TestReadyGateFailure tests that a node whose gadget terminated before being
+// ready is not waited for, and that the gate opens when terminating
+func TestReadyGateFailure(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	gate := newReadyGate([]int{0, 1}, printReady(mock))
+	gate.ready(0)
+	gate.ready(0)
+	gate.ready(1)
+	gate.wait()
+	if string(mock.output) != readyMarker+"\n" {
+		t.Fatalf("unexpected output %q", string(mock.output))
+	}
+
+	mock = &mockWriter{[]byte{}}
+	gate = newReadyGate([]int{0, 1}, printReady(mock))
+	gate.ready(0)
+	gate.open()
+	gate.wait()
+	gate.ready(1)
+	if len(mock.output) != 0 {
+		t.Fatalf("unexpected output %q", string(mock.output))
+	}
+}
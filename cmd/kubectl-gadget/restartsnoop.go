@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/restartsnoop"
+)
+
+// restartsnoopDelay is how long the termination of a container is delayed
+// before being explained, for the events of the nodes to arrive
+const restartsnoopDelay = 2 * time.Second
+
+var restartsnoopHeader = fmt.Sprintf("%-20s %-30s %-15s %8s %s",
+	"TIME", "POD", "CONTAINER", "RESTARTS", "EXPLANATION")
+
+// restartsnoopTransform records the events printed by the restartsnoop gadget.
+// Nothing is printed until a container terminates.
+func restartsnoopTransform(correlator *restartsnoop.Correlator) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := restartsnoop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		correlator.AddEvent(event)
+		return "", errSkipLine
+	}
+}
+
+// restartsnoopPodSelected applies the --podname, --pod-uid and --label
+// selectors. The namespace and the node are selected by the informer.
+func restartsnoopPodSelected(pod *corev1.Pod) bool {
+	if podUIDParam != "" {
+		if string(pod.UID) != podUIDParam {
+			return false
+		}
+	} else if podnameParam != "" && pod.Name != podnameParam {
+		return false
+	}
+	if labelParam != "" {
+		for _, pair := range strings.Split(labelParam, ",") {
+			kv := strings.Split(pair, "=")
+			if len(kv) != 2 || pod.Labels[kv[0]] != kv[1] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func formatRestart(r restartsnoop.Restart) (string, error) {
+	if jsonOutput {
+		buf, err := json.Marshal(r)
+		return string(buf), err
+	}
+	return fmt.Sprintf("%-20s %-30s %-15s %8d %s",
+		r.Timestamp, r.Namespace+"/"+r.Pod, r.Container, r.RestartCount, r.Explanation), nil
+}
+
+// restartsnoopHandler returns the function called by the pod informer, that
+// prints the terminated containers of the pods on w
+func restartsnoopHandler(correlator *restartsnoop.Correlator, w io.Writer, delay time.Duration) func(old, new *corev1.Pod) {
+	return func(old, new *corev1.Pod) {
+		if !restartsnoopPodSelected(new) {
+			return
+		}
+		time.AfterFunc(delay, func() {
+			for _, r := range correlator.PodUpdate(old, new) {
+				line, err := formatRestart(r)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "%s\n", line)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/restartsnoop"
+)
+
+// lineChan is a writer sending each write on a channel
+type lineChan chan string
+
+func (c lineChan) Write(p []byte) (int, error) {
+	c <- string(p)
+	return len(p), nil
+}
+
+func TestRestartsnoop(t *testing.T) {
+	id := "5c1ad1c0d66c0e8b3ab4b06e4ab3b91ce0dc8fd0c3e6d4e4b2b1f0a9b0e6d3c1"
+	correlator := restartsnoop.NewCorrelator()
+	output := runTransform("", restartsnoopTransform(correlator), `{"type":"oomkill","timestamp":"2020-06-01T12:00:00Z","pid":4242,"comm":"java","containerid":"`+id+`","memcg":true}`+"\n")
+	if len(output) != 0 {
+		t.Fatalf("events must not be printed: %q", output)
+	}
+
+	pod := func(restartCount int32) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web-1"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "app",
+					RestartCount: restartCount,
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						Reason:      "OOMKilled",
+						ExitCode:    137,
+						ContainerID: "docker://" + id,
+						FinishedAt:  metav1.NewTime(time.Date(2020, 6, 1, 12, 0, 1, 0, time.UTC)),
+					}},
+				}},
+			},
+		}
+	}
+
+	lines := make(lineChan, 1)
+	restartsnoopHandler(correlator, lines, 0)(pod(0), pod(1))
+	select {
+	case line := <-lines:
+		expected := "2020-06-01T12:00:01Z demo/web-1                     app                    1 OOM-killed: java (pid 4242) was killed because the container reached its memory limit\n"
+		if line != expected {
+			t.Fatalf("%q != %q", line, expected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("restart not printed")
+	}
+
+	podnameParam = "other"
+	defer func() { podnameParam = "" }()
+	restartsnoopHandler(correlator, lines, 0)(pod(1), pod(2))
+	select {
+	case line := <-lines:
+		t.Fatalf("restart of a pod not selected printed: %q", line)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
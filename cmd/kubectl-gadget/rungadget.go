@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/rungadget"
+)
+
+var runGadgetCmd = &cobra.Command{
+	Use:               "run-gadget",
+	Short:             "Run an external BPF gadget",
+	Run:               bccCmd("run-gadget", "/bin/rungadget"),
+	PersistentPreRunE: doesKubeconfigExist,
+}
+
+var (
+	runGadgetFile string
+	runGadgetMeta string
+)
+
+func init() {
+	rootCmd.AddCommand(runGadgetCmd)
+	runGadgetCmd.PersistentFlags().StringVar(&runGadgetFile, "file", "", "BPF object file of the gadget")
+	runGadgetCmd.PersistentFlags().StringVar(&runGadgetMeta, "meta", "", "YAML metadata describing the events of the gadget")
+	runGadgetCmd.PersistentFlags().StringVar(&nodeParam, "node", "", "Kubernetes node selector")
+	runGadgetCmd.PersistentFlags().StringVar(&outputDirParam, "output-dir", "", "Write the output of each node to a separate file in this directory")
+	runGadgetCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output events in JSON, one per line")
+	runGadgetCmd.PersistentFlags().DurationVar(&heartbeatParam, "heartbeat", 0,
+		"With --json, print a heartbeat record when no events were printed for this interval (e.g. 30s)")
+}
+
+// externalGadget is a gadget given with --file and --meta
+type externalGadget struct {
+	object []byte
+	meta   []byte
+}
+
+// readExternalGadget reads the files given with --file and --meta and
+// checks them against the safety constraints before anything is sent to
+// the nodes
+func readExternalGadget() (*externalGadget, error) {
+	if runGadgetFile == "" || runGadgetMeta == "" {
+		return nil, fmt.Errorf("run-gadget needs --file and --meta")
+	}
+	object, err := ioutil.ReadFile(runGadgetFile)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := ioutil.ReadFile(runGadgetMeta)
+	if err != nil {
+		return nil, err
+	}
+	m, err := rungadget.ParseMetadata(meta)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", runGadgetMeta, err)
+	}
+	if err := rungadget.ValidateObject(bytes.NewReader(object), int64(len(object)), m); err != nil {
+		return nil, fmt.Errorf("%s: %s", runGadgetFile, err)
+	}
+	return &externalGadget{object: object, meta: meta}, nil
+}
+
+// externalGadgetDir is where the gadget files are copied in the gadget pod
+func externalGadgetDir(tracerId string) string {
+	return "/run/rungadget-" + tracerId
+}
+
+// params returns the parameters of /bin/rungadget
+func (g *externalGadget) params(tracerId string) string {
+	dir := externalGadgetDir(tracerId)
+	return fmt.Sprintf("--object %s/gadget.o --meta %s/gadget.yaml", dir, dir)
+}
+
+// upload copies the gadget files to the gadget pod of the node
+func (g *externalGadget) upload(client *kubernetes.Clientset, node, tracerId string) error {
+	dir := externalGadgetDir(tracerId)
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{dir + "/gadget.o", g.object},
+		{dir + "/gadget.yaml", g.meta},
+	}
+	for _, f := range files {
+		var stderr bytes.Buffer
+		cmd := fmt.Sprintf("mkdir -p %s && cat > %s", dir, f.path)
+		err := execPodStdin(client, node, cmd, bytes.NewReader(f.data), ioutil.Discard, &stderr)
+		if err != nil {
+			return fmt.Errorf("copying %s to node %s: %s %s", f.path, node, err, stderr.String())
+		}
+	}
+	return nil
+}
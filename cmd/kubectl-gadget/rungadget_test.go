@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestReadExternalGadget(t *testing.T) {
+	defer func() { runGadgetFile, runGadgetMeta = "", "" }()
+
+	testdata := "../../pkg/gadgets/rungadget/testdata/"
+	runGadgetFile = testdata + "trivial.o"
+	runGadgetMeta = testdata + "trivial.yaml"
+	gadget, err := readExternalGadget()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := gadget.params("42")
+	expected := "--object /run/rungadget-42/gadget.o --meta /run/rungadget-42/gadget.yaml"
+	if params != expected {
+		t.Fatalf("%q != %q", params, expected)
+	}
+
+	runGadgetFile = testdata + "socketfilter.o"
+	if _, err := readExternalGadget(); err == nil {
+		t.Fatal("socket filter gadget accepted")
+	}
+
+	runGadgetFile = ""
+	if _, err := readExternalGadget(); err == nil {
+		t.Fatal("gadget without --file accepted")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/eventseq"
+)
+
+var seqFlag bool
+
+func init() {
+	for _, command := range []*cobra.Command{execsnoopCmd, tcpconnlatCmd, ugidsnoopCmd, swapinCmd, tcppingCmd, killsnoopCmd, hostpathsnoopCmd, solistenCmd, dnsconnectCmd, dnssnoopCmd, biosnoopCmd, ttysnoopCmd, nfsslowerCmd} {
+		command.PersistentFlags().BoolVarP(&seqFlag, "seq", "", false,
+			"Print the sequence numbers of the events on their node in a SEQ column, and when terminating, on stderr, the number of events lost on each node")
+	}
+}
+
+// seqHeader is the header of the SEQ column added with --seq
+var seqHeader = fmt.Sprintf("%-10s ", "SEQ")
+
+// seqColumn records the sequence number of an event line and returns its
+// SEQ column, empty without header or for lines without sequence number
+func (post *postProcessSingle) seqColumn(line string) string {
+	seq, ok := eventseq.Seq(line)
+	if !ok {
+		return ""
+	}
+	post.seq.Next(seq)
+	if post.header == "" {
+		return ""
+	}
+	return fmt.Sprintf("%-10d ", seq)
+}
+
+// setSeq follows the sequence numbers of the lines printed on outStreams,
+// one node each, and prints them in a SEQ column when there is a header
+func (p *postProcess) setSeq() {
+	for _, s := range p.outStreams {
+		s.seq = &eventseq.Tracker{}
+	}
+}
+
+// writeSeqReport writes the events lost on the nodes whose gadget lost some
+// or was restarted. nodeNames are the names of the nodes of the outStreams.
+func (p *postProcess) writeSeqReport(w io.Writer, nodeNames []string) error {
+	for i, s := range p.outStreams {
+		if s.seq == nil || (s.seq.Lost() == 0 && s.seq.Restarts() == 0) {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "Node %s: %d events lost, %d restarts of the gadget\n",
+			nodeNames[i], s.seq.Lost(), s.seq.Restarts()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/eventseq"
+)
+
+func TestPostProcessSeq(t *testing.T) {
+	containers := noContainers()
+
+	// 3 events lost after the second one, then the gadget was restarted
+	// and lost its first event
+	lines := `{"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"curl","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":12800,"seq":1}
+{"timestamp":"2020-06-01T12:00:01.000456Z","pid":4242,"comm":"curl","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":9100,"seq":2}
+{"timestamp":"2020-06-01T12:00:02.000123Z","pid":4242,"comm":"curl","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":8700,"seq":6}
+{"timestamp":"2020-06-01T12:00:05.000123Z","pid":4242,"comm":"curl","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":9900,"seq":2}
+`
+	mock := &mockWriter{[]byte{}}
+	postProcess := newPostProcess(1, mock, mock)
+	postProcess.setTransform(tcppingHeader, tcppingTransform(containers, nil))
+	postProcess.setSeq()
+	postProcess.outStreams[0].Write([]byte(lines))
+
+	expected := `
+NODE SEQ        TIME                        PID    COMM             ROLE   CONNECTION                                         LAT(us) POD
+[ 0] 1          2020-06-01T12:00:01.000123Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                      12800
+[ 0] 2          2020-06-01T12:00:01.000456Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                       9100
+[ 0] 6          2020-06-01T12:00:02.000123Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                       8700
+[ 0] 2          2020-06-01T12:00:05.000123Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                       9900
+`
+	if "\n"+string(mock.output) != expected {
+		t.Fatalf("%v != %v", string(mock.output), expected)
+	}
+
+	var buf bytes.Buffer
+	if err := postProcess.writeSeqReport(&buf, []string{"node-1"}); err != nil {
+		t.Fatal(err)
+	}
+	expected = "Node node-1: 4 events lost, 1 restarts of the gadget\n"
+	if buf.String() != expected {
+		t.Fatalf("%q != %q", buf.String(), expected)
+	}
+
+	// The sequence numbers are kept in the JSON events
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	mock = &mockWriter{[]byte{}}
+	postProcess = newPostProcessJSON([]string{"node-1"}, mock)
+	postProcess.setTransform("", tcppingTransform(containers, nil))
+	postProcess.setSeq()
+	postProcess.outStreams[0].Write([]byte(strings.SplitN(lines, "\n", 2)[0] + "\n"))
+	if seq, ok := eventseq.Seq(string(mock.output)); !ok || seq != 1 {
+		t.Fatalf("sequence number not kept in %s", mock.output)
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/snapshot"
+)
+
+// snapshotGadget is a resource of "kubectl gadget snapshot": unlike the
+// tracers, it prints the state of the nodes at one point in time, collected
+// on each node by /bin/snapshot. The umbrella command selects the entries
+// of the containers and prints them the same way for all the resources.
+type snapshotGadget interface {
+	// resource is the name of the subcommand and of the resource collected
+	// by /bin/snapshot
+	resource() string
+	short() string
+	// header is the header of the columns of the entries
+	header() string
+	// decode decodes an entry printed by /bin/snapshot
+	decode(line string) (snapshotEntry, error)
+}
+
+// snapshotEntry is an entry of a snapshot
+type snapshotEntry interface {
+	// container returns the container of the entry, to be completed
+	container() *snapshot.Container
+	// columns returns the columns of the entry under the header of its
+	// gadget
+	columns() string
+}
+
+var snapshotGadgets = []snapshotGadget{
+	processSnapshot{},
+	socketSnapshot{},
+	namespaceSnapshot{},
+	filesystemSnapshot{},
+	capabilitySnapshot{},
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Print the state of the containers at one point in time, like their processes or sockets",
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+
+	args := []string{"label", "node", "podname"}
+	vars := []*string{&labelParam, &nodeParam, &podnameParam}
+	for i := range args {
+		snapshotCmd.PersistentFlags().StringVarP(
+			vars[i],
+			args[i],
+			"",
+			"",
+			fmt.Sprintf("Kubernetes %s selector", args[i]))
+	}
+	snapshotCmd.PersistentFlags().VarP(
+		namespaceListValue{&namespaceParam},
+		"namespace",
+		"n",
+		"Kubernetes namespace selector, a comma-separated list or repeated for several namespaces")
+	snapshotCmd.PersistentFlags().BoolVarP(
+		&allNamespacesFlag,
+		"all-namespaces",
+		"A",
+		false,
+		"Print all namespaces, and the host, the default without --namespace when the current context has no namespace")
+	snapshotCmd.PersistentFlags().StringVar(
+		&podUIDParam,
+		"pod-uid",
+		"",
+		"Kubernetes pod UID selector (takes precedence over --podname)")
+	snapshotCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output entries in JSON, one per line")
+
+	for _, gadget := range snapshotGadgets {
+		snapshotCmd.AddCommand(&cobra.Command{
+			Use:   gadget.resource(),
+			Short: gadget.short(),
+			Run:   runSnapshot(gadget),
+		})
+	}
+}
+
+// snapshotPodHeader is the header of the columns identifying the container
+// of the entries, before the columns of the gadget
+var snapshotPodHeader = fmt.Sprintf("%-16s %-16s %-30s %-16s ", "NODE", "NAMESPACE", "POD", "CONTAINER")
+
+// writeSnapshot writes the entries of gadget, in JSON or under a header
+func writeSnapshot(w io.Writer, gadget snapshotGadget, entries []snapshotEntry, jsonOutput bool) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, snapshotPodHeader+gadget.header()); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		c := entry.container()
+		line := fmt.Sprintf("%-16s %-16s %-30s %-16s %s", c.Node, c.Namespace, c.Pod, c.Container, entry.columns())
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectSnapshot decodes the entries printed by /bin/snapshot on node and
+// returns the ones of the containers selected
+func selectSnapshot(gadget snapshotGadget, node, output string, containers *containercache.Cache, selector historySelector) ([]snapshotEntry, error) {
+	var entries []snapshotEntry
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, err := gadget.decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry %q: %s", line, err)
+		}
+		c := entry.container()
+		m := lookupContainer(containers, c.ContainerID)
+		if !selector.match(m) {
+			continue
+		}
+		c.Node = node
+		if m != nil {
+			c.Namespace = m.Namespace
+			c.Pod = m.Pod
+			c.Container = m.Container
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// sortSnapshot sorts the entries by node and container, keeping the order of
+// the gadget for the entries of a container
+func sortSnapshot(entries []snapshotEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].container(), entries[j].container()
+		if a.Node != b.Node {
+			return a.Node < b.Node
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		return a.Container < b.Container
+	})
+}
+
+func runSnapshot(gadget snapshotGadget) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		contextLogger := log.WithFields(log.Fields{
+			"command": fmt.Sprintf("kubectl-gadget snapshot %s", gadget.resource()),
+			"args":    args,
+		})
+
+		client, err := newClientset()
+		if err != nil {
+			contextLogger.Fatalf("Error in creating setting up Kubernetes client: %q", err)
+		}
+
+		namespaceParam, err = resolveNamespace(namespaceParam, allNamespacesFlag, kubeconfigNamespace)
+		if err != nil {
+			contextLogger.Fatalf("%s", err)
+		}
+		if labelParam != "" {
+			for _, pair := range strings.Split(labelParam, ",") {
+				if len(strings.Split(pair, "=")) != 2 {
+					contextLogger.Fatalf("labels should be a comma-separated list of key-value pairs (key=value[,key=value,...])\n")
+				}
+			}
+		}
+
+		var nodes []corev1.Node
+		if nodeParam != "" {
+			node, err := client.CoreV1().Nodes().Get(nodeParam, metaV1.GetOptions{})
+			if err != nil {
+				contextLogger.Fatalf("Error in getting node %q: %q", nodeParam, err)
+			}
+			nodes = append(nodes, *node)
+		} else {
+			list, err := client.CoreV1().Nodes().List(metaV1.ListOptions{})
+			if err != nil {
+				contextLogger.Fatalf("Error in listing nodes: %q", err)
+			}
+			nodes = list.Items
+		}
+
+		containers := containercache.New(lookupContainerByID(client, namespaceParam), containercache.DefaultConfig)
+		selector := newHistorySelector(namespaceParam, podnameParam, podUIDParam, labelParam)
+		podCmd := fmt.Sprintf("/bin/snapshot -resource %s", gadget.resource())
+
+		var (
+			mu      sync.Mutex
+			entries []snapshotEntry
+			wg      sync.WaitGroup
+			failed  bool
+		)
+		for _, node := range nodes {
+			wg.Add(1)
+			go func(node string) {
+				defer wg.Done()
+				stdout, stderr, err := execPodCapture(client, node, podCmd)
+				var nodeEntries []snapshotEntry
+				if err == nil {
+					nodeEntries, err = selectSnapshot(gadget, node, stdout, containers, selector)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					stderrLog.Errorf("Error on node %s: %s: %s", node, err, strings.TrimSpace(stderr))
+					failed = true
+					return
+				}
+				entries = append(entries, nodeEntries...)
+			}(node.Name)
+		}
+		wg.Wait()
+
+		sortSnapshot(entries)
+		if err := writeSnapshot(os.Stdout, gadget, entries, jsonOutput); err != nil {
+			contextLogger.Fatalf("%s", err)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	}
+}
+
+// Resources of "kubectl gadget snapshot", see package snapshot
+
+type processSnapshot struct{}
+type processEntry struct{ snapshot.Process }
+
+func (processSnapshot) resource() string { return "process" }
+func (processSnapshot) short() string    { return "Print the processes of the containers" }
+func (processSnapshot) header() string {
+	return fmt.Sprintf("%-7s %-7s %-6s %s", "PID", "PPID", "UID", "COMM")
+}
+func (processSnapshot) decode(line string) (snapshotEntry, error) {
+	e := &processEntry{}
+	return e, json.Unmarshal([]byte(line), &e.Process)
+}
+func (e *processEntry) container() *snapshot.Container { return &e.Container }
+func (e *processEntry) columns() string {
+	return fmt.Sprintf("%-7d %-7d %-6d %s", e.Pid, e.Ppid, e.Uid, e.Comm)
+}
+
+type socketSnapshot struct{}
+type socketEntry struct{ snapshot.Socket }
+
+func (socketSnapshot) resource() string { return "socket" }
+func (socketSnapshot) short() string {
+	return "Print the TCP and UDP sockets of the processes of the containers"
+}
+func (socketSnapshot) header() string {
+	return fmt.Sprintf("%-5s %-22s %-22s %-12s %-7s %s", "PROTO", "LOCAL", "REMOTE", "STATE", "PID", "COMM")
+}
+func (socketSnapshot) decode(line string) (snapshotEntry, error) {
+	e := &socketEntry{}
+	return e, json.Unmarshal([]byte(line), &e.Socket)
+}
+func (e *socketEntry) container() *snapshot.Container { return &e.Container }
+func (e *socketEntry) columns() string {
+	state := e.State
+	if state == "" {
+		state = "-"
+	}
+	return fmt.Sprintf("%-5s %-22s %-22s %-12s %-7d %s", e.Protocol,
+		snapshotAddress(e.LocalAddress, e.LocalPort), snapshotAddress(e.RemoteAddress, e.RemotePort),
+		state, e.Pid, e.Comm)
+}
+
+// snapshotAddress formats an address and a port, with the IPv6 addresses in
+// brackets
+func snapshotAddress(address string, port uint16) string {
+	if strings.Contains(address, ":") {
+		return fmt.Sprintf("[%s]:%d", address, port)
+	}
+	return fmt.Sprintf("%s:%d", address, port)
+}
+
+type namespaceSnapshot struct{}
+type namespaceEntry struct{ snapshot.Namespaces }
+
+func (namespaceSnapshot) resource() string { return "namespace" }
+func (namespaceSnapshot) short() string {
+	return "Print the Linux namespaces of the containers, the host included with -A"
+}
+func (namespaceSnapshot) header() string {
+	return fmt.Sprintf("%-7s %-10s %-10s %-10s %-10s %-10s %-10s %s", "PID", "MNT", "NET", "PIDNS", "UTS", "IPC", "USER", "CGROUP")
+}
+func (namespaceSnapshot) decode(line string) (snapshotEntry, error) {
+	e := &namespaceEntry{}
+	return e, json.Unmarshal([]byte(line), &e.Namespaces)
+}
+func (e *namespaceEntry) container() *snapshot.Container { return &e.Container }
+func (e *namespaceEntry) columns() string {
+	return fmt.Sprintf("%-7d %-10d %-10d %-10d %-10d %-10d %-10d %d", e.Pid, e.Mnt, e.Net, e.PidNs, e.Uts, e.Ipc, e.User, e.Cgroup)
+}
+
+type filesystemSnapshot struct{}
+type filesystemEntry struct{ snapshot.Filesystem }
+
+func (filesystemSnapshot) resource() string { return "filesystem" }
+func (filesystemSnapshot) short() string {
+	return "Print the filesystems mounted in the containers and their usage, like df"
+}
+func (filesystemSnapshot) header() string {
+	return fmt.Sprintf("%-7s %-9s %-9s %-9s %-4s %-5s %-10s %s", "PID", "SIZE", "USED", "AVAIL", "USE%", "IUSE%", "FSTYPE", "MOUNTPOINT")
+}
+func (filesystemSnapshot) decode(line string) (snapshotEntry, error) {
+	e := &filesystemEntry{}
+	return e, json.Unmarshal([]byte(line), &e.Filesystem)
+}
+func (e *filesystemEntry) container() *snapshot.Container { return &e.Container }
+func (e *filesystemEntry) columns() string {
+	if e.Error != "" {
+		return fmt.Sprintf("%-7d %-9s %-9s %-9s %-4s %-5s %-10s %s (%s)", e.Pid, "-", "-", "-", "-", "-", e.FsType, e.MountPoint, e.Error)
+	}
+	return fmt.Sprintf("%-7d %-9s %-9s %-9s %-4s %-5s %-10s %s", e.Pid,
+		units.BytesSize(float64(e.Size)), units.BytesSize(float64(e.Used)), units.BytesSize(float64(e.Avail)),
+		usePercent(e.Used, e.Used+e.Avail), usePercent(e.InodesUsed, e.Inodes), e.FsType, e.MountPoint)
+}
+
+// usePercent returns the percentage of used in total, rounded up as df does,
+// or "-" when total is 0, like the inodes of the filesystems without them
+func usePercent(used, total uint64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", (used*100+total-1)/total)
+}
+
+type capabilitySnapshot struct{}
+type capabilityEntry struct{ snapshot.Capabilities }
+
+func (capabilitySnapshot) resource() string { return "capability" }
+func (capabilitySnapshot) short() string {
+	return "Print the effective, permitted and bounding capabilities of the containers"
+}
+func (capabilitySnapshot) header() string {
+	return fmt.Sprintf("%-7s %-16s %s", "PID", "COMM", "EFFECTIVE / PERMITTED / BOUNDING")
+}
+func (capabilitySnapshot) decode(line string) (snapshotEntry, error) {
+	e := &capabilityEntry{}
+	return e, json.Unmarshal([]byte(line), &e.Capabilities)
+}
+func (e *capabilityEntry) container() *snapshot.Container { return &e.Container }
+func (e *capabilityEntry) columns() string {
+	return fmt.Sprintf("%-7d %-16s %s / %s / %s", e.Pid, e.Comm,
+		capabilityColumn(e.Effective), capabilityColumn(e.Permitted), capabilityColumn(e.Bounding))
+}
+
+// capabilityColumn formats a capability set like capsh, as the names in lower
+// case without their CAP_ prefix, or "-" when empty
+func capabilityColumn(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	short := make([]string, len(names))
+	for i, name := range names {
+		short[i] = strings.ToLower(strings.TrimPrefix(name, "CAP_"))
+	}
+	return strings.Join(short, ",")
+}
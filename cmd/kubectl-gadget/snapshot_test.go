@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/snapshot"
+)
+
+const snapshotTestContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// snapshotTestEntries are entries printed by /bin/snapshot on a node, one
+// in the container of a pod and one on the host
+var snapshotTestEntries = map[string][]interface{}{
+	"process": {
+		snapshot.Process{Container: snapshot.Container{ContainerID: snapshotTestContainerID}, Pid: 42, Ppid: 1, Uid: 101, Comm: "nginx"},
+		snapshot.Process{Pid: 1, Comm: "systemd"},
+	},
+	"socket": {
+		snapshot.Socket{Container: snapshot.Container{ContainerID: snapshotTestContainerID}, Protocol: "TCP", LocalAddress: "0.0.0.0", LocalPort: 80, RemoteAddress: "0.0.0.0", State: "LISTEN", Pid: 42, Comm: "nginx"},
+		snapshot.Socket{Protocol: "UDP", LocalAddress: "::1", LocalPort: 53, RemoteAddress: "::", Pid: 50, Comm: "dnsmasq"},
+	},
+	"namespace": {
+		snapshot.Namespaces{Container: snapshot.Container{ContainerID: snapshotTestContainerID}, Pid: 42, Mnt: 4026532201, Net: 4026532204, PidNs: 4026532202, Uts: 4026532199, Ipc: 4026532200, User: 4026531837, Cgroup: 4026531835},
+		snapshot.Namespaces{Pid: 1, Mnt: 4026531840, Net: 4026531992, PidNs: 4026531836, Uts: 4026531838, Ipc: 4026531839, User: 4026531837, Cgroup: 4026531835},
+	},
+	// The host is not collected, a container unknown to Kubernetes instead
+	"filesystem": {
+		snapshot.Filesystem{Container: snapshot.Container{ContainerID: snapshotTestContainerID}, Pid: 42, MountPoint: "/", Source: "overlay", FsType: "overlay", Size: 100 << 30, Used: 75 << 30, Avail: 20 << 30, Inodes: 6553600, InodesUsed: 655360},
+		snapshot.Filesystem{Container: snapshot.Container{ContainerID: strings.Repeat("f", 64)}, Pid: 30, MountPoint: "/data", Source: "/dev/sdb", FsType: "ext4", Error: "permission denied"},
+	},
+	"capability": {
+		snapshot.Capabilities{Container: snapshot.Container{ContainerID: snapshotTestContainerID}, Pid: 42, Comm: "nginx", Effective: []string{"CAP_CHOWN", "CAP_NET_BIND_SERVICE"}, Permitted: []string{"CAP_CHOWN", "CAP_NET_BIND_SERVICE"}, Bounding: []string{"CAP_CHOWN", "CAP_NET_BIND_SERVICE", "CAP_SYS_ADMIN"}},
+		snapshot.Capabilities{Container: snapshot.Container{ContainerID: strings.Repeat("f", 64)}, Pid: 30, Comm: "app", Effective: []string{}, Permitted: []string{}, Bounding: []string{"CAP_KILL"}},
+	},
+}
+
+func snapshotTestOutput(t *testing.T, resource string) string {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	for _, entry := range snapshotTestEntries[resource] {
+		if err := encoder.Encode(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out.String()
+}
+
+func snapshotTestContainers() *containercache.Cache {
+	return containercache.New(func(containerID string) (*containercache.Metadata, error) {
+		if containerID != snapshotTestContainerID {
+			return nil, nil
+		}
+		return &containercache.Metadata{Namespace: "default", Pod: "nginx-1", Container: "nginx"}, nil
+	}, containercache.DefaultConfig)
+}
+
+// TestSnapshotOutputStructure checks that all the snapshots are printed
+// the same way: the container of each entry in the first columns, and the
+// columns of the gadget under its header
+func TestSnapshotOutputStructure(t *testing.T) {
+	for _, gadget := range snapshotGadgets {
+		all := newHistorySelector("", "", "", "")
+		entries, err := selectSnapshot(gadget, "node-1", snapshotTestOutput(t, gadget.resource()), snapshotTestContainers(), all)
+		if err != nil {
+			t.Fatalf("%s: %s", gadget.resource(), err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("%s: %d entries, expected 2", gadget.resource(), len(entries))
+		}
+
+		var text bytes.Buffer
+		if err := writeSnapshot(&text, gadget, entries, false); err != nil {
+			t.Fatalf("%s: %s", gadget.resource(), err)
+		}
+		lines := strings.Split(strings.TrimSuffix(text.String(), "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("%s: unexpected output:\n%s", gadget.resource(), text.String())
+		}
+		header := strings.Fields(lines[0])
+		if strings.Join(header[:4], " ") != "NODE NAMESPACE POD CONTAINER" {
+			t.Fatalf("%s: unexpected header %q", gadget.resource(), lines[0])
+		}
+		// The entry of the pod has a value in each column
+		if fields := strings.Fields(lines[1]); len(fields) != len(header) {
+			t.Fatalf("%s: %d columns in %q, expected %d", gadget.resource(), len(fields), lines[1], len(header))
+		}
+		if !strings.HasPrefix(lines[1], "node-1           default          nginx-1                        nginx            ") {
+			t.Fatalf("%s: unexpected container in %q", gadget.resource(), lines[1])
+		}
+		// The columns of the gadget start at the same offset for the
+		// entries of the host
+		offset := len(snapshotPodHeader)
+		if lines[2][offset-1] != ' ' || lines[2][offset] == ' ' {
+			t.Fatalf("%s: columns of %q not aligned with the header %q", gadget.resource(), lines[2], lines[0])
+		}
+
+		var jsonOut bytes.Buffer
+		if err := writeSnapshot(&jsonOut, gadget, entries, true); err != nil {
+			t.Fatalf("%s: %s", gadget.resource(), err)
+		}
+		records := strings.Split(strings.TrimSuffix(jsonOut.String(), "\n"), "\n")
+		if len(records) != 2 {
+			t.Fatalf("%s: unexpected JSON output:\n%s", gadget.resource(), jsonOut.String())
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(records[0]), &record); err != nil {
+			t.Fatalf("%s: %s", gadget.resource(), err)
+		}
+		for key, expected := range map[string]string{
+			"node":        "node-1",
+			"namespace":   "default",
+			"pod":         "nginx-1",
+			"container":   "nginx",
+			"containerid": snapshotTestContainerID,
+		} {
+			if record[key] != expected {
+				t.Fatalf("%s: %s is %v in %s, expected %q", gadget.resource(), key, record[key], records[0], expected)
+			}
+		}
+		if record["pid"] != float64(42) {
+			t.Fatalf("%s: unexpected pid in %s", gadget.resource(), records[0])
+		}
+	}
+}
+
+func TestSnapshotSelector(t *testing.T) {
+	containers := snapshotTestContainers()
+	for _, gadget := range snapshotGadgets {
+		// The entries of the host are only printed with all namespaces
+		selector := newHistorySelector("default", "", "", "")
+		entries, err := selectSnapshot(gadget, "node-1", snapshotTestOutput(t, gadget.resource()), containers, selector)
+		if err != nil {
+			t.Fatalf("%s: %s", gadget.resource(), err)
+		}
+		if len(entries) != 1 || entries[0].container().Pod != "nginx-1" {
+			t.Fatalf("%s: unexpected entries %v", gadget.resource(), entries)
+		}
+
+		selector = newHistorySelector("kube-system", "", "", "")
+		entries, err = selectSnapshot(gadget, "node-1", snapshotTestOutput(t, gadget.resource()), containers, selector)
+		if err != nil {
+			t.Fatalf("%s: %s", gadget.resource(), err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("%s: unexpected entries %v", gadget.resource(), entries)
+		}
+	}
+}
+
+func TestSortSnapshot(t *testing.T) {
+	entry := func(node, pod string, pid int) snapshotEntry {
+		return &processEntry{snapshot.Process{Container: snapshot.Container{Node: node, Namespace: "default", Pod: pod}, Pid: pid}}
+	}
+	entries := []snapshotEntry{
+		entry("node-2", "a", 10),
+		entry("node-1", "b", 3),
+		entry("node-1", "a", 5),
+		entry("node-1", "b", 1),
+	}
+	sortSnapshot(entries)
+	var order []int
+	for _, e := range entries {
+		order = append(order, e.(*processEntry).Pid)
+	}
+	// The order of the gadget, by pid, is kept within a pod
+	if !reflect.DeepEqual(order, []int{5, 3, 1, 10}) {
+		t.Fatalf("unexpected order %v", order)
+	}
+}
+
+func TestFilesystemColumns(t *testing.T) {
+	e := &filesystemEntry{snapshotTestEntries["filesystem"][0].(snapshot.Filesystem)}
+	expected := "42      100GiB    75GiB     20GiB     79%  10%   overlay    /"
+	if columns := e.columns(); columns != expected {
+		t.Fatalf("unexpected columns %q, expected %q", columns, expected)
+	}
+	e = &filesystemEntry{snapshotTestEntries["filesystem"][1].(snapshot.Filesystem)}
+	expected = "30      -         -         -         -    -     ext4       /data (permission denied)"
+	if columns := e.columns(); columns != expected {
+		t.Fatalf("unexpected columns %q, expected %q", columns, expected)
+	}
+}
+
+func TestCapabilityColumns(t *testing.T) {
+	e := &capabilityEntry{snapshotTestEntries["capability"][0].(snapshot.Capabilities)}
+	expected := "42      nginx            chown,net_bind_service / chown,net_bind_service / chown,net_bind_service,sys_admin"
+	if columns := e.columns(); columns != expected {
+		t.Fatalf("unexpected columns %q, expected %q", columns, expected)
+	}
+	e = &capabilityEntry{snapshotTestEntries["capability"][1].(snapshot.Capabilities)}
+	expected = "30      app              - / - / kill"
+	if columns := e.columns(); columns != expected {
+		t.Fatalf("unexpected columns %q, expected %q", columns, expected)
+	}
+}
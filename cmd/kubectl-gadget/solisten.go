@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/solisten"
+)
+
+var solistenHeader = fmt.Sprintf("%-27s %-6s %-16s %-5s %-24s %-7s %-10s %s",
+	"TIME", "PID", "COMM", "PROTO", "ADDR", "BACKLOG", "RESULT", "POD")
+
+// solistenTransform returns the transform function rendering the listening
+// sockets printed by the solisten gadget with their pod
+func solistenTransform(containers *containercache.Cache) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := solisten.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		event.Error = solisten.ErrorMessage(event.Ret)
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		proto := fmt.Sprintf("%sv%d", event.Proto, event.IPVersion)
+		addr := net.JoinHostPort(event.Addr, strconv.Itoa(int(event.Port)))
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-5s %-24s %-7d %-10s %s",
+			event.Timestamp, event.Pid, event.Comm, proto, addr, event.Backlog,
+			solisten.Result(event.Ret), pod), " "), nil
+	}
+}
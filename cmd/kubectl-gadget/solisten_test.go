@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSolistenTransform(t *testing.T) {
+	containers := testContainers("nginx-6db4", "nginx")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000001Z","pid":4242,"comm":"nginx","containerid":"abc","proto":"TCP","ipversion":4,"addr":"0.0.0.0","port":80,"backlog":511,"ret":0}
+{"timestamp":"2020-06-01T12:00:02.000002Z","pid":4250,"comm":"nginx","containerid":"abc","proto":"TCP","ipversion":6,"addr":"::","port":80,"backlog":511,"ret":-98}
+`
+	output := runTransform(solistenHeader, solistenTransform(containers), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             PROTO ADDR                     BACKLOG RESULT     POD
+[ 0] 2020-06-01T12:00:01.000001Z 4242   nginx            TCPv4 0.0.0.0:80               511     OK         demo/nginx-6db4/nginx
+[ 0] 2020-06-01T12:00:02.000002Z 4250   nginx            TCPv6 [::]:80                  511     EADDRINUSE demo/nginx-6db4/nginx
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	output = runTransform(solistenHeader, solistenTransform(containers), strings.SplitAfter(lines, "\n")[1])
+
+	expected = `[ 0] {"timestamp":"2020-06-01T12:00:02.000002Z","pid":4250,"comm":"nginx","containerid":"abc","namespace":"demo","pod":"nginx-6db4","container":"nginx","proto":"TCP","ipversion":6,"addr":"::","port":80,"backlog":511,"ret":-98,"error":"address already in use"}
+`
+	if !strings.HasSuffix(output, expected) {
+		t.Fatalf("%v doesn't end with %v", output, expected)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/swapin"
+	"github.com/kinvolk/inspektor-gadget/pkg/histogram"
+)
+
+var swapinHistogram bool
+
+var swapinHeader = fmt.Sprintf("%-27s %-6s %-16s %-5s %10s %s",
+	"TIME", "PID", "COMM", "MAJOR", "LAT(us)", "POD")
+
+// swapinTransform returns the transform function rendering the page faults
+// printed by the swapin gadget with their pod. With hist, the latencies are
+// aggregated instead and nothing is printed.
+func swapinTransform(containers *containercache.Cache, hist *histogram.Histogram) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := swapin.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if hist != nil {
+			hist.Add(event.LatencyUs)
+			return "", errSkipLine
+		}
+		if event.ContainerID != "" {
+			m, err := containers.Get(event.ContainerID)
+			if err == nil && m != nil {
+				event.Namespace = m.Namespace
+				event.Pod = m.Pod
+				event.Container = m.Container
+			}
+		}
+		if protobufWriter != nil {
+			return writeProtobuf(eventpb.FromSwapin(event))
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-5t %10d %s",
+			event.Timestamp, event.Pid, event.Comm, event.Major, event.LatencyUs, pod), " "), nil
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/histogram"
+)
+
+func TestSwapinTransform(t *testing.T) {
+	containers := testContainers("web-1", "redis")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"redis-server","containerid":"abc","major":true,"latency_us":1800}
+{"timestamp":"2020-06-01T12:00:01.000456Z","pid":1,"comm":"systemd","major":false,"latency_us":3}
+`
+	output := runTransform(swapinHeader, swapinTransform(containers, nil), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             MAJOR    LAT(us) POD
+[ 0] 2020-06-01T12:00:01.000123Z 4242   redis-server     true        1800 demo/web-1/redis
+[ 0] 2020-06-01T12:00:01.000456Z 1      systemd          false          3
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	hist := &histogram.Histogram{}
+	output = runTransform("", swapinTransform(containers, hist), lines)
+	if len(output) != 0 {
+		t.Fatalf("unexpected output %q with a histogram", output)
+	}
+	if hist.Count() != 2 {
+		t.Fatalf("%d latencies in the histogram, expected 2", hist.Count())
+	}
+}
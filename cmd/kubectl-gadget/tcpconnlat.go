@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+	"github.com/kinvolk/inspektor-gadget/pkg/exposition"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpconnlat"
+	"github.com/kinvolk/inspektor-gadget/pkg/histogram"
+	"github.com/kinvolk/inspektor-gadget/pkg/podinformer"
+)
+
+var tcpconnlatHistogram bool
+
+// tcpconnlatHeader returns the header of the text output, with the pod
+// status columns when pods is not nil
+func tcpconnlatHeader(pods *podinformer.Store) string {
+	status := ""
+	if pods != nil {
+		status = fmt.Sprintf("%-9s %-5s ", "PHASE", "READY")
+	}
+	return fmt.Sprintf("%-6s %-16s %-2s %-16s %-16s %-5s %10s %sPOD",
+		"PID", "COMM", "IP", "SADDR", "DADDR", "DPORT", "LAT(us)", status)
+}
+
+// lookupContainerByID returns a containercache.LookupFunc finding the pod
+// and container of a container id
+func lookupContainerByID(client *kubernetes.Clientset, namespace string) containercache.LookupFunc {
+	return func(containerID string) (*containercache.Metadata, error) {
+		pods, err := client.CoreV1().Pods(listNamespace(namespace)).List(metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			for _, s := range pod.Status.ContainerStatuses {
+				// ContainerID is like docker://<id> or cri-o://<id>
+				if !strings.HasSuffix(s.ContainerID, "://"+containerID) {
+					continue
+				}
+				return &containercache.Metadata{
+					Namespace:   pod.Namespace,
+					Pod:         pod.Name,
+					PodUID:      string(pod.UID),
+					Labels:      pod.Labels,
+					Container:   s.Name,
+					HostNetwork: pod.Spec.HostNetwork,
+				}, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+// tcpconnlatKey identifies the container of a connection
+type tcpconnlatKey struct {
+	namespace, pod, container string
+}
+
+// tcpconnlatAggregate aggregates the latencies of the connections, of all the
+// containers together or per container
+type tcpconnlatAggregate struct {
+	mu           sync.Mutex
+	perContainer bool
+	histograms   map[tcpconnlatKey]*histogram.Histogram
+}
+
+func newTcpconnlatAggregate(perContainer bool) *tcpconnlatAggregate {
+	return &tcpconnlatAggregate{
+		perContainer: perContainer,
+		histograms:   make(map[tcpconnlatKey]*histogram.Histogram),
+	}
+}
+
+// histogram returns the histogram of a container, created if needed
+func (a *tcpconnlatAggregate) histogram(key tcpconnlatKey) *histogram.Histogram {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	h, ok := a.histograms[key]
+	if !ok {
+		h = &histogram.Histogram{}
+		a.histograms[key] = h
+	}
+	return h
+}
+
+func (a *tcpconnlatAggregate) add(event tcpconnlat.Event) {
+	key := tcpconnlatKey{}
+	if a.perContainer {
+		key = tcpconnlatKey{event.Namespace, event.Pod, event.Container}
+	}
+	a.histogram(key).Add(event.LatencyUs)
+}
+
+// writeExposition writes the latencies per container in the Prometheus text
+// exposition format
+func (a *tcpconnlatAggregate) writeExposition(w io.Writer) error {
+	a.mu.Lock()
+	samples := []exposition.HistogramSample{}
+	for key, h := range a.histograms {
+		samples = append(samples, exposition.HistogramSample{
+			Labels: exposition.Labels{
+				"namespace": key.namespace,
+				"pod":       key.pod,
+				"container": key.container,
+			},
+			Histogram: h,
+		})
+	}
+	a.mu.Unlock()
+	return exposition.WriteHistogram(w, "tcpconnlat_latency_seconds",
+		"Time from connect() to the reception of the SYN-ACK", 1e6, samples)
+}
+
+// tcpconnlatTransform returns the transform function rendering the
+// connections printed by the tcpconnlat gadget with their pod, and its status
+// if pods is not nil. With aggregate, the latencies are aggregated instead
+// and nothing is printed.
+func tcpconnlatTransform(containers *containercache.Cache, pods *podinformer.Store, aggregate *tcpconnlatAggregate) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := tcpconnlat.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if aggregate != nil && !aggregate.perContainer {
+			aggregate.add(event)
+			return "", errSkipLine
+		}
+		if event.ContainerID != "" {
+			m, err := containers.Get(event.ContainerID)
+			if err == nil && m != nil {
+				event.Namespace = m.Namespace
+				event.Pod = m.Pod
+				event.Container = m.Container
+			}
+		}
+		if aggregate != nil {
+			aggregate.add(event)
+			return "", errSkipLine
+		}
+		if pods != nil && event.Pod != "" {
+			if status, ok := pods.Status(event.Namespace, event.Pod, event.Container); ok {
+				event.PodPhase = status.Phase
+				event.ContainerReady = &status.Ready
+			}
+		}
+		if protobufWriter != nil {
+			return writeProtobuf(eventpb.FromTcpconnlat(event))
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		status := ""
+		if pods != nil {
+			ready := "-"
+			if event.ContainerReady != nil {
+				ready = fmt.Sprintf("%t", *event.ContainerReady)
+			}
+			status = fmt.Sprintf("%-9s %-5s ", event.PodPhase, ready)
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		return strings.TrimRight(fmt.Sprintf("%-6d %-16s %-2d %-16s %-16s %-5d %10d %s%s",
+			event.Pid, event.Comm, event.IPVersion, event.Saddr, event.Daddr,
+			event.Dport, event.LatencyUs, status, pod), " "), nil
+	}
+}
+
+// printTcpconnlatHistogram prints the histogram of the latencies, in JSON
+// with --json
+func printTcpconnlatHistogram(w io.Writer, aggregate *tcpconnlatAggregate) error {
+	return printHistogram(w, aggregate.histogram(tcpconnlatKey{}))
+}
+
+// printHistogram prints a histogram of latencies in microseconds, in JSON
+// with --json
+func printHistogram(w io.Writer, hist *histogram.Histogram) error {
+	if jsonOutput {
+		buf, err := json.Marshal(struct {
+			Type    string             `json:"type"`
+			Unit    string             `json:"unit"`
+			Buckets []histogram.Bucket `json:"buckets"`
+		}{"histogram", "usecs", hist.Buckets()})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", buf)
+		return err
+	}
+	_, err := fmt.Fprint(w, hist.String("usecs"))
+	return err
+}
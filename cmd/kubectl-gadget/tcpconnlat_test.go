@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/podinformer"
+)
+
+func TestTcpconnlatTransform(t *testing.T) {
+	containers := testContainers("mypod", "web")
+
+	lines := `{"pid":42,"comm":"wget","containerid":"abc","ipversion":4,"saddr":"10.2.232.47","daddr":"10.2.232.1","dport":80,"latency_us":120}
+{"pid":43,"comm":"curl","containerid":"","ipversion":4,"saddr":"10.0.0.1","daddr":"10.0.0.2","dport":443,"latency_us":3000}
+`
+	output := runTransform(tcpconnlatHeader(nil), tcpconnlatTransform(containers, nil, nil), lines)
+
+	expected := `
+NODE PID    COMM             IP SADDR            DADDR            DPORT    LAT(us) POD
+[ 0] 42     wget             4  10.2.232.47      10.2.232.1       80           120 demo/mypod/web
+[ 0] 43     curl             4  10.0.0.1         10.0.0.2         443         3000
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	// Histogram mode: nothing printed until the end
+	aggregate := newTcpconnlatAggregate(false)
+	output = runTransform("", tcpconnlatTransform(containers, nil, aggregate), lines)
+	if len(output) != 0 {
+		t.Fatalf("output in histogram mode: %q", output)
+	}
+	buckets := aggregate.histogram(tcpconnlatKey{}).Buckets()
+	if len(buckets) != 12 || buckets[6].Count != 1 || buckets[11].Count != 1 {
+		t.Fatalf("unexpected histogram %v", buckets)
+	}
+}
+
+func TestTcpconnlatTransformPodStatus(t *testing.T) {
+	containers := containercache.New(func(id string) (*containercache.Metadata, error) {
+		return &containercache.Metadata{Namespace: "demo", Pod: id, Container: "web"}, nil
+	}, containercache.DefaultConfig)
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "ready"},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "web", Ready: true}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "pending"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	)
+	stop := make(chan struct{})
+	defer close(stop)
+	pods, err := podinformer.NewStore(client, "demo", "", stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := `{"pid":42,"comm":"wget","containerid":"ready","ipversion":4,"saddr":"10.2.232.47","daddr":"10.2.232.1","dport":80,"latency_us":120}
+{"pid":43,"comm":"wget","containerid":"pending","ipversion":4,"saddr":"10.2.232.48","daddr":"10.2.232.1","dport":80,"latency_us":130}
+{"pid":44,"comm":"wget","containerid":"deleted","ipversion":4,"saddr":"10.2.232.49","daddr":"10.2.232.1","dport":80,"latency_us":140}
+`
+	output := runTransform(tcpconnlatHeader(pods), tcpconnlatTransform(containers, pods, nil), lines)
+
+	expected := `
+NODE PID    COMM             IP SADDR            DADDR            DPORT    LAT(us) PHASE     READY POD
+[ 0] 42     wget             4  10.2.232.47      10.2.232.1       80           120 Running   true  demo/ready/web
+[ 0] 43     wget             4  10.2.232.48      10.2.232.1       80           130 Pending   false demo/pending/web
+[ 0] 44     wget             4  10.2.232.49      10.2.232.1       80           140           -     demo/deleted/web
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
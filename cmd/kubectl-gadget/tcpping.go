@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpping"
+	"github.com/kinvolk/inspektor-gadget/pkg/histogram"
+)
+
+var (
+	tcppingHistogram   bool
+	tcppingTraceHeader string
+)
+
+var tcppingHeader = fmt.Sprintf("%-27s %-6s %-16s %-6s %-47s %10s %s",
+	"TIME", "PID", "COMM", "ROLE", "CONNECTION", "LAT(us)", "POD")
+
+// tcppingTraceIDHeader is the header with --trace-header, with the trace ids
+var tcppingTraceIDHeader = fmt.Sprintf("%-27s %-6s %-16s %-6s %-47s %10s %-32s %s",
+	"TIME", "PID", "COMM", "ROLE", "CONNECTION", "LAT(us)", "TRACEID", "POD")
+
+// tcppingHistograms aggregates the latencies of the clients and of the
+// servers separately: they don't measure the same thing
+type tcppingHistograms struct {
+	client histogram.Histogram
+	server histogram.Histogram
+}
+
+// tcppingTransform returns the transform function rendering the requests
+// printed by the tcpping gadget with their pod. With hists, the latencies are
+// aggregated instead and nothing is printed.
+func tcppingTransform(containers *containercache.Cache, hists *tcppingHistograms) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := tcpping.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if hists != nil {
+			switch event.Role {
+			case tcpping.RoleClient:
+				hists.client.Add(event.LatencyUs)
+			case tcpping.RoleServer:
+				hists.server.Add(event.LatencyUs)
+			}
+			return "", errSkipLine
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if event.TraceHeader != "" {
+			event.TraceID = tcpping.TraceID(tcppingTraceHeader, event.TraceHeader)
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := ""
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		if tcppingTraceHeader != "" {
+			traceID := event.TraceID
+			if traceID == "" {
+				traceID = "-"
+			}
+			return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-6s %-47s %10d %-32s %s",
+				event.Timestamp, event.Pid, event.Comm, event.Role, event.Connection(),
+				event.LatencyUs, traceID, pod), " "), nil
+		}
+		return strings.TrimRight(fmt.Sprintf("%-27s %-6d %-16s %-6s %-47s %10d %s",
+			event.Timestamp, event.Pid, event.Comm, event.Role, event.Connection(),
+			event.LatencyUs, pod), " "), nil
+	}
+}
+
+// printTcppingHistograms prints the histograms of the latencies of the
+// clients and of the servers, when there are some
+func printTcppingHistograms(w io.Writer, hists *tcppingHistograms) error {
+	for _, entry := range []struct {
+		role string
+		hist *histogram.Histogram
+	}{
+		{tcpping.RoleClient, &hists.client},
+		{tcpping.RoleServer, &hists.server},
+	} {
+		if entry.hist.Count() == 0 {
+			continue
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(struct {
+				Type    string             `json:"type"`
+				Role    string             `json:"role"`
+				Unit    string             `json:"unit"`
+				Buckets []histogram.Bucket `json:"buckets"`
+			}{"histogram", entry.role, "usecs", entry.hist.Buckets()})
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%s\n", buf); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\nrole = %s\n%s", entry.role, entry.hist.String("usecs")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTcppingTransform(t *testing.T) {
+	containers := testContainers("web-1", "frontend")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"curl","containerid":"abc","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":12800}
+{"timestamp":"2020-06-01T12:00:01.000456Z","pid":1210,"comm":"nginx","role":"server","ipversion":6,"saddr":"fd00::7","sport":443,"daddr":"fd00::5","dport":51622,"latency_us":950}
+{"timestamp":"2020-06-01T12:00:01.000789Z","pid":4242,"comm":"curl","containerid":"abc","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":9100}
+`
+	output := runTransform(tcppingHeader, tcppingTransform(containers, nil), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             ROLE   CONNECTION                                         LAT(us) POD
+[ 0] 2020-06-01T12:00:01.000123Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                      12800 demo/web-1/frontend
+[ 0] 2020-06-01T12:00:01.000456Z 1210   nginx            server [fd00::7]:443 -> [fd00::5]:51622                       950
+[ 0] 2020-06-01T12:00:01.000789Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                       9100 demo/web-1/frontend
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	// With --trace-header traceparent
+	tcppingTraceHeader = "traceparent"
+	defer func() { tcppingTraceHeader = "" }()
+	traced := `{"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"curl","containerid":"abc","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":12800,"traceheader":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+{"timestamp":"2020-06-01T12:00:01.000789Z","pid":4242,"comm":"curl","containerid":"abc","role":"client","ipversion":4,"saddr":"10.2.1.5","sport":43412,"daddr":"10.2.3.7","dport":8080,"latency_us":9100}
+`
+	output = runTransform(tcppingTraceIDHeader, tcppingTransform(containers, nil), traced)
+
+	expected = `
+NODE TIME                        PID    COMM             ROLE   CONNECTION                                         LAT(us) TRACEID                          POD
+[ 0] 2020-06-01T12:00:01.000123Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                      12800 4bf92f3577b34da6a3ce929d0e0e4736 demo/web-1/frontend
+[ 0] 2020-06-01T12:00:01.000789Z 4242   curl             client 10.2.1.5:43412 -> 10.2.3.7:8080                       9100 -                                demo/web-1/frontend
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	jsonOutput = true
+	output = runTransform(tcppingTraceIDHeader, tcppingTransform(containers, nil), strings.SplitAfter(traced, "\n")[0])
+	jsonOutput = false
+	if !strings.HasSuffix(output, `"latency_us":12800,"traceheader":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01","traceid":"4bf92f3577b34da6a3ce929d0e0e4736"}`+"\n") {
+		t.Fatalf("unexpected %v", output)
+	}
+
+	hists := &tcppingHistograms{}
+	output = runTransform("", tcppingTransform(containers, hists), lines)
+	if len(output) != 0 {
+		t.Fatalf("unexpected output %q with histograms", output)
+	}
+	if hists.client.Count() != 2 || hists.server.Count() != 1 {
+		t.Fatalf("%d client and %d server latencies in the histograms, expected 2 and 1",
+			hists.client.Count(), hists.server.Count())
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	var buf bytes.Buffer
+	if err := printTcppingHistograms(&buf, hists); err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"type":"histogram","role":"client","unit":"usecs","buckets":[` +
+		`{"min":0,"max":1,"count":0},{"min":2,"max":3,"count":0},{"min":4,"max":7,"count":0},{"min":8,"max":15,"count":0},` +
+		`{"min":16,"max":31,"count":0},{"min":32,"max":63,"count":0},{"min":64,"max":127,"count":0},{"min":128,"max":255,"count":0},` +
+		`{"min":256,"max":511,"count":0},{"min":512,"max":1023,"count":0},{"min":1024,"max":2047,"count":0},{"min":2048,"max":4095,"count":0},` +
+		`{"min":4096,"max":8191,"count":0},{"min":8192,"max":16383,"count":2}]}
+{"type":"histogram","role":"server","unit":"usecs","buckets":[` +
+		`{"min":0,"max":1,"count":0},{"min":2,"max":3,"count":0},{"min":4,"max":7,"count":0},{"min":8,"max":15,"count":0},` +
+		`{"min":16,"max":31,"count":0},{"min":32,"max":63,"count":0},{"min":64,"max":127,"count":0},{"min":128,"max":255,"count":0},` +
+		`{"min":256,"max":511,"count":0},{"min":512,"max":1023,"count":1}]}
+`
+	if buf.String() != expected {
+		t.Fatalf("%v != %v", buf.String(), expected)
+	}
+}
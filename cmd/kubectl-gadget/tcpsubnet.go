@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpsubnet"
+)
+
+var (
+	tcpsubnetSubnets  string
+	tcpsubnetInterval int
+)
+
+var tcpsubnetHeader = fmt.Sprintf("%-20s %-18s %12s %12s %s",
+	"TIME", "SUBNET", "SENT", "RECEIVED", "POD")
+
+// tcpsubnetTransform returns the transform function rendering the summaries
+// printed by the tcpsubnet gadget with the pod of the container. The
+// summaries of the incomplete last interval are dropped unless emitPartial is
+// set, and the summaries of the history of the node unless selected by
+// history.
+func tcpsubnetTransform(containers *containercache.Cache, emitPartial bool, history historySelector) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := tcpsubnet.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if event.Partial && !emitPartial {
+			return "", errSkipLine
+		}
+		m := lookupContainer(containers, event.ContainerID)
+		if m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if event.History && !history.match(m) {
+			return "", errSkipLine
+		}
+		if protobufWriter != nil {
+			return writeProtobuf(eventpb.FromTcpsubnet(event))
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		var pod string
+		switch {
+		case event.ContainerID == "":
+			pod = "(host)"
+		case event.Pod != "":
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		default:
+			// Container not found in the selected namespace
+			pod = "container " + shortContainerID(event.ContainerID)
+		}
+		if event.Partial {
+			pod += partialMarker
+		}
+		return fmt.Sprintf("%-20s %-18s %12d %12d %s",
+			event.Timestamp, event.Subnet, event.Sent, event.Received, pod), nil
+	}
+}
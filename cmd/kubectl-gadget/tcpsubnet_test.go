@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestTcpsubnetTransform(t *testing.T) {
+	containers := testContainers("web-1", "nginx")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01Z","subnet":"10.0.0.0/8","sent":1200,"received":64000}
+{"timestamp":"2020-06-01T12:00:01Z","containerid":"abc","subnet":"10.0.0.0/8","sent":5000,"received":300}
+{"timestamp":"2020-06-01T12:00:01Z","containerid":"abc","subnet":"0.0.0.0/0","sent":150,"received":2000000,"partial":true}
+{"timestamp":"2020-06-01T12:00:01Z","containerid":"0123456789abcdef","subnet":"0.0.0.0/0","sent":10,"received":20}
+`
+	output := runTransform(tcpsubnetHeader, tcpsubnetTransform(containers, true, historySelector{}), lines)
+
+	expected := `
+NODE TIME                 SUBNET                     SENT     RECEIVED POD
+[ 0] 2020-06-01T12:00:01Z 10.0.0.0/8                 1200        64000 (host)
+[ 0] 2020-06-01T12:00:01Z 10.0.0.0/8                 5000          300 demo/web-1/nginx
+[ 0] 2020-06-01T12:00:01Z 0.0.0.0/0                   150      2000000 demo/web-1/nginx (partial)
+[ 0] 2020-06-01T12:00:01Z 0.0.0.0/0                    10           20 container 0123456789ab
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package main
+
+import (
+	"errors"
+)
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func enableCbreak(fd uintptr) (func(), error) {
+	return nil, errors.New("not supported")
+}
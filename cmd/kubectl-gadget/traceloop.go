@@ -1,18 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"sort"
 	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/docker/go-units"
 	"github.com/syndtr/gocapability/capability"
@@ -20,10 +23,12 @@ import (
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"sigs.k8s.io/yaml"
 
-	"github.com/kinvolk/inspektor-gadget/pkg/k8sutil"
+	traceloopgadget "github.com/kinvolk/inspektor-gadget/pkg/gadgets/traceloop"
 	"github.com/kinvolk/traceloop/pkg/tracemeta"
 )
 
@@ -40,8 +45,8 @@ var traceloopListCmd = &cobra.Command{
 }
 
 var traceloopShowCmd = &cobra.Command{
-	Use:   "show",
-	Short: "show one trace",
+	Use:   "show TRACEID...",
+	Short: "show one or more traces",
 	Run:   runTraceloopShow,
 }
 
@@ -58,24 +63,65 @@ var traceloopPodCmd = &cobra.Command{
 }
 
 var traceloopCloseCmd = &cobra.Command{
-	Use:   "close",
-	Short: "close one trace",
+	Use:   "close [TRACEID]",
+	Short: "close one trace, or the traces of the pods selected with --all, and free their buffers",
 	Run:   runTraceloopClose,
 }
 
+var traceloopDiffCmd = &cobra.Command{
+	Use:   "diff CAPTURE_A CAPTURE_B",
+	Short: "compare two traces saved with show or pod",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("requires 2 arguments: the files of the two traces")
+		}
+		return nil
+	},
+	// The traces are read from local files: no need for a kubeconfig
+	PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+	Run:               runTraceloopDiff,
+}
+
 var (
-	optionListFull          bool
-	optionListAllNamespaces bool
-	optionListNoHeaders     bool
-	optionListNamespace     string
+	optionListFull      bool
+	optionListNoHeaders bool
+	optionListSeparator string
+	optionListNamespace string
+	optionListSelector  string
+	optionListOutput    string
+
+	optionLimitBytes int
+	optionShowOutput string
+	optionShowRaw    bool
+
+	optionShowDuration bool
+	optionShowFollow   bool
+
+	optionSyscalls        []string
+	optionExcludeSyscalls []string
+
+	optionMaxArgDisplay int
+
+	optionTrigger string
+	optionBefore  int
+	optionAfter   int
+
+	optionCloseAll       bool
+	optionCloseNamespace string
+	optionCloseSelector  string
 )
 
+// traceloopCloseTimeout is how long "traceloop close" waits for the traces
+// closed to be removed from the traces published by the gadget pods
+const traceloopCloseTimeout = 10 * time.Second
+
 func init() {
 	rootCmd.AddCommand(traceloopCmd)
 	traceloopCmd.AddCommand(traceloopListCmd)
 	traceloopCmd.AddCommand(traceloopShowCmd)
 	traceloopCmd.AddCommand(traceloopPodCmd)
 	traceloopCmd.AddCommand(traceloopCloseCmd)
+	traceloopCmd.AddCommand(traceloopDiffCmd)
 
 	traceloopListCmd.PersistentFlags().BoolVarP(
 		&optionListFull,
@@ -84,7 +130,7 @@ func init() {
 		"show all fields without truncating")
 
 	traceloopListCmd.PersistentFlags().BoolVarP(
-		&optionListAllNamespaces,
+		&allNamespacesFlag,
 		"all-namespaces", "A",
 		false,
 		"if present, list the traces across all namespaces.")
@@ -93,13 +139,490 @@ func init() {
 		&optionListNoHeaders,
 		"no-headers", "",
 		false,
-		"don't print headers.")
+		"don't print headers, and separate the fields with a tab instead of aligning them.")
+
+	traceloopListCmd.PersistentFlags().StringVarP(
+		&optionListSeparator,
+		"separator", "",
+		"",
+		"separate the fields with this string instead of aligning them.")
+
+	traceloopListCmd.PersistentFlags().VarP(
+		namespaceListValue{&optionListNamespace},
+		"namespace", "n",
+		"only show traces in the specified namespaces, a comma-separated list or repeated, instead of the one of the current context.")
+
+	traceloopListCmd.PersistentFlags().StringVarP(
+		&optionListSelector,
+		"selector", "l",
+		"",
+		"only show traces of the pods matching this label selector (e.g. key1=value1,key2=value2), as labeled now.")
 
 	traceloopListCmd.PersistentFlags().StringVarP(
-		&optionListNamespace,
+		&optionListOutput,
+		"output", "o",
+		"columns",
+		"output format: columns, wide to add the node and the age of the traces, or json or yaml with all the fields of the traces.")
+
+	traceloopCloseCmd.PersistentFlags().BoolVarP(
+		&optionCloseAll,
+		"all", "",
+		false,
+		"close all the traces of the namespace, or of the pods selected with -l, instead of one trace.")
+
+	traceloopCloseCmd.PersistentFlags().VarP(
+		namespaceListValue{&optionCloseNamespace},
 		"namespace", "n",
+		"with --all, close the traces in the specified namespaces, a comma-separated list or repeated, instead of the one of the current context.")
+
+	traceloopCloseCmd.PersistentFlags().BoolVarP(
+		&allNamespacesFlag,
+		"all-namespaces", "A",
+		false,
+		"with --all, close the traces across all namespaces.")
+
+	traceloopCloseCmd.PersistentFlags().StringVarP(
+		&optionCloseSelector,
+		"selector", "l",
 		"",
-		"only show traces in the specified namespace.")
+		"with --all, only close the traces of the pods matching this label selector.")
+
+	traceloopShowCmd.PersistentFlags().BoolVarP(
+		&optionShowFollow,
+		"follow", "f",
+		false,
+		"keep printing the new events of the trace until interrupted.")
+
+	for _, command := range []*cobra.Command{traceloopShowCmd, traceloopPodCmd} {
+		command.PersistentFlags().IntVarP(
+			&optionLimitBytes,
+			"limit-bytes", "",
+			0,
+			"maximum number of bytes of the trace to show, 0 for no limit.")
+		command.PersistentFlags().StringVarP(
+			&optionTrigger,
+			"trigger", "",
+			"",
+			"only show the events around the syscalls matching this expression, like syscall=open,errno=ENOENT.")
+		command.PersistentFlags().IntVarP(
+			&optionBefore,
+			"before", "",
+			10,
+			"number of events to show before each syscall matching --trigger.")
+		command.PersistentFlags().IntVarP(
+			&optionAfter,
+			"after", "",
+			10,
+			"number of events to show after each syscall matching --trigger.")
+		command.PersistentFlags().StringVarP(
+			&optionShowOutput,
+			"output", "o",
+			"text",
+			"output format: text, or json with one object per syscall.")
+		command.PersistentFlags().BoolVarP(
+			&optionShowRaw,
+			"raw", "",
+			false,
+			"print the arguments of the syscalls as given by traceloop, without decoding them. Implies -o json.")
+		command.PersistentFlags().BoolVarP(
+			&optionShowDuration,
+			"show-duration", "",
+			false,
+			"add the duration of the syscalls whose entry and exit are on two lines, like blocking reads, at the end of their exit line. Always in duration_ns with -o json.")
+		command.PersistentFlags().StringSliceVarP(
+			&optionSyscalls,
+			"syscall", "",
+			nil,
+			"only show the syscalls with these names, like write,openat, a comma-separated list or repeated.")
+		command.PersistentFlags().StringSliceVarP(
+			&optionExcludeSyscalls,
+			"exclude-syscall", "",
+			nil,
+			"do not show the syscalls with these names, a comma-separated list or repeated.")
+		command.PersistentFlags().IntVarP(
+			&optionMaxArgDisplay,
+			"max-arg-display", "",
+			traceloopgadget.DefaultMaxArgDisplay,
+			"maximum number of bytes of each string argument to print, followed by an ellipsis and its length when longer, 0 for no limit. Not applied to -o json.")
+	}
+}
+
+// lineLimitWriter writes complete lines to w until limit bytes have been
+// written. The line that would exceed the limit and all following lines are
+// dropped, so that the output is never cut in the middle of an event.
+type lineLimitWriter struct {
+	w         io.Writer
+	marker    io.Writer // where the truncation marker is written, w if nil
+	limit     int
+	written   int
+	buffer    []byte
+	truncated bool
+}
+
+func (l *lineLimitWriter) Write(p []byte) (int, error) {
+	if l.truncated {
+		return len(p), nil
+	}
+	l.buffer = append(l.buffer, p...)
+	for {
+		i := bytes.IndexByte(l.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		if err := l.writeLine(l.buffer[:i+1]); err != nil || l.truncated {
+			l.buffer = nil
+			return len(p), err
+		}
+		l.buffer = l.buffer[i+1:]
+	}
+	return len(p), nil
+}
+
+func (l *lineLimitWriter) writeLine(line []byte) error {
+	if l.written+len(line) > l.limit {
+		l.truncated = true
+		return nil
+	}
+	n, err := l.w.Write(line)
+	l.written += n
+	return err
+}
+
+// Close writes the last line if it was not terminated by a newline, and
+// the truncation marker if some lines were dropped.
+func (l *lineLimitWriter) Close() error {
+	if len(l.buffer) != 0 && !l.truncated {
+		if err := l.writeLine(l.buffer); err != nil {
+			return err
+		}
+		l.buffer = nil
+	}
+	if l.truncated {
+		marker := l.marker
+		if marker == nil {
+			marker = l.w
+		}
+		_, err := fmt.Fprintf(marker, "[trace truncated after %d bytes]\n", l.written)
+		return err
+	}
+	return nil
+}
+
+// linePrefixWriter writes the lines written to it to w, each preceded by
+// prefix
+type linePrefixWriter struct {
+	w      io.Writer
+	prefix string
+	buffer []byte
+}
+
+func (l *linePrefixWriter) Write(p []byte) (int, error) {
+	l.buffer = append(l.buffer, p...)
+	for {
+		i := bytes.IndexByte(l.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		line := l.buffer[:i+1]
+		l.buffer = l.buffer[i+1:]
+		if _, err := io.WriteString(l.w, l.prefix+string(line)); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Close writes the last line if it was not terminated by a newline
+func (l *linePrefixWriter) Close() error {
+	if len(l.buffer) == 0 {
+		return nil
+	}
+	line := string(l.buffer)
+	l.buffer = nil
+	_, err := io.WriteString(l.w, l.prefix+line+"\n")
+	return err
+}
+
+// snapshotTrigger is the trigger given with --trigger, nil to show the
+// whole trace
+var snapshotTrigger *traceloopgadget.Trigger
+
+// parseSnapshotOptions checks --trigger, --before and --after
+func parseSnapshotOptions() error {
+	if optionTrigger == "" {
+		return nil
+	}
+	if optionBefore < 0 || optionAfter < 0 {
+		return errors.New("--before and --after cannot be negative")
+	}
+	trigger, err := traceloopgadget.ParseTrigger(optionTrigger)
+	if err != nil {
+		return fmt.Errorf("Invalid --trigger: %s", err)
+	}
+	snapshotTrigger = &trigger
+	return nil
+}
+
+// syscallFilter selects the syscalls given with --syscall and
+// --exclude-syscall, nil to show them all
+var syscallFilter *traceloopgadget.SyscallFilter
+
+// parseShowOptions checks -o, --raw, --syscall, --exclude-syscall and
+// --max-arg-display
+func parseShowOptions() error {
+	switch optionShowOutput {
+	case "text", "json":
+	default:
+		return fmt.Errorf("Invalid argument %q for -o/--output=[text,json]", optionShowOutput)
+	}
+	if optionShowRaw {
+		optionShowOutput = "json"
+	}
+	if optionShowFollow && (optionTrigger != "" || optionLimitBytes > 0) {
+		return errors.New("--follow cannot be used with --trigger or --limit-bytes")
+	}
+	if optionMaxArgDisplay < 0 {
+		return errors.New("--max-arg-display cannot be negative")
+	}
+	if len(optionSyscalls) != 0 || len(optionExcludeSyscalls) != 0 {
+		filter, err := traceloopgadget.NewSyscallFilter(optionSyscalls, optionExcludeSyscalls)
+		if err != nil {
+			return fmt.Errorf("Invalid --syscall or --exclude-syscall: %s", err)
+		}
+		syscallFilter = filter
+	}
+	return nil
+}
+
+// traceSource identifies a trace among several shown at once
+type traceSource struct {
+	traceID string
+	// label precedes the lines of the trace in the text output, as
+	// "[label] "
+	label string
+}
+
+// showTrace prints the trace dumped by podCmd on node. The text output is
+// printed as received from traceloop. The JSON output is converted while the
+// trace is streamed, so that a large trace is never in memory. The lines and
+// the events are marked with source, unless it is nil.
+func showTrace(client *kubernetes.Clientset, node, podCmd string, source *traceSource) error {
+	if optionShowOutput == "text" {
+		if source == nil {
+			printTrace(execPodSimple(client, node, podCmd), os.Stdout)
+			return nil
+		}
+		out := &linePrefixWriter{w: os.Stdout, prefix: fmt.Sprintf("[%s] ", source.label)}
+		printTrace(execPodSimple(client, node, podCmd), out)
+		return out.Close()
+	}
+
+	// The limit applies to the JSON events written, one per line. The
+	// marker is not an event and goes to stderr like the other lines.
+	var out io.Writer = os.Stdout
+	var limit *lineLimitWriter
+	if optionLimitBytes > 0 {
+		limit = &lineLimitWriter{w: os.Stdout, marker: os.Stderr, limit: optionLimitBytes}
+		out = limit
+	}
+	events := traceloopgadget.NewEventWriter(out, os.Stderr, optionShowRaw)
+	if source != nil {
+		events.TraceID = source.traceID
+	}
+	var w io.Writer = events
+
+	// The syscalls are filtered first: --trigger and --limit-bytes apply
+	// to the syscalls shown
+	var err error
+	if snapshotTrigger == nil {
+		err = execPodFiltered(client, node, podCmd, w)
+	} else {
+		pr, pw := io.Pipe()
+		done := make(chan error)
+		go func() {
+			n, err := traceloopgadget.Snapshot(pr, w, *snapshotTrigger, optionBefore, optionAfter)
+			if err == nil && n == 0 {
+				stderrLog.Warnf("No event of the trace matches the trigger %q", optionTrigger)
+			}
+			// Unblock execPod if the snapshot stopped early
+			pr.CloseWithError(err)
+			done <- err
+		}()
+		err = execPodFiltered(client, node, podCmd, pw)
+		pw.CloseWithError(err)
+		if snapshotErr := <-done; snapshotErr != nil {
+			err = snapshotErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := events.Close(); err != nil {
+		return err
+	}
+	if limit != nil {
+		return limit.Close()
+	}
+	return nil
+}
+
+// execPodFiltered runs podCmd on node like execPod, writing to w the
+// syscalls of the trace printed selected by syscallFilter
+func execPodFiltered(client *kubernetes.Clientset, node, podCmd string, w io.Writer) error {
+	if syscallFilter == nil {
+		return execPod(client, node, podCmd, w, os.Stderr)
+	}
+	filter := traceloopgadget.NewFilterWriter(w, syscallFilter)
+	if err := execPod(client, node, podCmd, filter, os.Stderr); err != nil {
+		return err
+	}
+	return filter.Close()
+}
+
+// traceloopFollowInterval is the time between two dumps of a trace with
+// --follow
+const traceloopFollowInterval = time.Second
+
+// followTrace prints the trace traceID of node, and then its new events
+// every traceloopFollowInterval, until interrupted. Traceloop has no way to
+// stream the events of a trace: it is dumped again and again, and the
+// events already printed are skipped. It returns an error if the trace is
+// closed on the node.
+func followTrace(client *kubernetes.Clientset, node, traceID string) error {
+	podCmd := fmt.Sprintf(`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/dump-by-traceid?traceid=%s'`, traceID)
+	// Answer of traceloop once the trace is closed
+	closedAnswer := fmt.Sprintf("prog with traceid %q not found", traceID)
+
+	out := io.Writer(os.Stdout)
+	var events *traceloopgadget.EventWriter
+	var durations *traceloopgadget.DurationWriter
+	if optionShowOutput == "json" {
+		events = traceloopgadget.NewEventWriter(os.Stdout, os.Stderr, optionShowRaw)
+		out = events
+	} else if optionShowDuration {
+		durations = traceloopgadget.NewDurationWriter(os.Stdout)
+		out = durations
+	}
+	if syscallFilter != nil {
+		// The lines are written complete: nothing is left to flush
+		out = traceloopgadget.NewFilterWriter(out, syscallFilter)
+	}
+	write := func(lines []string) error {
+		for _, line := range lines {
+			if optionShowOutput == "text" {
+				line = traceloopgadget.TruncateArgs(line, optionMaxArgDisplay)
+			}
+			if _, err := io.WriteString(out, line+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	follower := &traceloopgadget.Follower{}
+	// finish prints the last event held back by the follower
+	finish := func() error {
+		if err := write(follower.Flush()); err != nil {
+			return err
+		}
+		if durations != nil {
+			if err := durations.Close(); err != nil {
+				return err
+			}
+		}
+		if events != nil {
+			return events.Close()
+		}
+		return nil
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	// poll prints the new events until interrupted
+	poll := func() error {
+		for {
+			stdout, stderr, err := execPodCapture(client, node, podCmd)
+			if err != nil {
+				return fmt.Errorf("%s%s", err, stderr)
+			}
+			if strings.TrimSpace(stdout) == closedAnswer {
+				return fmt.Errorf("trace %s was closed on node %s", traceID, node)
+			}
+			lines, lost := follower.Next(stdout)
+			if lost {
+				stderrLog.Warn("[events dropped by traceloop before they could be printed]")
+			}
+			if err := write(lines); err != nil {
+				return err
+			}
+
+			select {
+			case <-sigs:
+				return nil
+			case <-time.After(traceloopFollowInterval):
+			}
+		}
+	}
+
+	err := poll()
+	if finishErr := finish(); finishErr != nil {
+		if err == nil {
+			return finishErr
+		}
+		return fmt.Errorf("%s, and in printing the last event: %s", err, finishErr)
+	}
+	return err
+}
+
+// printTrace prints a trace, only with the syscalls selected by --syscall
+// and --exclude-syscall, with the durations of the syscalls with
+// --show-duration, only the events around --trigger if given, with the long
+// strings cut according to --max-arg-display, and truncated according to
+// --limit-bytes, to out
+func printTrace(trace string, out io.Writer) {
+	if syscallFilter != nil {
+		var b strings.Builder
+		w := traceloopgadget.NewFilterWriter(&b, syscallFilter)
+		io.WriteString(w, trace)
+		w.Close()
+		trace = b.String()
+	}
+	if optionShowDuration {
+		var b strings.Builder
+		w := traceloopgadget.NewDurationWriter(&b)
+		io.WriteString(w, trace)
+		w.Close()
+		trace = b.String()
+	}
+	if snapshotTrigger != nil {
+		var b strings.Builder
+		n, err := traceloopgadget.Snapshot(strings.NewReader(trace), &b, *snapshotTrigger, optionBefore, optionAfter)
+		if err != nil {
+			stderrLog.Errorf("Error reading the trace: %s", err)
+			return
+		}
+		if n == 0 {
+			stderrLog.Warnf("No event of the trace matches the trigger %q", optionTrigger)
+			return
+		}
+		trace = b.String()
+	}
+	if optionMaxArgDisplay > 0 {
+		lines := strings.Split(trace, "\n")
+		for i, line := range lines {
+			lines[i] = traceloopgadget.TruncateArgs(line, optionMaxArgDisplay)
+		}
+		trace = strings.Join(lines, "\n")
+	}
+	if optionLimitBytes <= 0 {
+		io.WriteString(out, trace)
+		return
+	}
+	w := &lineLimitWriter{w: out, limit: optionLimitBytes}
+	io.WriteString(w, trace)
+	w.Close()
 }
 
 const (
@@ -112,7 +635,7 @@ func getTracesListPerNode(client *kubernetes.Clientset) (out map[string][]tracem
 		LabelSelector: "k8s-app=gadget",
 		FieldSelector: fields.Everything().String(),
 	}
-	pods, err := client.CoreV1().Pods("kube-system").List(listOptions)
+	pods, err := client.CoreV1().Pods(gadgetNamespace()).List(listOptions)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot find gadget pods: %q", err)
 	}
@@ -142,9 +665,10 @@ func getTracesListPerNode(client *kubernetes.Clientset) (out map[string][]tracem
 
 		err := json.Unmarshal([]byte(state), &tm)
 		if err != nil {
-			fmt.Printf("%v:\n%s\n", err, state)
+			stderrLog.Errorf("%v:\n%s", err, state)
 			continue
 		}
+		log.Debugf("Gadget pod %s of node %s: %d traces", pod.Name, pod.Spec.NodeName, len(tm))
 		out[pod.Spec.NodeName] = tm
 	}
 
@@ -171,19 +695,52 @@ func runTraceloopList(cmd *cobra.Command, args []string) {
 		"args":    args,
 	})
 
-	client, err := k8sutil.NewClientset(viper.GetString("kubeconfig"))
+	switch optionListOutput {
+	case "columns", "wide":
+	case "json", "yaml":
+		if optionListNoHeaders || optionListSeparator != "" {
+			contextLogger.Fatalf("--no-headers and --separator only work with -o columns and -o wide")
+		}
+	default:
+		contextLogger.Fatalf("Invalid argument %q for -o/--output=[columns,wide,json,yaml]", optionListOutput)
+	}
+
+	namespace, err := resolveNamespace(optionListNamespace, allNamespacesFlag, getDefaultNamespace)
+	if err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
+
+	if _, err := labels.Parse(optionListSelector); err != nil {
+		contextLogger.Fatalf("Invalid selector %q: %s", optionListSelector, err)
+	}
+
+	client, err := newClientset()
 	if err != nil {
 		contextLogger.Fatalf("Error in creating setting up Kubernetes client: %q", err)
 	}
 
+	var podUIDs map[string]bool
+	if optionListSelector != "" {
+		podUIDs, err = traceloopSelectedPods(client, namespace, optionListSelector)
+		if err != nil {
+			contextLogger.Fatalf("Error in listing the pods matching %q: %s", optionListSelector, err)
+		}
+	}
+
 	tracesPerNode, err := getTracesListPerNode(client)
 	if err != nil {
 		contextLogger.Fatalf("Error in getting traces: %q", err)
 	}
 
 	var traces []tracemeta.TraceMeta
-	for _, tm := range tracesPerNode {
-		traces = append(traces, tm...)
+	for node, tm := range tracesPerNode {
+		// The node of a trace is the one of the gadget pod that owns it
+		for _, trace := range tm {
+			if trace.Node == "" {
+				trace.Node = node
+			}
+			traces = append(traces, trace)
+		}
 	}
 	sort.SliceStable(traces, func(i, j int) bool {
 		if traces[i].Namespace != traces[j].Namespace {
@@ -203,36 +760,42 @@ func runTraceloopList(cmd *cobra.Command, args []string) {
 		}
 		return false
 	})
+	traces = selectTraces(traces, namespace, podUIDs)
 
-	if optionListNamespace == "" {
-		optionListNamespace = getDefaultNamespace()
+	if optionListOutput == "json" || optionListOutput == "yaml" {
+		containers := traceloopContainerNames(client, namespace)
+		entries := []traceloopListEntry{}
+		for _, trace := range traces {
+			entries = append(entries, newTraceloopListEntry(trace, containers[trace.PodUID]))
+		}
+		if err := writeTraceloopList(os.Stdout, entries, optionListOutput); err != nil {
+			contextLogger.Fatalf("%s", err)
+		}
+		return
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	if !optionListNoHeaders {
-		if optionListFull {
-			fmt.Fprintln(w, "NODE\tNAMESPACE\tPODNAME\tPODUID\tINDEX\tTRACEID\tCONTAINERID\tSTATUS\tCAPABILITIES\t")
-		} else {
-			if !optionListAllNamespaces {
-				fmt.Fprintln(w, "PODNAME\tPODUID\tINDEX\tTRACEID\tCONTAINERID\tSTATUS\t")
-			} else {
-				fmt.Fprintln(w, "NAMESPACE\tPODNAME\tPODUID\tINDEX\tTRACEID\tCONTAINERID\tSTATUS\t")
-			}
+	var header []string
+	if optionListFull {
+		header = []string{"NODE", "NAMESPACE", "PODNAME", "PODUID", "INDEX", "TRACEID", "CONTAINERID", "STATUS", "CAPABILITIES"}
+	} else if namespace != "" {
+		header = []string{"PODNAME", "PODUID", "INDEX", "TRACEID", "CONTAINERID", "STATUS"}
+	} else {
+		header = []string{"NAMESPACE", "PODNAME", "PODUID", "INDEX", "TRACEID", "CONTAINERID", "STATUS"}
+	}
+	wide := optionListOutput == "wide"
+	if wide {
+		if !optionListFull {
+			header = append(header, "NODE")
 		}
+		header = append(header, "AGE")
+	}
+	if optionListNoHeaders {
+		header = nil
 	}
 
+	now := time.Now()
+	var rows [][]string
 	for _, trace := range traces {
-		if trace.Containeridx == -1 {
-			// The pause container
-			continue
-		}
-
-		if optionListNamespace != "" &&
-			trace.Namespace != optionListNamespace &&
-			!optionListAllNamespaces {
-			continue
-		}
-
 		status := ""
 		switch trace.Status {
 		case "created":
@@ -252,8 +815,10 @@ func runTraceloopList(cmd *cobra.Command, args []string) {
 		default:
 			status = fmt.Sprintf("unknown (%v)", trace.Status)
 		}
+		idx := fmt.Sprint(trace.Containeridx)
+		var row []string
 		if optionListFull {
-			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", trace.Node, trace.Namespace, trace.Podname, trace.PodUID, trace.Containeridx, trace.TraceID, trace.ContainerID, status, capDecode(trace.Capabilities))
+			row = []string{trace.Node, trace.Namespace, trace.Podname, trace.PodUID, idx, trace.TraceID, trace.ContainerID, status, capDecode(trace.Capabilities)}
 		} else {
 			uid := trace.PodUID
 			if len(uid) > 8 {
@@ -265,15 +830,203 @@ func runTraceloopList(cmd *cobra.Command, args []string) {
 			if len(containerID) > 8 {
 				containerID = containerID[:8]
 			}
-			if !optionListAllNamespaces {
-				fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", trace.Podname, uid, trace.Containeridx, trace.TraceID, containerID, status)
+			if namespace != "" {
+				row = []string{trace.Podname, uid, idx, trace.TraceID, containerID, status}
 			} else {
-				fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", trace.Namespace, trace.Podname, uid, trace.Containeridx, trace.TraceID, containerID, status)
+				row = []string{trace.Namespace, trace.Podname, uid, idx, trace.TraceID, containerID, status}
 			}
 		}
+		if wide {
+			if !optionListFull {
+				row = append(row, trace.Node)
+			}
+			row = append(row, traceAge(trace, now))
+		}
+		rows = append(rows, row)
 	}
-	w.Flush()
 
+	separator := optionListSeparator
+	if separator == "" && optionListNoHeaders {
+		separator = "\t"
+	}
+	writeTable(os.Stdout, header, rows, separator)
+}
+
+// traceAge returns the time elapsed between the start of trace and now,
+// like the AGE column of kubectl, or an empty field if the trace doesn't
+// have a valid creation time
+func traceAge(trace tracemeta.TraceMeta, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, trace.TimeCreation)
+	if err != nil {
+		return ""
+	}
+	return duration.HumanDuration(now.Sub(t))
+}
+
+// selectTraces returns the traces to list: the ones of namespace, all
+// namespaces if empty, and of the pods whose UID is in podUIDs, if not nil.
+// The traces of the pause containers are never listed.
+func selectTraces(traces []tracemeta.TraceMeta, namespace string, podUIDs map[string]bool) []tracemeta.TraceMeta {
+	selected := []tracemeta.TraceMeta{}
+	for _, trace := range traces {
+		if trace.Containeridx == -1 {
+			continue
+		}
+		if !namespaceSelected(namespace, trace.Namespace) {
+			continue
+		}
+		if podUIDs != nil && !podUIDs[trace.PodUID] {
+			continue
+		}
+		selected = append(selected, trace)
+	}
+	return selected
+}
+
+// traceloopSelectedPods returns the UIDs of the pods of namespace, all
+// namespaces if empty, matching selector. The pods are matched on their
+// labels in the API server, not on the ones they had when their traces
+// started: the pods deleted since don't match.
+func traceloopSelectedPods(client *kubernetes.Clientset, namespace, selector string) (map[string]bool, error) {
+	pods, err := client.CoreV1().Pods(listNamespace(namespace)).List(metaV1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	uids := map[string]bool{}
+	for _, pod := range pods.Items {
+		uids[string(pod.UID)] = true
+	}
+	return uids, nil
+}
+
+// traceloopListEntry is a trace printed by "traceloop list" with -o json or
+// -o yaml. Unlike the columns, the fields don't depend on the other flags.
+type traceloopListEntry struct {
+	Node             string   `json:"node"`
+	Namespace        string   `json:"namespace"`
+	Pod              string   `json:"pod"`
+	PodUID           string   `json:"podUID"`
+	Container        string   `json:"container,omitempty"`
+	ContainerIndex   int      `json:"containerIndex"`
+	ContainerID      string   `json:"containerID,omitempty"`
+	ContainerRuntime string   `json:"containerRuntime,omitempty"`
+	TraceID          string   `json:"traceID"`
+	State            string   `json:"state"`
+	TimeCreation     string   `json:"timeCreation,omitempty"`
+	TimeDeletion     string   `json:"timeDeletion,omitempty"`
+	Capabilities     []string `json:"capabilities,omitempty"`
+}
+
+// newTraceloopListEntry returns the entry of trace. containers are the
+// names of the containers of its pod, when the pod still exists.
+func newTraceloopListEntry(trace tracemeta.TraceMeta, containers []string) traceloopListEntry {
+	e := traceloopListEntry{
+		Node:           trace.Node,
+		Namespace:      trace.Namespace,
+		Pod:            trace.Podname,
+		PodUID:         trace.PodUID,
+		ContainerIndex: trace.Containeridx,
+		TraceID:        trace.TraceID,
+		TimeCreation:   trace.TimeCreation,
+		TimeDeletion:   trace.TimeDeletion,
+	}
+	if trace.Containeridx >= 0 && trace.Containeridx < len(containers) {
+		e.Container = containers[trace.Containeridx]
+	}
+	// ContainerID is like docker://<id> or cri-o://<id>
+	if parts := strings.SplitN(trace.ContainerID, "://", 2); len(parts) == 2 {
+		e.ContainerRuntime = parts[0]
+		e.ContainerID = parts[1]
+	} else {
+		e.ContainerID = trace.ContainerID
+	}
+	switch trace.Status {
+	case "created", "ready":
+		e.State = "started"
+	case "deleted":
+		e.State = "terminated"
+	default:
+		e.State = trace.Status
+	}
+	if caps := capDecode(trace.Capabilities); caps != "" {
+		e.Capabilities = strings.Split(caps, ",")
+	}
+	return e
+}
+
+// traceloopContainerNames returns the names of the containers of the pods
+// of namespace, all namespaces if empty, by pod UID. The traces only know
+// the index of their container: its name is omitted when the pods cannot be
+// listed.
+func traceloopContainerNames(client *kubernetes.Clientset, namespace string) map[string][]string {
+	names := map[string][]string{}
+	pods, err := client.CoreV1().Pods(listNamespace(namespace)).List(metaV1.ListOptions{})
+	if err != nil {
+		log.Warnf("Cannot list the pods, the names of the containers are omitted: %s", err)
+		return names
+	}
+	for _, pod := range pods.Items {
+		for _, c := range pod.Spec.Containers {
+			names[string(pod.UID)] = append(names[string(pod.UID)], c.Name)
+		}
+	}
+	return names
+}
+
+// writeTraceloopList writes the entries as a JSON or YAML list
+func writeTraceloopList(out io.Writer, entries []traceloopListEntry, format string) error {
+	var buf []byte
+	var err error
+	if format == "yaml" {
+		buf, err = yaml.Marshal(entries)
+	} else {
+		buf, err = json.MarshalIndent(entries, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		buf = append(buf, '\n')
+	}
+	_, err = out.Write(buf)
+	return err
+}
+
+// emptyField replaces the empty fields in the output with a separator
+const emptyField = "-"
+
+// writeTable writes the rows, under header if not nil. Without separator,
+// the columns are aligned with spaces, so the position of a field depends on
+// the width of the other fields of its column. With a separator, the fields
+// of each row are joined with it, for scripts: the empty fields are written
+// as emptyField so that splitting a row on blanks, as awk does by default,
+// doesn't shift the following fields.
+func writeTable(out io.Writer, header []string, rows [][]string, separator string) {
+	if separator != "" {
+		if header != nil {
+			fmt.Fprintln(out, strings.Join(header, separator))
+		}
+		for _, row := range rows {
+			fields := make([]string, len(row))
+			for i, field := range row {
+				if field == "" {
+					field = emptyField
+				}
+				fields[i] = field
+			}
+			fmt.Fprintln(out, strings.Join(fields, separator))
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 4, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
 }
 
 func runTraceloopShow(cmd *cobra.Command, args []string) {
@@ -282,11 +1035,20 @@ func runTraceloopShow(cmd *cobra.Command, args []string) {
 		"args":    args,
 	})
 
-	if len(args) != 1 {
+	if len(args) == 0 {
 		contextLogger.Fatalf("Missing parameter: trace name")
 	}
+	if optionShowFollow && len(args) > 1 {
+		contextLogger.Fatalf("--follow only works with a single trace")
+	}
+	if err := parseSnapshotOptions(); err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
+	if err := parseShowOptions(); err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
 
-	client, err := k8sutil.NewClientset(viper.GetString("kubeconfig"))
+	client, err := newClientset()
 	if err != nil {
 		contextLogger.Fatalf("Error in creating setting up Kubernetes client: %q", err)
 	}
@@ -296,15 +1058,76 @@ func runTraceloopShow(cmd *cobra.Command, args []string) {
 		contextLogger.Fatalf("Error in getting traces: %q", err)
 	}
 
+	traces, missing := findTraces(tracesPerNode, args)
+	sources := traceSources(traces)
+	for i, trace := range traces {
+		log.Debugf("Trace %s is recorded on node %s", trace.TraceID, trace.Node)
+		if optionShowFollow {
+			if err := followTrace(client, trace.Node, trace.TraceID); err != nil {
+				contextLogger.Fatalf("%s", err)
+			}
+			return
+		}
+		err := showTrace(client, trace.Node,
+			fmt.Sprintf(`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/dump-by-traceid?traceid=%s' ; echo`, trace.TraceID),
+			sources[i])
+		if err != nil {
+			contextLogger.Fatalf("Error in showing trace %s: %q", trace.TraceID, err)
+		}
+	}
+	if len(missing) == 1 {
+		contextLogger.Fatalf("Trace %s not found", missing[0])
+	} else if len(missing) > 1 {
+		contextLogger.Fatalf("Traces %s not found", strings.Join(missing, ", "))
+	}
+}
+
+// findTraces returns the traces with the given ids, in their order and
+// without duplicates, with their node, and the ids not found
+func findTraces(tracesPerNode map[string][]tracemeta.TraceMeta, ids []string) (traces []tracemeta.TraceMeta, missing []string) {
+	byID := map[string]tracemeta.TraceMeta{}
 	for node, tm := range tracesPerNode {
 		for _, trace := range tm {
-			if trace.TraceID == args[0] {
-				fmt.Printf("%s", execPodSimple(client, node,
-					fmt.Sprintf(`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/dump-by-traceid?traceid=%s' ; echo`, args[0])))
-			}
+			trace.Node = node
+			byID[trace.TraceID] = trace
+		}
+	}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
 		}
+		seen[id] = true
+		if trace, ok := byID[id]; ok {
+			traces = append(traces, trace)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return traces, missing
+}
 
+// traceSources returns the sources marking the lines of the given traces
+// when several are shown: each trace is labeled with its pod and the index
+// of its container, like "mypod#1", or with its id when two traces would
+// have the same label
+func traceSources(traces []tracemeta.TraceMeta) []*traceSource {
+	sources := make([]*traceSource, len(traces))
+	if len(traces) < 2 {
+		return sources
+	}
+	labels := map[string]int{}
+	for _, trace := range traces {
+		labels[fmt.Sprintf("%s#%d", trace.Podname, trace.Containeridx)]++
+	}
+	for i, trace := range traces {
+		label := fmt.Sprintf("%s#%d", trace.Podname, trace.Containeridx)
+		if trace.Podname == "" || labels[label] > 1 {
+			label = trace.TraceID
+		}
+		sources[i] = &traceSource{traceID: trace.TraceID, label: label}
 	}
+	return sources
 }
 
 func runTraceloopPod(cmd *cobra.Command, args []string) {
@@ -321,8 +1144,14 @@ func runTraceloopPod(cmd *cobra.Command, args []string) {
 	namespace := args[0]
 	podname := args[1]
 	idx := args[2]
+	if err := parseSnapshotOptions(); err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
+	if err := parseShowOptions(); err != nil {
+		contextLogger.Fatalf("%s", err)
+	}
 
-	client, err := k8sutil.NewClientset(viper.GetString("kubeconfig"))
+	client, err := newClientset()
 	if err != nil {
 		contextLogger.Fatalf("Error in creating setting up Kubernetes client: %q", err)
 	}
@@ -336,9 +1165,12 @@ func runTraceloopPod(cmd *cobra.Command, args []string) {
 		contextLogger.Fatalf("Pod %s not scheduled yet", podname)
 	}
 
-	fmt.Printf("%s", execPodSimple(client, pod.Spec.NodeName,
+	err = showTrace(client, pod.Spec.NodeName,
 		fmt.Sprintf(`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/dump-pod?namespace=%s&podname=%s&idx=%s' ; echo`,
-			namespace, podname, idx)))
+			namespace, podname, idx), nil)
+	if err != nil {
+		contextLogger.Fatalf("Error in showing the trace of pod %s: %q", podname, err)
+	}
 }
 
 func runTraceloopClose(cmd *cobra.Command, args []string) {
@@ -347,31 +1179,225 @@ func runTraceloopClose(cmd *cobra.Command, args []string) {
 		"args":    args,
 	})
 
-	if len(args) != 1 {
-		contextLogger.Fatalf("Missing parameter: trace name")
+	if optionCloseAll {
+		if len(args) != 0 {
+			contextLogger.Fatalf("--all closes the traces of the namespace: no trace ID expected")
+		}
+	} else {
+		if len(args) != 1 {
+			contextLogger.Fatalf("Missing parameter: trace ID, or --all")
+		}
+		if optionCloseNamespace != "" || allNamespacesFlag || optionCloseSelector != "" {
+			contextLogger.Fatalf("-n, -A and -l only work with --all")
+		}
+	}
+	if _, err := labels.Parse(optionCloseSelector); err != nil {
+		contextLogger.Fatalf("Invalid selector %q: %s", optionCloseSelector, err)
 	}
 
-	client, err := k8sutil.NewClientset(viper.GetString("kubeconfig"))
+	client, err := newClientset()
 	if err != nil {
 		contextLogger.Fatalf("Error in creating setting up Kubernetes client: %q", err)
 	}
 
+	tracesPerNode, err := getTracesListPerNode(client)
+	if err != nil {
+		contextLogger.Fatalf("Error in getting traces: %q", err)
+	}
+
+	toClose := map[string][]tracemeta.TraceMeta{}
+	if optionCloseAll {
+		namespace, err := resolveNamespace(optionCloseNamespace, allNamespacesFlag, getDefaultNamespace)
+		if err != nil {
+			contextLogger.Fatalf("%s", err)
+		}
+		var podUIDs map[string]bool
+		if optionCloseSelector != "" {
+			podUIDs, err = traceloopSelectedPods(client, namespace, optionCloseSelector)
+			if err != nil {
+				contextLogger.Fatalf("Error in listing the pods matching %q: %s", optionCloseSelector, err)
+			}
+		}
+		for node, tm := range tracesPerNode {
+			if traces := selectTraces(tm, namespace, podUIDs); len(traces) != 0 {
+				toClose[node] = traces
+			}
+		}
+		if len(toClose) == 0 {
+			fmt.Println("No traces to close.")
+			return
+		}
+	} else {
+		for node, tm := range tracesPerNode {
+			for _, trace := range tm {
+				if trace.TraceID == args[0] {
+					toClose[node] = append(toClose[node], trace)
+				}
+			}
+		}
+		if len(toClose) == 0 {
+			if !closeTraceByName(client, args[0]) {
+				contextLogger.Fatalf("Trace %s not found on any node", args[0])
+			}
+			return
+		}
+	}
+
+	nodes := []string{}
+	for node := range toClose {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	closed := map[string]bool{}
+	failed := false
+	for _, node := range nodes {
+		for _, result := range closeTraces(client, node, toClose[node]) {
+			if result.err != nil {
+				stderrLog.Errorf("Error in closing trace %s on node %s: %s", result.trace.TraceID, node, result.err)
+				failed = true
+				continue
+			}
+			fmt.Printf("Trace %s of %s/%s closed on node %s\n", result.trace.TraceID, result.trace.Namespace, result.trace.Podname, node)
+			closed[result.trace.TraceID] = true
+		}
+	}
+
+	if len(closed) != 0 {
+		if remaining := waitTracesClosed(client, closed, traceloopCloseTimeout); len(remaining) != 0 {
+			log.Warnf("Closed traces still listed after %s: %s", traceloopCloseTimeout, strings.Join(remaining, ", "))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// traceloopCloseResult is the result of closing a trace
+type traceloopCloseResult struct {
+	trace tracemeta.TraceMeta
+	err   error
+}
+
+// closeTraces closes the traces of node. Traceloop closes its programs by
+// index: they are found in the list of its programs by their container, see
+// traceloop.ProgIndex.
+func closeTraces(client *kubernetes.Clientset, node string, traces []tracemeta.TraceMeta) []traceloopCloseResult {
+	var results []traceloopCloseResult
+	list, stderr, err := execPodCapture(client, node,
+		`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/list'`)
+	if err != nil {
+		err = fmt.Errorf("cannot list the programs of traceloop: %s: %s", err, strings.TrimSpace(stderr))
+	}
+	for _, trace := range traces {
+		result := traceloopCloseResult{trace: trace, err: err}
+		if err == nil {
+			result.err = closeTrace(client, node, list, trace)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func closeTrace(client *kubernetes.Clientset, node, list string, trace tracemeta.TraceMeta) error {
+	index, err := traceloopgadget.ProgIndex(list, trace.Namespace, trace.Podname, trace.Containeridx, trace.Status)
+	if err != nil {
+		return err
+	}
+	stdout, stderr, err := execPodCapture(client, node,
+		fmt.Sprintf(`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/close?id=%d'`, index))
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr))
+	}
+	if answer := strings.TrimSpace(stdout); answer != "closed" {
+		return errors.New(answer)
+	}
+	return nil
+}
+
+// closeTraceByName closes the traces added to traceloop with name, that are
+// not published with the traces of the pods. Their name starts with the
+// address of their node. It returns false if no node has this address.
+func closeTraceByName(client *kubernetes.Clientset, name string) bool {
 	var listOptions = metaV1.ListOptions{
 		LabelSelector: labels.Everything().String(),
 		FieldSelector: fields.Everything().String(),
 	}
-
 	nodes, err := client.CoreV1().Nodes().List(listOptions)
 	if err != nil {
-		contextLogger.Fatalf("Error in listing nodes: %q", err)
+		log.Fatalf("Error in listing nodes: %q", err)
 	}
 
+	found := false
 	for _, node := range nodes.Items {
-		if !strings.HasPrefix(args[0], node.Status.Addresses[0].Address+"_") {
+		if len(node.Status.Addresses) == 0 || !strings.HasPrefix(name, node.Status.Addresses[0].Address+"_") {
 			continue
 		}
+		found = true
 		fmt.Printf("%s", execPodSimple(client, node.Name,
-			fmt.Sprintf(`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/close-by-name?name=%s' ; echo`, args[0])))
+			fmt.Sprintf(`curl --silent --unix-socket /run/traceloop.socket 'http://localhost/close-by-name?name=%s' ; echo`, name)))
+	}
+	return found
+}
+
+// waitTracesClosed waits until the traces closed are no longer published by
+// the gadget pods, so that "traceloop list" doesn't print them anymore. It
+// returns the ones still published after timeout.
+func waitTracesClosed(client *kubernetes.Clientset, closed map[string]bool, timeout time.Duration) []string {
+	var remaining []string
+	for id := range closed {
+		remaining = append(remaining, id)
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+		tracesPerNode, err := getTracesListPerNode(client)
+		if err != nil {
+			continue
+		}
+		remaining = nil
+		for _, tm := range tracesPerNode {
+			for _, trace := range tm {
+				if closed[trace.TraceID] {
+					remaining = append(remaining, trace.TraceID)
+				}
+			}
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
 	}
+	sort.Strings(remaining)
+	return remaining
+}
 
+func readCapture(path string) (traceloopgadget.Capture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return traceloopgadget.Parse(f)
+}
+
+func runTraceloopDiff(cmd *cobra.Command, args []string) {
+	contextLogger := log.WithFields(log.Fields{
+		"command": "kubectl-gadget traceloop diff",
+		"args":    args,
+	})
+
+	a, err := readCapture(args[0])
+	if err != nil {
+		contextLogger.Fatalf("Error in reading trace %s: %q", args[0], err)
+	}
+	b, err := readCapture(args[1])
+	if err != nil {
+		contextLogger.Fatalf("Error in reading trace %s: %q", args[1], err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "\tA\tB\tCOMM\tSYSCALL\tPATH\tERROR\t")
+	for _, c := range traceloopgadget.Diff(a, b) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", c.Status(), c.CountA, c.CountB, c.Comm, c.Syscall, c.Path, c.Errno)
+	}
+	w.Flush()
 }
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kinvolk/traceloop/pkg/tracemeta"
+	"sigs.k8s.io/yaml"
+)
+
+func TestLineLimitWriter(t *testing.T) {
+	table := []struct {
+		description string
+		limit       int
+		writes      []string
+		expected    string
+	}{
+		{
+			description: "under the limit",
+			limit:       100,
+			writes:      []string{"00:00.000 cat read(3) = 4\n", "00:00.001 cat close(3) = 0\n"},
+			expected:    "00:00.000 cat read(3) = 4\n00:00.001 cat close(3) = 0\n",
+		},
+		{
+			description: "truncated on a line boundary",
+			limit:       40,
+			writes:      []string{"00:00.000 cat read(3) = 4\n00:00.0", "01 cat close(3) = 0\n", "00:00.002 cat exit_group(0)\n"},
+			expected:    "00:00.000 cat read(3) = 4\n[trace truncated after 26 bytes]\n",
+		},
+		{
+			description: "exactly at the limit",
+			limit:       26,
+			writes:      []string{"00:00.000 cat read(3) = 4\n"},
+			expected:    "00:00.000 cat read(3) = 4\n",
+		},
+		{
+			description: "last line without newline",
+			limit:       100,
+			writes:      []string{"00:00.000 cat read(3) = 4\n", "00:00.001 cat close(3) = 0"},
+			expected:    "00:00.000 cat read(3) = 4\n00:00.001 cat close(3) = 0",
+		},
+	}
+
+	for _, entry := range table {
+		mock := &mockWriter{[]byte{}}
+		w := &lineLimitWriter{w: mock, limit: entry.limit}
+		for _, s := range entry.writes {
+			n, err := w.Write([]byte(s))
+			if err != nil || n != len(s) {
+				t.Fatalf("%s: Write returned %d, %v", entry.description, n, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: %v", entry.description, err)
+		}
+		if string(mock.output) != entry.expected {
+			t.Errorf("%s: %q != %q", entry.description, string(mock.output), entry.expected)
+		}
+	}
+}
+
+func TestLineLimitWriterMarker(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	marker := &mockWriter{[]byte{}}
+	w := &lineLimitWriter{w: mock, marker: marker, limit: 30}
+	for _, s := range []string{"{\"syscall\":\"read\",\"ret\":4}\n", "{\"syscall\":\"close\",\"ret\":0}\n"} {
+		if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("Write returned %d, %v", n, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "{\"syscall\":\"read\",\"ret\":4}\n"; string(mock.output) != expected {
+		t.Errorf("%q != %q", string(mock.output), expected)
+	}
+	if expected := "[trace truncated after 27 bytes]\n"; string(marker.output) != expected {
+		t.Errorf("%q != %q", string(marker.output), expected)
+	}
+}
+
+func TestLinePrefixWriter(t *testing.T) {
+	mock := &mockWriter{[]byte{}}
+	w := &linePrefixWriter{w: mock, prefix: "[mypod#1] "}
+	for _, s := range []string{"00:00.000 cat read(3) = 4\n00:00.0", "01 cat close(3) = 0\n", "00:00.002 cat exit_group(0)"} {
+		if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("Write returned %d, %v", n, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "[mypod#1] 00:00.000 cat read(3) = 4\n[mypod#1] 00:00.001 cat close(3) = 0\n[mypod#1] 00:00.002 cat exit_group(0)\n"
+	if string(mock.output) != expected {
+		t.Errorf("%q != %q", string(mock.output), expected)
+	}
+}
+
+func TestFindTraces(t *testing.T) {
+	tracesPerNode := map[string][]tracemeta.TraceMeta{
+		"node-1": {
+			{TraceID: "web", Podname: "mypod", Containeridx: 0},
+			{TraceID: "sidecar", Podname: "mypod", Containeridx: 1},
+		},
+		"node-2": {
+			{TraceID: "db", Podname: "mypod", Containeridx: 0},
+		},
+	}
+	traces, missing := findTraces(tracesPerNode, []string{"sidecar", "nope", "web", "sidecar", "db"})
+	ids := []string{}
+	for _, trace := range traces {
+		ids = append(ids, trace.Node+"/"+trace.TraceID)
+	}
+	if expected := []string{"node-1/sidecar", "node-1/web", "node-2/db"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("got %v, expected %v", ids, expected)
+	}
+	if expected := []string{"nope"}; !reflect.DeepEqual(missing, expected) {
+		t.Errorf("missing %v, expected %v", missing, expected)
+	}
+
+	// The traces of two pods with the same name are labeled with their ids
+	labels := []string{}
+	for _, source := range traceSources(traces) {
+		labels = append(labels, source.label)
+	}
+	if expected := []string{"mypod#1", "web", "db"}; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("labels %v, expected %v", labels, expected)
+	}
+	if sources := traceSources(traces[:1]); sources[0] != nil {
+		t.Errorf("single trace labeled: %+v", sources[0])
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	header := []string{"NAMESPACE", "PODNAME", "INDEX", "CONTAINERID", "STATUS"}
+	rows := [][]string{
+		{"default", "mypod", "0", "2d9a1e4f", "started 5 minutes ago"},
+		{"a-very-long-namespace-name", "p", "12", "", "terminated 2 hours ago"},
+		{"kube-system", "coredns-6955765f44-x8w3c", "1", "7c1e2f5b", "unknown (creating)"},
+	}
+
+	// The fields of a row are always at the same position, whatever the
+	// width of the fields of the other rows
+	for _, separator := range []string{"\t", ",", " "} {
+		out := &mockWriter{}
+		writeTable(out, nil, rows, separator)
+		lines := strings.Split(strings.TrimSuffix(string(out.output), "\n"), "\n")
+		if len(lines) != len(rows) {
+			t.Fatalf("separator %q: %d lines != %d rows", separator, len(lines), len(rows))
+		}
+		for i, line := range lines {
+			fields := strings.Split(line, separator)
+			if separator == " " {
+				// As awk does by default
+				fields = strings.Fields(line)
+			}
+			if fields[1] != rows[i][1] || fields[2] != rows[i][2] {
+				t.Fatalf("separator %q: unexpected fields %q for row %q", separator, fields, rows[i])
+			}
+			if rows[i][3] == "" && fields[3] != emptyField {
+				t.Fatalf("separator %q: empty field written as %q", separator, fields[3])
+			}
+			if separator != " " && fields[4] != rows[i][4] {
+				t.Fatalf("separator %q: %q != %q", separator, fields[4], rows[i][4])
+			}
+		}
+	}
+
+	out := &mockWriter{}
+	writeTable(out, header, rows[:1], "\t")
+	expected := "NAMESPACE\tPODNAME\tINDEX\tCONTAINERID\tSTATUS\ndefault\tmypod\t0\t2d9a1e4f\tstarted 5 minutes ago\n"
+	if string(out.output) != expected {
+		t.Fatalf("%q != %q", out.output, expected)
+	}
+
+	// Without separator, the columns are aligned
+	out = &mockWriter{}
+	writeTable(out, header, rows, "")
+	expected = `NAMESPACE                     PODNAME                     INDEX    CONTAINERID    STATUS
+default                       mypod                       0        2d9a1e4f       started 5 minutes ago
+a-very-long-namespace-name    p                           12                      terminated 2 hours ago
+kube-system                   coredns-6955765f44-x8w3c    1        7c1e2f5b       unknown (creating)
+`
+	if string(out.output) != expected {
+		t.Fatalf("%q != %q", out.output, expected)
+	}
+}
+
+func TestSelectTraces(t *testing.T) {
+	traces := []tracemeta.TraceMeta{
+		{TraceID: "pause", Namespace: "default", PodUID: "uid-1", Containeridx: -1},
+		{TraceID: "web", Namespace: "default", PodUID: "uid-1", Containeridx: 0},
+		{TraceID: "db", Namespace: "default", PodUID: "uid-2", Containeridx: 0},
+		{TraceID: "dns", Namespace: "kube-system", PodUID: "uid-3", Containeridx: 0},
+	}
+	table := []struct {
+		namespace string
+		podUIDs   map[string]bool
+		expected  []string
+	}{
+		{"", nil, []string{"web", "db", "dns"}},
+		{"default", nil, []string{"web", "db"}},
+		{"default,kube-system", nil, []string{"web", "db", "dns"}},
+		// The pods matching the selector, in all namespaces with -A
+		{"", map[string]bool{"uid-1": true, "uid-3": true}, []string{"web", "dns"}},
+		{"default", map[string]bool{"uid-1": true, "uid-3": true}, []string{"web"}},
+		// No pod matches
+		{"", map[string]bool{}, []string{}},
+	}
+	for i, entry := range table {
+		ids := []string{}
+		for _, trace := range selectTraces(traces, entry.namespace, entry.podUIDs) {
+			ids = append(ids, trace.TraceID)
+		}
+		if !reflect.DeepEqual(ids, entry.expected) {
+			t.Errorf("%d: got %v, expected %v", i, ids, entry.expected)
+		}
+	}
+}
+
+func TestTraceAge(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	table := []struct {
+		timeCreation string
+		expected     string
+	}{
+		{"2020-06-01T11:59:30Z", "30s"},
+		{"2020-06-01T11:55:00Z", "5m"},
+		{"2020-05-30T12:00:00Z", "2d"},
+		// Traces of containers not started yet
+		{"", ""},
+		{"invalid", ""},
+	}
+	for _, entry := range table {
+		if got := traceAge(tracemeta.TraceMeta{TimeCreation: entry.timeCreation}, now); got != entry.expected {
+			t.Errorf("%q: got %q, expected %q", entry.timeCreation, got, entry.expected)
+		}
+	}
+}
+
+func TestWriteTraceloopList(t *testing.T) {
+	traces := []tracemeta.TraceMeta{
+		{
+			Status:       "ready",
+			TraceID:      "10.0.30.247_default_mypod",
+			PodUID:       "8e5fd2c0-7d5a-4b7e-9a39-1d8c1f0e4a11",
+			ContainerID:  "docker://2d9a1e4f5b6c",
+			Namespace:    "default",
+			Podname:      "mypod",
+			Containeridx: 1,
+			TimeCreation: "2020-06-01T12:00:00Z",
+			Node:         "ip-10-0-30-247",
+		},
+		{
+			// Not started yet, and its pod is gone
+			Status:       "created",
+			TraceID:      "10.0.30.247_default_gone",
+			PodUID:       "2f1e0d9c",
+			Namespace:    "default",
+			Podname:      "gone",
+			Containeridx: 0,
+			Node:         "ip-10-0-30-247",
+		},
+	}
+	entries := []traceloopListEntry{
+		newTraceloopListEntry(traces[0], []string{"init", "app"}),
+		newTraceloopListEntry(traces[1], nil),
+	}
+
+	out := &mockWriter{}
+	if err := writeTraceloopList(out, entries, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(out.output, &objects); err != nil {
+		t.Fatalf("%s: %s", err, out.output)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("unexpected objects %v", objects)
+	}
+	for key, expected := range map[string]interface{}{
+		"node":             "ip-10-0-30-247",
+		"namespace":        "default",
+		"pod":              "mypod",
+		"container":        "app",
+		"containerIndex":   float64(1),
+		"containerID":      "2d9a1e4f5b6c",
+		"containerRuntime": "docker",
+		"traceID":          "10.0.30.247_default_mypod",
+		"state":            "started",
+	} {
+		if objects[0][key] != expected {
+			t.Fatalf("%s is %v, expected %v", key, objects[0][key], expected)
+		}
+	}
+	for _, key := range []string{"container", "containerID", "containerRuntime"} {
+		if _, ok := objects[1][key]; ok {
+			t.Fatalf("unexpected %s in %v", key, objects[1])
+		}
+	}
+
+	out = &mockWriter{}
+	if err := writeTraceloopList(out, entries, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	var parsed []traceloopListEntry
+	if err := yaml.Unmarshal(out.output, &parsed); err != nil {
+		t.Fatalf("%s: %s", err, out.output)
+	}
+	if !reflect.DeepEqual(parsed, entries) {
+		t.Fatalf("%+v != %+v", parsed, entries)
+	}
+
+	// No traces: still a list for the tools parsing it
+	out = &mockWriter{}
+	if err := writeTraceloopList(out, []traceloopListEntry{}, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if string(out.output) != "[]\n" {
+		t.Fatalf("unexpected output %q", out.output)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ttysnoop"
+)
+
+// ttysnoopConfirm must be set with --confirm-capture: the output of the
+// terminals can contain secrets
+var ttysnoopConfirm bool
+
+var ttysnoopHeader = fmt.Sprintf("%-27s %-6s %-16s %-40s %s",
+	"TIME", "PID", "COMM", "POD", "OUTPUT")
+
+// ttysnoopTransform returns the transform function rendering the writes to
+// terminals printed by the ttysnoop gadget with their pod
+func ttysnoopTransform(containers *containercache.Cache) func(line string) (string, error) {
+	return func(line string) (string, error) {
+		event := ttysnoop.Event{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return "", err
+		}
+		if m := lookupContainer(containers, event.ContainerID); m != nil {
+			event.Namespace = m.Namespace
+			event.Pod = m.Pod
+			event.Container = m.Container
+		}
+		if jsonOutput {
+			buf, err := json.Marshal(event)
+			return string(buf), err
+		}
+		pod := "-"
+		if event.Pod != "" {
+			pod = fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+		}
+		return fmt.Sprintf("%-27s %-6d %-16s %-40s %s",
+			event.Timestamp, event.Pid, event.Comm, pod, event.Quote()), nil
+	}
+}
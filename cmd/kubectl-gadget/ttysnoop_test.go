@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTtysnoopTransform(t *testing.T) {
+	containers := testContainers("web-1", "frontend")
+
+	lines := `{"timestamp":"2020-06-01T12:00:01.000123Z","pid":4242,"comm":"bash","containerid":"abc","count":17,"data":"\u001b]0;root@web-1\u0007# ","seq":1}
+{"timestamp":"2020-06-01T12:00:02.000456Z","pid":4250,"comm":"cat","containerid":"abc","count":2048,"data_base64":"f0VMRgIB","truncated":true,"seq":2}
+`
+	output := runTransform(ttysnoopHeader, ttysnoopTransform(containers), lines)
+
+	expected := `
+NODE TIME                        PID    COMM             POD                                      OUTPUT
+[ 0] 2020-06-01T12:00:01.000123Z 4242   bash             demo/web-1/frontend                      "\x1b]0;root@web-1\a# "
+[ 0] 2020-06-01T12:00:02.000456Z 4250   cat              demo/web-1/frontend                      "\x7fELF\x02\x01"...
+`
+	if "\n"+output != expected {
+		t.Fatalf("%v != %v", output, expected)
+	}
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+	output = runTransform(ttysnoopHeader, ttysnoopTransform(containers), strings.SplitAfter(lines, "\n")[1])
+	expected = `{"timestamp":"2020-06-01T12:00:02.000456Z","pid":4250,"comm":"cat","containerid":"abc","namespace":"demo","pod":"web-1","container":"frontend","count":2048,"data_base64":"f0VMRgIB","truncated":true,"seq":2}` + "\n"
+	if !strings.HasSuffix(output, expected) {
+		t.Fatalf("%v doesn't end with %v", output, expected)
+	}
+}
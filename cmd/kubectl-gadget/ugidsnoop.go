@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/eventpb"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ugidsnoop"
+)
+
+var ugidsnoopHeader = fmt.Sprintf("%-6s %-16s %-10s %s", "PID", "COMM", "SYSCALL", "CHANGES")
+
+// ugidsnoopTransform renders a credential change printed by the ugidsnoop
+// gadget, with symbolic capabilities
+func ugidsnoopTransform(line string) (string, error) {
+	event := ugidsnoop.Event{}
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return "", err
+	}
+	if protobufWriter != nil {
+		return writeProtobuf(eventpb.FromUgidsnoop(event))
+	}
+	if jsonOutput {
+		buf, err := json.Marshal(ugidsnoop.Decode(event))
+		return string(buf), err
+	}
+	return fmt.Sprintf("%-6d %-16s %-10s %s",
+		event.Pid, event.Comm, event.Syscall, ugidsnoop.FormatChanges(event)), nil
+}
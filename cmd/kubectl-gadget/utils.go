@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -14,54 +16,103 @@ import (
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/tools/remotecommand"
 
 	"github.com/kinvolk/inspektor-gadget/pkg/factory"
 )
 
-// doesKubeconfigExist checks if the kubeconfig provided by user exists
+// doesKubeconfigExist checks if the kubeconfig provided by user exists. With
+// several files, as KUBECONFIG can list, one of them must exist.
 func doesKubeconfigExist(*cobra.Command, []string) error {
 	var err error
 	kubeconfig := viper.GetString("kubeconfig")
-	if _, err = os.Stat(kubeconfig); os.IsNotExist(err) {
-		return fmt.Errorf("Kubeconfig %q not found", kubeconfig)
+	for _, path := range filepath.SplitList(kubeconfig) {
+		if _, err = os.Stat(path); !os.IsNotExist(err) {
+			return err
+		}
 	}
-	return err
+	return fmt.Errorf("Kubeconfig %q not found", kubeconfig)
 }
 
-// getDefaultNamespace returns the configured default namespace for kubectl
-// returns "default" if it is not possible to determine the default namespace
-func getDefaultNamespace() string {
+// kubeClientConfig returns the client configuration of the kubeconfig given
+// by --kubeconfig, or else by KUBECONFIG, for the context given by
+// --context, or else the current context of the kubeconfig. With several
+// files, they are merged as kubectl does.
+func kubeClientConfig() clientcmd.ClientConfig {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	loadingRules.DefaultClientConfig = &clientcmd.DefaultClientConfig
-	if viper.GetString("kubeconfig") != "" {
-		loadingRules.ExplicitPath = viper.GetString("kubeconfig")
+	if kubeconfig := viper.GetString("kubeconfig"); kubeconfig != "" {
+		if paths := filepath.SplitList(kubeconfig); len(paths) > 1 {
+			loadingRules.Precedence = paths
+		} else {
+			loadingRules.ExplicitPath = kubeconfig
+		}
 	}
+	overrides := &clientcmd.ConfigOverrides{
+		ClusterDefaults: clientcmd.ClusterDefaults,
+		CurrentContext:  viper.GetString("context"),
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
 
-	pathOptions := clientcmd.NewDefaultPathOptions()
-	pathOptions.LoadingRules = loadingRules
-
-	config, err := pathOptions.GetStartingConfig()
+// newClientset returns a clientset for the cluster of kubeClientConfig
+func newClientset() (*kubernetes.Clientset, error) {
+	config, err := kubeClientConfig().ClientConfig()
 	if err != nil {
-		return "default"
+		return nil, err
 	}
+	return kubernetes.NewForConfig(config)
+}
 
-	if config.CurrentContext == "" {
-		return "default"
+// getDefaultNamespace returns the configured default namespace for kubectl
+// returns "default" if it is not possible to determine the default namespace
+func getDefaultNamespace() string {
+	if namespace := kubeconfigNamespace(); namespace != "" {
+		return namespace
 	}
+	return "default"
+}
 
-	if config.Contexts == nil {
-		return "default"
+// kubeconfigNamespace returns the namespace of the context of the
+// kubeconfig, as selected by kubeClientConfig, "" if it doesn't set one or
+// the kubeconfig cannot be read
+func kubeconfigNamespace() string {
+	config, err := kubeClientConfig().RawConfig()
+	if err != nil {
+		return ""
 	}
+	if context := viper.GetString("context"); context != "" {
+		config.CurrentContext = context
+	}
+	return contextNamespace(&config)
+}
 
-	namespace := config.Contexts[config.CurrentContext].Namespace
-	if namespace == "" {
-		return "default"
+// contextNamespace returns the namespace of the current context of config,
+// "" if it doesn't set one
+func contextNamespace(config *clientcmdapi.Config) string {
+	if config.CurrentContext == "" || config.Contexts == nil {
+		return ""
 	}
+	context, ok := config.Contexts[config.CurrentContext]
+	if !ok || context == nil {
+		return ""
+	}
+	return context.Namespace
+}
 
-	return namespace
+// findPodByUID returns the pod with the given UID. Other pods, even with the
+// same name, are not considered.
+func findPodByUID(pods []corev1.Pod, uid string) (*corev1.Pod, error) {
+	for i := range pods {
+		if string(pods[i].ObjectMeta.UID) == uid {
+			return &pods[i], nil
+		}
+	}
+	return nil, fmt.Errorf("Pod with UID %q not found", uid)
 }
 
 func execPodSimple(client *kubernetes.Clientset, node string, podCmd string) string {
@@ -80,11 +131,17 @@ func execPodCapture(client *kubernetes.Clientset, node string, podCmd string) (s
 }
 
 func execPod(client *kubernetes.Clientset, node string, podCmd string, cmdStdout io.Writer, cmdStderr io.Writer) error {
+	return execPodStdin(client, node, podCmd, nil, cmdStdout, cmdStderr)
+}
+
+// execPodStdin is like execPod but streams cmdStdin to the command, when not
+// nil
+func execPodStdin(client *kubernetes.Clientset, node string, podCmd string, cmdStdin io.Reader, cmdStdout io.Writer, cmdStderr io.Writer) error {
 	var listOptions = metaV1.ListOptions{
 		LabelSelector: "k8s-app=gadget",
 		FieldSelector: "spec.nodeName=" + node + ",status.phase=Running",
 	}
-	pods, err := client.CoreV1().Pods("kube-system").List(listOptions)
+	pods, err := client.CoreV1().Pods(gadgetNamespace()).List(listOptions)
 	if err != nil {
 		return err
 	}
@@ -95,16 +152,10 @@ func execPod(client *kubernetes.Clientset, node string, podCmd string, cmdStdout
 		return errors.New("Multiple Gadget Daemons found")
 	}
 	podName := pods.Items[0].Name
+	log.Debugf("Running in gadget pod %s/%s of node %s", gadgetNamespace(), podName, node)
+	log.Tracef("Command: %s", podCmd)
 
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	loadingRules.DefaultClientConfig = &clientcmd.DefaultClientConfig
-	if viper.GetString("kubeconfig") != "" {
-		loadingRules.ExplicitPath = viper.GetString("kubeconfig")
-	}
-	overrides := &clientcmd.ConfigOverrides{ClusterDefaults: clientcmd.ClusterDefaults}
-	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
-
-	restConfig, err := clientConfig.ClientConfig()
+	restConfig, err := kubeClientConfig().ClientConfig()
 	if err != nil {
 		return err
 	}
@@ -116,28 +167,36 @@ func execPod(client *kubernetes.Clientset, node string, podCmd string, cmdStdout
 	req := restClient.Post().
 		Resource("pods").
 		Name(podName).
-		Namespace("kube-system").
+		Namespace(gadgetNamespace()).
 		SubResource("exec").
 		Param("container", "gadget").
 		VersionedParams(&corev1.PodExecOptions{
 			Container: "gadget",
 			Command:   []string{"/bin/sh", "-c", podCmd},
-			Stdin:     false,
+			Stdin:     cmdStdin != nil,
 			Stdout:    true,
 			Stderr:    true,
 			TTY:       false,
 		}, scheme.ParameterCodec)
 
+	log.Tracef("Exec request: POST %s", req.URL())
 	exec, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
 	if err != nil {
 		return err
 	}
 
 	err = exec.Stream(remotecommand.StreamOptions{
-		Stdin:  nil,
-		Stdout: cmdStdout,
-		Stderr: cmdStderr,
+		Stdin:  cmdStdin,
+		Stdout: newTraceWriter(cmdStdout, node, "stdout"),
+		Stderr: newTraceWriter(cmdStderr, node, "stderr"),
 		Tty:    false,
 	})
+	log.Tracef("Command in the gadget pod of node %s ended: %v", node, err)
 	return err
 }
+
+// gadgetNamespace returns the namespace of the gadget pods, given by
+// --gadget-namespace
+func gadgetNamespace() string {
+	return viper.GetString("gadget-namespace")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// verbosity is the number of -v/--verbose
+var verbosity int
+
+// plainField marks the log entries printed as their message only, like the
+// errors printed on stderr before -v existed, so that the output without -v
+// stays the same
+const plainField = "plain"
+
+// stderrLog logs the messages for the user, printed on stderr as they are
+var stderrLog = log.WithField(plainField, true)
+
+// plainFormatter prints the entries of stderrLog as their message only, and
+// the others as the default formatter of logrus
+type plainFormatter struct {
+	log.TextFormatter
+}
+
+func (f *plainFormatter) Format(entry *log.Entry) ([]byte, error) {
+	if _, ok := entry.Data[plainField]; ok {
+		return []byte(entry.Message + "\n"), nil
+	}
+	return f.TextFormatter.Format(entry)
+}
+
+func init() {
+	log.SetFormatter(&plainFormatter{})
+	rootCmd.PersistentFlags().CountVarP(
+		&verbosity,
+		"verbose", "v",
+		"Print debug messages on stderr, like the gadget pod running each command, and with -vv, the commands run in the gadget pods and the data received from them")
+}
+
+// verbosityLevel returns the log level of verbosity: the default level of
+// logrus without -v, debug with -v and trace with -vv or more
+func verbosityLevel(verbosity int) log.Level {
+	switch {
+	case verbosity <= 0:
+		return log.InfoLevel
+	case verbosity == 1:
+		return log.DebugLevel
+	default:
+		return log.TraceLevel
+	}
+}
+
+// traceWriter logs the size of the writes on a stream of a command run in a
+// gadget pod, at trace level, before passing them to w
+type traceWriter struct {
+	w      io.Writer
+	node   string
+	stream string
+}
+
+// newTraceWriter returns w, wrapped in a traceWriter at trace level
+func newTraceWriter(w io.Writer, node, stream string) io.Writer {
+	if w == nil || !log.IsLevelEnabled(log.TraceLevel) {
+		return w
+	}
+	return &traceWriter{w: w, node: node, stream: stream}
+}
+
+func (t *traceWriter) Write(p []byte) (int, error) {
+	log.Tracef("Received %d bytes on %s from the gadget pod of node %s", len(p), t.stream, t.node)
+	return t.w.Write(p)
+}
+
+// deprecateCapabilitiesVerbose keeps --verbose after capabilities, the former
+// name of its --include-non-audit flag, working: the count flag can't tell
+// --verbose from -v, so they are told apart on the command line
+func deprecateCapabilitiesVerbose(cmd *cobra.Command, args []string) {
+	n := longVerboseArgs(os.Args[1:], cmd.Name())
+	if n == 0 {
+		return
+	}
+	stderrLog.Warn("Flag --verbose has been deprecated, use --include-non-audit instead")
+	includeNonAuditFlag = true
+	verbosity -= n
+	log.SetLevel(verbosityLevel(verbosity))
+}
+
+// longVerboseArgs returns the number of --verbose in args after the command
+// name, up to "--"
+func longVerboseArgs(args []string, command string) int {
+	n := 0
+	afterCommand := false
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return n
+		case arg == command:
+			afterCommand = true
+		case afterCommand && arg == "--verbose":
+			n++
+		}
+	}
+	return n
+}
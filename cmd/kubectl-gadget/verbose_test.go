@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestVerbosityLevel(t *testing.T) {
+	for verbosity, expected := range []log.Level{log.InfoLevel, log.DebugLevel, log.TraceLevel, log.TraceLevel} {
+		if level := verbosityLevel(verbosity); level != expected {
+			t.Errorf("verbosity %d: got level %v, expected %v", verbosity, level, expected)
+		}
+	}
+}
+
+func TestNewTraceWriter(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+
+	var buf bytes.Buffer
+	log.SetLevel(log.DebugLevel)
+	if w := newTraceWriter(&buf, "node1", "stdout"); w != &buf {
+		t.Errorf("stdout wrapped without -vv")
+	}
+
+	log.SetLevel(log.TraceLevel)
+	if w := newTraceWriter(nil, "node1", "stderr"); w != nil {
+		t.Errorf("nil writer wrapped")
+	}
+	w := newTraceWriter(&buf, "node1", "stdout")
+	if _, ok := w.(*traceWriter); !ok {
+		t.Fatalf("stdout not wrapped with -vv")
+	}
+	w.Write([]byte("hello"))
+	if buf.String() != "hello" {
+		t.Errorf("got %q, expected %q", buf.String(), "hello")
+	}
+}
+
+func TestLongVerboseArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"capabilities", "-v"}, 0},
+		{[]string{"--verbose", "capabilities", "-n", "demo"}, 0},
+		{[]string{"-v", "capabilities", "--verbose", "--unique"}, 1},
+		{[]string{"capabilities", "--verbose", "--", "--verbose"}, 1},
+	} {
+		if n := longVerboseArgs(tc.args, "capabilities"); n != tc.expected {
+			t.Errorf("%v: got %d, expected %d", tc.args, n, tc.expected)
+		}
+	}
+}
+
+func TestPlainFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.Out = &buf
+	logger.Formatter = &plainFormatter{TextFormatter: log.TextFormatter{DisableTimestamp: true}}
+
+	logger.WithField(plainField, true).Errorf("Error on node %s: %s", "node1", "exit code 1")
+	logger.Debugf("not printed at the default level")
+	logger.WithField("command", "version").Warnf("Unknown version")
+	expected := "Error on node node1: exit code 1\n" +
+		"level=warning msg=\"Unknown version\" command=version\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, expected %q", buf.String(), expected)
+	}
+}
@@ -2,21 +2,91 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 // This variable is used by the "version" command and is set during build.
 var version = "undefined"
 
+// versionTimeout bounds the requests to the cluster, so that the client
+// version is printed without waiting when the cluster is unreachable
+const versionTimeout = 5 * time.Second
+
+var versionClientOnly bool
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
+	versionCmd.PersistentFlags().BoolVar(&versionClientOnly, "client", false, "Only print the version of kubectl-gadget, without querying the gadget pods")
 }
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version",
+	Long: `Show the version of kubectl-gadget and the version of the gadget pods
+deployed in the cluster, with a warning when they differ. When the gadget
+pods cannot be queried, only the version of kubectl-gadget is printed.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(version)
+		fmt.Printf("Client version: %s\n", version)
+		if versionClientOnly {
+			return
+		}
+		server, node, err := gadgetVersion()
+		if err != nil {
+			stderrLog.Errorf("Cannot get the version of the gadget pods: %s", err)
+			return
+		}
+		fmt.Printf("Server version: %s (gadget pod of node %s)\n", server, node)
+		warnVersionMismatch(os.Stderr, version, server)
 	},
 }
+
+// gadgetVersion returns the version of the gadget image, as printed by
+// gadgettracermanager in the gadget pod of the first node where it runs
+func gadgetVersion() (string, string, error) {
+	if err := doesKubeconfigExist(nil, nil); err != nil {
+		return "", "", err
+	}
+	config, err := kubeClientConfig().ClientConfig()
+	if err != nil {
+		return "", "", err
+	}
+	config.Timeout = versionTimeout
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", "", err
+	}
+	pods, err := gadgetPods(client, "")
+	if err != nil {
+		return "", "", err
+	}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		stdout, stderr, err := execPodCapture(client, pod.Spec.NodeName, "/bin/gadgettracermanager -version")
+		if err != nil {
+			// Images older than the -version flag fail with a usage
+			return "", "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr))
+		}
+		return strings.TrimSpace(stdout), pod.Spec.NodeName, nil
+	}
+	return "", "", fmt.Errorf("No gadget pod running")
+}
+
+// warnVersionMismatch warns on w when the gadget pods don't run the version
+// of kubectl-gadget: the gadgets and kubectl-gadget exchange events and
+// parameters whose format changes between versions
+func warnVersionMismatch(w io.Writer, client, server string) {
+	if client == server {
+		return
+	}
+	fmt.Fprintf(w, "Warning: kubectl-gadget %s does not match the gadget pods %s: deploy the gadget pods of this version with \"kubectl gadget deploy\", or use kubectl-gadget %s\n",
+		client, server, server)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWarnVersionMismatch(t *testing.T) {
+	var out bytes.Buffer
+	warnVersionMismatch(&out, "v0.2.0", "v0.2.0")
+	if out.Len() != 0 {
+		t.Fatalf("unexpected warning %q", out.String())
+	}
+
+	warnVersionMismatch(&out, "v0.2.1", "v0.2.0")
+	if !strings.HasPrefix(out.String(), "Warning: kubectl-gadget v0.2.1 does not match the gadget pods v0.2.0") {
+		t.Fatalf("unexpected warning %q", out.String())
+	}
+}
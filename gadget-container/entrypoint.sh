@@ -25,6 +25,20 @@ env | grep '^INSPEKTOR_GADGET_OPTION_.*='
 echo -n "Inspektor Gadget version: "
 echo $INSPEKTOR_GADGET_VERSION
 
+# With a read-only root filesystem, the gadgets can only write to the volumes
+# of the deployment: check that they are there, rather than failing later in
+# the gadgets
+if [ "$INSPEKTOR_GADGET_OPTION_READ_ONLY_ROOT" = "true" ] ; then
+  for DIR in /tmp /usr/src/kernels ; do
+    if ! touch $DIR/.gadget-write-test 2>/dev/null ; then
+      echo "$DIR is not writable with a read-only root filesystem: deploy again with kubectl gadget deploy --read-only-root" >&2
+      exit 1
+    fi
+    rm -f $DIR/.gadget-write-test
+  done
+  echo "Read-only root filesystem: /tmp and /usr/src/kernels are writable."
+fi
+
 # gobpf currently uses global kprobes via debugfs/tracefs and not the Perf
 # Event file descriptor based kprobe (Linux >=4.17). So unfortunately, kprobes
 # can remain from previous executions. Ideally, gobpf should implement Perf
@@ -133,14 +147,63 @@ if [ "$RUNC_HOOK_MODE" = "flatcar_edge" ] ||
   echo "Installation done"
 fi
 
+# When the gadget pod terminates, stop the gadgets still running so that
+# they detach their BPF programs, then the Gadget Tracer Manager, which
+# removes the maps of the tracers from the bpffs of the node.
+shutdown() {
+  # First, so that it doesn't restart the gadgets it records
+  if [ -n "$HISTORY_PID" ] ; then
+    echo "Stopping the history of the gadgets..."
+    kill -TERM $HISTORY_PID 2>/dev/null
+    wait $HISTORY_PID || true
+  fi
+
+  echo "Stopping the gadgets..."
+  STOP_PIDS=""
+  for PIDFILE in /run/bcc-wrapper-*.pid ; do
+    [ -e "$PIDFILE" ] || continue
+    TRACERID=$(basename "$PIDFILE" .pid)
+    TRACERID=${TRACERID#bcc-wrapper-}
+    /opt/bcck8s/bcc-wrapper.sh --tracerid "$TRACERID" --nomanager --stop &
+    STOP_PIDS="$STOP_PIDS $!"
+  done
+  for PID in $STOP_PIDS ; do
+    wait $PID || true
+  done
+
+  echo "Stopping the Gadget Tracer Manager..."
+  for PID in $TRACELOOP_PID $MANAGER_PID ; do
+    kill -TERM $PID 2>/dev/null
+  done
+  for PID in $TRACELOOP_PID $MANAGER_PID ; do
+    wait $PID || true
+  done
+  echo "Stopped."
+  exit 0
+}
+trap shutdown TERM INT
+
 echo "Starting the Gadget Tracer Manager in the background..."
 rm -f /run/gadgettracermanager.socket
 /bin/gadgettracermanager -serve &
+MANAGER_PID=$!
+
+if [ -n "$INSPEKTOR_GADGET_OPTION_HISTORY" ] ; then
+  echo "Recording the history of the aggregate gadgets for $INSPEKTOR_GADGET_OPTION_HISTORY..."
+  /bin/gadgethistory -serve -history "$INSPEKTOR_GADGET_OPTION_HISTORY" &
+  HISTORY_PID=$!
+fi
 
 if [ "$INSPEKTOR_GADGET_OPTION_TRACELOOP" = "true" ] ; then
   rm -f /run/traceloop.socket
-  exec /bin/traceloop $ARGS
+  # Not exec'ed so that the shutdown above runs on termination
+  /bin/traceloop $ARGS &
+  TRACELOOP_PID=$!
+  wait $TRACELOOP_PID || exit $?
+  exit 0
 fi
 
 echo "Ready."
-sleep infinity
+# In the background so that the trap runs as soon as the signal arrives
+sleep infinity &
+wait $!
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/history"
+)
+
+var (
+	serve      bool
+	socketfile string
+	maxAge     time.Duration
+	interval   time.Duration
+	maxBytes   int
+	dump       string
+	since      time.Duration
+)
+
+func init() {
+	flag.StringVar(&socketfile, "socketfile", history.SocketFile, "Socket file")
+
+	flag.BoolVar(&serve, "serve", false, "Record the aggregate gadgets and serve their history")
+	flag.DurationVar(&maxAge, "history", 5*time.Minute, "how long the summaries are kept")
+	flag.DurationVar(&interval, "interval", 10*time.Second, "interval between two summaries of the recorded gadgets")
+	flag.IntVar(&maxBytes, "max-bytes", history.DefaultMaxBytes, "maximum size of the summaries kept for each gadget")
+
+	flag.StringVar(&dump, "dump", "", "Print the history of a gadget (cachestat, tcpsubnet)")
+	flag.DurationVar(&since, "since", 5*time.Minute, "how far back the history is printed with -dump")
+}
+
+// recordedGadgets are the parameters of the aggregate gadgets recorded. The
+// summaries of tcpsubnet depend on the subnets: only the default ones of
+// kubectl-gadget are recorded.
+func recordedGadgets(interval time.Duration) map[string][]string {
+	seconds := strconv.Itoa(int(interval / time.Second))
+	return map[string][]string{
+		"cachestat": {"--interval", seconds},
+		"tcpsubnet": {"--interval", seconds, "--subnets", "0.0.0.0/0"},
+	}
+}
+
+// recorder runs an aggregate gadget on the whole node and keeps its
+// summaries
+type recorder struct {
+	name  string
+	args  []string
+	store *history.Store
+
+	mu       sync.Mutex
+	process  *os.Process
+	stopping bool
+}
+
+// run runs the gadget until stop is called, restarting it if it fails
+func (r *recorder) run() {
+	for {
+		// Run by bcc-wrapper.sh with a pidfile like the gadgets started
+		// by kubectl-gadget, so that it is stopped with them when the
+		// gadget pod terminates
+		args := append([]string{
+			"--tracerid", "history-" + r.name, "--nomanager",
+			"--gadget", "/opt/bcck8s/" + r.name, "--",
+		}, r.args...)
+		cmd := exec.Command("/opt/bcck8s/bcc-wrapper.sh", args...)
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			log.Fatalf("gadgethistory failed to run %s: %v", r.name, err)
+		}
+
+		r.mu.Lock()
+		if r.stopping {
+			r.mu.Unlock()
+			return
+		}
+		err = cmd.Start()
+		if err == nil {
+			r.process = cmd.Process
+		}
+		r.mu.Unlock()
+
+		if err == nil {
+			scanner := bufio.NewScanner(stdout)
+			for scanner.Scan() {
+				// The other lines are messages of the gadget
+				if line := scanner.Text(); strings.HasPrefix(line, "{") {
+					r.store.Add(line)
+				}
+			}
+			err = cmd.Wait()
+		}
+
+		r.mu.Lock()
+		stopping := r.stopping
+		r.process = nil
+		r.mu.Unlock()
+		if stopping {
+			return
+		}
+		log.Printf("gadgethistory: %s stopped (%v), restarting it in %s", r.name, err, interval)
+		time.Sleep(interval)
+	}
+}
+
+// stop stops the gadget. The summary of its incomplete last interval is
+// kept.
+func (r *recorder) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopping = true
+	if r.process != nil {
+		r.process.Signal(syscall.SIGINT)
+	}
+}
+
+// handle answers a request "gadget seconds" with the summaries of the
+// gadget printed during the last seconds, or with a line "error: message"
+func handle(conn net.Conn, recorders map[string]*recorder) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	request, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+	var name string
+	var seconds int
+	if _, err := fmt.Sscanf(request, "%s %d\n", &name, &seconds); err != nil {
+		fmt.Fprintf(conn, "error: invalid request %q\n", strings.TrimSpace(request))
+		return
+	}
+	r, ok := recorders[name]
+	if !ok {
+		fmt.Fprintf(conn, "error: the history of %s is not recorded\n", name)
+		return
+	}
+	w := bufio.NewWriter(conn)
+	for _, line := range r.store.Since(time.Duration(seconds) * time.Second) {
+		marked, err := history.Mark(line)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintln(w, marked)
+	}
+	w.Flush()
+}
+
+func runServe() {
+	if interval < time.Second || interval%time.Second != 0 {
+		log.Fatalf("invalid -interval %s: must be a number of seconds, at least 1s", interval)
+	}
+	log.Printf("gadgethistory keeps the summaries of the last %s, at most %d bytes for each gadget", maxAge, maxBytes)
+
+	os.Remove(socketfile)
+	lis, err := net.Listen("unix", socketfile)
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	recorders := map[string]*recorder{}
+	var running sync.WaitGroup
+	for name, args := range recordedGadgets(interval) {
+		r := &recorder{
+			name:  name,
+			args:  args,
+			store: history.NewStore(maxAge, maxBytes),
+		}
+		recorders[name] = r
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			r.run()
+		}()
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-sigs
+		log.Printf("gadgethistory received %s, stopping", sig)
+		lis.Close()
+	}()
+
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			break
+		}
+		go handle(conn, recorders)
+	}
+
+	for _, r := range recorders {
+		r.stop()
+	}
+	running.Wait()
+	os.Remove(socketfile)
+}
+
+func runDump() {
+	if since < time.Second || since%time.Second != 0 {
+		fmt.Fprintf(os.Stderr, "invalid -since %s: must be a number of seconds, at least 1s\n", since)
+		os.Exit(1)
+	}
+	conn, err := net.Dial("unix", socketfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "The history of the gadgets is not recorded on this node, see kubectl gadget deploy --history\n")
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "%s %d\n", dump, int(since/time.Second))
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "error: ") {
+			fmt.Fprintf(os.Stderr, "%s\n", strings.TrimPrefix(line, "error: "))
+			os.Exit(1)
+		}
+		fmt.Println(line)
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	switch {
+	case flag.NArg() > 0 || (serve && dump != ""):
+		fmt.Println("invalid command")
+		flag.PrintDefaults()
+		os.Exit(1)
+	case serve:
+		runServe()
+	case dump != "":
+		runDump()
+	default:
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+}
@@ -4,6 +4,7 @@ set -e
 
 CONTAINERINDEX=-1
 MANAGER=true
+INCLUDESELF=false
 PROBECLEANUP=false
 FLATCAREDGEONLY=false
 
@@ -33,6 +34,10 @@ case $key in
         PROBECLEANUP=true
         shift
         ;;
+    --includeself)
+        INCLUDESELF=true
+        shift
+        ;;
     --gadget)
         GADGET="$2"
         shift
@@ -53,11 +58,21 @@ case $key in
         shift
         shift
         ;;
+    --poduid)
+        PODUID="$2"
+        shift
+        shift
+        ;;
     --containerindex)
         CONTAINERINDEX="$2"
         shift
         shift
         ;;
+    --perfbufferpages)
+        PERFBUFFERPAGES="$2"
+        shift
+        shift
+        ;;
     --)
         shift
         break
@@ -128,8 +143,22 @@ echo $$ > $PIDFILE
 export TERM=xterm-256color
 export PYTHONUNBUFFERED=TRUE
 
+# Size of the perf buffers: given by kubectl-gadget, or the default of the
+# deployment for this gadget, e.g. INSPEKTOR_GADGET_OPTION_PERF_BUFFER_PAGES=execsnoop=128,swapin=256
+if [ -z "$PERFBUFFERPAGES" ] ; then
+  GADGETNAME=$(basename "$GADGET")
+  for ENTRY in ${INSPEKTOR_GADGET_OPTION_PERF_BUFFER_PAGES//,/ } ; do
+    if [ "${ENTRY%%=*}" = "$GADGETNAME" ] ; then
+      PERFBUFFERPAGES="${ENTRY#*=}"
+    fi
+  done
+fi
+if [ ! -z "$PERFBUFFERPAGES" ] ; then
+  set -- --perf-buffer-pages "$PERFBUFFERPAGES" "$@"
+fi
+
 if [ "$MANAGER" = "true" ] ; then
-  $GADGETTRACERMANAGER -call add-tracer -tracerid "$TRACERID" -label "$LABEL" -namespace "$NAMESPACE" -podname "$PODNAME" -containerindex "$CONTAINERINDEX" > /dev/null
+  $GADGETTRACERMANAGER -call add-tracer -tracerid "$TRACERID" -label "$LABEL" -namespace "$NAMESPACE" -podname "$PODNAME" -poduid "$PODUID" -containerindex "$CONTAINERINDEX" -includeself="$INCLUDESELF" > /dev/null
   # use the --cgroupmap option if the system is using cgroup-v2
   MODE="--mntnsmap"
   MAPPATH=$BPFDIR/gadget/mntnsset-$TRACERID
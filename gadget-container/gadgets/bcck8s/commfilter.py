@@ -0,0 +1,51 @@
+# commfilter  Trace only the processes with given names, for the gadgets
+#             started with --comm.
+#
+# The BPF programs of the gadget include COMMS_MAP and call COMMS_CHECK before
+# handling an event, and the gadget calls fill() once the programs are
+# loaded. The names are compared with the comm of the current task, that the
+# kernel truncates to TASK_COMM_LEN - 1 bytes: key() truncates the names
+# given the same way, as kubectl-gadget does already, see pkg/commfilter.
+#
+# Licensed under the Apache License, Version 2.0 (the "License")
+
+from __future__ import print_function
+import ctypes as ct
+
+TASK_COMM_LEN = 16
+
+COMMS_MAP = """
+struct comm_t {
+    char name[TASK_COMM_LEN];
+};
+BPF_HASH(comms, struct comm_t, u8, 64);
+"""
+
+COMMS_CHECK = """
+    struct comm_t comm = {};
+    bpf_get_current_comm(&comm.name, sizeof(comm.name));
+    if (comms.lookup(&comm) == NULL)
+        return 1;
+"""
+
+
+def names(arg):
+    return [c for c in arg.split(",") if c]
+
+
+def bpf_text(text, comms):
+    text = text.replace("COMMS_MAP", COMMS_MAP if comms else "")
+    return text.replace("COMMS_CHECK", COMMS_CHECK if comms else "")
+
+
+def key(name):
+    if not isinstance(name, bytes):
+        name = name.encode("utf-8")
+    return name[:TASK_COMM_LEN - 1]
+
+
+def fill(b, comms):
+    for name in comms:
+        k = b["comms"].Key()
+        k.name = key(name)
+        b["comms"][k] = ct.c_ubyte(1)
@@ -0,0 +1,144 @@
+# cpubudget  Cap the run time of the BPF programs of a gadget by sampling its
+#            events, for the gadgets started with --cpu-budget.
+#
+# The BPF programs of the gadget call sampled_out() before the work it saves,
+# from SAMPLING_MAP, and the gadget calls CPUBudget.poll() between two polls
+# of its perf buffers. Every INTERVAL seconds, the run time of the programs
+# over the interval is compared to the budget, a share of one CPU, and the
+# sampling rate is adapted by next_rate(). Each change is printed on stderr.
+#
+# The events sampled out are dropped by the BPF programs: they are not
+# numbered and not reported as lost.
+#
+# The run time of the programs is only counted while the BPF statistics are
+# enabled (Linux 5.1 or later). They are enabled as bpfmetrics does, with
+# the same count of users, and restored when the gadget exits.
+#
+# Licensed under the Apache License, Version 2.0 (the "License")
+
+from __future__ import print_function
+import atexit
+import ctypes as ct
+import fcntl
+import os
+import sys
+import time
+
+INTERVAL = 1.0
+MAX_RATE = 1024
+
+STATS_SYSCTL = "/proc/sys/kernel/bpf_stats_enabled"
+# Shared with bpfmetrics: the number of gadgets using the statistics and the
+# value of the sysctl before the first one started, as "users previous"
+STATS_USERS = "/run/bpfmetrics-stats"
+
+# Included in the BPF programs of the gadgets: a map holding the sampling
+# rate, and sampled_out() telling whether the current event is dropped.
+# Without --cpu-budget, sampled_out() never drops any event.
+SAMPLING_MAP = """
+BPF_ARRAY(sampling, u32, 1);
+
+static inline int sampled_out() {
+    u32 zero = 0;
+    u32 *rate = sampling.lookup(&zero);
+    if (rate == NULL || *rate <= 1)
+        return 0;
+    return bpf_get_prandom_u32() % *rate != 0;
+}
+"""
+
+NO_SAMPLING = """
+static inline int sampled_out() {
+    return 0;
+}
+"""
+
+def bpf_text(text, budget):
+    return text.replace("SAMPLING_MAP", SAMPLING_MAP if budget else NO_SAMPLING)
+
+# next_rate returns the sampling rate following rate, given the share of one
+# CPU the programs ran over the last interval. The rate is doubled while the
+# programs run longer than the budget, and halved when they run less than a
+# quarter of it: once halved, they should still stay within half of the
+# budget, so the rate doesn't oscillate. It is tested by pkg/cpubudget.
+def next_rate(rate, share, budget):
+    if share > budget and rate < MAX_RATE:
+        return rate * 2
+    if share < budget / 4 and rate > 1:
+        return rate // 2
+    return rate
+
+def update_stats_users(delta):
+    with open(STATS_USERS, "a+") as f:
+        fcntl.flock(f, fcntl.LOCK_EX)
+        f.seek(0)
+        fields = f.read().split()
+        users, previous = 0, "0"
+        if len(fields) == 2:
+            users, previous = int(fields[0]), fields[1]
+        if users == 0 and delta > 0:
+            with open(STATS_SYSCTL) as sysctl:
+                previous = sysctl.read().strip()
+        users = max(users + delta, 0)
+        with open(STATS_SYSCTL, "w") as sysctl:
+            sysctl.write("1" if users > 0 else previous)
+        f.seek(0)
+        f.truncate()
+        if users > 0:
+            f.write("%d %s\n" % (users, previous))
+
+def run_time_ns():
+    # The programs loaded by the gadget are held by its file descriptors
+    total = 0
+    for fd in os.listdir("/proc/self/fd"):
+        try:
+            if os.readlink("/proc/self/fd/%s" % fd) != "anon_inode:bpf-prog":
+                continue
+            with open("/proc/self/fdinfo/%s" % fd) as f:
+                content = f.read()
+        except (IOError, OSError):
+            continue
+        for line in content.splitlines():
+            key, sep, value = line.partition(":")
+            if sep and key.strip() == "run_time_ns":
+                total += int(value.strip())
+    return total
+
+class CPUBudget(object):
+    def __init__(self, b, budget):
+        self.table = b["sampling"]
+        self.budget = budget
+        self.rate = 1
+        if not os.path.exists(STATS_SYSCTL):
+            print("--cpu-budget: the BPF statistics are not supported by this kernel, Linux 5.1 or later is required",
+                file=sys.stderr)
+            sys.exit(1)
+        try:
+            update_stats_users(1)
+        except (IOError, OSError) as e:
+            print("--cpu-budget: cannot enable the BPF statistics: %s" % e, file=sys.stderr)
+            sys.exit(1)
+        atexit.register(update_stats_users, -1)
+        self.last_run_time = run_time_ns()
+        self.last = time.time()
+
+    def poll(self):
+        now = time.time()
+        elapsed = now - self.last
+        if elapsed < INTERVAL:
+            return
+        run_time = run_time_ns()
+        share = (run_time - self.last_run_time) / (elapsed * 1e9)
+        self.last_run_time, self.last = run_time, now
+
+        rate = next_rate(self.rate, share, self.budget)
+        if rate == self.rate:
+            return
+        self.rate = rate
+        self.table[ct.c_int(0)] = ct.c_uint32(rate)
+        kept = "keeping all the events"
+        if rate > 1:
+            kept = "keeping 1 event out of %d" % rate
+        print("BPF programs ran %.2f%% of one CPU, budget %.2f%%: %s" %
+            (share * 100, self.budget * 100, kept), file=sys.stderr)
+        sys.stderr.flush()
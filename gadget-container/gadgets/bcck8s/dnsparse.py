@@ -0,0 +1,165 @@
+# dnsparse  Parse the DNS messages read by the DNS gadgets: dnsconnect, for
+#           the addresses resolved, and dnssnoop, for the header, the
+#           question and the EDNS options of each message.
+#
+# The messages may be truncated by the gadgets, that only copy their first
+# bytes: the parsers return what they could read.
+#
+# Licensed under the Apache License, Version 2.0 (the "License")
+
+from socket import inet_ntop, AF_INET, AF_INET6
+import struct
+
+DNS_TYPE_A = 1
+DNS_TYPE_AAAA = 28
+DNS_TYPE_OPT = 41
+DNS_CLASS_IN = 1
+
+TYPES = {
+    1: "A", 2: "NS", 5: "CNAME", 6: "SOA", 12: "PTR", 15: "MX", 16: "TXT",
+    28: "AAAA", 33: "SRV", 35: "NAPTR", 41: "OPT", 43: "DS", 46: "RRSIG",
+    47: "NSEC", 48: "DNSKEY", 50: "NSEC3", 52: "TLSA", 64: "SVCB",
+    65: "HTTPS", 252: "AXFR", 255: "ANY", 257: "CAA",
+}
+
+RCODES = {
+    0: "NOERROR", 1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP",
+    5: "REFUSED", 6: "YXDOMAIN", 7: "YXRRSET", 8: "NXRRSET", 9: "NOTAUTH",
+    10: "NOTZONE", 16: "BADVERS", 23: "BADCOOKIE",
+}
+
+# EDNS0 options, see the IANA "DNS EDNS0 Option Codes (OPT)" registry
+EDNS_OPTIONS = {
+    3: "nsid", 5: "dau", 6: "dhu", 7: "n3u", 8: "client-subnet",
+    9: "expire", 10: "cookie", 11: "tcp-keepalive", 12: "padding",
+    13: "chain", 14: "key-tag", 15: "extended-error",
+}
+
+# Flags of the header, from the most significant bit, after QR and OPCODE
+FLAGS = [(0x0400, "aa"), (0x0200, "tc"), (0x0100, "rd"), (0x0080, "ra"),
+    (0x0020, "ad"), (0x0010, "cd")]
+
+# DNSSEC OK, in the TTL of the OPT record
+EDNS_DO = 0x8000
+
+def dns_name(msg, offset):
+    # Returns the name at offset, following the compression pointers, and
+    # the offset after the name in the record
+    labels = []
+    end = None
+    for _ in range(128):
+        length = ord(msg[offset:offset+1])
+        if length & 0xc0 == 0xc0:
+            if end is None:
+                end = offset + 2
+            offset = ((length & 0x3f) << 8) | ord(msg[offset+1:offset+2])
+            continue
+        offset += 1
+        if length == 0:
+            break
+        labels.append(msg[offset:offset+length].decode("ascii", "replace"))
+        offset += length
+    else:
+        raise ValueError("too many labels")
+    if end is None:
+        end = offset
+    return ".".join(labels), end
+
+def parse_response(msg):
+    # Returns the name asked in a DNS response and the addresses of its A and
+    # AAAA records, or None if it is not a successful response
+    if len(msg) < 12:
+        return None
+    _, flags, qdcount, ancount = struct.unpack("!HHHH", msg[:8])
+    # QR set: a response; RCODE 0: no error
+    if not flags & 0x8000 or flags & 0xf != 0 or qdcount != 1:
+        return None
+    name, offset = dns_name(msg, 12)
+    offset += 4
+    addresses = []
+    for _ in range(ancount):
+        _, offset = dns_name(msg, offset)
+        if offset + 10 > len(msg):
+            break
+        rtype, rclass, _, rdlength = struct.unpack("!HHIH", msg[offset:offset+10])
+        offset += 10
+        rdata = msg[offset:offset+rdlength]
+        offset += rdlength
+        if len(rdata) != rdlength or rclass != DNS_CLASS_IN:
+            continue
+        if rtype == DNS_TYPE_A and rdlength == 4:
+            addresses.append(inet_ntop(AF_INET, rdata))
+        elif rtype == DNS_TYPE_AAAA and rdlength == 16:
+            addresses.append(inet_ntop(AF_INET6, rdata))
+    return name, addresses
+
+def parse_edns(rclass, ttl, rdata):
+    # Returns the fields of the OPT record, whose class is the UDP payload
+    # size of the sender and TTL the extended RCODE, the version and the flags
+    options = []
+    offset = 0
+    while offset + 4 <= len(rdata):
+        code, length = struct.unpack("!HH", rdata[offset:offset+4])
+        options.append(EDNS_OPTIONS.get(code, str(code)))
+        offset += 4 + length
+    edns = {
+        "udpsize": rclass,
+        "version": (ttl >> 16) & 0xff,
+        "do": bool(ttl & EDNS_DO),
+    }
+    if options:
+        edns["options"] = options
+    return edns
+
+def parse_message(msg, size):
+    # Returns the fields of a DNS message of size bytes, whose first bytes
+    # are msg, or None if it is not a DNS message with one question
+    if len(msg) < 12:
+        return None
+    msgid, flags, qdcount, ancount, nscount, arcount = struct.unpack("!HHHHHH", msg[:12])
+    if qdcount != 1 or (flags >> 11) & 0xf != 0:
+        # Only standard queries, with one question as all the resolvers
+        return None
+    name, offset = dns_name(msg, 12)
+    if offset + 4 > len(msg):
+        return None
+    qtype, _ = struct.unpack("!HH", msg[offset:offset+4])
+    offset += 4
+
+    result = {
+        "id": msgid,
+        "qr": "response" if flags & 0x8000 else "query",
+        "name": name,
+        "qtype": TYPES.get(qtype, str(qtype)),
+        "flags": [f for bit, f in FLAGS if flags & bit],
+        "truncated": bool(flags & 0x0200),
+        "answers": ancount,
+    }
+
+    # The OPT record is in the additional section, after all the others
+    rcode = flags & 0xf
+    complete = False
+    try:
+        for i in range(ancount + nscount + arcount):
+            _, offset = dns_name(msg, offset)
+            if offset + 10 > len(msg):
+                break
+            rtype, rclass, ttl, rdlength = struct.unpack("!HHIH", msg[offset:offset+10])
+            offset += 10
+            rdata = msg[offset:offset+rdlength]
+            offset += rdlength
+            if len(rdata) != rdlength:
+                break
+            if rtype == DNS_TYPE_OPT and i >= ancount + nscount:
+                result["edns"] = parse_edns(rclass, ttl, rdata)
+                rcode |= (ttl >> 24) << 4
+        else:
+            complete = True
+    except (ValueError, IndexError, TypeError, struct.error):
+        pass
+    if result["qr"] == "response":
+        result["rcode"] = RCODES.get(rcode, str(rcode))
+    if not complete and len(msg) < size:
+        # The records after the bytes copied by the gadget are unknown
+        result["partial"] = True
+    return result
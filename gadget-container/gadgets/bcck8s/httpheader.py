@@ -0,0 +1,34 @@
+# httpheader  Find a header in the beginning of a HTTP/1.x request, as copied
+#             by tcpping with --trace-header, like the traceparent header
+#             carrying the id of a distributed trace.
+#
+# Only the bytes of the first write or read of the request are copied: the
+# headers after them are not found, and the last line, that may be cut, is
+# ignored. HTTP/2 and TLS are not parsed.
+#
+# Licensed under the Apache License, Version 2.0 (the "License")
+
+METHODS = (b"GET", b"HEAD", b"POST", b"PUT", b"DELETE", b"CONNECT",
+    b"OPTIONS", b"TRACE", b"PATCH")
+
+def header_value(head, name):
+    """Returns the value of the header name, in lower case, in the request
+    starting with head, or None when head doesn't start a HTTP/1.x request
+    or the header is not in it."""
+    end = head.find(b"\r\n\r\n")
+    if end != -1:
+        lines = head[:end].split(b"\r\n")
+    else:
+        # The last line may be cut
+        lines = head.split(b"\r\n")[:-1]
+    if not lines:
+        return None
+    request = lines[0].split(b" ")
+    if len(request) != 3 or request[0] not in METHODS or \
+            not request[2].startswith(b"HTTP/1."):
+        return None
+    prefix = name.encode("ascii") + b":"
+    for line in lines[1:]:
+        if line.lower().startswith(prefix):
+            return line[len(prefix):].strip().decode("latin-1")
+    return None
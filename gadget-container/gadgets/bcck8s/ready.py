@@ -0,0 +1,21 @@
+# ready  Tell kubectl-gadget that a gadget is capturing.
+#
+# The gadgets printing events call signal() once their BPF programs are
+# attached and their perf buffers opened, before the first poll: the events
+# happening after the record is printed are captured. The record is printed
+# on stdout, before the events, so that both are received in order.
+# kubectl-gadget does not print it: it prints its own ready signal once the
+# gadgets of all the nodes are ready.
+#
+# Licensed under the Apache License, Version 2.0 (the "License")
+
+from __future__ import print_function
+import json
+import sys
+
+RECORD = {"type": "ready"}
+
+
+def signal():
+    print(json.dumps(RECORD))
+    sys.stdout.flush()
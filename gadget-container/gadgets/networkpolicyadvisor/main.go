@@ -17,16 +17,18 @@ import (
 
 	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/networkpolicy/types"
 	"github.com/kinvolk/inspektor-gadget/pkg/k8sutil"
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
 )
 
 var (
 	namespaceList string
 	namespaceSet  map[string]struct{}
+	allowlist     nsallowlist.Allowlist
 	kubeconfig    string
 )
 
 func init() {
-	flag.StringVar(&namespaceList, "namespace", "", "comma-separated list of namespaces")
+	flag.StringVar(&namespaceList, "namespace", "", "comma-separated list of namespaces, all the allowed namespaces if empty")
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "path to a kubeconfig")
 }
 
@@ -45,6 +47,16 @@ func (t *tcpEventTracer) TCPEventV4(e tracer.TcpV4) {
 	t.queue <- e
 }
 
+// monitored returns whether the connections of the pods of a namespace are
+// recorded
+func monitored(namespace string) bool {
+	if len(namespaceSet) == 0 {
+		return allowlist.Allowed(namespace)
+	}
+	_, ok := namespaceSet[namespace]
+	return ok
+}
+
 func (t *tcpEventTracer) handleEvent(e tracer.TcpV4, pods *corev1.PodList, svcs *corev1.ServiceList) {
 	var event types.KubernetesConnectionEvent
 	event.Type = e.Type.String()
@@ -60,14 +72,15 @@ func (t *tcpEventTracer) handleEvent(e tracer.TcpV4, pods *corev1.PodList, svcs
 	localPodIndex := -1
 	for i, pod := range pods.Items {
 		if pod.Status.PodIP == e.SAddr.String() {
-			if _, ok := namespaceSet[pod.Namespace]; ok {
+			if monitored(pod.Namespace) {
 				localPodIndex = i
 				event.LocalPodNamespace = pod.Namespace
 				event.LocalPodName = pod.Name
 				event.LocalPodLabels = pod.Labels
 			}
 		}
-		if pod.Status.PodIP == e.DAddr.String() {
+		// Pods outside of the allowed namespaces are only seen as IPs
+		if pod.Status.PodIP == e.DAddr.String() && allowlist.Allowed(pod.Namespace) {
 			event.RemoteKind = "pod"
 			event.RemotePodNamespace = pod.Namespace
 			event.RemotePodName = pod.Name
@@ -90,7 +103,7 @@ func (t *tcpEventTracer) handleEvent(e tracer.TcpV4, pods *corev1.PodList, svcs
 
 	if event.RemoteKind == "" {
 		for _, svc := range svcs.Items {
-			if svc.Spec.ClusterIP == e.DAddr.String() {
+			if svc.Spec.ClusterIP == e.DAddr.String() && allowlist.Allowed(svc.Namespace) {
 				event.RemoteKind = "svc"
 				event.RemoteSvcNamespace = svc.Namespace
 				event.RemoteSvcName = svc.Name
@@ -136,9 +149,16 @@ func main() {
 		flag.PrintDefaults()
 		panic(fmt.Errorf("invalid command"))
 	}
+	allowlist = nsallowlist.FromEnv()
 	namespaceSet = make(map[string]struct{})
-	for _, item := range strings.Split(namespaceList, ",") {
-		namespaceSet[item] = struct{}{}
+	if namespaceList != "" {
+		for _, item := range strings.Split(namespaceList, ",") {
+			if err := allowlist.Check(item); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			namespaceSet[item] = struct{}{}
+		}
 	}
 
 	// Connect to the API server
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+
+	"github.com/iovisor/gobpf/elf"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/rungadget"
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
+	"github.com/kinvolk/inspektor-gadget/pkg/perfbuffer"
+)
+
+var (
+	objectPath      string
+	metaPath        string
+	jsonOutput      bool
+	perfBufferPages int
+)
+
+func init() {
+	flag.StringVar(&objectPath, "object", "", "path to the BPF object file of the gadget")
+	flag.StringVar(&metaPath, "meta", "", "path to the metadata of the gadget")
+	flag.BoolVar(&jsonOutput, "json", false, "output events in JSON, one per line")
+	flag.IntVar(&perfBufferPages, "perf-buffer-pages", perfbuffer.DefaultPages, "size of the perf buffer of each CPU, in pages (a power of 2)")
+}
+
+func fatalf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(1)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() > 0 || objectPath == "" || metaPath == "" {
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if err := perfbuffer.CheckPages(perfBufferPages); err != nil {
+		fatalf("Invalid --perf-buffer-pages: %s", err)
+	}
+
+	// External gadgets trace the whole node
+	if allowlist := nsallowlist.FromEnv(); allowlist.Enabled() {
+		fatalf("run-gadget is not available: Inspektor Gadget was deployed with --allowed-namespaces=%s", allowlist)
+	}
+
+	metaBytes, err := ioutil.ReadFile(metaPath)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	meta, err := rungadget.ParseMetadata(metaBytes)
+	if err != nil {
+		fatalf("%s", err)
+	}
+
+	// kubectl-gadget already validated the object file but the check is
+	// cheap and the gadget pod is the one running the programs
+	f, err := os.Open(objectPath)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		fatalf("%s", err)
+	}
+	if err := rungadget.ValidateObject(f, fi.Size(), meta); err != nil {
+		fatalf("%s", err)
+	}
+
+	module := elf.NewModuleFromReader(f)
+	params := map[string]elf.SectionParams{
+		"maps/" + meta.PerfMap: {PerfRingBufferPageCount: perfBufferPages},
+	}
+	if err := module.Load(params); err != nil {
+		fatalf("Error loading %q: %s\n%s", meta.Name, err, module.Log())
+	}
+	defer module.Close()
+
+	if err := module.EnableKprobes(0); err != nil {
+		fatalf("Error enabling kprobes: %s", err)
+	}
+	for tp := range module.IterTracepointProgram() {
+		if err := module.EnableTracepoint(tp.Name); err != nil {
+			fatalf("Error enabling tracepoint %q: %s", tp.Name, err)
+		}
+	}
+
+	events := make(chan []byte)
+	lost := make(chan uint64)
+	perfMap, err := elf.InitPerfMap(module, meta.PerfMap, events, lost)
+	if err != nil {
+		fatalf("Error initializing perf map %q: %s", meta.PerfMap, err)
+	}
+
+	if !jsonOutput {
+		fmt.Println(meta.Header())
+	}
+
+	go func() {
+		for {
+			select {
+			case data, ok := <-events:
+				if !ok {
+					return
+				}
+				values, err := meta.Decode(data)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+					continue
+				}
+				if jsonOutput {
+					out, err := rungadget.FormatJSON(values)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+						continue
+					}
+					fmt.Println(out)
+				} else {
+					fmt.Println(rungadget.FormatText(values))
+				}
+			case count := <-lost:
+				fmt.Fprintf(os.Stderr, "ERROR: lost %d events!\n", count)
+			}
+		}
+	}()
+
+	perfMap.PollStart()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, os.Kill)
+	<-sig
+
+	perfMap.PollStop()
+}
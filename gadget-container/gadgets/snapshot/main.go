@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/snapshot"
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
+)
+
+var (
+	resource string
+	procRoot string
+)
+
+func init() {
+	flag.StringVar(&resource, "resource", "", fmt.Sprintf("resource to collect, one of %s", strings.Join(snapshot.Resources, ", ")))
+	flag.StringVar(&procRoot, "proc", "/proc", "path to the /proc of the host")
+}
+
+func fatalf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(1)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() > 0 || resource == "" {
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	// The snapshots cover the whole node, the containers are only known by
+	// kubectl-gadget
+	if allowlist := nsallowlist.FromEnv(); allowlist.Enabled() {
+		fatalf("snapshot is not available: Inspektor Gadget was deployed with --allowed-namespaces=%s", allowlist)
+	}
+
+	entries, err := snapshot.Collect(procRoot, resource)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			fatalf("%s", err)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/weaveworks/tcptracer-bpf/pkg/tracer"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcptracer/types"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/containerutils"
+	"github.com/kinvolk/inspektor-gadget/pkg/k8sutil"
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
+	"github.com/kinvolk/inspektor-gadget/pkg/podinformer"
+	"github.com/kinvolk/inspektor-gadget/pkg/procage"
+)
+
+var (
+	namespaceList string
+	namespaceSet  map[string]struct{}
+	allowlist     nsallowlist.Allowlist
+	podname       string
+	podUID        string
+	label         string
+	labelSet      map[string]string
+	jsonOutput    bool
+	podStatus     bool
+	showAge       bool
+	showCgroup    bool
+	kubeconfig    string
+	includeSelf   bool
+)
+
+func init() {
+	flag.StringVar(&namespaceList, "namespace", "", "comma-separated list of namespaces, all namespaces if empty")
+	flag.StringVar(&podname, "podname", "", "only trace this pod")
+	flag.StringVar(&podUID, "poduid", "", "only trace the pod with this uid")
+	flag.StringVar(&label, "label", "", "key=value,key=value labels the pods must have")
+	flag.BoolVar(&jsonOutput, "json", false, "output events in JSON, one per line")
+	flag.BoolVar(&podStatus, "podstatus", false, "add the pod phase and the container readiness to events")
+	flag.BoolVar(&showAge, "showage", false, "add the start time and the age of the process to events")
+	flag.BoolVar(&showCgroup, "showcgroup", false, "add the cgroup path of the process to events")
+	flag.StringVar(&kubeconfig, "kubeconfig", "", "path to a kubeconfig")
+	flag.BoolVar(&includeSelf, "includeself", false, "also trace the container of the gadget")
+}
+
+// tcpEvent is the common part of tracer.TcpV4 and tracer.TcpV6
+type tcpEvent struct {
+	Type      tracer.EventType
+	Pid       uint32
+	Comm      string
+	IPVersion int
+	SAddr     net.IP
+	DAddr     net.IP
+	SPort     uint16
+	DPort     uint16
+}
+
+type tcpEventTracer struct {
+	queue      chan tcpEvent
+	node       string
+	containers *containercache.Cache
+	pods       *podinformer.Store // only with --podstatus
+	directions *types.Directions
+
+	// The connections of the gadget itself are skipped, unless
+	// --includeself is set
+	self *containerutils.SelfCgroup
+}
+
+func (t *tcpEventTracer) TCPEventV4(e tracer.TcpV4) {
+	if e.Type == tracer.EventFdInstall {
+		return
+	}
+	t.queue <- tcpEvent{e.Type, e.Pid, e.Comm, 4, e.SAddr, e.DAddr, e.SPort, e.DPort}
+}
+
+func (t *tcpEventTracer) TCPEventV6(e tracer.TcpV6) {
+	if e.Type == tracer.EventFdInstall {
+		return
+	}
+	t.queue <- tcpEvent{e.Type, e.Pid, e.Comm, 6, e.SAddr, e.DAddr, e.SPort, e.DPort}
+}
+
+func (t *tcpEventTracer) LostV4(count uint64) {
+	fmt.Fprintf(os.Stderr, "ERROR: lost %d events!\n", count)
+}
+
+func (t *tcpEventTracer) LostV6(count uint64) {
+	fmt.Fprintf(os.Stderr, "ERROR: lost %d events!\n", count)
+}
+
+func podSelected(m *containercache.Metadata) bool {
+	if !allowlist.Allowed(m.Namespace) {
+		return false
+	}
+	if len(namespaceSet) != 0 {
+		if _, ok := namespaceSet[m.Namespace]; !ok {
+			return false
+		}
+	}
+	if podname != "" && m.Pod != podname {
+		return false
+	}
+	if podUID != "" && m.PodUID != podUID {
+		return false
+	}
+	for k, v := range labelSet {
+		if m.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupContainer returns a containercache.LookupFunc finding the container
+// of a cgroup among the pods of the node, by looking for the container id in
+// the cgroup path.
+func lookupContainer(clientset *kubernetes.Clientset, node string) containercache.LookupFunc {
+	return func(cgroupPath string) (*containercache.Metadata, error) {
+		pods, err := clientset.CoreV1().Pods("").List(metav1.ListOptions{
+			FieldSelector: "spec.nodeName=" + node,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			for _, s := range pod.Status.ContainerStatuses {
+				id := s.ContainerID
+				id = strings.TrimPrefix(id, "docker://")
+				id = strings.TrimPrefix(id, "cri-o://")
+				if id == "" {
+					continue
+				}
+				if strings.Contains(cgroupPath, id) {
+					return &containercache.Metadata{
+						Namespace: pod.Namespace,
+						Pod:       pod.Name,
+						PodUID:    string(pod.UID),
+						Labels:    pod.Labels,
+						Container: s.Name,
+					}, nil
+				}
+			}
+		}
+		return nil, nil
+	}
+}
+
+func (t *tcpEventTracer) handleEvent(e tcpEvent) {
+	// Before the lookup of the process, that can exit before the close
+	// of its connections
+	direction := t.directions.Direction(e.Type.String(), types.Connection{
+		IPVersion: e.IPVersion,
+		Saddr:     e.SAddr.String(),
+		Sport:     e.SPort,
+		Daddr:     e.DAddr.String(),
+		Dport:     e.DPort,
+	})
+
+	// Before the lookup of the container too, that can take a call to
+	// the API server
+	var age *time.Duration
+	var start time.Time
+	if showAge {
+		// The process might be gone already, like for the close of
+		// its connections at its exit
+		if d, s, err := procage.Get(int(e.Pid), time.Now()); err == nil {
+			age, start = &d, s
+		}
+	}
+
+	cgroupPathV1, cgroupPathV2, err := containerutils.GetCgroupPaths(int(e.Pid))
+	if err != nil {
+		// The process might be gone already
+		return
+	}
+	if t.self != nil && t.self.Matches(cgroupPathV1, cgroupPathV2) {
+		return
+	}
+	// Both paths are used as key: depending on the cgroup mode, one of
+	// them can be empty or "/"
+	m, err := t.containers.Get(cgroupPathV1 + ":" + cgroupPathV2)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return
+	}
+	if m == nil || !podSelected(m) {
+		return
+	}
+
+	event := t.newEvent(e, direction, m, cgroupPathV1, cgroupPathV2)
+	if age != nil {
+		ns := int64(*age)
+		event.ProcessStart = start.UTC().Format(time.RFC3339)
+		event.ProcessAgeNs = &ns
+	}
+
+	if jsonOutput {
+		buf, err := json.Marshal(event)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return
+		}
+		fmt.Printf("%s\n", string(buf))
+		return
+	}
+
+	status := ""
+	if podStatus {
+		ready := "-"
+		if event.ContainerReady != nil {
+			ready = fmt.Sprintf("%t", *event.ContainerReady)
+		}
+		status = fmt.Sprintf("%-9s %-5s ", event.PodPhase, ready)
+	}
+	if showAge {
+		processAge := "-"
+		if event.ProcessAgeNs != nil {
+			processAge = time.Duration(*event.ProcessAgeNs).Round(10 * time.Millisecond).String()
+		}
+		status += fmt.Sprintf("%-12s ", processAge)
+	}
+	direction = event.Direction
+	if direction == "" {
+		direction = "-"
+	}
+	pod := fmt.Sprintf("%s/%s/%s", event.Namespace, event.Pod, event.Container)
+	if showCgroup {
+		pod = fmt.Sprintf("%-40s %s", pod, event.CgroupPath)
+	}
+	fmt.Printf("%-7s %-8s %-6d %-16s %-2d %-16s %-16s %-6d %-6d %s%s\n",
+		event.Type, direction, event.Pid, event.Comm, event.IPVersion,
+		event.Saddr, event.Daddr, event.Sport, event.Dport,
+		status, pod)
+}
+
+// newEvent returns the event of e, made by a process of the container m
+// with the cgroup paths returned by containerutils.GetCgroupPaths
+func (t *tcpEventTracer) newEvent(e tcpEvent, direction string, m *containercache.Metadata, cgroupPathV1, cgroupPathV2 string) types.Event {
+	event := types.Event{
+		Type:      e.Type.String(),
+		Direction: direction,
+		Node:      t.node,
+		Namespace: m.Namespace,
+		Pod:       m.Pod,
+		Container: m.Container,
+		Pid:       e.Pid,
+		Comm:      e.Comm,
+		IPVersion: e.IPVersion,
+		Saddr:     e.SAddr.String(),
+		Sport:     e.SPort,
+		Daddr:     e.DAddr.String(),
+		Dport:     e.DPort,
+	}
+	if t.pods != nil {
+		if status, ok := t.pods.Status(m.Namespace, m.Pod, m.Container); ok {
+			event.PodPhase = status.Phase
+			event.ContainerReady = &status.Ready
+		}
+	}
+	if showCgroup {
+		event.CgroupPath = containerutils.CgroupPath(cgroupPathV1, cgroupPathV2)
+	}
+	return event
+}
+
+func main() {
+	// Parse arguments
+	flag.Parse()
+	if flag.NArg() > 0 {
+		flag.PrintDefaults()
+		panic(fmt.Errorf("invalid command"))
+	}
+	allowlist = nsallowlist.FromEnv()
+	namespaceSet = make(map[string]struct{})
+	if namespaceList != "" {
+		for _, item := range strings.Split(namespaceList, ",") {
+			if err := allowlist.Check(item); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			namespaceSet[item] = struct{}{}
+		}
+	}
+	labelSet = make(map[string]string)
+	if label != "" {
+		for _, pair := range strings.Split(label, ",") {
+			kv := strings.Split(pair, "=")
+			if len(kv) != 2 {
+				fmt.Fprintf(os.Stderr, "invalid key=value[,key=value,...] %q\n", label)
+				os.Exit(1)
+			}
+			labelSet[kv[0]] = kv[1]
+		}
+	}
+
+	// Connect to the API server
+	clientset, err := k8sutil.NewClientset(kubeconfig)
+	if err != nil {
+		panic(err)
+	}
+
+	// Start the BPF tracer
+	node := os.Getenv("NODE_NAME")
+	mytracer := &tcpEventTracer{
+		queue:      make(chan tcpEvent, 500),
+		node:       node,
+		directions: types.NewDirections(),
+		containers: containercache.New(lookupContainer(clientset, node), containercache.DefaultConfig),
+	}
+	if !includeSelf {
+		self, err := containerutils.GetSelfCgroup()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot get the cgroup of the gadget, its connections will be traced: %v\n", err)
+		} else {
+			mytracer.self = &self
+		}
+	}
+	if podStatus {
+		stop := make(chan struct{})
+		defer close(stop)
+		mytracer.pods, err = podinformer.NewStore(clientset, "", node, stop)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+	t, err := tracer.NewTracer(mytracer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if !jsonOutput {
+		status := ""
+		if podStatus {
+			status = fmt.Sprintf("%-9s %-5s ", "PHASE", "READY")
+		}
+		if showAge {
+			status += fmt.Sprintf("%-12s ", "AGE")
+		}
+		pod := "POD"
+		if showCgroup {
+			pod = fmt.Sprintf("%-40s %s", pod, "CGROUP")
+		}
+		fmt.Printf("%-7s %-8s %-6s %-16s %-2s %-16s %-16s %-6s %-6s %s%s\n",
+			"T", "DIR", "PID", "COMM", "IP", "SADDR", "DADDR", "SPORT", "DPORT", status, pod)
+	}
+
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case e := <-mytracer.queue:
+				mytracer.handleEvent(e)
+			}
+		}
+	}()
+
+	t.Start()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, os.Kill)
+
+	<-sig
+
+	t.Stop()
+	done <- true
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/weaveworks/tcptracer-bpf/pkg/tracer"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/containercache"
+)
+
+func TestNewEventCgroupPath(t *testing.T) {
+	tr := &tcpEventTracer{node: "ip-10-0-30-247"}
+	e := tcpEvent{tracer.EventConnect, 19223, "wget", 4, net.ParseIP("10.2.232.47"), net.ParseIP("10.2.232.1"), 45866, 80}
+	m := &containercache.Metadata{Namespace: "demo", Pod: "mypod", Container: "mypod"}
+	cgroupPath := "/kubepods/burstable/pod3c5c1d26-7e5a-4f2b-9d0e-2a1c7bb0f9a4/5c8a1e3f"
+
+	event := tr.newEvent(e, "outbound", m, cgroupPath, "")
+	if event.CgroupPath != "" {
+		t.Errorf("cgroup path %q without --showcgroup", event.CgroupPath)
+	}
+
+	showCgroup = true
+	defer func() { showCgroup = false }()
+	event = tr.newEvent(e, "outbound", m, cgroupPath, "")
+	if event.Pod != "mypod" || event.CgroupPath != cgroupPath {
+		t.Errorf("unexpected pod %q and cgroup path %q", event.Pod, event.CgroupPath)
+	}
+
+	// On hosts with only the unified hierarchy
+	cgroupPath = "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod3c5c1d26_7e5a_4f2b_9d0e_2a1c7bb0f9a4.slice/cri-containerd-5c8a1e3f.scope"
+	event = tr.newEvent(e, "outbound", m, "", cgroupPath)
+	if event.CgroupPath != cgroupPath {
+		t.Errorf("unexpected cgroup path %q", event.CgroupPath)
+	}
+}
@@ -2,24 +2,31 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
 
+	"github.com/kinvolk/inspektor-gadget/pkg/bpfmaps"
 	"github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager"
 	pb "github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/api"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/containerutils"
 	"github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/initialcontainers"
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
 )
 
 var (
 	serve          bool
 	dump           bool
+	dumpBPFMaps    bool
 	socketfile     string
 	method         string
 	label          string
@@ -29,9 +36,16 @@ var (
 	cgroupId       uint64
 	namespace      string
 	podname        string
+	podUID         string
 	containerIndex int
+	includeSelf    bool
+	printVersion   bool
 )
 
+// version is the version of the gadget image, set during build. It is
+// printed with -version, for "kubectl gadget version".
+var version = "undefined"
+
 func init() {
 	flag.StringVar(&socketfile, "socketfile", "/run/gadgettracermanager.socket", "Socket file")
 
@@ -45,9 +59,14 @@ func init() {
 	flag.Uint64Var(&cgroupId, "cgroupid", 0, "cgroup id to use in add-container")
 	flag.StringVar(&namespace, "namespace", "", "namespace to use in add-container")
 	flag.StringVar(&podname, "podname", "", "podname to use in add-container")
+	flag.StringVar(&podUID, "poduid", "", "pod uid to use in add-tracer or add-container")
 	flag.IntVar(&containerIndex, "containerindex", -1, "container index to use in add-container")
+	flag.BoolVar(&includeSelf, "includeself", false, "also trace the container of the gadget in add-tracer")
 
 	flag.BoolVar(&dump, "dump", false, "Dump state for debugging")
+	flag.BoolVar(&dumpBPFMaps, "dump-bpf-maps", false, "Dump the BPF maps of the gadget pod in JSON for debugging")
+
+	flag.BoolVar(&printVersion, "version", false, "Print the version of the gadget image")
 }
 
 func main() {
@@ -59,6 +78,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	if printVersion {
+		fmt.Println(version)
+		os.Exit(0)
+	}
+
+	if dumpBPFMaps {
+		maps, err := bpfmaps.Collect(bpfmaps.NewProcInventory("/proc"))
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		out, err := json.MarshalIndent(maps, "", "  ")
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Println(string(out))
+		os.Exit(0)
+	}
+
 	labels := []*pb.Label{}
 	if label != "" {
 		pairs := strings.Split(label, ",")
@@ -100,6 +137,8 @@ func main() {
 				Podname:        podname,
 				Labels:         labels,
 				ContainerIndex: int32(containerIndex),
+				PodUid:         podUID,
+				IncludeSelf:    includeSelf,
 			},
 		})
 		if err != nil {
@@ -126,6 +165,7 @@ func main() {
 			Podname:        podname,
 			ContainerIndex: int32(containerIndex),
 			Labels:         labels,
+			PodUid:         podUID,
 		})
 		if err != nil {
 			log.Fatalf("%v", err)
@@ -164,13 +204,47 @@ func main() {
 
 		var opts []grpc.ServerOption
 		grpcServer := grpc.NewServer(opts...)
+		cgroupMode, err := containerutils.GetCgroupMode()
+		if err != nil {
+			log.Printf("gadgettracermanager failed to detect cgroup mode: %v", err)
+		} else {
+			log.Printf("gadgettracermanager detected cgroup mode: %s", cgroupMode)
+		}
 		containers, err := initialcontainers.InitialContainers()
 		if err != nil {
 			log.Printf("gadgettracermanager failed to get initial containers: %v", err)
 		} else {
 			log.Printf("gadgettracermanager found %d initial containers: %+v", len(containers), containers)
 		}
-		pb.RegisterGadgetTracerManagerServer(grpcServer, gadgettracermanager.NewServer(containers))
-		grpcServer.Serve(lis)
+		allowlist := nsallowlist.FromEnv()
+		if allowlist.Enabled() {
+			log.Printf("gadgettracermanager only traces the namespaces %s", allowlist)
+		}
+		self, err := containerutils.GetSelfCgroup()
+		if err != nil {
+			log.Printf("gadgettracermanager failed to get its own cgroup, its container will be traced: %v", err)
+		} else {
+			log.Printf("gadgettracermanager excludes its own cgroup: %+v", self)
+		}
+		server := gadgettracermanager.NewServer(containers, allowlist, self)
+		pb.RegisterGadgetTracerManagerServer(grpcServer, server)
+
+		// When the gadget pod terminates, finish the pending requests
+		// and remove the maps of the tracers from the bpffs of the node
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+		go func() {
+			sig := <-sigs
+			log.Printf("gadgettracermanager received %s, stopping", sig)
+			grpcServer.GracefulStop()
+		}()
+
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Printf("gadgettracermanager failed to serve: %v", err)
+		}
+		if err := server.Close(); err != nil {
+			log.Printf("gadgettracermanager failed to remove the tracers: %v", err)
+		}
+		os.Remove(socketfile)
 	}
 }
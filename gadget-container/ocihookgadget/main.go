@@ -168,6 +168,7 @@ func main() {
 	}
 	namespace := ""
 	podname := ""
+	podUID := ""
 	containerIndex := -1
 	labels := []*pb.Label{}
 	for _, p := range pods.Items {
@@ -184,6 +185,7 @@ func main() {
 		}
 		namespace = p.ObjectMeta.Namespace
 		podname = p.ObjectMeta.Name
+		podUID = uid
 
 		for k, v := range p.ObjectMeta.Labels {
 			labels = append(labels, &pb.Label{Key: k, Value: v})
@@ -209,6 +211,7 @@ func main() {
 		Podname:        podname,
 		ContainerIndex: int32(containerIndex),
 		Labels:         labels,
+		PodUid:         podUID,
 	})
 	if err != nil {
 		panic(err)
@@ -0,0 +1,156 @@
+// Package bpfmaps lists the BPF maps held by the processes of the gadget pod,
+// like "bpftool map", for debugging the gadgets. It implements the
+// -dump-bpf-maps option of the gadget tracer manager.
+package bpfmaps
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Map is a BPF map held by the processes of the gadget pod
+type Map struct {
+	ID         uint32 `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	KeySize    uint32 `json:"key_size"`
+	ValueSize  uint32 `json:"value_size"`
+	MaxEntries uint32 `json:"max_entries"`
+	Flags      uint32 `json:"flags"`
+
+	/* Number of keys, -1 when they can't be counted, like for the
+	 * queues, Error tells why */
+	Entries int `json:"entries"`
+
+	/* Processes holding the map, and the gadgets they run, like
+	 * execsnoop */
+	Pids    []int    `json:"pids"`
+	Gadgets []string `json:"gadgets"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Info is the description of a map given by the kernel
+type Info struct {
+	Name       string
+	Type       uint32
+	KeySize    uint32
+	ValueSize  uint32
+	MaxEntries uint32
+	Flags      uint32
+}
+
+// Inventory gives the BPF maps of the gadget pod, see NewProcInventory
+type Inventory interface {
+	// Holders returns the ids of the maps held by each process
+	Holders() (map[int][]uint32, error)
+	// Command returns the command line of the process pid, nil if it
+	// exited
+	Command(pid int) []string
+	// Info returns the description of the map id
+	Info(id uint32) (Info, error)
+	// Count returns the number of keys of the map id, at most max
+	Count(id uint32, max uint32) (int, error)
+}
+
+// mapTypes are the names of the map types, from enum bpf_map_type
+var mapTypes = []string{
+	"unspec", "hash", "array", "prog_array", "perf_event_array",
+	"percpu_hash", "percpu_array", "stack_trace", "cgroup_array", "lru_hash",
+	"lru_percpu_hash", "lpm_trie", "array_of_maps", "hash_of_maps", "devmap",
+	"sockmap", "cpumap", "xskmap", "sockhash", "cgroup_storage",
+	"reuseport_sockarray", "percpu_cgroup_storage", "queue", "stack",
+	"sk_storage", "devmap_hash", "struct_ops", "ringbuf", "inode_storage",
+	"task_storage",
+}
+
+// TypeName returns the name of a map type, like hash, or its number when
+// unknown
+func TypeName(t uint32) string {
+	if int(t) < len(mapTypes) {
+		return mapTypes[t]
+	}
+	return strconv.FormatUint(uint64(t), 10)
+}
+
+// GadgetName returns the gadget run by a process of the gadget pod, from its
+// command line: the script for the gadgets written in Python, like
+// /usr/bin/python /opt/bcck8s/execsnoop, or else the program
+func GadgetName(argv []string) string {
+	if len(argv) == 0 || argv[0] == "" {
+		return ""
+	}
+	if strings.Contains(filepath.Base(argv[0]), "python") && len(argv) > 1 && argv[1] != "" {
+		return filepath.Base(argv[1])
+	}
+	return filepath.Base(argv[0])
+}
+
+// Collect returns the maps of the inventory sorted by id. A map that can't
+// be described anymore, usually because all its holders closed it since, is
+// returned with its Error.
+func Collect(inv Inventory) ([]Map, error) {
+	holders, err := inv.Holders()
+	if err != nil {
+		return nil, err
+	}
+	pids := make([]int, 0, len(holders))
+	for pid := range holders {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+
+	maps := map[uint32]*Map{}
+	for _, pid := range pids {
+		gadget := GadgetName(inv.Command(pid))
+		for _, id := range holders[pid] {
+			m, ok := maps[id]
+			if !ok {
+				m = &Map{ID: id, Pids: []int{}, Gadgets: []string{}}
+				maps[id] = m
+			}
+			if len(m.Pids) == 0 || m.Pids[len(m.Pids)-1] != pid {
+				m.Pids = append(m.Pids, pid)
+			}
+			if gadget != "" && !contains(m.Gadgets, gadget) {
+				m.Gadgets = append(m.Gadgets, gadget)
+			}
+		}
+	}
+
+	result := make([]Map, 0, len(maps))
+	for id, m := range maps {
+		info, err := inv.Info(id)
+		if err != nil {
+			m.Entries = -1
+			m.Error = err.Error()
+			result = append(result, *m)
+			continue
+		}
+		m.Name = info.Name
+		m.Type = TypeName(info.Type)
+		m.KeySize = info.KeySize
+		m.ValueSize = info.ValueSize
+		m.MaxEntries = info.MaxEntries
+		m.Flags = info.Flags
+		m.Entries, err = inv.Count(id, info.MaxEntries)
+		if err != nil {
+			m.Entries = -1
+			m.Error = err.Error()
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
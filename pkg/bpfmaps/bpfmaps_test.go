@@ -0,0 +1,110 @@
+package bpfmaps
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeInventory is the inventory of a gadget pod running execsnoop and
+// opensnoop, sharing a map, with a map closed since listed and a map that
+// can't be iterated
+type fakeInventory struct{}
+
+func (fakeInventory) Holders() (map[int][]uint32, error) {
+	return map[int][]uint32{
+		// Listed in the fds of a process, and closed since
+		12: {42, 7, 9},
+		10: {7, 3, 3},
+		11: {5},
+	}, nil
+}
+
+func (fakeInventory) Command(pid int) []string {
+	switch pid {
+	case 10:
+		return []string{"/usr/bin/python", "/opt/bcck8s/execsnoop", "--json"}
+	case 11:
+		return []string{"/bin/gadgettracermanager", "-serve"}
+	}
+	return nil
+}
+
+func (fakeInventory) Info(id uint32) (Info, error) {
+	switch id {
+	case 3:
+		return Info{Name: "events", Type: 4, KeySize: 4, ValueSize: 4, MaxEntries: 2}, nil
+	case 5:
+		return Info{Name: "containers", Type: 1, KeySize: 64, ValueSize: 1, MaxEntries: 1024, Flags: 1}, nil
+	case 7:
+		return Info{Name: "mntns_set", Type: 1, KeySize: 8, ValueSize: 1, MaxEntries: 1024}, nil
+	case 9:
+		return Info{Name: "queue", Type: 22, ValueSize: 8, MaxEntries: 16}, nil
+	}
+	return Info{}, errors.New("cannot open map 42: no such file or directory")
+}
+
+func (fakeInventory) Count(id uint32, max uint32) (int, error) {
+	switch id {
+	case 3:
+		return 2, nil
+	case 5:
+		return 12, nil
+	case 7:
+		return 3, nil
+	}
+	return 0, errors.New("cannot iterate over map 9: invalid argument")
+}
+
+func TestCollect(t *testing.T) {
+	maps, err := Collect(fakeInventory{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := []Map{
+		{ID: 3, Name: "events", Type: "perf_event_array", KeySize: 4, ValueSize: 4, MaxEntries: 2, Entries: 2, Pids: []int{10}, Gadgets: []string{"execsnoop"}},
+		{ID: 5, Name: "containers", Type: "hash", KeySize: 64, ValueSize: 1, MaxEntries: 1024, Flags: 1, Entries: 12, Pids: []int{11}, Gadgets: []string{"gadgettracermanager"}},
+		{ID: 7, Name: "mntns_set", Type: "hash", KeySize: 8, ValueSize: 1, MaxEntries: 1024, Entries: 3, Pids: []int{10, 12}, Gadgets: []string{"execsnoop"}},
+		{ID: 9, Name: "queue", Type: "queue", ValueSize: 8, MaxEntries: 16, Entries: -1, Pids: []int{12}, Gadgets: []string{}, Error: "cannot iterate over map 9: invalid argument"},
+		{ID: 42, Entries: -1, Pids: []int{12}, Gadgets: []string{}, Error: "cannot open map 42: no such file or directory"},
+	}
+	if !reflect.DeepEqual(maps, expected) {
+		t.Fatalf("%+v != %+v", maps, expected)
+	}
+
+	out, err := json.Marshal(maps[0])
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if s := `{"id":3,"name":"events","type":"perf_event_array","key_size":4,"value_size":4,"max_entries":2,"flags":0,"entries":2,"pids":[10],"gadgets":["execsnoop"]}`; string(out) != s {
+		t.Fatalf("%s != %s", out, s)
+	}
+}
+
+func TestGadgetName(t *testing.T) {
+	table := []struct {
+		argv     []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"/usr/bin/python", "/opt/bcck8s/biosnoop"}, "biosnoop"},
+		{[]string{"/usr/bin/python3", "/opt/bcck8s/netqtop", "--interval", "1"}, "netqtop"},
+		{[]string{"/usr/bin/python"}, "python"},
+		{[]string{"/bin/traceloop", "k8s"}, "traceloop"},
+	}
+	for _, entry := range table {
+		if name := GadgetName(entry.argv); name != entry.expected {
+			t.Fatalf("GadgetName(%q) = %q, expected %q", entry.argv, name, entry.expected)
+		}
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	if name := TypeName(9); name != "lru_hash" {
+		t.Fatalf("unexpected %q", name)
+	}
+	if name := TypeName(1000); name != "1000" {
+		t.Fatalf("unexpected %q", name)
+	}
+}
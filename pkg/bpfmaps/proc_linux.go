@@ -0,0 +1,224 @@
+package bpfmaps
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// sysBPF are the numbers of the bpf() syscall, missing from the syscall
+// package on some architectures
+var sysBPF = map[string]uintptr{
+	"amd64":   321,
+	"arm64":   280,
+	"ppc64le": 361,
+	"s390x":   351,
+}
+
+// Commands of the bpf() syscall, from enum bpf_cmd
+const (
+	bpfMapGetNextKey    = 4
+	bpfMapGetFdByID     = 14
+	bpfObjGetInfoByFd   = 15
+	bpfObjNameLen       = 16
+	bpfMapFdLinkPattern = "anon_inode:bpf-map"
+)
+
+type getFdByIDAttr struct {
+	id        uint32
+	nextID    uint32
+	openFlags uint32
+}
+
+type getInfoAttr struct {
+	fd      uint32
+	infoLen uint32
+	info    uint64
+}
+
+type getNextKeyAttr struct {
+	fd      uint32
+	_       uint32
+	key     uint64
+	nextKey uint64
+}
+
+// mapInfo is the beginning of struct bpf_map_info, up to the name: the
+// kernel fills only infoLen bytes
+type mapInfo struct {
+	mapType    uint32
+	id         uint32
+	keySize    uint32
+	valueSize  uint32
+	maxEntries uint32
+	mapFlags   uint32
+	name       [bpfObjNameLen]byte
+}
+
+func bpf(cmd uintptr, attr unsafe.Pointer, size uintptr) (uintptr, error) {
+	nr, ok := sysBPF[runtime.GOARCH]
+	if !ok {
+		return 0, syscall.ENOSYS
+	}
+	r, _, errno := syscall.Syscall(nr, cmd, uintptr(attr), size)
+	if errno != 0 {
+		return 0, errno
+	}
+	return r, nil
+}
+
+// procInventory finds the maps in the file descriptors of the processes in
+// the same mount namespace as the caller, that is the processes of the
+// gadget pod
+type procInventory struct {
+	procRoot string
+}
+
+// NewProcInventory returns the inventory of the maps of the gadget pod, read
+// in procRoot, usually /proc. It needs CAP_SYS_ADMIN to describe the maps.
+func NewProcInventory(procRoot string) Inventory {
+	return procInventory{procRoot: procRoot}
+}
+
+func (p procInventory) Holders() (map[int][]uint32, error) {
+	ownNs, err := os.Readlink(filepath.Join(p.procRoot, "self/ns/mnt"))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(p.procRoot)
+	if err != nil {
+		return nil, err
+	}
+	holders := map[int][]uint32{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		dir := filepath.Join(p.procRoot, entry.Name())
+		if ns, err := os.Readlink(filepath.Join(dir, "ns/mnt")); err != nil || ns != ownNs {
+			// Exited, or not in the gadget pod
+			continue
+		}
+		fds, err := ioutil.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || link != bpfMapFdLinkPattern {
+				continue
+			}
+			if id, ok := fdinfoMapID(filepath.Join(dir, "fdinfo", fd.Name())); ok {
+				holders[pid] = append(holders[pid], id)
+			}
+		}
+	}
+	return holders, nil
+}
+
+// fdinfoMapID returns the id of the map of a file descriptor, given by the
+// kernel since Linux 4.13
+func fdinfoMapID(path string) (uint32, bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "map_id:" {
+			id, err := strconv.ParseUint(fields[1], 10, 32)
+			return uint32(id), err == nil
+		}
+	}
+	return 0, false
+}
+
+func (p procInventory) Command(pid int) []string {
+	content, err := ioutil.ReadFile(filepath.Join(p.procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil || len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\x00"), "\x00")
+}
+
+// open returns a new file descriptor of the map id
+func open(id uint32) (int, error) {
+	attr := getFdByIDAttr{id: id}
+	fd, err := bpf(bpfMapGetFdByID, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if err != nil {
+		return -1, fmt.Errorf("cannot open map %d: %v", id, err)
+	}
+	return int(fd), nil
+}
+
+func (p procInventory) Info(id uint32) (Info, error) {
+	fd, err := open(id)
+	if err != nil {
+		return Info{}, err
+	}
+	defer syscall.Close(fd)
+	info := new(mapInfo)
+	attr := getInfoAttr{
+		fd:      uint32(fd),
+		infoLen: uint32(unsafe.Sizeof(*info)),
+		info:    uint64(uintptr(unsafe.Pointer(info))),
+	}
+	_, err = bpf(bpfObjGetInfoByFd, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	runtime.KeepAlive(info)
+	if err != nil {
+		return Info{}, fmt.Errorf("cannot describe map %d: %v", id, err)
+	}
+	name := info.name[:]
+	if i := bytes.IndexByte(name, 0); i != -1 {
+		name = name[:i]
+	}
+	return Info{
+		Name:       string(name),
+		Type:       info.mapType,
+		KeySize:    info.keySize,
+		ValueSize:  info.valueSize,
+		MaxEntries: info.maxEntries,
+		Flags:      info.mapFlags,
+	}, nil
+}
+
+// Count iterates over the keys of the map. The iteration is stopped after
+// max keys: a hash map being updated can return the same keys again.
+func (p procInventory) Count(id uint32, max uint32) (int, error) {
+	info, err := p.Info(id)
+	if err != nil {
+		return 0, err
+	}
+	fd, err := open(id)
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.Close(fd)
+	key := make([]byte, info.KeySize+1)
+	next := make([]byte, info.KeySize+1)
+	attr := getNextKeyAttr{fd: uint32(fd), nextKey: uint64(uintptr(unsafe.Pointer(&next[0])))}
+	count := 0
+	for uint32(count) < max {
+		_, err := bpf(bpfMapGetNextKey, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+		if err == syscall.ENOENT {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("cannot iterate over map %d: %v", id, err)
+		}
+		count++
+		copy(key, next)
+		attr.key = uint64(uintptr(unsafe.Pointer(&key[0])))
+	}
+	runtime.KeepAlive(key)
+	runtime.KeepAlive(next)
+	return count, nil
+}
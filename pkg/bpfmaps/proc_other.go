@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package bpfmaps
+
+import (
+	"errors"
+)
+
+// NewProcInventory is only used on the nodes, on Linux
+func NewProcInventory(procRoot string) Inventory {
+	return otherInventory{}
+}
+
+type otherInventory struct{}
+
+var errNotLinux = errors.New("BPF maps not available on this system")
+
+func (otherInventory) Holders() (map[int][]uint32, error) { return nil, errNotLinux }
+func (otherInventory) Command(pid int) []string           { return nil }
+func (otherInventory) Info(id uint32) (Info, error)       { return Info{}, errNotLinux }
+func (otherInventory) Count(id uint32, max uint32) (int, error) {
+	return 0, errNotLinux
+}
@@ -0,0 +1,62 @@
+// Package commfilter implements the --comm option of the gadgets, tracing
+// only the processes with given names. The names are compared in the BPF
+// programs with the comm of the tasks, that the kernel truncates to
+// TASK_COMM_LEN - 1 bytes: the names given are truncated the same way by the
+// gadgets, see gadget-container/gadgets/bcck8s/commfilter.py.
+package commfilter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxLen is the length of the comm of a task, TASK_COMM_LEN without
+	// the terminating NUL
+	MaxLen = 15
+
+	// MaxNames is the size of the BPF map of the names in the gadgets
+	MaxNames = 64
+)
+
+// invalidChars can't be in a name: "," separates the names given to the
+// gadgets, and the others would be interpreted by the shell running them
+const invalidChars = ",\"\\$`"
+
+// Truncate returns the name as the comm of a task running a program with this
+// name, like "kube-controller" for "kube-controller-manager". As in the
+// kernel, a UTF-8 character can be cut.
+func Truncate(name string) string {
+	if len(name) > MaxLen {
+		return name[:MaxLen]
+	}
+	return name
+}
+
+// Check validates the names given with --comm and returns the ones that are
+// truncated by Truncate, and so also match the other names with the same
+// first MaxLen bytes
+func Check(names []string) (truncated []string, err error) {
+	comms := map[string]bool{}
+	for _, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("empty name")
+		}
+		if strings.ContainsAny(name, invalidChars) {
+			return nil, fmt.Errorf("invalid name %q: it can't contain any of %s", name, invalidChars)
+		}
+		for _, r := range name {
+			if r < ' ' || r == 0x7f {
+				return nil, fmt.Errorf("invalid name %q: it can't contain control characters", name)
+			}
+		}
+		if Truncate(name) != name {
+			truncated = append(truncated, name)
+		}
+		comms[Truncate(name)] = true
+	}
+	if len(comms) > MaxNames {
+		return nil, fmt.Errorf("too many names, at most %d", MaxNames)
+	}
+	return truncated, nil
+}
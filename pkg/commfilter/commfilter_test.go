@@ -0,0 +1,134 @@
+package commfilter
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	table := []struct {
+		names     []string
+		truncated []string
+		err       string
+	}{
+		{
+			names: []string{"nginx", "java"},
+		},
+		{
+			// 15 bytes: not truncated
+			names: []string{"kube-controller"},
+		},
+		{
+			names:     []string{"kube-controller-manager", "kube-controller"},
+			truncated: []string{"kube-controller-manager"},
+		},
+		{
+			names: []string{"nginx", ""},
+			err:   "empty name",
+		},
+		{
+			names: []string{"nginx,java"},
+			err:   `invalid name "nginx,java"`,
+		},
+		{
+			names: []string{"$(reboot)"},
+			err:   `invalid name "$(reboot)"`,
+		},
+		{
+			names: []string{"tab\tname"},
+			err:   `invalid name "tab\tname": it can't contain control characters`,
+		},
+	}
+	for _, entry := range table {
+		truncated, err := Check(entry.names)
+		if entry.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), entry.err) {
+				t.Fatalf("%q: expected error %q, got %v", entry.names, entry.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %s", entry.names, err)
+		}
+		if !reflect.DeepEqual(truncated, entry.truncated) {
+			t.Errorf("%q: truncated %q != %q", entry.names, truncated, entry.truncated)
+		}
+	}
+}
+
+func TestCheckTooMany(t *testing.T) {
+	var names []string
+	for i := 0; i < MaxNames; i++ {
+		names = append(names, fmt.Sprintf("worker-%d", i))
+	}
+	// Names with the same comm count once
+	names = append(names, "worker-0")
+	if _, err := Check(names); err != nil {
+		t.Fatalf("%d names refused: %s", MaxNames, err)
+	}
+	names = append(names, "one-too-many")
+	if _, err := Check(names); err == nil {
+		t.Fatalf("%d names accepted", MaxNames+1)
+	}
+}
+
+// keyDriver prints, for each name given as argument, the key of the BPF map
+// of the names in commfilter.py, in hexadecimal
+const keyDriver = `
+import binascii, sys
+sys.path.insert(0, sys.argv[1])
+from commfilter import key
+for name in sys.argv[2:]:
+    print(binascii.hexlify(key(name)).decode())
+`
+
+// TestKey tests that the gadgets truncate the names as Truncate, and so
+// as the kernel truncates the comm of the tasks
+func TestKey(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is needed to run commfilter.py")
+	}
+
+	table := []struct {
+		name     string
+		expected string
+	}{
+		{"nginx", "nginx"},
+		// 15 bytes: not truncated
+		{"kube-controller", "kube-controller"},
+		// The comm of kube-controller-manager, as read in the kernel
+		{"kube-controller-manager", "kube-controller"},
+		// Truncated in the middle of a UTF-8 character, as by the kernel
+		{"abcdefghijklmné", "abcdefghijklmn\xc3"},
+	}
+
+	args := []string{"-B", "-c", keyDriver, "../../gadget-container/gadgets/bcck8s"}
+	for _, entry := range table {
+		args = append(args, entry.name)
+	}
+	cmd := exec.Command(python, args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running commfilter.py: %v", err)
+	}
+	keys := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(keys) != len(table) {
+		t.Fatalf("unexpected output of commfilter.py: %q", out)
+	}
+	for i, entry := range table {
+		key, err := hex.DecodeString(keys[i])
+		if err != nil {
+			t.Fatalf("%q: %v", entry.name, err)
+		}
+		if string(key) != entry.expected || string(key) != Truncate(entry.name) {
+			t.Errorf("%q: key %q, expected %q", entry.name, key, entry.expected)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package containercache caches the resolution of cgroups to the Kubernetes
+// container they belong to, so that gadgets don't query the API server for
+// each event.
+package containercache
+
+import (
+	"sync"
+	"time"
+)
+
+// Metadata describes the container a cgroup belongs to
+type Metadata struct {
+	Namespace string
+	Pod       string
+	PodUID    string
+	Labels    map[string]string
+	Container string
+
+	// HostNetwork is set when the pod uses the network namespace of the
+	// node
+	HostNetwork bool
+}
+
+// LookupFunc resolves a cgroup path. It returns nil without error when the
+// cgroup does not belong to a container, for example for processes running
+// on the host. Errors are retried and not cached.
+type LookupFunc func(cgroupPath string) (*Metadata, error)
+
+// Config configures a Cache
+type Config struct {
+	// TTL is how long a resolved cgroup is kept
+	TTL time.Duration
+
+	// NegativeTTL is how long a cgroup that does not belong to a container
+	// is kept. It is usually shorter than TTL: the container might be
+	// created just after the lookup.
+	NegativeTTL time.Duration
+
+	// Retries is the number of times a failing lookup is retried
+	Retries int
+
+	// RetryDelay is the delay between two attempts
+	RetryDelay time.Duration
+}
+
+// DefaultConfig is suited to gadgets resolving cgroups with the API server
+var DefaultConfig = Config{
+	TTL:         time.Minute,
+	NegativeTTL: 5 * time.Second,
+	Retries:     2,
+	RetryDelay:  100 * time.Millisecond,
+}
+
+type entry struct {
+	metadata *Metadata
+	expires  time.Time
+}
+
+// call is a lookup in progress. Concurrent Get on the same cgroup wait for
+// it instead of starting their own lookup.
+type call struct {
+	done     chan struct{}
+	metadata *Metadata
+	err      error
+}
+
+// Cache is a cache of cgroup paths to container metadata, safe for
+// concurrent use
+type Cache struct {
+	lookup LookupFunc
+	config Config
+
+	// now can be replaced in tests
+	now func() time.Time
+
+	mu       sync.Mutex
+	entries  map[string]entry
+	inflight map[string]*call
+	lastGC   time.Time
+}
+
+// New returns a Cache resolving cgroups with lookup
+func New(lookup LookupFunc, config Config) *Cache {
+	return &Cache{
+		lookup:   lookup,
+		config:   config,
+		now:      time.Now,
+		entries:  make(map[string]entry),
+		inflight: make(map[string]*call),
+	}
+}
+
+// Get returns the metadata of the container the cgroup belongs to, or nil if
+// it does not belong to a container
+func (c *Cache) Get(cgroupPath string) (*Metadata, error) {
+	c.mu.Lock()
+	now := c.now()
+	if e, ok := c.entries[cgroupPath]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		return e.metadata, nil
+	}
+	if cl, ok := c.inflight[cgroupPath]; ok {
+		c.mu.Unlock()
+		<-cl.done
+		return cl.metadata, cl.err
+	}
+	cl := &call{done: make(chan struct{})}
+	c.inflight[cgroupPath] = cl
+	c.mu.Unlock()
+
+	cl.metadata, cl.err = c.lookupWithRetries(cgroupPath)
+
+	c.mu.Lock()
+	delete(c.inflight, cgroupPath)
+	if cl.err == nil {
+		ttl := c.config.TTL
+		if cl.metadata == nil {
+			ttl = c.config.NegativeTTL
+		}
+		now = c.now()
+		c.entries[cgroupPath] = entry{metadata: cl.metadata, expires: now.Add(ttl)}
+		c.gc(now)
+	}
+	c.mu.Unlock()
+	close(cl.done)
+
+	return cl.metadata, cl.err
+}
+
+func (c *Cache) lookupWithRetries(cgroupPath string) (metadata *Metadata, err error) {
+	for i := 0; i <= c.config.Retries; i++ {
+		if i > 0 {
+			time.Sleep(c.config.RetryDelay)
+		}
+		metadata, err = c.lookup(cgroupPath)
+		if err == nil {
+			return metadata, nil
+		}
+	}
+	return nil, err
+}
+
+// gc removes the expired entries, at most once per TTL. Called with mu held.
+func (c *Cache) gc(now time.Time) {
+	if now.Sub(c.lastGC) < c.config.TTL {
+		return
+	}
+	c.lastGC = now
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Len returns the number of cached cgroups, including expired ones not
+// removed yet
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
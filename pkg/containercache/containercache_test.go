@@ -0,0 +1,166 @@
+package containercache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testConfig = Config{
+	TTL:         time.Minute,
+	NegativeTTL: time.Second,
+	Retries:     2,
+	RetryDelay:  0,
+}
+
+func TestSingleFlight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	lookup := func(cgroupPath string) (*Metadata, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &Metadata{Pod: "mypod"}, nil
+	}
+	c := New(lookup, testConfig)
+
+	const n = 50
+	var wg sync.WaitGroup
+	results := make(chan *Metadata, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := c.Get("/kubepods/pod1/abc")
+			if err != nil {
+				t.Error(err)
+			}
+			results <- m
+		}()
+	}
+
+	// Wait for the first lookup to start and the other goroutines to
+	// queue behind it
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if calls != 1 {
+		t.Fatalf("%d lookups for concurrent Get, expected 1", calls)
+	}
+	for m := range results {
+		if m == nil || m.Pod != "mypod" {
+			t.Fatalf("unexpected metadata %+v", m)
+		}
+	}
+}
+
+func TestTTL(t *testing.T) {
+	calls := map[string]int{}
+	lookup := func(cgroupPath string) (*Metadata, error) {
+		calls[cgroupPath]++
+		if cgroupPath == "/host" {
+			return nil, nil
+		}
+		return &Metadata{Container: cgroupPath}, nil
+	}
+	c := New(lookup, testConfig)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+
+	for _, key := range []string{"/host", "/container", "/host", "/container"} {
+		if _, err := c.Get(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls["/host"] != 1 || calls["/container"] != 1 {
+		t.Fatalf("cached cgroups looked up again: %v", calls)
+	}
+
+	// The negative entry expires first
+	now = now.Add(2 * time.Second)
+	c.Get("/host")
+	c.Get("/container")
+	if calls["/host"] != 2 || calls["/container"] != 1 {
+		t.Fatalf("unexpected lookups after negative TTL: %v", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	m, _ := c.Get("/container")
+	if calls["/container"] != 2 || m.Container != "/container" {
+		t.Fatalf("unexpected lookups after TTL: %v", calls)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expired entries not removed: %d entries", c.Len())
+	}
+}
+
+func TestRetries(t *testing.T) {
+	calls := 0
+	lookup := func(cgroupPath string) (*Metadata, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return &Metadata{Pod: "mypod"}, nil
+	}
+	c := New(lookup, testConfig)
+
+	m, err := c.Get("/container")
+	if err != nil || m.Pod != "mypod" || calls != 3 {
+		t.Fatalf("Get: %+v %v after %d calls", m, err, calls)
+	}
+
+	// Errors are not cached
+	calls = 0
+	c = New(func(string) (*Metadata, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	}, testConfig)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get("/container"); err == nil {
+			t.Fatal("error not returned")
+		}
+	}
+	if calls != 6 || c.Len() != 0 {
+		t.Fatalf("%d calls, %d entries, expected 6 calls and no entries", calls, c.Len())
+	}
+}
+
+func BenchmarkGetHit(b *testing.B) {
+	c := New(func(string) (*Metadata, error) {
+		return &Metadata{Pod: "mypod"}, nil
+	}, testConfig)
+	c.Get("/container")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Get("/container")
+	}
+}
+
+// BenchmarkGetParallel simulates a tracer receiving events from a few
+// hundred containers on many CPUs, with a slow lookup
+func BenchmarkGetParallel(b *testing.B) {
+	c := New(func(string) (*Metadata, error) {
+		time.Sleep(time.Millisecond)
+		return &Metadata{Pod: "mypod"}, nil
+	}, testConfig)
+	keys := make([]string, 256)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("/kubepods/pod%d", i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
@@ -0,0 +1,25 @@
+// Package cpubudget caps the overhead of a gadget on its node: with
+// --cpu-budget, when the BPF programs of the gadget run longer than the
+// budget, the gadget samples its events, dropping a fraction of them in the
+// BPF programs before doing most of their work. The sampling rate is adapted
+// by the gadgets, see gadget-container/gadgets/bcck8s/cpubudget.py.
+package cpubudget
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a budget given as a percentage of one CPU, like "5%" or
+// "0.5%", and returns it as a share of one CPU, like 0.05
+func Parse(s string) (float64, error) {
+	if !strings.HasSuffix(s, "%") {
+		return 0, fmt.Errorf("%q is not a percentage of one CPU, like 5%%", s)
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("%q is not a percentage of one CPU between 0%% and 100%%", s)
+	}
+	return percent / 100, nil
+}
@@ -0,0 +1,106 @@
+package cpubudget
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for s, expected := range map[string]float64{
+		"5%":   0.05,
+		"0.5%": 0.005,
+		"100%": 1,
+	} {
+		budget, err := Parse(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if budget != expected {
+			t.Fatalf("%s: %v != %v", s, budget, expected)
+		}
+	}
+	for _, s := range []string{"", "5", "0.05", "0%", "-1%", "101%", "five%"} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+}
+
+// rateDriver runs next_rate of cpubudget.py, the sampling done by the
+// gadgets, on the cases read on stdin: for each case, the rates after each
+// interval are printed on a line, starting from keeping all the events
+const rateDriver = `
+import json, sys
+sys.path.insert(0, sys.argv[1])
+from cpubudget import next_rate
+for case in json.load(sys.stdin):
+    rate, rates = 1, []
+    for share in case["shares"]:
+        rate = next_rate(rate, share, case["budget"])
+        rates.append(rate)
+    print(json.dumps(rates))
+`
+
+func TestNextRate(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is needed to run cpubudget.py")
+	}
+
+	table := []struct {
+		Description string    `json:"description"`
+		Budget      float64   `json:"budget"`
+		Shares      []float64 `json:"shares"`
+		expected    []int
+	}{
+		{
+			// Within the budget, then a burst of events: the programs
+			// run 30% of one CPU, 6 times the budget, and less with
+			// each doubling of the rate until they are within the
+			// budget. Once the burst is over, the rate is halved while
+			// the programs run less than a quarter of the budget.
+			Description: "over budget",
+			Budget:      0.05,
+			Shares:      []float64{0.04, 0.3, 0.15, 0.075, 0.0375, 0.001, 0.001, 0.001, 0.001},
+			expected:    []int{1, 2, 4, 8, 8, 4, 2, 1, 1},
+		},
+		{
+			// The programs keep running over the budget, for instance
+			// because the events are dropped after most of the work
+			Description: "max rate",
+			Budget:      0.01,
+			Shares:      []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+			expected:    []int{2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 1024, 1024},
+		},
+	}
+
+	input, err := json.Marshal(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(python, "-B", "-c", rateDriver, "../../gadget-container/gadgets/bcck8s")
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running cpubudget.py: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != len(table) {
+		t.Fatalf("unexpected output of cpubudget.py: %q", out)
+	}
+	for i, entry := range table {
+		var rates []int
+		if err := json.Unmarshal([]byte(lines[i]), &rates); err != nil {
+			t.Fatalf("%s: %v", entry.Description, err)
+		}
+		if !reflect.DeepEqual(rates, entry.expected) {
+			t.Errorf("%s: rates %v, expected %v", entry.Description, rates, entry.expected)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package eventcount counts the JSON events of a gadget grouped by the
+// values of some of their fields, like the events of each pod and command,
+// instead of printing them one by one.
+package eventcount
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Group is the number of events with the same values of the fields counted
+type Group struct {
+	// Values are the JSON values of the fields, in the order of the fields,
+	// null for the events without the field
+	Values []json.RawMessage
+	Count  uint64
+}
+
+// Counter counts the events by the values of its fields
+type Counter struct {
+	fields []string
+	groups map[string]*Group
+}
+
+// ParseFields parses the comma-separated list of fields to count by, like
+// "pod,comm", and checks that they are all in known, the JSON fields of the
+// events of the gadget
+func ParseFields(list string, known []string) ([]string, error) {
+	knownSet := map[string]bool{}
+	for _, f := range known {
+		knownSet[f] = true
+	}
+	var fields, unknown []string
+	seen := map[string]bool{}
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			return nil, fmt.Errorf("empty field in %q", list)
+		}
+		if seen[f] {
+			return nil, fmt.Errorf("field %q repeated", f)
+		}
+		seen[f] = true
+		if !knownSet[f] {
+			unknown = append(unknown, f)
+		}
+		fields = append(fields, f)
+	}
+	if len(unknown) != 0 {
+		return nil, fmt.Errorf("unknown fields: %s (the fields are: %s)",
+			strings.Join(unknown, ", "), strings.Join(known, ", "))
+	}
+	return fields, nil
+}
+
+// New returns a Counter of the events by the values of fields
+func New(fields []string) *Counter {
+	return &Counter{
+		fields: fields,
+		groups: map[string]*Group{},
+	}
+}
+
+// Fields returns the fields counted by
+func (c *Counter) Fields() []string {
+	return c.fields
+}
+
+// Add counts event, a JSON object
+func (c *Counter) Add(event []byte) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(event, &object); err != nil {
+		return err
+	}
+	values := make([]json.RawMessage, len(c.fields))
+	var key bytes.Buffer
+	for i, f := range c.fields {
+		v, ok := object[f]
+		if !ok {
+			v = json.RawMessage("null")
+		}
+		values[i] = v
+		// The JSON values can't contain a NUL byte
+		key.Write(v)
+		key.WriteByte(0)
+	}
+	g, ok := c.groups[key.String()]
+	if !ok {
+		g = &Group{Values: values}
+		c.groups[key.String()] = g
+	}
+	g.Count++
+	return nil
+}
+
+// Flush returns the groups counted since the last call, the largest counts
+// first and then sorted by their JSON values, and resets the counts
+func (c *Counter) Flush() []Group {
+	groups := make([]Group, 0, len(c.groups))
+	for _, g := range c.groups {
+		groups = append(groups, *g)
+	}
+	c.groups = map[string]*Group{}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		for k := range groups[i].Values {
+			if cmp := bytes.Compare(groups[i].Values[k], groups[j].Values[k]); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+	return groups
+}
+
+// FormatValue returns a value of a group as printed in a column: the strings
+// without their quotes, and "" for the events without the field
+func FormatValue(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+	if string(v) == "null" {
+		return ""
+	}
+	return string(v)
+}
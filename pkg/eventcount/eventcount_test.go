@@ -0,0 +1,64 @@
+package eventcount
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	known := []string{"pod", "comm", "ret"}
+	fields, err := ParseFields("pod, ret", known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"pod", "ret"}; !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("got %v, expected %v", fields, expected)
+	}
+	for _, list := range []string{"", "pod,", "pod,pod", "pod,syscall"} {
+		if _, err := ParseFields(list, known); err == nil {
+			t.Errorf("%q: no error", list)
+		}
+	}
+}
+
+func TestCounter(t *testing.T) {
+	c := New([]string{"pod", "ret"})
+	events := []string{
+		`{"pod":"web","comm":"cat","ret":0}`,
+		`{"pod":"db","comm":"ls","ret":-2}`,
+		`{"pod":"web","comm":"ls","ret":0}`,
+		`{"comm":"init","ret":0}`,
+		`{"pod":"db","comm":"cat","ret":-2}`,
+		`{"pod":"web","comm":"cat","ret":-2}`,
+		`{"pod":"web","comm":"cat","ret":0}`,
+	}
+	for _, e := range events {
+		if err := c.Add([]byte(e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Add([]byte("not an event")); err == nil {
+		t.Errorf("no error for a line that is not JSON")
+	}
+
+	var got [][]string
+	var counts []uint64
+	for _, g := range c.Flush() {
+		var values []string
+		for _, v := range g.Values {
+			values = append(values, FormatValue(v))
+		}
+		got = append(got, values)
+		counts = append(counts, g.Count)
+	}
+	// The largest counts first, then by their JSON values
+	expected := [][]string{{"web", "0"}, {"db", "-2"}, {"web", "-2"}, {"", "0"}}
+	if !reflect.DeepEqual(got, expected) || !reflect.DeepEqual(counts, []uint64{3, 2, 1, 1}) {
+		t.Fatalf("got %v %v, expected %v [3 2 1 1]", got, counts, expected)
+	}
+
+	// The counts restart after Flush
+	if groups := c.Flush(); len(groups) != 0 {
+		t.Fatalf("counts not reset: %v", groups)
+	}
+}
@@ -0,0 +1,92 @@
+// Package eventdedup suppresses the events repeating a flow, like the same
+// connection made again and again by a pod, for a window of time. The first
+// event of a flow opens a window: the following events of the flow are only
+// counted until the window closes, and the flow is printed again after that.
+//
+// It is unlike the --unique option of capabilities, that prints each event
+// once for the whole run: a flow that keeps repeating is still seen every
+// window, with the number of times it repeated.
+package eventdedup
+
+import (
+	"sort"
+	"time"
+)
+
+// Repeat is a flow whose events were suppressed during a window
+type Repeat struct {
+	Key string
+	// Start is the time of the event that opened the window
+	Start time.Time
+	// Count is the number of events suppressed
+	Count int
+	// Last is the last event suppressed, as given to Add
+	Last interface{}
+}
+
+type window struct {
+	start time.Time
+	count int
+	last  interface{}
+}
+
+// Window suppresses the events of the flows seen less than its duration ago
+type Window struct {
+	duration time.Duration
+	windows  map[string]*window
+}
+
+// New returns a Window of duration d
+func New(d time.Duration) *Window {
+	return &Window{
+		duration: d,
+		windows:  map[string]*window{},
+	}
+}
+
+// Add records an event of the flow key, received at now, and returns whether
+// it must be printed: when it opens a window. Expire must be called before
+// with the same now, so that the windows closed since are reported.
+func (w *Window) Add(key string, event interface{}, now time.Time) bool {
+	if win, ok := w.windows[key]; ok && now.Sub(win.start) < w.duration {
+		win.count++
+		win.last = event
+		return false
+	}
+	w.windows[key] = &window{start: now}
+	return true
+}
+
+// Expire closes the windows opened a duration or more before now and returns
+// those that suppressed events, oldest first
+func (w *Window) Expire(now time.Time) []Repeat {
+	return w.close(func(win *window) bool {
+		return now.Sub(win.start) >= w.duration
+	})
+}
+
+// Flush closes all the windows, when no more events are expected, and
+// returns those that suppressed events, oldest first
+func (w *Window) Flush() []Repeat {
+	return w.close(func(*window) bool { return true })
+}
+
+func (w *Window) close(closed func(*window) bool) []Repeat {
+	var repeats []Repeat
+	for key, win := range w.windows {
+		if !closed(win) {
+			continue
+		}
+		delete(w.windows, key)
+		if win.count != 0 {
+			repeats = append(repeats, Repeat{Key: key, Start: win.start, Count: win.count, Last: win.last})
+		}
+	}
+	sort.Slice(repeats, func(i, j int) bool {
+		if !repeats[i].Start.Equal(repeats[j].Start) {
+			return repeats[i].Start.Before(repeats[j].Start)
+		}
+		return repeats[i].Key < repeats[j].Key
+	})
+	return repeats
+}
@@ -0,0 +1,50 @@
+package eventdedup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	w := New(5 * time.Second)
+
+	if !w.Add("a", "a1", at(0)) {
+		t.Fatalf("first event of a suppressed")
+	}
+	if w.Add("a", "a2", at(time.Second)) || w.Add("a", "a3", at(2*time.Second)) {
+		t.Fatalf("repeated events of a printed")
+	}
+	if !w.Add("b", "b1", at(3*time.Second)) {
+		t.Fatalf("first event of b suppressed")
+	}
+	if repeats := w.Expire(at(4 * time.Second)); len(repeats) != 0 {
+		t.Fatalf("windows closed too early: %v", repeats)
+	}
+
+	// The window of a closes: its repeats are reported and the flow is
+	// printed again
+	repeats := w.Expire(at(5 * time.Second))
+	expected := []Repeat{{Key: "a", Start: at(0), Count: 2, Last: "a3"}}
+	if !reflect.DeepEqual(repeats, expected) {
+		t.Fatalf("unexpected repeats %v, expected %v", repeats, expected)
+	}
+	if !w.Add("a", "a4", at(6*time.Second)) {
+		t.Fatalf("event of a suppressed after its window closed")
+	}
+	if w.Add("a", "a5", at(7*time.Second)) {
+		t.Fatalf("repeated event of a printed in its new window")
+	}
+
+	// b did not repeat: nothing to report
+	repeats = w.Flush()
+	expected = []Repeat{{Key: "a", Start: at(6 * time.Second), Count: 1, Last: "a5"}}
+	if !reflect.DeepEqual(repeats, expected) {
+		t.Fatalf("unexpected repeats %v, expected %v", repeats, expected)
+	}
+	if repeats := w.Flush(); len(repeats) != 0 {
+		t.Fatalf("windows not closed by Flush: %v", repeats)
+	}
+}
@@ -0,0 +1,96 @@
+// Package eventlatency records how long the events of the gadgets take to
+// reach the user, to diagnose slow enrichment or backpressure.
+package eventlatency
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WindowSize is the number of latest latencies the percentiles are
+// computed on
+const WindowSize = 4096
+
+// Recorder records latencies and computes their percentiles over the last
+// WindowSize ones. It is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	window [WindowSize]time.Duration
+	next   int
+	count  uint64
+	sum    time.Duration
+}
+
+// Record records a latency. Negative latencies, caused by clocks of the
+// nodes ahead of the local one, are recorded as 0.
+func (r *Recorder) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	r.mu.Lock()
+	r.window[r.next] = d
+	r.next = (r.next + 1) % WindowSize
+	r.count++
+	r.sum += d
+	r.mu.Unlock()
+}
+
+// Count returns the number of latencies recorded
+func (r *Recorder) Count() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Sum returns the sum of the latencies recorded
+func (r *Recorder) Sum() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sum
+}
+
+// Percentile returns the p-th percentile, between 0 and 1, of the last
+// WindowSize latencies recorded, by the nearest-rank method, or 0 when none
+// were recorded
+func (r *Recorder) Percentile(p float64) time.Duration {
+	r.mu.Lock()
+	n := WindowSize
+	if r.count < WindowSize {
+		n = int(r.count)
+	}
+	values := make([]time.Duration, n)
+	copy(values, r.window[:n])
+	r.mu.Unlock()
+
+	if n == 0 {
+		return 0
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	rank := int(math.Ceil(p * float64(n)))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > n {
+		rank = n
+	}
+	return values[rank-1]
+}
+
+// EventTime returns the time an event was produced on its node, from the
+// "timestamp" field of the JSON events of the gadgets, in RFC 3339
+func EventTime(line string) (time.Time, bool) {
+	var event struct {
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Timestamp == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,79 @@
+package eventlatency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	r := &Recorder{}
+	if p := r.Percentile(0.5); p != 0 {
+		t.Fatalf("percentile without latencies: %s != 0", p)
+	}
+	for i := 1; i <= 100; i++ {
+		r.Record(time.Duration(i) * time.Millisecond)
+	}
+	r.Record(-time.Second)
+
+	table := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{0, 0},
+		{0.5, 50 * time.Millisecond},
+		{0.9, 90 * time.Millisecond},
+		{0.99, 99 * time.Millisecond},
+		{1, 100 * time.Millisecond},
+	}
+	for _, entry := range table {
+		if p := r.Percentile(entry.p); p != entry.expected {
+			t.Errorf("percentile %v: %s != %s", entry.p, p, entry.expected)
+		}
+	}
+	if r.Count() != 101 {
+		t.Errorf("count: %d != 101", r.Count())
+	}
+	if r.Sum() != 5050*time.Millisecond {
+		t.Errorf("sum: %s != 5.05s", r.Sum())
+	}
+}
+
+func TestPercentileWindow(t *testing.T) {
+	r := &Recorder{}
+	for i := 0; i < WindowSize; i++ {
+		r.Record(time.Hour)
+	}
+	// The old latencies are forgotten
+	for i := 0; i < WindowSize; i++ {
+		r.Record(time.Millisecond)
+	}
+	if p := r.Percentile(1); p != time.Millisecond {
+		t.Errorf("percentile 1: %s != 1ms", p)
+	}
+	if r.Count() != 2*WindowSize {
+		t.Errorf("count: %d != %d", r.Count(), 2*WindowSize)
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	table := []struct {
+		line     string
+		expected time.Time
+		ok       bool
+	}{
+		{
+			line:     `{"timestamp":"2020-04-01T10:00:00.123456Z","pid":42}`,
+			expected: time.Date(2020, 4, 1, 10, 0, 0, 123456000, time.UTC),
+			ok:       true,
+		},
+		{line: `{"pid":42}`},
+		{line: `{"timestamp":"yesterday"}`},
+		{line: `PID COMM`},
+	}
+	for _, entry := range table {
+		ts, ok := EventTime(entry.line)
+		if ok != entry.ok || !ts.Equal(entry.expected) {
+			t.Errorf("%q: %s, %v != %s, %v", entry.line, ts, ok, entry.expected, entry.ok)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Package eventpb encodes the typed events of the gadgets as protocol
+// buffers, for "kubectl gadget <gadget> -o protobuf". The schema is in
+// events.proto.
+package eventpb
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/cachestat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/swapin"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpconnlat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpsubnet"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ugidsnoop"
+)
+
+// maxEventSize is the maximum size of an encoded event accepted by Reader
+const maxEventSize = 1024 * 1024
+
+func podInfo(containerID, namespace, pod, container string) *PodInfo {
+	if containerID == "" && pod == "" {
+		return nil
+	}
+	return &PodInfo{
+		ContainerId: containerID,
+		Namespace:   namespace,
+		Pod:         pod,
+		Container:   container,
+	}
+}
+
+// FromTcpconnlat returns the message of a tcpconnlat event
+func FromTcpconnlat(e tcpconnlat.Event) *Event {
+	ready := ContainerReady_READY_UNKNOWN
+	if e.ContainerReady != nil {
+		ready = ContainerReady_READY_FALSE
+		if *e.ContainerReady {
+			ready = ContainerReady_READY_TRUE
+		}
+	}
+	return &Event{Event: &Event_Tcpconnlat{&TcpconnlatEvent{
+		Pid:            e.Pid,
+		Comm:           e.Comm,
+		Pod:            podInfo(e.ContainerID, e.Namespace, e.Pod, e.Container),
+		PodPhase:       e.PodPhase,
+		ContainerReady: ready,
+		IpVersion:      uint32(e.IPVersion),
+		Saddr:          e.Saddr,
+		Daddr:          e.Daddr,
+		Dport:          uint32(e.Dport),
+		LatencyUs:      e.LatencyUs,
+	}}}
+}
+
+// ToTcpconnlat returns the tcpconnlat event of a message
+func ToTcpconnlat(m *TcpconnlatEvent) tcpconnlat.Event {
+	e := tcpconnlat.Event{
+		Pid:       m.GetPid(),
+		Comm:      m.GetComm(),
+		PodPhase:  m.GetPodPhase(),
+		IPVersion: int(m.GetIpVersion()),
+		Saddr:     m.GetSaddr(),
+		Daddr:     m.GetDaddr(),
+		Dport:     uint16(m.GetDport()),
+		LatencyUs: m.GetLatencyUs(),
+	}
+	if m.GetContainerReady() != ContainerReady_READY_UNKNOWN {
+		ready := m.GetContainerReady() == ContainerReady_READY_TRUE
+		e.ContainerReady = &ready
+	}
+	p := m.GetPod()
+	e.ContainerID, e.Namespace, e.Pod, e.Container = p.GetContainerId(), p.GetNamespace(), p.GetPod(), p.GetContainer()
+	return e
+}
+
+func fromCredentials(c ugidsnoop.Credentials) *Credentials {
+	return &Credentials{
+		Uid:          c.Uid,
+		Gid:          c.Gid,
+		Euid:         c.Euid,
+		Egid:         c.Egid,
+		CapEffective: c.CapEffective,
+		CapPermitted: c.CapPermitted,
+	}
+}
+
+func toCredentials(m *Credentials) ugidsnoop.Credentials {
+	return ugidsnoop.Credentials{
+		Uid:          m.GetUid(),
+		Gid:          m.GetGid(),
+		Euid:         m.GetEuid(),
+		Egid:         m.GetEgid(),
+		CapEffective: m.GetCapEffective(),
+		CapPermitted: m.GetCapPermitted(),
+	}
+}
+
+// FromUgidsnoop returns the message of an ugidsnoop event
+func FromUgidsnoop(e ugidsnoop.Event) *Event {
+	return &Event{Event: &Event_Ugidsnoop{&UgidsnoopEvent{
+		Pid:     e.Pid,
+		Comm:    e.Comm,
+		Syscall: e.Syscall,
+		Old:     fromCredentials(e.Old),
+		New:     fromCredentials(e.New),
+	}}}
+}
+
+// ToUgidsnoop returns the ugidsnoop event of a message
+func ToUgidsnoop(m *UgidsnoopEvent) ugidsnoop.Event {
+	return ugidsnoop.Event{
+		Pid:     m.GetPid(),
+		Comm:    m.GetComm(),
+		Syscall: m.GetSyscall(),
+		Old:     toCredentials(m.GetOld()),
+		New:     toCredentials(m.GetNew()),
+	}
+}
+
+// FromCachestat returns the message of a cachestat summary
+func FromCachestat(e cachestat.Event) *Event {
+	return &Event{Event: &Event_Cachestat{&CachestatEvent{
+		Timestamp: e.Timestamp,
+		Scope:     e.Scope,
+		Pod:       podInfo(e.ContainerID, e.Namespace, e.Pod, e.Container),
+		Hits:      e.Hits,
+		Misses:    e.Misses,
+		Dirties:   e.Dirties,
+		HitRatio:  e.HitRatio,
+		Partial:   e.Partial,
+	}}}
+}
+
+// ToCachestat returns the cachestat summary of a message
+func ToCachestat(m *CachestatEvent) cachestat.Event {
+	p := m.GetPod()
+	return cachestat.Event{
+		Timestamp:   m.GetTimestamp(),
+		Scope:       m.GetScope(),
+		ContainerID: p.GetContainerId(),
+		Namespace:   p.GetNamespace(),
+		Pod:         p.GetPod(),
+		Container:   p.GetContainer(),
+		Hits:        m.GetHits(),
+		Misses:      m.GetMisses(),
+		Dirties:     m.GetDirties(),
+		HitRatio:    m.GetHitRatio(),
+		Partial:     m.GetPartial(),
+	}
+}
+
+// FromTcpsubnet returns the message of a tcpsubnet summary
+func FromTcpsubnet(e tcpsubnet.Event) *Event {
+	return &Event{Event: &Event_Tcpsubnet{&TcpsubnetEvent{
+		Timestamp: e.Timestamp,
+		Pod:       podInfo(e.ContainerID, e.Namespace, e.Pod, e.Container),
+		Subnet:    e.Subnet,
+		Sent:      e.Sent,
+		Received:  e.Received,
+		Partial:   e.Partial,
+	}}}
+}
+
+// ToTcpsubnet returns the tcpsubnet summary of a message
+func ToTcpsubnet(m *TcpsubnetEvent) tcpsubnet.Event {
+	p := m.GetPod()
+	return tcpsubnet.Event{
+		Timestamp:   m.GetTimestamp(),
+		ContainerID: p.GetContainerId(),
+		Namespace:   p.GetNamespace(),
+		Pod:         p.GetPod(),
+		Container:   p.GetContainer(),
+		Subnet:      m.GetSubnet(),
+		Sent:        m.GetSent(),
+		Received:    m.GetReceived(),
+		Partial:     m.GetPartial(),
+	}
+}
+
+// FromSwapin returns the message of a swapin event
+func FromSwapin(e swapin.Event) *Event {
+	return &Event{Event: &Event_Swapin{&SwapinEvent{
+		Timestamp: e.Timestamp,
+		Pid:       e.Pid,
+		Comm:      e.Comm,
+		Pod:       podInfo(e.ContainerID, e.Namespace, e.Pod, e.Container),
+		Major:     e.Major,
+		LatencyUs: e.LatencyUs,
+	}}}
+}
+
+// ToSwapin returns the swapin event of a message
+func ToSwapin(m *SwapinEvent) swapin.Event {
+	p := m.GetPod()
+	return swapin.Event{
+		Timestamp:   m.GetTimestamp(),
+		Pid:         m.GetPid(),
+		Comm:        m.GetComm(),
+		ContainerID: p.GetContainerId(),
+		Namespace:   p.GetNamespace(),
+		Pod:         p.GetPod(),
+		Container:   p.GetContainer(),
+		Major:       m.GetMajor(),
+		LatencyUs:   m.GetLatencyUs(),
+	}
+}
+
+// Writer writes events preceded by their size, encoded as a varint. It can
+// be used by several goroutines.
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf *proto.Buffer
+}
+
+// NewWriter returns a Writer writing on w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, buf: proto.NewBuffer(nil)}
+}
+
+// Write writes an event
+func (w *Writer) Write(e *Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Reset()
+	if err := w.buf.EncodeMessage(e); err != nil {
+		return err
+	}
+	_, err := w.w.Write(w.buf.Bytes())
+	return err
+}
+
+// Reader reads the events written by a Writer
+type Reader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewReader returns a Reader reading from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+var errEventTooLarge = errors.New("event too large")
+
+// Read returns the next event, or io.EOF at the end of the stream
+func (r *Reader) Read() (*Event, error) {
+	size, err := readVarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxEventSize {
+		return nil, errEventTooLarge
+	}
+	if uint64(cap(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	e := &Event{}
+	if err := proto.Unmarshal(r.buf, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// readVarint reads a varint, returning io.EOF only if the stream ends before
+// its first byte
+func readVarint(r io.ByteReader) (uint64, error) {
+	var x uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && shift != 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		x |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return x, nil
+		}
+	}
+	return 0, errors.New("invalid varint")
+}
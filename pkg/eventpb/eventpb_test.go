@@ -0,0 +1,148 @@
+package eventpb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/cachestat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/swapin"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpconnlat"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/tcpsubnet"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/ugidsnoop"
+)
+
+var ready = true
+
+var tcpconnlatEvent = tcpconnlat.Event{
+	Pid: 4242, Comm: "curl", ContainerID: "5c1ad1c0d66c",
+	Namespace: "demo", Pod: "web-1", Container: "nginx",
+	PodPhase: "Running", ContainerReady: &ready,
+	IPVersion: 4, Saddr: "10.0.0.12", Daddr: "10.0.3.4", Dport: 443, LatencyUs: 1834,
+}
+
+func TestRoundTrip(t *testing.T) {
+	events := []interface{}{
+		tcpconnlatEvent,
+		tcpconnlat.Event{Pid: 1, Comm: "wget", IPVersion: 6, Saddr: "::1", Daddr: "::1", Dport: 80, LatencyUs: 3},
+		ugidsnoop.Event{Pid: 1, Comm: "sudo", Syscall: "setuid",
+			Old: ugidsnoop.Credentials{Uid: 1000, Gid: 1000, Euid: 1000, Egid: 1000},
+			New: ugidsnoop.Credentials{CapEffective: 0x3fffffffff, CapPermitted: 0x3fffffffff}},
+		cachestat.Event{Timestamp: "2020-06-01T12:00:01Z", Scope: cachestat.ScopeContainer, ContainerID: "abc",
+			Namespace: "demo", Pod: "web-1", Container: "nginx", Hits: 99, Misses: 1, HitRatio: 0.99, Partial: true},
+		tcpsubnet.Event{Timestamp: "2020-06-01T12:00:01Z", Subnet: "10.0.0.0/8", Sent: 1200, Received: 64000},
+		swapin.Event{Timestamp: "2020-06-01T12:00:01.000123Z", Pid: 4242, Comm: "redis-server",
+			ContainerID: "abc", Major: true, LatencyUs: 1800},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, e := range events {
+		var m *Event
+		switch e := e.(type) {
+		case tcpconnlat.Event:
+			m = FromTcpconnlat(e)
+		case ugidsnoop.Event:
+			m = FromUgidsnoop(e)
+		case cachestat.Event:
+			m = FromCachestat(e)
+		case tcpsubnet.Event:
+			m = FromTcpsubnet(e)
+		case swapin.Event:
+			m = FromSwapin(e)
+		}
+		if err := w.Write(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewReader(&buf)
+	for i, expected := range events {
+		m, err := r.Read()
+		if err != nil {
+			t.Fatalf("event %d: %s", i, err)
+		}
+		var e interface{}
+		switch m := m.GetEvent().(type) {
+		case *Event_Tcpconnlat:
+			e = ToTcpconnlat(m.Tcpconnlat)
+		case *Event_Ugidsnoop:
+			e = ToUgidsnoop(m.Ugidsnoop)
+		case *Event_Cachestat:
+			e = ToCachestat(m.Cachestat)
+		case *Event_Tcpsubnet:
+			e = ToTcpsubnet(m.Tcpsubnet)
+		case *Event_Swapin:
+			e = ToSwapin(m.Swapin)
+		}
+		if !reflect.DeepEqual(e, expected) {
+			t.Fatalf("event %d: %+v, expected %+v", i, e, expected)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Fatalf("%v at the end of the stream, expected EOF", err)
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Write(FromTcpconnlat(tcpconnlatEvent)); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	if _, err := r.Read(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("%v, expected %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func BenchmarkEncodeJSON(b *testing.B) {
+	enc := json.NewEncoder(ioutil.Discard)
+	for i := 0; i < b.N; i++ {
+		if err := enc.Encode(tcpconnlatEvent); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeProtobuf(b *testing.B) {
+	w := NewWriter(ioutil.Discard)
+	for i := 0; i < b.N; i++ {
+		if err := w.Write(FromTcpconnlat(tcpconnlatEvent)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeJSON(b *testing.B) {
+	buf, err := json.Marshal(tcpconnlatEvent)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		e := tcpconnlat.Event{}
+		if err := json.Unmarshal(buf, &e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeProtobuf(b *testing.B) {
+	buf, err := proto.Marshal(FromTcpconnlat(tcpconnlatEvent))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		m := &Event{}
+		if err := proto.Unmarshal(buf, m); err != nil {
+			b.Fatal(err)
+		}
+		ToTcpconnlat(m.GetTcpconnlat())
+	}
+}
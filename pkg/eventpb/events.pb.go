@@ -0,0 +1,767 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: events.proto
+
+/*
+Package eventpb is a generated protocol buffer package.
+
+It is generated from these files:
+	events.proto
+
+It has these top-level messages:
+	Event
+	PodInfo
+	TcpconnlatEvent
+	Credentials
+	UgidsnoopEvent
+	CachestatEvent
+	TcpsubnetEvent
+	SwapinEvent
+*/
+package eventpb
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type ContainerReady int32
+
+const (
+	ContainerReady_READY_UNKNOWN ContainerReady = 0
+	ContainerReady_READY_FALSE   ContainerReady = 1
+	ContainerReady_READY_TRUE    ContainerReady = 2
+)
+
+var ContainerReady_name = map[int32]string{
+	0: "READY_UNKNOWN",
+	1: "READY_FALSE",
+	2: "READY_TRUE",
+}
+var ContainerReady_value = map[string]int32{
+	"READY_UNKNOWN": 0,
+	"READY_FALSE":   1,
+	"READY_TRUE":    2,
+}
+
+func (x ContainerReady) String() string {
+	return proto.EnumName(ContainerReady_name, int32(x))
+}
+func (ContainerReady) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type Event struct {
+	// Types that are valid to be assigned to Event:
+	//	*Event_Tcpconnlat
+	//	*Event_Ugidsnoop
+	//	*Event_Cachestat
+	//	*Event_Tcpsubnet
+	//	*Event_Swapin
+	Event isEvent_Event `protobuf_oneof:"event"`
+}
+
+func (m *Event) Reset()                    { *m = Event{} }
+func (m *Event) String() string            { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()               {}
+func (*Event) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type isEvent_Event interface{ isEvent_Event() }
+
+type Event_Tcpconnlat struct {
+	Tcpconnlat *TcpconnlatEvent `protobuf:"bytes,1,opt,name=tcpconnlat,oneof"`
+}
+type Event_Ugidsnoop struct {
+	Ugidsnoop *UgidsnoopEvent `protobuf:"bytes,2,opt,name=ugidsnoop,oneof"`
+}
+type Event_Cachestat struct {
+	Cachestat *CachestatEvent `protobuf:"bytes,3,opt,name=cachestat,oneof"`
+}
+type Event_Tcpsubnet struct {
+	Tcpsubnet *TcpsubnetEvent `protobuf:"bytes,4,opt,name=tcpsubnet,oneof"`
+}
+type Event_Swapin struct {
+	Swapin *SwapinEvent `protobuf:"bytes,5,opt,name=swapin,oneof"`
+}
+
+func (*Event_Tcpconnlat) isEvent_Event() {}
+func (*Event_Ugidsnoop) isEvent_Event()  {}
+func (*Event_Cachestat) isEvent_Event()  {}
+func (*Event_Tcpsubnet) isEvent_Event()  {}
+func (*Event_Swapin) isEvent_Event()     {}
+
+func (m *Event) GetEvent() isEvent_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (m *Event) GetTcpconnlat() *TcpconnlatEvent {
+	if x, ok := m.GetEvent().(*Event_Tcpconnlat); ok {
+		return x.Tcpconnlat
+	}
+	return nil
+}
+
+func (m *Event) GetUgidsnoop() *UgidsnoopEvent {
+	if x, ok := m.GetEvent().(*Event_Ugidsnoop); ok {
+		return x.Ugidsnoop
+	}
+	return nil
+}
+
+func (m *Event) GetCachestat() *CachestatEvent {
+	if x, ok := m.GetEvent().(*Event_Cachestat); ok {
+		return x.Cachestat
+	}
+	return nil
+}
+
+func (m *Event) GetTcpsubnet() *TcpsubnetEvent {
+	if x, ok := m.GetEvent().(*Event_Tcpsubnet); ok {
+		return x.Tcpsubnet
+	}
+	return nil
+}
+
+func (m *Event) GetSwapin() *SwapinEvent {
+	if x, ok := m.GetEvent().(*Event_Swapin); ok {
+		return x.Swapin
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*Event) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _Event_OneofMarshaler, _Event_OneofUnmarshaler, _Event_OneofSizer, []interface{}{
+		(*Event_Tcpconnlat)(nil),
+		(*Event_Ugidsnoop)(nil),
+		(*Event_Cachestat)(nil),
+		(*Event_Tcpsubnet)(nil),
+		(*Event_Swapin)(nil),
+	}
+}
+
+func _Event_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*Event)
+	// event
+	switch x := m.Event.(type) {
+	case *Event_Tcpconnlat:
+		b.EncodeVarint(1<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Tcpconnlat); err != nil {
+			return err
+		}
+	case *Event_Ugidsnoop:
+		b.EncodeVarint(2<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Ugidsnoop); err != nil {
+			return err
+		}
+	case *Event_Cachestat:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Cachestat); err != nil {
+			return err
+		}
+	case *Event_Tcpsubnet:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Tcpsubnet); err != nil {
+			return err
+		}
+	case *Event_Swapin:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Swapin); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("Event.Event has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _Event_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Event)
+	switch tag {
+	case 1: // event.tcpconnlat
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(TcpconnlatEvent)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_Tcpconnlat{msg}
+		return true, err
+	case 2: // event.ugidsnoop
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(UgidsnoopEvent)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_Ugidsnoop{msg}
+		return true, err
+	case 3: // event.cachestat
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(CachestatEvent)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_Cachestat{msg}
+		return true, err
+	case 4: // event.tcpsubnet
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(TcpsubnetEvent)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_Tcpsubnet{msg}
+		return true, err
+	case 5: // event.swapin
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SwapinEvent)
+		err := b.DecodeMessage(msg)
+		m.Event = &Event_Swapin{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Event_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Event)
+	// event
+	switch x := m.Event.(type) {
+	case *Event_Tcpconnlat:
+		s := proto.Size(x.Tcpconnlat)
+		n += proto.SizeVarint(1<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Event_Ugidsnoop:
+		s := proto.Size(x.Ugidsnoop)
+		n += proto.SizeVarint(2<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Event_Cachestat:
+		s := proto.Size(x.Cachestat)
+		n += proto.SizeVarint(3<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Event_Tcpsubnet:
+		s := proto.Size(x.Tcpsubnet)
+		n += proto.SizeVarint(4<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Event_Swapin:
+		s := proto.Size(x.Swapin)
+		n += proto.SizeVarint(5<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// Pod of the container of a process, empty if unknown
+type PodInfo struct {
+	ContainerId string `protobuf:"bytes,1,opt,name=container_id,json=containerId" json:"container_id,omitempty"`
+	Namespace   string `protobuf:"bytes,2,opt,name=namespace" json:"namespace,omitempty"`
+	Pod         string `protobuf:"bytes,3,opt,name=pod" json:"pod,omitempty"`
+	Container   string `protobuf:"bytes,4,opt,name=container" json:"container,omitempty"`
+}
+
+func (m *PodInfo) Reset()                    { *m = PodInfo{} }
+func (m *PodInfo) String() string            { return proto.CompactTextString(m) }
+func (*PodInfo) ProtoMessage()               {}
+func (*PodInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *PodInfo) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *PodInfo) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *PodInfo) GetPod() string {
+	if m != nil {
+		return m.Pod
+	}
+	return ""
+}
+
+func (m *PodInfo) GetContainer() string {
+	if m != nil {
+		return m.Container
+	}
+	return ""
+}
+
+type TcpconnlatEvent struct {
+	Pid  uint32   `protobuf:"varint,1,opt,name=pid" json:"pid,omitempty"`
+	Comm string   `protobuf:"bytes,2,opt,name=comm" json:"comm,omitempty"`
+	Pod  *PodInfo `protobuf:"bytes,3,opt,name=pod" json:"pod,omitempty"`
+	// With --pod-status, the status of the pod when the event was enriched
+	PodPhase       string         `protobuf:"bytes,4,opt,name=pod_phase,json=podPhase" json:"pod_phase,omitempty"`
+	ContainerReady ContainerReady `protobuf:"varint,5,opt,name=container_ready,json=containerReady,enum=eventpb.ContainerReady" json:"container_ready,omitempty"`
+	// 4 or 6
+	IpVersion uint32 `protobuf:"varint,6,opt,name=ip_version,json=ipVersion" json:"ip_version,omitempty"`
+	Saddr     string `protobuf:"bytes,7,opt,name=saddr" json:"saddr,omitempty"`
+	Daddr     string `protobuf:"bytes,8,opt,name=daddr" json:"daddr,omitempty"`
+	Dport     uint32 `protobuf:"varint,9,opt,name=dport" json:"dport,omitempty"`
+	// Time from connect() to the reception of the SYN-ACK
+	LatencyUs uint64 `protobuf:"varint,10,opt,name=latency_us,json=latencyUs" json:"latency_us,omitempty"`
+}
+
+func (m *TcpconnlatEvent) Reset()                    { *m = TcpconnlatEvent{} }
+func (m *TcpconnlatEvent) String() string            { return proto.CompactTextString(m) }
+func (*TcpconnlatEvent) ProtoMessage()               {}
+func (*TcpconnlatEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *TcpconnlatEvent) GetPid() uint32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *TcpconnlatEvent) GetComm() string {
+	if m != nil {
+		return m.Comm
+	}
+	return ""
+}
+
+func (m *TcpconnlatEvent) GetPod() *PodInfo {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func (m *TcpconnlatEvent) GetPodPhase() string {
+	if m != nil {
+		return m.PodPhase
+	}
+	return ""
+}
+
+func (m *TcpconnlatEvent) GetContainerReady() ContainerReady {
+	if m != nil {
+		return m.ContainerReady
+	}
+	return ContainerReady_READY_UNKNOWN
+}
+
+func (m *TcpconnlatEvent) GetIpVersion() uint32 {
+	if m != nil {
+		return m.IpVersion
+	}
+	return 0
+}
+
+func (m *TcpconnlatEvent) GetSaddr() string {
+	if m != nil {
+		return m.Saddr
+	}
+	return ""
+}
+
+func (m *TcpconnlatEvent) GetDaddr() string {
+	if m != nil {
+		return m.Daddr
+	}
+	return ""
+}
+
+func (m *TcpconnlatEvent) GetDport() uint32 {
+	if m != nil {
+		return m.Dport
+	}
+	return 0
+}
+
+func (m *TcpconnlatEvent) GetLatencyUs() uint64 {
+	if m != nil {
+		return m.LatencyUs
+	}
+	return 0
+}
+
+type Credentials struct {
+	Uid  uint32 `protobuf:"varint,1,opt,name=uid" json:"uid,omitempty"`
+	Gid  uint32 `protobuf:"varint,2,opt,name=gid" json:"gid,omitempty"`
+	Euid uint32 `protobuf:"varint,3,opt,name=euid" json:"euid,omitempty"`
+	Egid uint32 `protobuf:"varint,4,opt,name=egid" json:"egid,omitempty"`
+	// Bitmasks of the capabilities
+	CapEffective uint64 `protobuf:"varint,5,opt,name=cap_effective,json=capEffective" json:"cap_effective,omitempty"`
+	CapPermitted uint64 `protobuf:"varint,6,opt,name=cap_permitted,json=capPermitted" json:"cap_permitted,omitempty"`
+}
+
+func (m *Credentials) Reset()                    { *m = Credentials{} }
+func (m *Credentials) String() string            { return proto.CompactTextString(m) }
+func (*Credentials) ProtoMessage()               {}
+func (*Credentials) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *Credentials) GetUid() uint32 {
+	if m != nil {
+		return m.Uid
+	}
+	return 0
+}
+
+func (m *Credentials) GetGid() uint32 {
+	if m != nil {
+		return m.Gid
+	}
+	return 0
+}
+
+func (m *Credentials) GetEuid() uint32 {
+	if m != nil {
+		return m.Euid
+	}
+	return 0
+}
+
+func (m *Credentials) GetEgid() uint32 {
+	if m != nil {
+		return m.Egid
+	}
+	return 0
+}
+
+func (m *Credentials) GetCapEffective() uint64 {
+	if m != nil {
+		return m.CapEffective
+	}
+	return 0
+}
+
+func (m *Credentials) GetCapPermitted() uint64 {
+	if m != nil {
+		return m.CapPermitted
+	}
+	return 0
+}
+
+type UgidsnoopEvent struct {
+	Pid     uint32       `protobuf:"varint,1,opt,name=pid" json:"pid,omitempty"`
+	Comm    string       `protobuf:"bytes,2,opt,name=comm" json:"comm,omitempty"`
+	Syscall string       `protobuf:"bytes,3,opt,name=syscall" json:"syscall,omitempty"`
+	Old     *Credentials `protobuf:"bytes,4,opt,name=old" json:"old,omitempty"`
+	New     *Credentials `protobuf:"bytes,5,opt,name=new" json:"new,omitempty"`
+}
+
+func (m *UgidsnoopEvent) Reset()                    { *m = UgidsnoopEvent{} }
+func (m *UgidsnoopEvent) String() string            { return proto.CompactTextString(m) }
+func (*UgidsnoopEvent) ProtoMessage()               {}
+func (*UgidsnoopEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *UgidsnoopEvent) GetPid() uint32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *UgidsnoopEvent) GetComm() string {
+	if m != nil {
+		return m.Comm
+	}
+	return ""
+}
+
+func (m *UgidsnoopEvent) GetSyscall() string {
+	if m != nil {
+		return m.Syscall
+	}
+	return ""
+}
+
+func (m *UgidsnoopEvent) GetOld() *Credentials {
+	if m != nil {
+		return m.Old
+	}
+	return nil
+}
+
+func (m *UgidsnoopEvent) GetNew() *Credentials {
+	if m != nil {
+		return m.New
+	}
+	return nil
+}
+
+type CachestatEvent struct {
+	// RFC 3339
+	Timestamp string `protobuf:"bytes,1,opt,name=timestamp" json:"timestamp,omitempty"`
+	// "node" or "container"
+	Scope    string   `protobuf:"bytes,2,opt,name=scope" json:"scope,omitempty"`
+	Pod      *PodInfo `protobuf:"bytes,3,opt,name=pod" json:"pod,omitempty"`
+	Hits     uint64   `protobuf:"varint,4,opt,name=hits" json:"hits,omitempty"`
+	Misses   uint64   `protobuf:"varint,5,opt,name=misses" json:"misses,omitempty"`
+	Dirties  uint64   `protobuf:"varint,6,opt,name=dirties" json:"dirties,omitempty"`
+	HitRatio float64  `protobuf:"fixed64,7,opt,name=hit_ratio,json=hitRatio" json:"hit_ratio,omitempty"`
+	// Summary of the incomplete last interval
+	Partial bool `protobuf:"varint,8,opt,name=partial" json:"partial,omitempty"`
+}
+
+func (m *CachestatEvent) Reset()                    { *m = CachestatEvent{} }
+func (m *CachestatEvent) String() string            { return proto.CompactTextString(m) }
+func (*CachestatEvent) ProtoMessage()               {}
+func (*CachestatEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *CachestatEvent) GetTimestamp() string {
+	if m != nil {
+		return m.Timestamp
+	}
+	return ""
+}
+
+func (m *CachestatEvent) GetScope() string {
+	if m != nil {
+		return m.Scope
+	}
+	return ""
+}
+
+func (m *CachestatEvent) GetPod() *PodInfo {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func (m *CachestatEvent) GetHits() uint64 {
+	if m != nil {
+		return m.Hits
+	}
+	return 0
+}
+
+func (m *CachestatEvent) GetMisses() uint64 {
+	if m != nil {
+		return m.Misses
+	}
+	return 0
+}
+
+func (m *CachestatEvent) GetDirties() uint64 {
+	if m != nil {
+		return m.Dirties
+	}
+	return 0
+}
+
+func (m *CachestatEvent) GetHitRatio() float64 {
+	if m != nil {
+		return m.HitRatio
+	}
+	return 0
+}
+
+func (m *CachestatEvent) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+type TcpsubnetEvent struct {
+	// RFC 3339
+	Timestamp string `protobuf:"bytes,1,opt,name=timestamp" json:"timestamp,omitempty"`
+	// Empty for processes that are not in a container
+	Pod      *PodInfo `protobuf:"bytes,2,opt,name=pod" json:"pod,omitempty"`
+	Subnet   string   `protobuf:"bytes,3,opt,name=subnet" json:"subnet,omitempty"`
+	Sent     uint64   `protobuf:"varint,4,opt,name=sent" json:"sent,omitempty"`
+	Received uint64   `protobuf:"varint,5,opt,name=received" json:"received,omitempty"`
+	// Summary of the incomplete last interval
+	Partial bool `protobuf:"varint,6,opt,name=partial" json:"partial,omitempty"`
+}
+
+func (m *TcpsubnetEvent) Reset()                    { *m = TcpsubnetEvent{} }
+func (m *TcpsubnetEvent) String() string            { return proto.CompactTextString(m) }
+func (*TcpsubnetEvent) ProtoMessage()               {}
+func (*TcpsubnetEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *TcpsubnetEvent) GetTimestamp() string {
+	if m != nil {
+		return m.Timestamp
+	}
+	return ""
+}
+
+func (m *TcpsubnetEvent) GetPod() *PodInfo {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func (m *TcpsubnetEvent) GetSubnet() string {
+	if m != nil {
+		return m.Subnet
+	}
+	return ""
+}
+
+func (m *TcpsubnetEvent) GetSent() uint64 {
+	if m != nil {
+		return m.Sent
+	}
+	return 0
+}
+
+func (m *TcpsubnetEvent) GetReceived() uint64 {
+	if m != nil {
+		return m.Received
+	}
+	return 0
+}
+
+func (m *TcpsubnetEvent) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+type SwapinEvent struct {
+	// RFC 3339, with microseconds
+	Timestamp string   `protobuf:"bytes,1,opt,name=timestamp" json:"timestamp,omitempty"`
+	Pid       uint32   `protobuf:"varint,2,opt,name=pid" json:"pid,omitempty"`
+	Comm      string   `protobuf:"bytes,3,opt,name=comm" json:"comm,omitempty"`
+	Pod       *PodInfo `protobuf:"bytes,4,opt,name=pod" json:"pod,omitempty"`
+	// Whether the page was read from the swap device
+	Major bool `protobuf:"varint,5,opt,name=major" json:"major,omitempty"`
+	// Time spent handling the page fault
+	LatencyUs uint64 `protobuf:"varint,6,opt,name=latency_us,json=latencyUs" json:"latency_us,omitempty"`
+}
+
+func (m *SwapinEvent) Reset()                    { *m = SwapinEvent{} }
+func (m *SwapinEvent) String() string            { return proto.CompactTextString(m) }
+func (*SwapinEvent) ProtoMessage()               {}
+func (*SwapinEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *SwapinEvent) GetTimestamp() string {
+	if m != nil {
+		return m.Timestamp
+	}
+	return ""
+}
+
+func (m *SwapinEvent) GetPid() uint32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *SwapinEvent) GetComm() string {
+	if m != nil {
+		return m.Comm
+	}
+	return ""
+}
+
+func (m *SwapinEvent) GetPod() *PodInfo {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func (m *SwapinEvent) GetMajor() bool {
+	if m != nil {
+		return m.Major
+	}
+	return false
+}
+
+func (m *SwapinEvent) GetLatencyUs() uint64 {
+	if m != nil {
+		return m.LatencyUs
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Event)(nil), "eventpb.Event")
+	proto.RegisterType((*PodInfo)(nil), "eventpb.PodInfo")
+	proto.RegisterType((*TcpconnlatEvent)(nil), "eventpb.TcpconnlatEvent")
+	proto.RegisterType((*Credentials)(nil), "eventpb.Credentials")
+	proto.RegisterType((*UgidsnoopEvent)(nil), "eventpb.UgidsnoopEvent")
+	proto.RegisterType((*CachestatEvent)(nil), "eventpb.CachestatEvent")
+	proto.RegisterType((*TcpsubnetEvent)(nil), "eventpb.TcpsubnetEvent")
+	proto.RegisterType((*SwapinEvent)(nil), "eventpb.SwapinEvent")
+	proto.RegisterEnum("eventpb.ContainerReady", ContainerReady_name, ContainerReady_value)
+}
+
+func init() { proto.RegisterFile("events.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 761 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xcd, 0x6e, 0x33, 0x35,
+	0x14, 0xed, 0x24, 0x93, 0x49, 0xe6, 0xa6, 0x49, 0x83, 0x55, 0x95, 0x11, 0x3f, 0x52, 0x19, 0x24,
+	0x54, 0xb1, 0xc8, 0xa2, 0x2c, 0x90, 0x58, 0x51, 0xda, 0x20, 0x2a, 0x50, 0xa9, 0xdc, 0x06, 0xc4,
+	0x2a, 0x72, 0x6d, 0xb7, 0x31, 0xca, 0xd8, 0xd6, 0xd8, 0x49, 0x55, 0x9e, 0x06, 0x89, 0x05, 0x12,
+	0x6b, 0x56, 0xdf, 0xfb, 0x7c, 0xef, 0xf1, 0xc9, 0x1e, 0xcf, 0xe4, 0xa7, 0x52, 0xdb, 0xdd, 0x3d,
+	0xc7, 0xe7, 0xcc, 0xf5, 0xbd, 0xbe, 0xf6, 0xc0, 0x3e, 0x5f, 0x71, 0x69, 0xcd, 0x58, 0x97, 0xca,
+	0x2a, 0xd4, 0xf5, 0x48, 0xdf, 0xe5, 0xff, 0xb6, 0xa0, 0x33, 0x71, 0x31, 0xfa, 0x0e, 0xc0, 0x52,
+	0x4d, 0x95, 0x94, 0x0b, 0x62, 0xb3, 0xe8, 0x38, 0x3a, 0xe9, 0x9f, 0x66, 0xe3, 0xa0, 0x1b, 0xdf,
+	0x36, 0x4b, 0x5e, 0xfd, 0xd3, 0x1e, 0xde, 0x50, 0xa3, 0x6f, 0x21, 0x5d, 0x3e, 0x08, 0x66, 0xa4,
+	0x52, 0x3a, 0x6b, 0x79, 0xeb, 0xc7, 0x8d, 0x75, 0x5a, 0xaf, 0xd4, 0xce, 0xb5, 0xd6, 0x19, 0x29,
+	0xa1, 0x73, 0x6e, 0x2c, 0xb1, 0x59, 0x7b, 0xc7, 0x78, 0x5e, 0xaf, 0x34, 0xc6, 0x46, 0xeb, 0x8c,
+	0x96, 0x6a, 0xb3, 0xbc, 0x93, 0xdc, 0x66, 0xf1, 0x8e, 0xf1, 0xb6, 0x5e, 0x69, 0x8c, 0x8d, 0x16,
+	0x8d, 0x21, 0x31, 0x8f, 0x44, 0x0b, 0x99, 0x75, 0xbc, 0xeb, 0xb0, 0x71, 0xdd, 0x78, 0xba, 0xb6,
+	0x04, 0xd5, 0x0f, 0x5d, 0xe8, 0x78, 0x41, 0xfe, 0x17, 0x74, 0xaf, 0x15, 0xbb, 0x94, 0xf7, 0x0a,
+	0x7d, 0x01, 0xfb, 0x54, 0x49, 0x4b, 0x84, 0xe4, 0xe5, 0x4c, 0x30, 0xdf, 0xac, 0x14, 0xf7, 0x1b,
+	0xee, 0x92, 0xa1, 0xcf, 0x20, 0x95, 0xa4, 0xe0, 0x46, 0x13, 0xca, 0x7d, 0x47, 0x52, 0xbc, 0x26,
+	0xd0, 0x08, 0xda, 0x5a, 0x31, 0x5f, 0x70, 0x8a, 0x5d, 0xe8, 0xf4, 0x8d, 0xdd, 0xd7, 0x93, 0xe2,
+	0x35, 0x91, 0xbf, 0x6b, 0xc1, 0xc1, 0xce, 0x09, 0xf8, 0x6f, 0x84, 0xdc, 0x03, 0xec, 0x42, 0x84,
+	0x20, 0xa6, 0xaa, 0x28, 0x42, 0x3a, 0x1f, 0xa3, 0x7c, 0x9d, 0xa9, 0x7f, 0x3a, 0x6a, 0x6a, 0x0d,
+	0x95, 0x54, 0xb9, 0x3f, 0x85, 0x54, 0x2b, 0x36, 0xd3, 0x73, 0x62, 0x78, 0xc8, 0xdd, 0xd3, 0x8a,
+	0x5d, 0x3b, 0x8c, 0xbe, 0x87, 0x83, 0x75, 0xad, 0x25, 0x27, 0xec, 0xc9, 0x37, 0x6e, 0xb8, 0x79,
+	0x4e, 0xf5, 0x3a, 0x76, 0xcb, 0x78, 0x48, 0xb7, 0x30, 0xfa, 0x1c, 0x40, 0xe8, 0xd9, 0x8a, 0x97,
+	0x46, 0x28, 0x99, 0x25, 0x7e, 0xbf, 0xa9, 0xd0, 0xbf, 0x55, 0x04, 0x3a, 0x84, 0x8e, 0x21, 0x8c,
+	0x95, 0x59, 0xd7, 0x67, 0xae, 0x80, 0x63, 0x99, 0x67, 0x7b, 0x15, 0xcb, 0x1a, 0x56, 0xab, 0xd2,
+	0x66, 0xa9, 0xff, 0x4a, 0x05, 0x5c, 0x82, 0x05, 0xb1, 0x5c, 0xd2, 0xa7, 0xd9, 0xd2, 0x64, 0x70,
+	0x1c, 0x9d, 0xc4, 0x38, 0x0d, 0xcc, 0xd4, 0xe4, 0xff, 0x44, 0xd0, 0x3f, 0x2f, 0x39, 0xe3, 0xd2,
+	0x0a, 0xb2, 0x30, 0xae, 0x71, 0xcb, 0x75, 0xe3, 0x96, 0x82, 0x39, 0xe6, 0x41, 0x30, 0xdf, 0xb7,
+	0x01, 0x6e, 0x3f, 0x54, 0xad, 0xe4, 0x4b, 0x51, 0xf5, 0x6d, 0x80, 0x7d, 0xec, 0x39, 0x27, 0x8b,
+	0x03, 0xe7, 0x74, 0x5f, 0xc2, 0x80, 0x12, 0x3d, 0xe3, 0xf7, 0xf7, 0x9c, 0x5a, 0xb1, 0xe2, 0xbe,
+	0x37, 0x31, 0xde, 0xa7, 0x44, 0x4f, 0x6a, 0xae, 0x16, 0x69, 0x5e, 0x16, 0xc2, 0x5a, 0xce, 0xb2,
+	0xa4, 0x11, 0x5d, 0xd7, 0x5c, 0xfe, 0x77, 0x04, 0xc3, 0xed, 0x9b, 0xf2, 0xc6, 0x13, 0xce, 0xa0,
+	0x6b, 0x9e, 0x0c, 0x25, 0x8b, 0x45, 0x98, 0xa7, 0x1a, 0xa2, 0xaf, 0xa0, 0xad, 0x16, 0x2c, 0x8b,
+	0x77, 0xe6, 0x7c, 0xa3, 0x17, 0xd8, 0x09, 0x9c, 0x4e, 0xf2, 0xc7, 0xac, 0xf3, 0x92, 0x4e, 0xf2,
+	0xc7, 0xfc, 0x7d, 0x04, 0xc3, 0xed, 0x3b, 0xe9, 0xc6, 0xd6, 0x8a, 0xc2, 0x11, 0x85, 0x0e, 0xd7,
+	0x60, 0x4d, 0xf8, 0xa3, 0xa5, 0x4a, 0xd7, 0x17, 0xa0, 0x02, 0x6f, 0x1a, 0x49, 0x04, 0xf1, 0x5c,
+	0x58, 0xe3, 0xf7, 0x1e, 0x63, 0x1f, 0xa3, 0x23, 0x48, 0x0a, 0x61, 0x0c, 0x37, 0xa1, 0xc9, 0x01,
+	0xb9, 0x06, 0x30, 0x51, 0x5a, 0xc1, 0x4d, 0x68, 0x6c, 0x0d, 0xdd, 0x60, 0xcf, 0x85, 0x9d, 0x95,
+	0xc4, 0x0a, 0xe5, 0xc7, 0x2b, 0xc2, 0xbd, 0xb9, 0xb0, 0xd8, 0x61, 0x67, 0xd3, 0xa4, 0x74, 0xe5,
+	0xf9, 0x19, 0xeb, 0xe1, 0x1a, 0xe6, 0xff, 0x47, 0x30, 0xdc, 0x7e, 0x42, 0x5e, 0xa9, 0x33, 0x54,
+	0xd4, 0x7a, 0xa9, 0xa2, 0x23, 0x48, 0xc2, 0x6b, 0x55, 0x9d, 0x52, 0x40, 0xae, 0x52, 0xc3, 0xa5,
+	0xad, 0x2b, 0x75, 0x31, 0xfa, 0x04, 0x7a, 0x25, 0xa7, 0x5c, 0xac, 0x38, 0x0b, 0xb5, 0x36, 0x78,
+	0x73, 0xdb, 0xc9, 0xf6, 0xb6, 0xff, 0x8b, 0xa0, 0xbf, 0xf1, 0x86, 0xbd, 0xb2, 0xe7, 0x30, 0x5c,
+	0xad, 0xe7, 0xc3, 0xd5, 0x7e, 0xfe, 0x7c, 0xc4, 0x2f, 0x55, 0x76, 0x08, 0x9d, 0x82, 0xfc, 0xa9,
+	0x4a, 0xbf, 0xd5, 0x1e, 0xae, 0xc0, 0xce, 0xa5, 0x4c, 0x76, 0x2e, 0xe5, 0xd7, 0x17, 0x30, 0xdc,
+	0x7e, 0x36, 0xd0, 0x47, 0x30, 0xc0, 0x93, 0xb3, 0x8b, 0x3f, 0x66, 0xd3, 0xab, 0x9f, 0xaf, 0x7e,
+	0xfd, 0xfd, 0x6a, 0xb4, 0x87, 0x0e, 0xa0, 0x5f, 0x51, 0x3f, 0x9e, 0xfd, 0x72, 0x33, 0x19, 0x45,
+	0x68, 0x08, 0x50, 0x11, 0xb7, 0x78, 0x3a, 0x19, 0xb5, 0xee, 0x12, 0xff, 0x37, 0xfb, 0xe6, 0xc3,
+	0x00, 0x8c, 0xc8, 0x5b, 0xae, 0xdd, 0x06, 0x00, 0x00,
+}
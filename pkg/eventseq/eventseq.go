@@ -0,0 +1,59 @@
+// Package eventseq follows the sequence numbers of the events printed by the
+// gadgets, to detect the events lost between the nodes and the user.
+//
+// A gadget numbers its events from 1 each time it starts, and counts the
+// samples lost in its perf buffer too: a gap in the sequence means events
+// were lost, and a sequence going back means the gadget was restarted, for
+// instance with the gadget pod, and its numbering was reset.
+package eventseq
+
+import (
+	"encoding/json"
+)
+
+// Seq returns the sequence number of an event, from the "seq" field of the
+// JSON events of the gadgets
+func Seq(line string) (uint64, bool) {
+	var event struct {
+		Seq uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Seq == 0 {
+		return 0, false
+	}
+	return event.Seq, true
+}
+
+// Tracker follows the sequence numbers of the events of a gadget on one
+// node, in the order they are printed by the gadget
+type Tracker struct {
+	last     uint64
+	lost     uint64
+	restarts int
+}
+
+// Next records the sequence number of the next event. It returns the number
+// of events lost since the previous one, and whether the gadget was
+// restarted in between, the events lost then being those before seq in the
+// new sequence.
+func (t *Tracker) Next(seq uint64) (lost uint64, restarted bool) {
+	if seq <= t.last {
+		restarted = true
+		t.restarts++
+		lost = seq - 1
+	} else {
+		lost = seq - t.last - 1
+	}
+	t.last = seq
+	t.lost += lost
+	return lost, restarted
+}
+
+// Lost returns the number of events lost so far
+func (t *Tracker) Lost() uint64 {
+	return t.lost
+}
+
+// Restarts returns the number of times the gadget was restarted
+func (t *Tracker) Restarts() int {
+	return t.restarts
+}
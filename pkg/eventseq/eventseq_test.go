@@ -0,0 +1,62 @@
+package eventseq
+
+import (
+	"testing"
+)
+
+func TestSeq(t *testing.T) {
+	seq, ok := Seq(`{"timestamp":"2020-06-01T12:00:01.000001Z","pid":42,"seq":1234}`)
+	if !ok || seq != 1234 {
+		t.Fatalf("unexpected sequence number %d (%t)", seq, ok)
+	}
+	for _, line := range []string{
+		`{"timestamp":"2020-06-01T12:00:01.000001Z","pid":42}`,
+		"Possibly lost 3 samples",
+		"",
+	} {
+		if _, ok := Seq(line); ok {
+			t.Fatalf("unexpected sequence number in %q", line)
+		}
+	}
+}
+
+func TestTrackerMonotonic(t *testing.T) {
+	var tracker Tracker
+	for seq := uint64(1); seq <= 100; seq++ {
+		if lost, restarted := tracker.Next(seq); lost != 0 || restarted {
+			t.Fatalf("seq %d: unexpected %d lost (restarted: %t)", seq, lost, restarted)
+		}
+	}
+	if tracker.Lost() != 0 || tracker.Restarts() != 0 {
+		t.Fatalf("unexpected %d lost, %d restarts", tracker.Lost(), tracker.Restarts())
+	}
+}
+
+func TestTrackerGapsAndRestarts(t *testing.T) {
+	var tracker Tracker
+	for _, step := range []struct {
+		seq       uint64
+		lost      uint64
+		restarted bool
+	}{
+		// The first events were lost
+		{3, 2, false},
+		{4, 0, false},
+		{10, 5, false},
+		{11, 0, false},
+		// The gadget was restarted and lost its first event
+		{2, 1, true},
+		{3, 0, false},
+		// Restarted again, without losing events
+		{1, 0, true},
+	} {
+		lost, restarted := tracker.Next(step.seq)
+		if lost != step.lost || restarted != step.restarted {
+			t.Fatalf("seq %d: %d lost (restarted: %t), expected %d (restarted: %t)",
+				step.seq, lost, restarted, step.lost, step.restarted)
+		}
+	}
+	if tracker.Lost() != 8 || tracker.Restarts() != 2 {
+		t.Fatalf("unexpected %d lost, %d restarts", tracker.Lost(), tracker.Restarts())
+	}
+}
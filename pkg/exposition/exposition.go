@@ -0,0 +1,194 @@
+// Package exposition writes snapshots of aggregated gadget data in the
+// Prometheus text exposition format, to be pushed to a Pushgateway or
+// scraped from a file.
+package exposition
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/histogram"
+)
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Labels of a sample. They are written sorted by name.
+type Labels map[string]string
+
+// Sample is a value of a counter or a gauge
+type Sample struct {
+	Labels Labels
+	Value  float64
+}
+
+// HistogramSample is a histogram with its labels
+type HistogramSample struct {
+	Labels    Labels
+	Histogram *histogram.Histogram
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// format returns the labels as {a="1",b="2"}, with extra labels appended,
+// or "" without labels
+func (l Labels) format(extra ...string) (string, error) {
+	names := make([]string, 0, len(l))
+	for name := range l {
+		if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return "", fmt.Errorf("invalid label name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(l[name])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], labelValueEscaper.Replace(extra[i+1])))
+	}
+	if len(pairs) == 0 {
+		return "", nil
+	}
+	return "{" + strings.Join(pairs, ",") + "}", nil
+}
+
+func writeHeader(w io.Writer, name, help, typ string) error {
+	if !metricNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid metric name %q", name)
+	}
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, helpEscaper.Replace(help), name, typ)
+	return err
+}
+
+type line struct {
+	labels string
+	text   string
+}
+
+// writeSorted writes lines sorted by labels, for a stable output
+func writeSorted(w io.Writer, lines []line) error {
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].labels < lines[j].labels })
+	for _, l := range lines {
+		if _, err := io.WriteString(w, l.text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSamples(w io.Writer, name, help, typ string, samples []Sample) error {
+	if err := writeHeader(w, name, help, typ); err != nil {
+		return err
+	}
+	var lines []line
+	for _, s := range samples {
+		labels, err := s.Labels.format()
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line{labels, fmt.Sprintf("%s%s %s\n", name, labels, formatFloat(s.Value))})
+	}
+	return writeSorted(w, lines)
+}
+
+// WriteCounter writes a counter metric
+func WriteCounter(w io.Writer, name, help string, samples []Sample) error {
+	return writeSamples(w, name, help, "counter", samples)
+}
+
+// WriteGauge writes a gauge metric
+func WriteGauge(w io.Writer, name, help string, samples []Sample) error {
+	return writeSamples(w, name, help, "gauge", samples)
+}
+
+// WriteHistogram writes a histogram metric. The bounds of the buckets and
+// the sum are divided by divisor, to convert them to the unit of the metric
+// (e.g. 1e6 for a histogram in microseconds and a metric in seconds).
+func WriteHistogram(w io.Writer, name, help string, divisor float64, samples []HistogramSample) error {
+	if err := writeHeader(w, name, help, "histogram"); err != nil {
+		return err
+	}
+	// All the series of the metric have the same buckets
+	buckets := make([][]histogram.Bucket, len(samples))
+	n := 0
+	for i, s := range samples {
+		buckets[i] = s.Histogram.Buckets()
+		if len(buckets[i]) > n {
+			n = len(buckets[i])
+		}
+	}
+
+	var lines []line
+	for i, s := range samples {
+		labels, err := s.Labels.format()
+		if err != nil {
+			return err
+		}
+		var sb strings.Builder
+		cumulative := uint64(0)
+		for j := 0; j < n; j++ {
+			max := uint64(1)<<uint(j+1) - 1
+			if j < len(buckets[i]) {
+				cumulative += buckets[i][j].Count
+			}
+			bucketLabels, _ := s.Labels.format("le", formatFloat(float64(max)/divisor))
+			fmt.Fprintf(&sb, "%s_bucket%s %d\n", name, bucketLabels, cumulative)
+		}
+		infLabels, _ := s.Labels.format("le", "+Inf")
+		fmt.Fprintf(&sb, "%s_bucket%s %d\n", name, infLabels, cumulative)
+		fmt.Fprintf(&sb, "%s_sum%s %s\n", name, labels, formatFloat(float64(s.Histogram.Sum())/divisor))
+		fmt.Fprintf(&sb, "%s_count%s %d\n", name, labels, cumulative)
+		lines = append(lines, line{labels, sb.String()})
+	}
+	return writeSorted(w, lines)
+}
+
+// Quantile is the value of a quantile of a summary, like the median for 0.5
+type Quantile struct {
+	Quantile float64
+	Value    float64
+}
+
+// SummarySample is a summary with its labels
+type SummarySample struct {
+	Labels    Labels
+	Quantiles []Quantile
+	Sum       float64
+	Count     uint64
+}
+
+// WriteSummary writes a summary metric
+func WriteSummary(w io.Writer, name, help string, samples []SummarySample) error {
+	if err := writeHeader(w, name, help, "summary"); err != nil {
+		return err
+	}
+	var lines []line
+	for _, s := range samples {
+		labels, err := s.Labels.format()
+		if err != nil {
+			return err
+		}
+		var sb strings.Builder
+		for _, q := range s.Quantiles {
+			quantileLabels, _ := s.Labels.format("quantile", formatFloat(q.Quantile))
+			fmt.Fprintf(&sb, "%s%s %s\n", name, quantileLabels, formatFloat(q.Value))
+		}
+		fmt.Fprintf(&sb, "%s_sum%s %s\n", name, labels, formatFloat(s.Sum))
+		fmt.Fprintf(&sb, "%s_count%s %d\n", name, labels, s.Count)
+		lines = append(lines, line{labels, sb.String()})
+	}
+	return writeSorted(w, lines)
+}
@@ -0,0 +1,115 @@
+package exposition
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/histogram"
+)
+
+// sampleRegexp matches a sample line of the text exposition format
+var sampleRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*` +
+	`(\{[a-zA-Z_][a-zA-Z0-9_]*="([^"\\]|\\.)*"(,[a-zA-Z_][a-zA-Z0-9_]*="([^"\\]|\\.)*")*\})?` +
+	` [-+]?([0-9.e+-]+|Inf|NaN)$`)
+
+func validate(t *testing.T, out string) {
+	if !strings.HasSuffix(out, "\n") {
+		t.Errorf("output does not end with a new line")
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if strings.HasPrefix(l, "# HELP ") || strings.HasPrefix(l, "# TYPE ") {
+			continue
+		}
+		if !sampleRegexp.MatchString(l) {
+			t.Errorf("invalid sample line %q", l)
+		}
+	}
+}
+
+func TestWriteHistogram(t *testing.T) {
+	web := &histogram.Histogram{}
+	for _, v := range []uint64{90, 120, 250, 3000} {
+		web.Add(v)
+	}
+	db := &histogram.Histogram{}
+	db.Add(1)
+
+	var out bytes.Buffer
+	err := WriteHistogram(&out, "tcpconnlat_latency_seconds",
+		"Latency of TCP connections", 1e6, []HistogramSample{
+			{Labels{"namespace": "demo", "pod": "web-1", "container": "web"}, web},
+			{Labels{"namespace": "demo", "pod": "db-0", "container": "db\"1\""}, db},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = WriteCounter(&out, "tcpconnlat_connections_total",
+		"Number of TCP connections\nwith a latency", []Sample{
+			{Labels{}, 5},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validate(t, out.String())
+
+	golden, err := ioutil.ReadFile("testdata/histogram.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != string(golden) {
+		t.Fatalf("Unexpected output:\n%s\nExpected:\n%s\n", out.String(), string(golden))
+	}
+}
+
+func TestInvalidNames(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteGauge(&out, "tcp-latency", "", nil); err == nil {
+		t.Errorf("invalid metric name accepted")
+	}
+	if err := WriteGauge(&out, "latency", "", []Sample{{Labels{"pod-name": "x"}, 1}}); err == nil {
+		t.Errorf("invalid label name accepted")
+	}
+	if err := WriteGauge(&out, "latency", "", []Sample{{Labels{"__name__": "x"}, 1}}); err == nil {
+		t.Errorf("reserved label name accepted")
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteSummary(&out, "gadget_event_latency_seconds", "Latency of the events", []SummarySample{
+		{
+			Labels:    Labels{"stage": "transport"},
+			Quantiles: []Quantile{{0.5, 0.25}, {0.99, 1.5}},
+			Sum:       3,
+			Count:     4,
+		},
+		{
+			Labels:    Labels{"stage": "enrichment"},
+			Quantiles: []Quantile{{0.5, 0.001}},
+			Sum:       0.002,
+			Count:     2,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate(t, out.String())
+
+	expected := `# HELP gadget_event_latency_seconds Latency of the events
+# TYPE gadget_event_latency_seconds summary
+gadget_event_latency_seconds{stage="enrichment",quantile="0.5"} 0.001
+gadget_event_latency_seconds_sum{stage="enrichment"} 0.002
+gadget_event_latency_seconds_count{stage="enrichment"} 2
+gadget_event_latency_seconds{stage="transport",quantile="0.5"} 0.25
+gadget_event_latency_seconds{stage="transport",quantile="0.99"} 1.5
+gadget_event_latency_seconds_sum{stage="transport"} 3
+gadget_event_latency_seconds_count{stage="transport"} 4
+`
+	if out.String() != expected {
+		t.Fatalf("Unexpected output:\n%s\nExpected:\n%s\n", out.String(), expected)
+	}
+}
@@ -0,0 +1,169 @@
+// Package fieldmap renames the fields of the JSON output of the gadgets, to
+// match the schema of existing logs (e.g. "pod" to "k8s.pod.name").
+package fieldmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// FieldMap maps the names of the top-level fields of the JSON events to new
+// names. Fields not in the map keep their names.
+type FieldMap map[string]string
+
+// Load reads a field map from a YAML file like:
+//
+//	pod: k8s.pod.name
+//	namespace: k8s.namespace.name
+func Load(path string) (FieldMap, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a field map in YAML
+func Parse(data []byte) (FieldMap, error) {
+	m := FieldMap{}
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid field map: %w", err)
+	}
+	targets := map[string]string{}
+	for from, to := range m {
+		if to == "" {
+			return nil, fmt.Errorf("invalid field map: empty name for field %q", from)
+		}
+		if other, ok := targets[to]; ok {
+			return nil, fmt.Errorf("invalid field map: fields %q and %q both renamed to %q", other, from, to)
+		}
+		targets[to] = from
+	}
+	return m, nil
+}
+
+// Check returns an error if the map renames fields that are not in fields
+func (m FieldMap) Check(fields []string) error {
+	known := map[string]struct{}{}
+	for _, f := range fields {
+		known[f] = struct{}{}
+	}
+	var unknown []string
+	for from := range m {
+		if _, ok := known[from]; !ok {
+			unknown = append(unknown, from)
+		}
+	}
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields in the field map: %s (the fields are: %s)",
+			strings.Join(unknown, ", "), strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// JSONFields returns the names of the top-level JSON fields of a struct, as
+// encoded by encoding/json
+func JSONFields(v interface{}) []string {
+	var fields []string
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// Apply renames the fields of a JSON object, keeping their order
+func (m FieldMap) Apply(line []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object: %q", line)
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; dec.More(); i++ {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name := t.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if to, ok := m[name]; ok {
+			name = to
+		}
+		key, _ := json.Marshal(name)
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	m      FieldMap
+	buffer []byte // incomplete line
+}
+
+// NewWriter returns a writer applying the field map to each line written
+// before writing it to w. Lines that are not JSON objects are written as is.
+func NewWriter(w io.Writer, m FieldMap) io.Writer {
+	return &writer{w: w, m: m}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := w.buffer[:i]
+		if mapped, err := w.m.Apply(line); err == nil {
+			line = mapped
+		}
+		if _, err := fmt.Fprintf(w.w, "%s\n", line); err != nil {
+			return 0, err
+		}
+		w.buffer = w.buffer[i+1:]
+	}
+}
@@ -0,0 +1,63 @@
+package fieldmap
+
+import (
+	"bytes"
+	"testing"
+)
+
+type event struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod"`
+	Pid       uint32
+	Ignored   string `json:"-"`
+	internal  string
+}
+
+func TestApply(t *testing.T) {
+	m, err := Parse([]byte("pod: k8s.pod.name\nnamespace: k8s.namespace.name\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(JSONFields(event{})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mock := &bytes.Buffer{}
+	w := NewWriter(mock, m)
+	w.Write([]byte(`{"type":"connect","namespace":"demo","pod":"web-1","pid":4242,"saddr":{"ip":"10.0.0.1"}}` + "\n" + `{"type":"close",`))
+	w.Write([]byte(`"pod":"web-1","latency":1.5e-3}` + "\nnot json\n"))
+
+	expected := `{"type":"connect","k8s.namespace.name":"demo","k8s.pod.name":"web-1","pid":4242,"saddr":{"ip":"10.0.0.1"}}
+{"type":"close","k8s.pod.name":"web-1","latency":1.5e-3}
+not json
+`
+	if mock.String() != expected {
+		t.Fatalf("%v != %v", mock.String(), expected)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if fields := JSONFields(&event{}); len(fields) != 4 || fields[3] != "Pid" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	m, err := Parse([]byte("pod: k8s.pod.name\npodname: k8s.pod.name2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(JSONFields(event{})); err == nil {
+		t.Fatalf("unknown field podname not reported")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{
+		"pod: [a, b]\n",
+		"pod: k8s.name\nnamespace: k8s.name\n",
+		"pod: \"\"\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%q: expected an error", data)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package biosnoop
+
+// Event is a block I/O request, as printed by the biosnoop gadget, completed
+// with the pod of the container of the issuing process by kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* Name of the block device, like sda or nvme0n1 */
+	Disk string `json:"disk"`
+
+	/* read, write, flush or discard */
+	Operation string `json:"operation"`
+
+	/* First sector of the request, in 512-byte sectors, and its size */
+	Sector uint64 `json:"sector"`
+	Bytes  uint64 `json:"bytes"`
+
+	/* Time from the request being sent to the device driver to its
+	 * completion */
+	LatencyUs uint64 `json:"latency_us"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
@@ -0,0 +1,44 @@
+package bpfmetrics
+
+// Event is the run count and run time of a BPF program loaded by a gadget
+// over an interval, as printed by the bpfmetrics gadget
+type Event struct {
+	Timestamp string `json:"timestamp"`
+
+	/* Process holding the program, and the gadget it runs, like execsnoop */
+	PID      uint32 `json:"pid"`
+	Gadget   string `json:"gadget"`
+	TracerID string `json:"tracerid,omitempty"`
+
+	ProgID uint32 `json:"progid"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+
+	RunCount  uint64 `json:"runcount"`
+	RunTimeNs uint64 `json:"runtimens"`
+
+	/* Duration of the interval, in seconds */
+	Interval float64 `json:"interval"`
+
+	/* Summary of the incomplete last interval, printed when the gadget
+	 * is stopped */
+	Partial bool `json:"partial,omitempty"`
+}
+
+// AverageNs returns the average run time of the program, in nanoseconds, or
+// 0 if it did not run
+func (e Event) AverageNs() uint64 {
+	if e.RunCount == 0 {
+		return 0
+	}
+	return e.RunTimeNs / e.RunCount
+}
+
+// CPUShare returns the share of one CPU spent running the program over the
+// interval, like 0.01 for 1%
+func (e Event) CPUShare() float64 {
+	if e.Interval <= 0 {
+		return 0
+	}
+	return float64(e.RunTimeNs) / (e.Interval * 1e9)
+}
@@ -0,0 +1,35 @@
+package bpfmetrics
+
+import (
+	"testing"
+)
+
+func TestAverageNs(t *testing.T) {
+	table := []struct {
+		event    Event
+		expected uint64
+	}{
+		{Event{RunCount: 4, RunTimeNs: 1000}, 250},
+		{Event{RunCount: 0, RunTimeNs: 0}, 0},
+	}
+	for _, entry := range table {
+		if avg := entry.event.AverageNs(); avg != entry.expected {
+			t.Errorf("%+v: %d != %d", entry.event, avg, entry.expected)
+		}
+	}
+}
+
+func TestCPUShare(t *testing.T) {
+	table := []struct {
+		event    Event
+		expected float64
+	}{
+		{Event{RunTimeNs: 50000000, Interval: 5}, 0.01},
+		{Event{RunTimeNs: 1000, Interval: 0}, 0},
+	}
+	for _, entry := range table {
+		if share := entry.event.CPUShare(); share != entry.expected {
+			t.Errorf("%+v: %v != %v", entry.event, share, entry.expected)
+		}
+	}
+}
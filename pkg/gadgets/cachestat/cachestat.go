@@ -0,0 +1,38 @@
+package cachestat
+
+const (
+	// ScopeNode is the scope of the counts of the whole node
+	ScopeNode = "node"
+	// ScopeContainer is the scope of the counts of one container
+	ScopeContainer = "container"
+)
+
+// Event is a summary of the page cache activity over an interval, as printed
+// by the cachestat gadget, completed with the pod of the container by
+// kubectl-gadget
+type Event struct {
+	Timestamp string `json:"timestamp"`
+
+	/* ScopeNode or ScopeContainer */
+	Scope       string `json:"scope"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Dirties uint64 `json:"dirties"`
+
+	/* Hits / (Hits + Misses), 0 without page cache accesses */
+	HitRatio float64 `json:"hitratio"`
+
+	/* Summary of the incomplete last interval, printed when the gadget
+	 * is stopped */
+	Partial bool `json:"partial,omitempty"`
+
+	/* Summary recorded on the node before the gadget was started, with
+	 * kubectl gadget deploy --history */
+	History bool `json:"history,omitempty"`
+}
@@ -0,0 +1,71 @@
+// Package dnsconnect describes the connections printed by the dnsconnect
+// gadget, with the DNS names resolved by the processes.
+package dnsconnect
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DefaultMaxAge is how long the addresses resolved by a process are kept by
+// default
+const DefaultMaxAge = 60 * time.Second
+
+// MaxMaxAge is the longest --max-age: the resolutions are kept in the gadget
+// pods, for each process
+const MaxMaxAge = 10 * time.Minute
+
+// Event is a TCP connection as printed by the dnsconnect gadget, completed
+// with the pod of the container by kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* 4 or 6 */
+	IPVersion int    `json:"ipversion"`
+	Daddr     string `json:"daddr"`
+	Dport     uint16 `json:"dport"`
+
+	/* Name resolved by the process to Daddr, the one asked in the DNS
+	 * query, empty if the process didn't resolve it within --max-age */
+	Name string `json:"name,omitempty"`
+	/* Time between the DNS response and the connection */
+	ResolvedMs *uint64 `json:"resolved_ms,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// CheckMaxAge checks the --max-age of the gadget
+func CheckMaxAge(maxAge time.Duration) error {
+	if maxAge < time.Second || maxAge > MaxMaxAge {
+		return fmt.Errorf("--max-age must be between 1s and %s", MaxMaxAge)
+	}
+	return nil
+}
+
+// Destination returns the address and port of the connection, preceded by
+// the name resolved by the process if any, like
+// "api.example.com -> 203.0.113.10:443"
+func Destination(e Event) string {
+	addr := net.JoinHostPort(e.Daddr, strconv.Itoa(int(e.Dport)))
+	if e.Name == "" {
+		return addr
+	}
+	return e.Name + " -> " + addr
+}
+
+// FlowKey returns the flow of a connection, to suppress the connections
+// repeating it: the container, the command, the destination and the name
+// it was resolved from
+func FlowKey(e Event) string {
+	return fmt.Sprintf("%s|%s|%s|%d|%s", e.ContainerID, e.Comm, e.Daddr, e.Dport, e.Name)
+}
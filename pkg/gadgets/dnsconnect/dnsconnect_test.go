@@ -0,0 +1,35 @@
+package dnsconnect
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDestination(t *testing.T) {
+	table := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Daddr: "203.0.113.10", Dport: 443, Name: "api.example.com"}, "api.example.com -> 203.0.113.10:443"},
+		{Event{Daddr: "2001:db8::1", Dport: 443, Name: "api.example.com"}, "api.example.com -> [2001:db8::1]:443"},
+		{Event{Daddr: "10.96.0.1", Dport: 443}, "10.96.0.1:443"},
+	}
+	for _, entry := range table {
+		if d := Destination(entry.event); d != entry.expected {
+			t.Errorf("%q != %q", d, entry.expected)
+		}
+	}
+}
+
+func TestCheckMaxAge(t *testing.T) {
+	for _, maxAge := range []time.Duration{time.Second, DefaultMaxAge, MaxMaxAge} {
+		if err := CheckMaxAge(maxAge); err != nil {
+			t.Errorf("%s: unexpected error %s", maxAge, err)
+		}
+	}
+	for _, maxAge := range []time.Duration{0, 500 * time.Millisecond, time.Hour} {
+		if err := CheckMaxAge(maxAge); err == nil {
+			t.Errorf("%s: expected an error", maxAge)
+		}
+	}
+}
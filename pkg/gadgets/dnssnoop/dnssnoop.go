@@ -0,0 +1,117 @@
+// Package dnssnoop describes the DNS messages printed by the dnssnoop
+// gadget, sent and received over UDP and TCP.
+package dnssnoop
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// EDNS is the OPT record of a DNS message, see RFC 6891
+type EDNS struct {
+	/* UDP payload size of the sender */
+	UDPSize int `json:"udpsize"`
+	Version int `json:"version"`
+	/* DNSSEC OK */
+	DO bool `json:"do"`
+	/* Options, like "cookie" or "client-subnet", or their code when
+	 * unknown */
+	Options []string `json:"options,omitempty"`
+}
+
+// Event is a DNS message as printed by the dnssnoop gadget, completed with
+// the pod of the container by kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	/* The pod uses the network of the node: its messages are the ones of
+	 * the node, sent from and received on its addresses */
+	HostNetwork bool `json:"hostnetwork,omitempty"`
+
+	/* "udp" or "tcp" */
+	Transport string `json:"transport"`
+	/* "sent" or "received" */
+	Direction string `json:"direction"`
+	/* Size of the message, without the length prefix over TCP */
+	Size int `json:"size"`
+
+	/* Remote address and port of the socket, unknown for some unconnected
+	 * sockets */
+	IPVersion int    `json:"ipversion,omitempty"`
+	Raddr     string `json:"raddr,omitempty"`
+	Rport     uint16 `json:"rport,omitempty"`
+
+	ID uint16 `json:"id"`
+	/* "query" or "response" */
+	QR    string `json:"qr"`
+	Name  string `json:"name"`
+	QType string `json:"qtype"`
+	/* Flags of the header set, among aa, tc, rd, ra, ad and cd */
+	Flags     []string `json:"flags"`
+	Truncated bool     `json:"truncated"`
+	Answers   int      `json:"answers"`
+	/* Responses only, with the extended RCODE of EDNS */
+	Rcode string `json:"rcode,omitempty"`
+	EDNS  *EDNS  `json:"edns,omitempty"`
+	/* Only the beginning of the message was copied by the gadget: EDNS is
+	 * unknown when not set */
+	Partial bool `json:"partial,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Peer returns the remote address and port of the message, like
+// "10.96.0.10:53", or "-" when unknown
+func Peer(e Event) string {
+	if e.Raddr == "" {
+		return "-"
+	}
+	return net.JoinHostPort(e.Raddr, strconv.Itoa(int(e.Rport)))
+}
+
+// FlagsString returns the flags of the header, comma-separated, or "-"
+func FlagsString(e Event) string {
+	if len(e.Flags) == 0 {
+		return "-"
+	}
+	return strings.Join(e.Flags, ",")
+}
+
+// EDNSString returns the EDNS fields of the message, like
+// "1232,do,cookie": the UDP payload size, do when DNSSEC OK is set, the
+// version when not 0, then the options. It is "-" without EDNS, and "?" when
+// the OPT record was not copied.
+func EDNSString(e Event) string {
+	if e.EDNS == nil {
+		if e.Partial {
+			return "?"
+		}
+		return "-"
+	}
+	fields := []string{strconv.Itoa(e.EDNS.UDPSize)}
+	if e.EDNS.DO {
+		fields = append(fields, "do")
+	}
+	if e.EDNS.Version != 0 {
+		fields = append(fields, fmt.Sprintf("v%d", e.EDNS.Version))
+	}
+	fields = append(fields, e.EDNS.Options...)
+	return strings.Join(fields, ",")
+}
+
+// FlowKey returns the flow of a DNS message, to suppress the messages
+// repeating it, like the same query sent again and again: the container,
+// the command, the peer and the question, without the id of the message
+func FlowKey(e Event) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%d|%s|%s|%s|%s", e.ContainerID, e.Comm, e.Transport, e.Direction,
+		e.Raddr, e.Rport, e.QR, e.Name, e.QType, e.Rcode)
+}
@@ -0,0 +1,48 @@
+package dnssnoop
+
+import (
+	"testing"
+)
+
+func TestPeer(t *testing.T) {
+	table := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Raddr: "10.96.0.10", Rport: 53}, "10.96.0.10:53"},
+		{Event{Raddr: "fd00::a", Rport: 53}, "[fd00::a]:53"},
+		{Event{}, "-"},
+	}
+	for _, entry := range table {
+		if p := Peer(entry.event); p != entry.expected {
+			t.Errorf("%q != %q", p, entry.expected)
+		}
+	}
+}
+
+func TestEDNSString(t *testing.T) {
+	table := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{EDNS: &EDNS{UDPSize: 1232, DO: true, Options: []string{"cookie"}}}, "1232,do,cookie"},
+		{Event{EDNS: &EDNS{UDPSize: 4096}}, "4096"},
+		{Event{EDNS: &EDNS{UDPSize: 512, Version: 1}}, "512,v1"},
+		{Event{}, "-"},
+		{Event{Partial: true}, "?"},
+	}
+	for _, entry := range table {
+		if s := EDNSString(entry.event); s != entry.expected {
+			t.Errorf("%q != %q", s, entry.expected)
+		}
+	}
+}
+
+func TestFlagsString(t *testing.T) {
+	if s := FlagsString(Event{Flags: []string{"tc", "rd", "ra"}}); s != "tc,rd,ra" {
+		t.Errorf("unexpected flags %q", s)
+	}
+	if s := FlagsString(Event{Flags: []string{}}); s != "-" {
+		t.Errorf("unexpected flags %q", s)
+	}
+}
@@ -0,0 +1,182 @@
+// Package execsnoop describes the processes printed by the execsnoop gadget,
+// and filters the environment variables captured with --env, redacting the
+// ones likely to hold secrets.
+package execsnoop
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultDeny are the patterns of the names of the variables whose
+	// value is redacted by default
+	DefaultDeny = "*PASSWORD*,*PASSWD*,*SECRET*,*TOKEN*,*KEY*,*CREDENTIAL*,*AUTH*,*COOKIE*"
+
+	// Redacted replaces the value of the denied variables
+	Redacted = "<redacted>"
+
+	// TruncationMarker is appended to the arguments truncated by the gadget
+	// when printing them
+	TruncationMarker = "..."
+)
+
+// Limits of the arguments read by the gadget. The BPF program reads the
+// arguments in an unrolled loop, so each argument adds instructions to the
+// program, limited to 4096 before Linux 5.2. An argument is copied in the
+// event on the BPF stack, limited to 512 bytes with the rest of the event.
+const (
+	DefaultMaxArgs   = 20
+	MaxArgsLimit     = 64
+	DefaultMaxArgLen = 128
+	MaxArgLenLimit   = 256
+)
+
+// CheckLimits returns an error if the gadget can't read maxArgs arguments,
+// the file name included, of maxArgLen bytes
+func CheckLimits(maxArgs, maxArgLen int) error {
+	if maxArgs < 1 || maxArgs > MaxArgsLimit {
+		return fmt.Errorf("--max-args must be between 1 and %d", MaxArgsLimit)
+	}
+	if maxArgLen < 1 || maxArgLen > MaxArgLenLimit {
+		return fmt.Errorf("--max-arg-len must be between 1 and %d", MaxArgLenLimit)
+	}
+	return nil
+}
+
+// Event is a process printed by the execsnoop gadget
+type Event struct {
+	Timestamp string   `json:"timestamp"`
+	Pid       uint32   `json:"pid"`
+	Ppid      uint32   `json:"ppid"`
+	Comm      string   `json:"comm"`
+	Ret       int      `json:"ret"`
+	Args      []string `json:"args"`
+
+	/* Whether there were more arguments than read, and the indexes of
+	 * the arguments longer than read */
+	ArgsTruncated bool  `json:"args_truncated,omitempty"`
+	TruncatedArgs []int `json:"truncated_args,omitempty"`
+
+	/* The indexes of the arguments that are not valid UTF-8, encoded in
+	 * base64 */
+	Base64Args []int `json:"base64_args,omitempty"`
+
+	Env         []string `json:"env"`
+	ContainerID string   `json:"containerid,omitempty"`
+
+	/* Whether the program is the entrypoint of the container, the first
+	 * one executed in its pid namespace */
+	IsEntrypoint bool `json:"isEntrypoint,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// patternRegexp restricts the patterns to the syntax shared by the gadget,
+// written in Python, and kubectl-gadget
+var patternRegexp = regexp.MustCompile(`^[A-Za-z0-9_*?]+$`)
+
+// EnvPolicy selects the environment variables to capture by name. The
+// patterns are case-insensitive and can contain the wildcards * and ?.
+type EnvPolicy struct {
+	// Allow are the variables captured
+	Allow []string
+	// Deny are the variables whose value is redacted, even if allowed
+	Deny []string
+}
+
+func parsePatterns(list string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !patternRegexp.MatchString(p) {
+			return nil, fmt.Errorf("invalid pattern %q: only letters, digits, _, * and ? are allowed", p)
+		}
+		patterns = append(patterns, strings.ToUpper(p))
+	}
+	return patterns, nil
+}
+
+// ParseEnvPolicy parses comma-separated lists of patterns. The policy
+// captures nothing if allow is empty.
+func ParseEnvPolicy(allow, deny string) (EnvPolicy, error) {
+	a, err := parsePatterns(allow)
+	if err != nil {
+		return EnvPolicy{}, err
+	}
+	d, err := parsePatterns(deny)
+	if err != nil {
+		return EnvPolicy{}, err
+	}
+	return EnvPolicy{Allow: a, Deny: d}, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, p := range patterns {
+		// The patterns are validated: path.Match can't fail
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the allowed variables of env, as NAME=value, with the value
+// of the denied ones replaced by Redacted
+func (p EnvPolicy) Filter(env []string) []string {
+	var filtered []string
+	for _, v := range env {
+		i := strings.IndexByte(v, '=')
+		if i <= 0 {
+			continue
+		}
+		name := v[:i]
+		if !matchAny(p.Allow, name) {
+			continue
+		}
+		if matchAny(p.Deny, name) {
+			v = name + "=" + Redacted
+		}
+		filtered = append(filtered, v)
+	}
+	return filtered
+}
+
+// FormatArgs returns the arguments of e separated by spaces, with
+// TruncationMarker after the truncated ones, and as last argument if there
+// were more arguments than read. The arguments encoded in base64 are
+// decoded and quoted with their invalid bytes escaped, like "\xff".
+func FormatArgs(e Event) string {
+	args := make([]string, len(e.Args), len(e.Args)+1)
+	copy(args, e.Args)
+	for _, i := range e.Base64Args {
+		if i < 0 || i >= len(args) {
+			continue
+		}
+		if arg, err := base64.StdEncoding.DecodeString(args[i]); err == nil {
+			args[i] = strconv.Quote(string(arg))
+		}
+	}
+	for _, i := range e.TruncatedArgs {
+		if i >= 0 && i < len(args) {
+			args[i] += TruncationMarker
+		}
+	}
+	if e.ArgsTruncated {
+		args = append(args, TruncationMarker)
+	}
+	return strings.Join(args, " ")
+}
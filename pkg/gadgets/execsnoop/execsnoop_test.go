@@ -0,0 +1,142 @@
+package execsnoop
+
+import (
+	"reflect"
+	"testing"
+)
+
+var env = []string{
+	"PATH=/usr/local/bin:/usr/bin",
+	"JAVA_OPTS=-Xmx512m",
+	"JAVA_HOME=/usr/lib/jvm",
+	"DB_PASSWORD=hunter2",
+	"aws_secret_access_key=abc",
+	"GITHUB_TOKEN=ghp_123",
+	"EMPTY=",
+	"NOT_A_VARIABLE",
+	"=no-name",
+}
+
+func TestFilter(t *testing.T) {
+	table := []struct {
+		description string
+		allow       string
+		deny        string
+		expected    []string
+	}{
+		{
+			description: "nothing by default",
+			deny:        DefaultDeny,
+		},
+		{
+			description: "allowlist",
+			allow:       "PATH,java_*",
+			deny:        DefaultDeny,
+			expected:    []string{"PATH=/usr/local/bin:/usr/bin", "JAVA_OPTS=-Xmx512m", "JAVA_HOME=/usr/lib/jvm"},
+		},
+		{
+			description: "all with the default denylist",
+			allow:       "*",
+			deny:        DefaultDeny,
+			expected: []string{
+				"PATH=/usr/local/bin:/usr/bin",
+				"JAVA_OPTS=-Xmx512m",
+				"JAVA_HOME=/usr/lib/jvm",
+				"DB_PASSWORD=<redacted>",
+				"aws_secret_access_key=<redacted>",
+				"GITHUB_TOKEN=<redacted>",
+				"EMPTY=",
+			},
+		},
+		{
+			description: "denylist winning over the allowlist",
+			allow:       "DB_PASSWORD, JAVA_OPT?",
+			deny:        "*PASSWORD*,JAVA_*",
+			expected:    []string{"JAVA_OPTS=<redacted>", "DB_PASSWORD=<redacted>"},
+		},
+		{
+			description: "without denylist",
+			allow:       "DB_*",
+			expected:    []string{"DB_PASSWORD=hunter2"},
+		},
+	}
+	for _, entry := range table {
+		p, err := ParseEnvPolicy(entry.allow, entry.deny)
+		if err != nil {
+			t.Fatalf("%s: %s", entry.description, err)
+		}
+		if filtered := p.Filter(env); !reflect.DeepEqual(filtered, entry.expected) {
+			t.Errorf("%s: %q, expected %q", entry.description, filtered, entry.expected)
+		}
+	}
+}
+
+func TestParseEnvPolicyInvalid(t *testing.T) {
+	for _, allow := range []string{"[A-Z]*", "PATH=x", "A B", "*/x"} {
+		if _, err := ParseEnvPolicy(allow, ""); err == nil {
+			t.Errorf("%q: no error", allow)
+		}
+	}
+}
+
+func TestFormatArgs(t *testing.T) {
+	table := []struct {
+		description string
+		event       Event
+		expected    string
+	}{
+		{
+			description: "not truncated",
+			event:       Event{Args: []string{"/bin/ls", "-l"}},
+			expected:    "/bin/ls -l",
+		},
+		{
+			description: "too many arguments",
+			event:       Event{Args: []string{"/bin/ls", "-l"}, ArgsTruncated: true},
+			expected:    "/bin/ls -l ...",
+		},
+		{
+			description: "arguments too long",
+			event:       Event{Args: []string{"/bin/sh", "-c", "for i in"}, TruncatedArgs: []int{2}},
+			expected:    "/bin/sh -c for i in...",
+		},
+		{
+			description: "both, with an invalid index",
+			event:       Event{Args: []string{"/usr/bin/jav", "-jar"}, TruncatedArgs: []int{0, 5}, ArgsTruncated: true},
+			expected:    "/usr/bin/jav... -jar ...",
+		},
+		{
+			description: "argument with a space",
+			event:       Event{Args: []string{"/bin/sh", "-c", "echo hello"}},
+			expected:    "/bin/sh -c echo hello",
+		},
+		{
+			description: "binary arguments, one truncated",
+			event:       Event{Args: []string{"/app", "/w==", "YWL/", "not base64"}, TruncatedArgs: []int{2}, Base64Args: []int{1, 2, 3, 4}},
+			expected:    `/app "\xff" "ab\xff"... not base64`,
+		},
+	}
+	for _, entry := range table {
+		args := entry.event.Args
+		if s := FormatArgs(entry.event); s != entry.expected {
+			t.Fatalf("%s: %q != %q", entry.description, s, entry.expected)
+		}
+		if !reflect.DeepEqual(entry.event.Args, args) {
+			t.Fatalf("%s: the arguments were modified", entry.description)
+		}
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	if err := CheckLimits(DefaultMaxArgs, DefaultMaxArgLen); err != nil {
+		t.Fatalf("default limits: %s", err)
+	}
+	if err := CheckLimits(MaxArgsLimit, MaxArgLenLimit); err != nil {
+		t.Fatalf("maximum limits: %s", err)
+	}
+	for _, l := range [][2]int{{0, 128}, {MaxArgsLimit + 1, 128}, {20, 0}, {20, MaxArgLenLimit + 1}} {
+		if err := CheckLimits(l[0], l[1]); err == nil {
+			t.Fatalf("no error for --max-args %d --max-arg-len %d", l[0], l[1])
+		}
+	}
+}
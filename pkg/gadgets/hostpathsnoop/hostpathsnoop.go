@@ -0,0 +1,94 @@
+// Package hostpathsnoop describes the accesses to host paths reported by the
+// hostpathsnoop gadget.
+package hostpathsnoop
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"syscall"
+)
+
+// MaxPaths is the maximum number of paths watched by the gadget
+const MaxPaths = 16
+
+// Event is a file opened under a watched host path, as printed by the
+// hostpathsnoop gadget, completed with the pod of the container by
+// kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	Uid         uint32 `json:"uid"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* The watched path containing the file */
+	Watched string `json:"watched"`
+
+	/* The file opened, as a path on the host. Only the name of the watched
+	 * path is known if the file is deeper below it than the gadget looks. */
+	Path string `json:"path"`
+
+	/* Flags given to open(), see Access */
+	Flags uint32 `json:"flags"`
+
+	/* Set by kubectl-gadget */
+	Access string `json:"access,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// ParsePaths parses a comma-separated list of absolute paths on the hosts
+func ParsePaths(list string) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !path.IsAbs(p) {
+			return nil, fmt.Errorf("%q is not an absolute path", p)
+		}
+		// The gadget matches the inodes of the paths: /etc/../etc is
+		// /etc, and the trailing / doesn't matter
+		p = path.Clean(p)
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no path given")
+	}
+	if len(paths) > MaxPaths {
+		return nil, fmt.Errorf("%d paths given, at most %d can be watched", len(paths), MaxPaths)
+	}
+	return paths, nil
+}
+
+// Access returns how a file is opened: "read", "write", "read-write", or
+// "exec" when it is opened to be executed
+func Access(flags uint32) string {
+	// __FMODE_EXEC, set in the f_flags of the files opened by execve()
+	const fmodeExec = 0x20
+	if flags&fmodeExec != 0 {
+		return "exec"
+	}
+	switch flags & syscall.O_ACCMODE {
+	case syscall.O_WRONLY:
+		return "write"
+	case syscall.O_RDWR:
+		return "read-write"
+	}
+	if flags&syscall.O_TRUNC != 0 {
+		return "write"
+	}
+	return "read"
+}
@@ -0,0 +1,76 @@
+package hostpathsnoop
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestParsePaths(t *testing.T) {
+	table := []struct {
+		description string
+		list        string
+		expected    []string
+		err         string
+	}{
+		{
+			description: "one path",
+			list:        "/etc/kubernetes",
+			expected:    []string{"/etc/kubernetes"},
+		},
+		{
+			description: "cleaned and deduplicated",
+			list:        "/etc/kubernetes/, /var/lib/kubelet/../kubelet,/etc/kubernetes",
+			expected:    []string{"/etc/kubernetes", "/var/lib/kubelet"},
+		},
+		{
+			description: "relative path",
+			list:        "/etc,var/run",
+			err:         `"var/run" is not an absolute path`,
+		},
+		{
+			description: "empty",
+			list:        " , ",
+			err:         "no path given",
+		},
+		{
+			description: "too many paths",
+			list:        "/a,/b,/c,/d,/e,/f,/g,/h,/i,/j,/k,/l,/m,/n,/o,/p,/q",
+			err:         "17 paths given, at most 16 can be watched",
+		},
+	}
+
+	for _, entry := range table {
+		paths, err := ParsePaths(entry.list)
+		if entry.err != "" {
+			if err == nil || err.Error() != entry.err {
+				t.Fatalf("%s: expected error %q, got %v", entry.description, entry.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", entry.description, err)
+		}
+		if !reflect.DeepEqual(paths, entry.expected) {
+			t.Fatalf("%s: %v != %v", entry.description, paths, entry.expected)
+		}
+	}
+}
+
+func TestAccess(t *testing.T) {
+	table := []struct {
+		flags    uint32
+		expected string
+	}{
+		{syscall.O_RDONLY, "read"},
+		{syscall.O_WRONLY | syscall.O_CREAT, "write"},
+		{syscall.O_RDWR, "read-write"},
+		{syscall.O_RDONLY | syscall.O_TRUNC, "write"},
+		{syscall.O_RDONLY | 0x20, "exec"},
+	}
+	for _, entry := range table {
+		if access := Access(entry.flags); access != entry.expected {
+			t.Fatalf("flags %#x: %q != %q", entry.flags, access, entry.expected)
+		}
+	}
+}
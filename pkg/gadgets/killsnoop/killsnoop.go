@@ -0,0 +1,87 @@
+// Package killsnoop tells where the SIGKILL and SIGTERM received by the
+// processes of containers come from.
+package killsnoop
+
+const (
+	// OriginContainer is a process of the same container
+	OriginContainer = "container"
+	// OriginPod is a process of another container of the same pod
+	OriginPod = "pod"
+	// OriginOtherPod is a process of another pod
+	OriginOtherPod = "other-pod"
+	// OriginHost is a process of the node that is not in a container, like
+	// the kubelet or the container runtime
+	OriginHost = "host"
+	// OriginKernel is the kernel, for example on a fatal error
+	OriginKernel = "kernel"
+	// OriginOOMKiller is the OOM killer of the kernel
+	OriginOOMKiller = "oom-killer"
+)
+
+// Event is a signal printed by the killsnoop gadget
+type Event struct {
+	Timestamp string `json:"timestamp"`
+	Signal    int    `json:"signal"`
+
+	/* "delivered", or why the signal was not, like "already_pending" */
+	Result string `json:"result"`
+
+	/* Receiver */
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Pod         string `json:"pod,omitempty"`
+	Container   string `json:"container,omitempty"`
+
+	/* Sender. For signals sent by the kernel, the process that was
+	 * running when the signal was sent. */
+	SenderPid         uint32 `json:"sender_pid"`
+	SenderComm        string `json:"sender_comm"`
+	SenderContainerID string `json:"sender_containerid,omitempty"`
+	SenderNamespace   string `json:"sender_namespace,omitempty"`
+	SenderPod         string `json:"sender_pod,omitempty"`
+	SenderContainer   string `json:"sender_container,omitempty"`
+
+	Kernel  bool `json:"kernel,omitempty"`
+	OOMKill bool `json:"oomkill,omitempty"`
+
+	/* Set by Classify */
+	Origin   string `json:"origin"`
+	External bool   `json:"external"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Classify sets the origin of the signal, and whether it comes from outside
+// of the container of the receiver. The pods of the sender and of the
+// receiver must be set first, when known.
+func Classify(e *Event) {
+	switch {
+	case e.OOMKill:
+		e.Origin = OriginOOMKiller
+	case e.Kernel:
+		e.Origin = OriginKernel
+	case e.SenderContainerID == "":
+		e.Origin = OriginHost
+	case e.SenderContainerID == e.ContainerID:
+		e.Origin = OriginContainer
+	case e.SenderPod != "" && e.SenderNamespace == e.Namespace && e.SenderPod == e.Pod:
+		e.Origin = OriginPod
+	default:
+		e.Origin = OriginOtherPod
+	}
+	e.External = e.Origin != OriginContainer
+}
+
+// SignalName returns the name of the signals traced by killsnoop
+func SignalName(signal int) string {
+	switch signal {
+	case 9:
+		return "SIGKILL"
+	case 15:
+		return "SIGTERM"
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package killsnoop
+
+import (
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	table := []struct {
+		description string
+		event       Event
+		origin      string
+		external    bool
+	}{
+		{
+			description: "kill in the container",
+			event:       Event{ContainerID: "a", SenderContainerID: "a"},
+			origin:      OriginContainer,
+		},
+		{
+			description: "kubelet",
+			event:       Event{ContainerID: "a"},
+			origin:      OriginHost,
+			external:    true,
+		},
+		{
+			description: "OOM killer",
+			event:       Event{ContainerID: "a", SenderContainerID: "a", Kernel: true, OOMKill: true},
+			origin:      OriginOOMKiller,
+			external:    true,
+		},
+		{
+			description: "kernel",
+			event:       Event{ContainerID: "a", SenderContainerID: "a", Kernel: true},
+			origin:      OriginKernel,
+			external:    true,
+		},
+		{
+			description: "sidecar",
+			event: Event{ContainerID: "a", Namespace: "demo", Pod: "web-1",
+				SenderContainerID: "b", SenderNamespace: "demo", SenderPod: "web-1"},
+			origin:   OriginPod,
+			external: true,
+		},
+		{
+			description: "another pod",
+			event: Event{ContainerID: "a", Namespace: "demo", Pod: "web-1",
+				SenderContainerID: "c", SenderNamespace: "ops", SenderPod: "chaos-1"},
+			origin:   OriginOtherPod,
+			external: true,
+		},
+		{
+			description: "unknown container",
+			event:       Event{ContainerID: "a", SenderContainerID: "c"},
+			origin:      OriginOtherPod,
+			external:    true,
+		},
+	}
+	for _, entry := range table {
+		e := entry.event
+		Classify(&e)
+		if e.Origin != entry.origin || e.External != entry.external {
+			t.Errorf("%s: origin %q external %t, expected %q %t",
+				entry.description, e.Origin, e.External, entry.origin, entry.external)
+		}
+	}
+}
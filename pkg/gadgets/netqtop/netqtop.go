@@ -0,0 +1,31 @@
+package netqtop
+
+// Event is the traffic of a queue of a network device over an interval, as
+// printed by the netqtop gadget
+type Event struct {
+	Timestamp string `json:"timestamp"`
+
+	Device string `json:"device"`
+	Queue  uint16 `json:"queue"`
+
+	RxPackets uint64 `json:"rxpackets"`
+	RxBytes   uint64 `json:"rxbytes"`
+	TxPackets uint64 `json:"txpackets"`
+	TxBytes   uint64 `json:"txbytes"`
+
+	/* Duration of the interval, in seconds */
+	Interval float64 `json:"interval"`
+
+	/* Summary of the incomplete last interval, printed when the gadget
+	 * is stopped */
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Rate returns count per second over the interval of the event, or 0 for an
+// empty interval
+func (e Event) Rate(count uint64) float64 {
+	if e.Interval <= 0 {
+		return 0
+	}
+	return float64(count) / e.Interval
+}
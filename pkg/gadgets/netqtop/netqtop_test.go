@@ -0,0 +1,22 @@
+package netqtop
+
+import (
+	"testing"
+)
+
+func TestRate(t *testing.T) {
+	table := []struct {
+		event    Event
+		count    uint64
+		expected float64
+	}{
+		{Event{Interval: 2}, 3000, 1500},
+		{Event{Interval: 0.5}, 10, 20},
+		{Event{Interval: 0}, 10, 0},
+	}
+	for _, entry := range table {
+		if rate := entry.event.Rate(entry.count); rate != entry.expected {
+			t.Errorf("%+v: %v != %v", entry.event, rate, entry.expected)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package nfsslower
+
+// Event is a slow operation of the NFS client, as printed by the nfsslower
+// gadget, completed with the pod of the container of the process by
+// kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* read, write, open or getattr */
+	Operation string `json:"operation"`
+
+	/* Name of the file, without its directories */
+	Path string `json:"path"`
+
+	/* Bytes read or written, negative for an error, and the offset in the
+	 * file, only for the reads and writes */
+	Bytes  *int64  `json:"bytes,omitempty"`
+	Offset *uint64 `json:"offset,omitempty"`
+
+	/* Time spent in the NFS client */
+	LatencyUs uint64 `json:"latency_us"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
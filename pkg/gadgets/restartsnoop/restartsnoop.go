@@ -0,0 +1,256 @@
+// Package restartsnoop explains the restarts of containers by correlating the
+// exits and the OOM kills traced on the nodes with the container statuses of
+// the pods.
+package restartsnoop
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// EventExit is the exit of the main process of a container, or of
+	// another process killed by a signal
+	EventExit = "exit"
+	// EventOOMKill is a process killed by the OOM killer
+	EventOOMKill = "oomkill"
+)
+
+// Event is an event printed by the restartsnoop gadget on a node
+type Event struct {
+	Type        string `json:"type"`
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid"`
+
+	/* EventExit: whether the process is the main process of the container */
+	Init     bool `json:"init,omitempty"`
+	ExitCode int  `json:"exitcode,omitempty"`
+	Signal   int  `json:"signal,omitempty"`
+	CoreDump bool `json:"coredump,omitempty"`
+
+	/* EventOOMKill: whether the memory limit of the container was reached,
+	 * instead of the memory of the node */
+	MemCG bool `json:"memcg,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Restart is the explanation of the termination of a container
+type Restart struct {
+	Timestamp    string `json:"timestamp"`
+	Node         string `json:"node"`
+	Namespace    string `json:"namespace"`
+	Pod          string `json:"pod"`
+	Container    string `json:"container"`
+	ContainerID  string `json:"containerid"`
+	RestartCount int32  `json:"restartcount"`
+
+	/* As reported by the container runtime */
+	Reason   string `json:"reason,omitempty"`
+	ExitCode int32  `json:"exitcode"`
+	Signal   int32  `json:"signal,omitempty"`
+
+	/* As traced on the node, if available */
+	OOMKill *Event  `json:"oomkill,omitempty"`
+	Exit    *Event  `json:"exit,omitempty"`
+	Killed  []Event `json:"killed,omitempty"`
+
+	Explanation string `json:"explanation"`
+}
+
+const (
+	// maxAge is how long the events of a container are kept, waiting for
+	// its termination to be reported
+	maxAge = 10 * time.Minute
+	// maxEvents is the number of events kept per container
+	maxEvents = 16
+)
+
+type receivedEvent struct {
+	Event
+	received time.Time
+}
+
+// Correlator keeps the recent events of the containers until their
+// termination is reported in the status of their pod
+type Correlator struct {
+	mu     sync.Mutex
+	events map[string][]receivedEvent // by container id
+	now    func() time.Time           // can be replaced in tests
+}
+
+// NewCorrelator returns an empty Correlator
+func NewCorrelator() *Correlator {
+	return &Correlator{
+		events: make(map[string][]receivedEvent),
+		now:    time.Now,
+	}
+}
+
+// AddEvent records an event traced on a node
+func (c *Correlator) AddEvent(e Event) {
+	if e.ContainerID == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for id, events := range c.events {
+		if now.Sub(events[len(events)-1].received) > maxAge {
+			delete(c.events, id)
+		}
+	}
+	events := append(c.events[e.ContainerID], receivedEvent{e, now})
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+	c.events[e.ContainerID] = events
+}
+
+// take returns and forgets the events of a container
+func (c *Correlator) take(containerID string) []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var events []Event
+	for _, e := range c.events[containerID] {
+		events = append(events, e.Event)
+	}
+	delete(c.events, containerID)
+	return events
+}
+
+// trimContainerID removes the runtime prefix, like docker://
+func trimContainerID(id string) string {
+	if i := strings.Index(id, "://"); i >= 0 {
+		return id[i+3:]
+	}
+	return id
+}
+
+// PodUpdate returns the containers of the pod that terminated between the old
+// and the new version of the pod, restarted or not, with their explanation
+func (c *Correlator) PodUpdate(old, new *corev1.Pod) []Restart {
+	var restarts []Restart
+	oldStatuses := map[string]corev1.ContainerStatus{}
+	for _, s := range append(old.Status.InitContainerStatuses, old.Status.ContainerStatuses...) {
+		oldStatuses[s.Name] = s
+	}
+	for _, s := range append(new.Status.InitContainerStatuses, new.Status.ContainerStatuses...) {
+		oldStatus, ok := oldStatuses[s.Name]
+		if !ok {
+			continue
+		}
+		var terminated *corev1.ContainerStateTerminated
+		switch {
+		case s.RestartCount > oldStatus.RestartCount:
+			terminated = s.LastTerminationState.Terminated
+		case s.State.Terminated != nil && oldStatus.State.Terminated == nil:
+			// Not restarted, e.g. with restartPolicy: Never
+			terminated = s.State.Terminated
+		}
+		if terminated == nil {
+			continue
+		}
+		containerID := terminated.ContainerID
+		if containerID == "" {
+			containerID = oldStatus.ContainerID
+		}
+		r := Restart{
+			Timestamp:    terminated.FinishedAt.UTC().Format(time.RFC3339),
+			Node:         new.Spec.NodeName,
+			Namespace:    new.Namespace,
+			Pod:          new.Name,
+			Container:    s.Name,
+			ContainerID:  trimContainerID(containerID),
+			RestartCount: s.RestartCount,
+			Reason:       terminated.Reason,
+			ExitCode:     terminated.ExitCode,
+			Signal:       terminated.Signal,
+		}
+		for _, e := range c.take(r.ContainerID) {
+			e := e
+			switch {
+			case e.Type == EventOOMKill:
+				r.OOMKill = &e
+			case e.Init:
+				r.Exit = &e
+			case e.Signal != 0:
+				r.Killed = append(r.Killed, e)
+			}
+		}
+		r.Explanation = explain(&r)
+		restarts = append(restarts, r)
+	}
+	return restarts
+}
+
+var signalNames = map[int]string{
+	1:  "SIGHUP",
+	2:  "SIGINT",
+	3:  "SIGQUIT",
+	4:  "SIGILL",
+	5:  "SIGTRAP",
+	6:  "SIGABRT",
+	7:  "SIGBUS",
+	8:  "SIGFPE",
+	9:  "SIGKILL",
+	11: "SIGSEGV",
+	13: "SIGPIPE",
+	14: "SIGALRM",
+	15: "SIGTERM",
+}
+
+// SignalName returns the name of a signal, like SIGKILL
+func SignalName(signal int) string {
+	if name, ok := signalNames[signal]; ok {
+		return name
+	}
+	return fmt.Sprintf("signal %d", signal)
+}
+
+func explain(r *Restart) string {
+	var explanation string
+	switch {
+	case r.OOMKill != nil && r.OOMKill.MemCG:
+		explanation = fmt.Sprintf("OOM-killed: %s (pid %d) was killed because the container reached its memory limit",
+			r.OOMKill.Comm, r.OOMKill.Pid)
+	case r.OOMKill != nil:
+		explanation = fmt.Sprintf("OOM-killed: %s (pid %d) was killed because the node ran out of memory",
+			r.OOMKill.Comm, r.OOMKill.Pid)
+	case r.Reason == "OOMKilled":
+		explanation = "OOM-killed, according to the container runtime"
+	case r.Exit != nil && r.Exit.Signal == 9:
+		explanation = fmt.Sprintf("main process %s was killed by SIGKILL, not by the OOM killer: check the liveness probe and the events of the pod",
+			r.Exit.Comm)
+	case r.Exit != nil && r.Exit.Signal == 15:
+		explanation = fmt.Sprintf("main process %s was terminated by SIGTERM, usually sent by the kubelet to stop the container",
+			r.Exit.Comm)
+	case r.Exit != nil && r.Exit.Signal != 0:
+		explanation = fmt.Sprintf("main process %s was killed by %s", r.Exit.Comm, SignalName(r.Exit.Signal))
+		if r.Exit.CoreDump {
+			explanation += " (core dumped)"
+		}
+	case r.Exit != nil:
+		explanation = fmt.Sprintf("main process %s exited with code %d", r.Exit.Comm, r.Exit.ExitCode)
+	case r.Signal != 0:
+		explanation = fmt.Sprintf("killed by %s, according to the container runtime", SignalName(int(r.Signal)))
+	default:
+		explanation = fmt.Sprintf("exited with code %d, according to the container runtime", r.ExitCode)
+	}
+	if r.Exit == nil && r.OOMKill == nil {
+		explanation += " (not traced on the node)"
+	}
+	if n := len(r.Killed); n != 0 {
+		last := r.Killed[n-1]
+		explanation += fmt.Sprintf("; %d other process(es) killed by a signal before, the last one %s (pid %d) by %s",
+			n, last.Comm, last.Pid, SignalName(last.Signal))
+	}
+	return explanation
+}
@@ -0,0 +1,151 @@
+package restartsnoop
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const containerID = "5c1ad1c0d66c0e8b3ab4b06e4ab3b91ce0dc8fd0c3e6d4e4b2b1f0a9b0e6d3c1"
+
+func pod(restartCount int32, last *corev1.ContainerStateTerminated) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web-1"},
+		Spec:       corev1.PodSpec{NodeName: "node-a"},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 "app",
+				RestartCount:         restartCount,
+				LastTerminationState: corev1.ContainerState{Terminated: last},
+			}},
+		},
+	}
+}
+
+func terminated(reason string, exitCode int32) *corev1.ContainerStateTerminated {
+	return &corev1.ContainerStateTerminated{
+		Reason:      reason,
+		ExitCode:    exitCode,
+		ContainerID: "docker://" + containerID,
+		FinishedAt:  metav1.NewTime(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)),
+	}
+}
+
+func TestPodUpdate(t *testing.T) {
+	table := []struct {
+		description string
+		events      []Event
+		terminated  *corev1.ContainerStateTerminated
+		explanation string
+	}{
+		{
+			description: "OOM kill on the memory limit",
+			events: []Event{
+				{Type: EventOOMKill, Pid: 4242, Comm: "java", ContainerID: containerID, MemCG: true},
+				{Type: EventExit, Pid: 4242, Comm: "java", ContainerID: containerID, Init: true, Signal: 9},
+			},
+			terminated:  terminated("OOMKilled", 137),
+			explanation: "OOM-killed: java (pid 4242) was killed because the container reached its memory limit",
+		},
+		{
+			description: "OOM kill of the node",
+			events: []Event{
+				{Type: EventOOMKill, Pid: 4242, Comm: "java", ContainerID: containerID},
+			},
+			terminated:  terminated("OOMKilled", 137),
+			explanation: "OOM-killed: java (pid 4242) was killed because the node ran out of memory",
+		},
+		{
+			description: "SIGKILL without OOM kill",
+			events: []Event{
+				{Type: EventExit, Pid: 4242, Comm: "nginx", ContainerID: containerID, Init: true, Signal: 9},
+			},
+			terminated:  terminated("Error", 137),
+			explanation: "main process nginx was killed by SIGKILL, not by the OOM killer: check the liveness probe and the events of the pod",
+		},
+		{
+			description: "crash of a child process",
+			events: []Event{
+				{Type: EventExit, Pid: 4250, Comm: "worker", ContainerID: containerID, Signal: 11, CoreDump: true},
+				{Type: EventExit, Pid: 4242, Comm: "server", ContainerID: containerID, Init: true, ExitCode: 1},
+			},
+			terminated:  terminated("Error", 1),
+			explanation: "main process server exited with code 1; 1 other process(es) killed by a signal before, the last one worker (pid 4250) by SIGSEGV",
+		},
+		{
+			description: "main process crash",
+			events: []Event{
+				{Type: EventExit, Pid: 4242, Comm: "server", ContainerID: containerID, Init: true, Signal: 6, CoreDump: true},
+			},
+			terminated:  terminated("Error", 134),
+			explanation: "main process server was killed by SIGABRT (core dumped)",
+		},
+		{
+			description: "not traced",
+			terminated:  terminated("Error", 2),
+			explanation: "exited with code 2, according to the container runtime (not traced on the node)",
+		},
+		{
+			description: "events of another container",
+			events: []Event{
+				{Type: EventOOMKill, Pid: 4242, Comm: "java", ContainerID: "other", MemCG: true},
+			},
+			terminated:  terminated("OOMKilled", 137),
+			explanation: "OOM-killed, according to the container runtime (not traced on the node)",
+		},
+	}
+
+	for _, entry := range table {
+		c := NewCorrelator()
+		for _, e := range entry.events {
+			c.AddEvent(e)
+		}
+		restarts := c.PodUpdate(pod(2, nil), pod(3, entry.terminated))
+		if len(restarts) != 1 {
+			t.Errorf("%s: %d restarts, expected 1", entry.description, len(restarts))
+			continue
+		}
+		r := restarts[0]
+		if r.Explanation != entry.explanation {
+			t.Errorf("%s: explanation %q, expected %q", entry.description, r.Explanation, entry.explanation)
+		}
+		if r.Namespace != "demo" || r.Pod != "web-1" || r.Container != "app" || r.Node != "node-a" ||
+			r.ContainerID != containerID || r.RestartCount != 3 || r.Timestamp != "2020-06-01T12:00:00Z" {
+			t.Errorf("%s: unexpected restart %+v", entry.description, r)
+		}
+	}
+}
+
+func TestPodUpdateWithoutRestart(t *testing.T) {
+	c := NewCorrelator()
+	// Resync of the informer
+	if restarts := c.PodUpdate(pod(3, terminated("Error", 1)), pod(3, terminated("Error", 1))); len(restarts) != 0 {
+		t.Fatalf("unexpected restarts %+v", restarts)
+	}
+
+	// Terminated without restart
+	old := pod(0, nil)
+	new := pod(0, nil)
+	new.Status.ContainerStatuses[0].State.Terminated = terminated("Completed", 0)
+	restarts := c.PodUpdate(old, new)
+	if len(restarts) != 1 || restarts[0].Explanation != "exited with code 0, according to the container runtime (not traced on the node)" {
+		t.Fatalf("unexpected restarts %+v", restarts)
+	}
+}
+
+func TestExpiredEvents(t *testing.T) {
+	c := NewCorrelator()
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	c.AddEvent(Event{Type: EventOOMKill, Pid: 1, Comm: "old", ContainerID: containerID})
+	now = now.Add(maxAge + time.Second)
+	c.AddEvent(Event{Type: EventOOMKill, Pid: 2, Comm: "new", ContainerID: "other"})
+	if _, ok := c.events[containerID]; ok {
+		t.Fatalf("expired events not removed")
+	}
+	if len(c.events["other"]) != 1 {
+		t.Fatalf("recent events removed")
+	}
+}
@@ -0,0 +1,341 @@
+package rungadget
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Safety constraints enforced on external gadgets
+const (
+	// MaxObjectSize is the maximum size of the BPF object file
+	MaxObjectSize = 1024 * 1024
+
+	// MaxEventSize is the maximum size of an event sent on the perf map
+	MaxEventSize = 4096
+
+	// MaxStringSize is the maximum size of a string field
+	MaxStringSize = 256
+)
+
+// AllowedSectionPrefixes are the program types an external gadget can
+// attach. They only observe the system: programs able to filter or modify
+// packets (socket, cgroup, xdp, tc) are refused.
+var AllowedSectionPrefixes = []string{
+	"kprobe/",
+	"kretprobe/",
+	"tracepoint/",
+}
+
+// ForbiddenHelpers are the BPF helpers an external gadget cannot call, by
+// id. They modify the processes traced or kill them, where the programs
+// allowed are otherwise only able to observe them.
+var ForbiddenHelpers = map[int32]string{
+	36:  "bpf_probe_write_user",
+	58:  "bpf_override_return",
+	109: "bpf_send_signal",
+	117: "bpf_send_signal_thread",
+}
+
+// Instructions calling a helper: BPF_JMP | BPF_CALL, with the id of the
+// helper in imm. The other calls, to functions of the program, have a
+// source register.
+const (
+	insnSize   = 8
+	opcodeCall = 0x85
+)
+
+var fieldNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Field describes one field of the C struct sent on the perf map
+type Field struct {
+	// Name is the key used in the JSON output
+	Name string `json:"name"`
+
+	// Type is one of u8, u16, u32, u64, s8, s16, s32, s64, string or ipv4
+	Type string `json:"type"`
+
+	// Size is the size of the char array for the string type
+	Size int `json:"size,omitempty"`
+
+	// Column is the header in the text output. Fields without column are
+	// only printed in the JSON output.
+	Column string `json:"column,omitempty"`
+
+	// Width is the width of the column in the text output
+	Width int `json:"width,omitempty"`
+
+	// offset is computed from the previous fields with the C alignment
+	// rules
+	offset int
+}
+
+// Metadata describes an external gadget: the perf map it sends events on
+// and the layout of the events
+type Metadata struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	PerfMap     string  `json:"perfMap"`
+	Fields      []Field `json:"fields"`
+
+	eventSize int
+}
+
+var typeSizes = map[string]int{
+	"u8":   1,
+	"u16":  2,
+	"u32":  4,
+	"u64":  8,
+	"s8":   1,
+	"s16":  2,
+	"s32":  4,
+	"s64":  8,
+	"ipv4": 4,
+}
+
+// ParseMetadata parses and validates the YAML metadata of an external
+// gadget
+func ParseMetadata(data []byte) (*Metadata, error) {
+	m := &Metadata{}
+	if err := yaml.UnmarshalStrict(data, m); err != nil {
+		return nil, fmt.Errorf("cannot parse metadata: %s", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Metadata) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("metadata: name is missing")
+	}
+	if m.PerfMap == "" {
+		return fmt.Errorf("metadata: perfMap is missing")
+	}
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("metadata: no fields")
+	}
+
+	names := make(map[string]struct{})
+	offset := 0
+	maxAlign := 1
+	for i := range m.Fields {
+		f := &m.Fields[i]
+		if !fieldNameRegexp.MatchString(f.Name) {
+			return fmt.Errorf("metadata: invalid field name %q", f.Name)
+		}
+		if _, ok := names[f.Name]; ok {
+			return fmt.Errorf("metadata: duplicate field %q", f.Name)
+		}
+		names[f.Name] = struct{}{}
+		if f.Width < 0 {
+			return fmt.Errorf("metadata: field %q: invalid width %d", f.Name, f.Width)
+		}
+
+		var size, align int
+		if f.Type == "string" {
+			if f.Size < 1 || f.Size > MaxStringSize {
+				return fmt.Errorf("metadata: field %q: string size must be between 1 and %d", f.Name, MaxStringSize)
+			}
+			size, align = f.Size, 1
+		} else {
+			var ok bool
+			size, ok = typeSizes[f.Type]
+			if !ok {
+				return fmt.Errorf("metadata: field %q: unknown type %q", f.Name, f.Type)
+			}
+			if f.Size != 0 {
+				return fmt.Errorf("metadata: field %q: size is only valid for strings", f.Name)
+			}
+			align = size
+		}
+
+		offset = (offset + align - 1) / align * align
+		f.offset = offset
+		offset += size
+		if align > maxAlign {
+			maxAlign = align
+		}
+	}
+	// Trailing padding, as in sizeof(struct)
+	m.eventSize = (offset + maxAlign - 1) / maxAlign * maxAlign
+	if m.eventSize > MaxEventSize {
+		return fmt.Errorf("metadata: event size %d is larger than %d", m.eventSize, MaxEventSize)
+	}
+	return nil
+}
+
+// EventSize returns the size of the C struct described by the fields
+func (m *Metadata) EventSize() int {
+	return m.eventSize
+}
+
+// ValidateObject checks that the BPF object file only contains allowed
+// programs, not calling forbidden helpers, and that it defines the perf map
+// of the metadata
+func ValidateObject(r io.ReaderAt, size int64, m *Metadata) error {
+	if size > MaxObjectSize {
+		return fmt.Errorf("object file is too large: %d bytes, maximum %d", size, MaxObjectSize)
+	}
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return fmt.Errorf("cannot parse object file: %s", err)
+	}
+	defer f.Close()
+
+	if f.Machine != elf.EM_BPF {
+		return fmt.Errorf("object file is not a BPF object: machine %s", f.Machine)
+	}
+
+	programs := 0
+	for _, section := range f.Sections {
+		if section.Flags&elf.SHF_EXECINSTR == 0 || section.Size == 0 {
+			continue
+		}
+		allowed := false
+		for _, prefix := range AllowedSectionPrefixes {
+			if strings.HasPrefix(section.Name, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("program section %q is not allowed, only %s",
+				section.Name, strings.Join(AllowedSectionPrefixes, ", "))
+		}
+		if err := checkHelpers(section, f.ByteOrder); err != nil {
+			return err
+		}
+		programs++
+	}
+	if programs == 0 {
+		return fmt.Errorf("object file does not contain any program")
+	}
+
+	if f.Section("maps/"+m.PerfMap) == nil {
+		return fmt.Errorf("perf map %q not found in object file", m.PerfMap)
+	}
+	return nil
+}
+
+// checkHelpers checks that the programs of section don't call any of
+// ForbiddenHelpers
+func checkHelpers(section *elf.Section, order binary.ByteOrder) error {
+	insns, err := section.Data()
+	if err != nil {
+		return fmt.Errorf("cannot read program section %q: %s", section.Name, err)
+	}
+	for i := 0; i+insnSize <= len(insns); i += insnSize {
+		insn := insns[i : i+insnSize]
+		srcReg := insn[1] >> 4
+		if order == binary.BigEndian {
+			srcReg = insn[1] & 0xf
+		}
+		if insn[0] != opcodeCall || srcReg != 0 {
+			continue
+		}
+		id := int32(order.Uint32(insn[4:]))
+		if name, ok := ForbiddenHelpers[id]; ok {
+			return fmt.Errorf("program section %q calls %s, which is not allowed", section.Name, name)
+		}
+	}
+	return nil
+}
+
+// Value is a decoded field of an event
+type Value struct {
+	Field *Field
+	Value interface{}
+}
+
+// Decode decodes an event sent on the perf map, in the order of the fields
+func (m *Metadata) Decode(data []byte) ([]Value, error) {
+	if len(data) < m.eventSize {
+		return nil, fmt.Errorf("event too short: %d bytes, expected %d", len(data), m.eventSize)
+	}
+	values := make([]Value, len(m.Fields))
+	for i := range m.Fields {
+		f := &m.Fields[i]
+		b := data[f.offset:]
+		var v interface{}
+		switch f.Type {
+		case "u8":
+			v = b[0]
+		case "u16":
+			v = binary.LittleEndian.Uint16(b)
+		case "u32":
+			v = binary.LittleEndian.Uint32(b)
+		case "u64":
+			v = binary.LittleEndian.Uint64(b)
+		case "s8":
+			v = int8(b[0])
+		case "s16":
+			v = int16(binary.LittleEndian.Uint16(b))
+		case "s32":
+			v = int32(binary.LittleEndian.Uint32(b))
+		case "s64":
+			v = int64(binary.LittleEndian.Uint64(b))
+		case "string":
+			s := b[:f.Size]
+			if i := bytes.IndexByte(s, 0); i != -1 {
+				s = s[:i]
+			}
+			v = string(s)
+		case "ipv4":
+			v = net.IPv4(b[0], b[1], b[2], b[3]).String()
+		}
+		values[i] = Value{Field: f, Value: v}
+	}
+	return values, nil
+}
+
+// Header returns the header of the text output
+func (m *Metadata) Header() string {
+	var columns []string
+	for _, f := range m.Fields {
+		if f.Column == "" {
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%-*s", f.Width, f.Column))
+	}
+	return strings.TrimRight(strings.Join(columns, " "), " ")
+}
+
+// FormatText formats decoded values in the columns of the header
+func FormatText(values []Value) string {
+	var columns []string
+	for _, v := range values {
+		if v.Field.Column == "" {
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%-*v", v.Field.Width, v.Value))
+	}
+	return strings.TrimRight(strings.Join(columns, " "), " ")
+}
+
+// FormatJSON formats decoded values as a JSON object, keeping the order of
+// the fields
+func FormatJSON(values []Value) (string, error) {
+	var out []string
+	for _, v := range values {
+		key, err := json.Marshal(v.Field.Name)
+		if err != nil {
+			return "", err
+		}
+		value, err := json.Marshal(v.Value)
+		if err != nil {
+			return "", err
+		}
+		out = append(out, string(key)+":"+string(value))
+	}
+	return "{" + strings.Join(out, ",") + "}", nil
+}
@@ -0,0 +1,137 @@
+package rungadget
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadTestdata(t *testing.T, name string) (*Metadata, *os.File, int64) {
+	meta, err := ioutil.ReadFile("testdata/trivial.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ParseMetadata(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, f, fi.Size()
+}
+
+func TestLoadTrivialGadget(t *testing.T) {
+	m, f, size := loadTestdata(t, "trivial.o")
+	defer f.Close()
+
+	if err := ValidateObject(f, size, m); err != nil {
+		t.Fatalf("trivial gadget refused: %s", err)
+	}
+	if m.EventSize() != 32 {
+		t.Fatalf("event size %d, expected 32", m.EventSize())
+	}
+
+	// struct { u32 pid; char comm[16]; u32 daddr; s64 ret; }
+	event := make([]byte, 32)
+	binary.LittleEndian.PutUint32(event[0:], 4242)
+	copy(event[4:], "cat\x00garbage")
+	copy(event[20:], []byte{10, 0, 0, 1})
+	binary.LittleEndian.PutUint64(event[24:], uint64(0xffffffffffffffff))
+
+	values, err := m.Decode(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := m.Header()
+	expectedHeader := "PID    COMM             DADDR"
+	if header != expectedHeader {
+		t.Fatalf("header %q, expected %q", header, expectedHeader)
+	}
+	text := FormatText(values)
+	expectedText := "4242   cat              10.0.0.1"
+	if text != expectedText {
+		t.Fatalf("text %q, expected %q", text, expectedText)
+	}
+	out, err := FormatJSON(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJSON := `{"pid":4242,"comm":"cat","daddr":"10.0.0.1","ret":-1}`
+	if out != expectedJSON {
+		t.Fatalf("JSON %q, expected %q", out, expectedJSON)
+	}
+
+	if _, err := m.Decode(event[:31]); err == nil {
+		t.Fatal("short event decoded")
+	}
+}
+
+func TestValidateObject(t *testing.T) {
+	m, f, size := loadTestdata(t, "socketfilter.o")
+	defer f.Close()
+
+	err := ValidateObject(f, size, m)
+	if err == nil || !strings.Contains(err.Error(), `"socket/filter" is not allowed`) {
+		t.Fatalf("socket filter not refused: %v", err)
+	}
+
+	m, f, size = loadTestdata(t, "writeuser.o")
+	defer f.Close()
+	err = ValidateObject(f, size, m)
+	if err == nil || !strings.Contains(err.Error(), "calls bpf_probe_write_user") {
+		t.Fatalf("bpf_probe_write_user not refused: %v", err)
+	}
+
+	m, f, size = loadTestdata(t, "trivial.o")
+	defer f.Close()
+	m.PerfMap = "other"
+	err = ValidateObject(f, size, m)
+	if err == nil || !strings.Contains(err.Error(), `perf map "other" not found`) {
+		t.Fatalf("missing perf map not refused: %v", err)
+	}
+	m.PerfMap = "events"
+	err = ValidateObject(f, MaxObjectSize+1, m)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("large object not refused: %v", err)
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	// 17 strings of 256 bytes
+	large := "name: x\nperfMap: events\nfields:\n"
+	for _, c := range "abcdefghijklmnopq" {
+		large += "- {name: " + string(c) + ", type: string, size: 256}\n"
+	}
+
+	table := []struct {
+		meta string
+		err  string
+	}{
+		{"perfMap: events\nfields:\n- {name: a, type: u8}", "name is missing"},
+		{"name: x\nfields:\n- {name: a, type: u8}", "perfMap is missing"},
+		{"name: x\nperfMap: events", "no fields"},
+		{"name: x\nperfMap: events\nfields:\n- {name: a, type: u128}", `unknown type "u128"`},
+		{"name: x\nperfMap: events\nfields:\n- {name: a, type: u8}\n- {name: a, type: u8}", `duplicate field "a"`},
+		{"name: x\nperfMap: events\nfields:\n- {name: A-b, type: u8}", `invalid field name "A-b"`},
+		{"name: x\nperfMap: events\nfields:\n- {name: a, type: string}", "string size"},
+		{"name: x\nperfMap: events\nfields:\n- {name: a, type: string, size: 257}", "string size"},
+		{"name: x\nperfMap: events\nfields:\n- {name: a, type: u32, size: 4}", "only valid for strings"},
+		{"name: x\nperfMap: events\nfields:\n- {name: a, type: u8, colour: red}", "cannot parse metadata"},
+		{large, "larger than 4096"},
+	}
+	for _, entry := range table {
+		_, err := ParseMetadata([]byte(entry.meta))
+		if err == nil || !strings.Contains(err.Error(), entry.err) {
+			t.Errorf("metadata %q: error %v, expected %q", entry.meta, err, entry.err)
+		}
+	}
+}
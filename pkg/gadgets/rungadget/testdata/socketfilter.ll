@@ -0,0 +1,17 @@
+; External gadget attaching a socket filter, which run-gadget refuses to
+; load.
+;
+; Regenerate socketfilter.o with:
+;   llc -march=bpf -filetype=obj -o socketfilter.o socketfilter.ll
+
+%struct.bpf_map_def = type { i32, i32, i32, i32, i32, i32, [256 x i8] }
+
+@events = global %struct.bpf_map_def { i32 4, i32 4, i32 4, i32 1024, i32 0, i32 0, [256 x i8] zeroinitializer }, section "maps/events", align 4
+@_license = global [4 x i8] c"GPL\00", section "license", align 1
+@_version = global i32 4294967294, section "version", align 4
+
+define i32 @socket__filter(i8* %ctx) #0 section "socket/filter" {
+  ret i32 0
+}
+
+attributes #0 = { nounwind }
@@ -0,0 +1,18 @@
+; Trivial external gadget used by the rungadget tests. It does not emit
+; events: only the ELF layout (program section, perf map, license and
+; version) matters.
+;
+; Regenerate trivial.o with:
+;   llc -march=bpf -filetype=obj -o trivial.o trivial.ll
+
+%struct.bpf_map_def = type { i32, i32, i32, i32, i32, i32, [256 x i8] }
+
+@events = global %struct.bpf_map_def { i32 4, i32 4, i32 4, i32 1024, i32 0, i32 0, [256 x i8] zeroinitializer }, section "maps/events", align 4
+@_license = global [4 x i8] c"GPL\00", section "license", align 1
+@_version = global i32 4294967294, section "version", align 4
+
+define i32 @kprobe__sys_execve(i8* %ctx) #0 section "kprobe/sys_execve" {
+  ret i32 0
+}
+
+attributes #0 = { nounwind }
@@ -0,0 +1,19 @@
+; External gadget calling bpf_probe_write_user, used by the rungadget tests
+; to check that the helpers modifying processes are refused.
+;
+; Regenerate writeuser.o with:
+;   llc -march=bpf -filetype=obj -o writeuser.o writeuser.ll
+
+%struct.bpf_map_def = type { i32, i32, i32, i32, i32, i32, [256 x i8] }
+
+@events = global %struct.bpf_map_def { i32 4, i32 4, i32 4, i32 1024, i32 0, i32 0, [256 x i8] zeroinitializer }, section "maps/events", align 4
+@_license = global [4 x i8] c"GPL\00", section "license", align 1
+@_version = global i32 4294967294, section "version", align 4
+
+define i32 @kprobe__sys_execve(i8* %ctx) #0 section "kprobe/sys_execve" {
+  %write_user = inttoptr i64 36 to i64 (i8*, i8*, i32)*
+  %ret = call i64 %write_user(i8* null, i8* %ctx, i32 8)
+  ret i32 0
+}
+
+attributes #0 = { nounwind }
@@ -0,0 +1,155 @@
+package snapshot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Capabilities are the capability sets of a container, as the names of the
+// capabilities, like CAP_NET_ADMIN. Pid is the first process of the
+// container, whose sets are read: the processes it starts can only have
+// fewer capabilities, unless they run programs with file capabilities.
+type Capabilities struct {
+	Container
+	Pid       int      `json:"pid"`
+	Comm      string   `json:"comm"`
+	Effective []string `json:"effective"`
+	Permitted []string `json:"permitted"`
+	Bounding  []string `json:"bounding"`
+}
+
+// capabilityNames are the names of the capabilities by number, see
+// include/uapi/linux/capability.h
+var capabilityNames = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_DAC_READ_SEARCH",
+	"CAP_FOWNER",
+	"CAP_FSETID",
+	"CAP_KILL",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETPCAP",
+	"CAP_LINUX_IMMUTABLE",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN",
+	"CAP_NET_RAW",
+	"CAP_IPC_LOCK",
+	"CAP_IPC_OWNER",
+	"CAP_SYS_MODULE",
+	"CAP_SYS_RAWIO",
+	"CAP_SYS_CHROOT",
+	"CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT",
+	"CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT",
+	"CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME",
+	"CAP_SYS_TTY_CONFIG",
+	"CAP_MKNOD",
+	"CAP_LEASE",
+	"CAP_AUDIT_WRITE",
+	"CAP_AUDIT_CONTROL",
+	"CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE",
+	"CAP_MAC_ADMIN",
+	"CAP_SYSLOG",
+	"CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND",
+	"CAP_AUDIT_READ",
+	"CAP_PERFMON",
+	"CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+// CapabilityNames returns the names of the capabilities of a set, as printed
+// in hexadecimal in /proc/<pid>/status. The capabilities of kernels newer
+// than this list are named by number, like CAP_41.
+func CapabilityNames(set uint64) []string {
+	names := []string{}
+	for c := uint(0); c < 64; c++ {
+		if set&(1<<c) == 0 {
+			continue
+		}
+		if int(c) < len(capabilityNames) {
+			names = append(names, capabilityNames[c])
+		} else {
+			names = append(names, fmt.Sprintf("CAP_%d", c))
+		}
+	}
+	return names
+}
+
+// ContainerCapabilities returns the capabilities of the containers of the
+// node whose /proc is procRoot. The host is skipped.
+func ContainerCapabilities(procRoot string) ([]Capabilities, error) {
+	all, err := pids(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	var capabilities []Capabilities
+	seen := map[string]bool{}
+	for _, pid := range all {
+		id := containerID(procRoot, pid)
+		if id == "" || seen[id] {
+			continue
+		}
+		status, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "status"))
+		if err != nil {
+			// Exited
+			continue
+		}
+		c, err := parseCapabilities(string(status))
+		if err != nil {
+			return nil, fmt.Errorf("pid %d: %s", pid, err)
+		}
+		c.ContainerID = id
+		c.Pid = pid
+		seen[id] = true
+		capabilities = append(capabilities, c)
+	}
+	return capabilities, nil
+}
+
+// parseCapabilities parses the name and the capability sets of a process in
+// its /proc/<pid>/status, like:
+//
+//	CapEff:	00000000a80425fb
+func parseCapabilities(status string) (Capabilities, error) {
+	c := Capabilities{}
+	sets := map[string]*[]string{
+		"CapEff:": &c.Effective,
+		"CapPrm:": &c.Permitted,
+		"CapBnd:": &c.Bounding,
+	}
+	for _, line := range strings.Split(status, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if fields[0] == "Name:" {
+			c.Comm = strings.TrimSpace(strings.TrimPrefix(line, "Name:"))
+			continue
+		}
+		names, ok := sets[fields[0]]
+		if !ok {
+			continue
+		}
+		set, err := strconv.ParseUint(fields[1], 16, 64)
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("invalid %s %q", strings.TrimSuffix(fields[0], ":"), fields[1])
+		}
+		*names = CapabilityNames(set)
+	}
+	for field, names := range sets {
+		if *names == nil {
+			return Capabilities{}, fmt.Errorf("no %s", strings.TrimSuffix(field, ":"))
+		}
+	}
+	return c, nil
+}
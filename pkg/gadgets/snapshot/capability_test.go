@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Default capabilities of a container of Docker, and of a privileged one
+const testCapStatus = `Name:	nginx
+State:	S (sleeping)
+PPid:	1
+CapInh:	00000000a80425fb
+CapPrm:	00000000a80425fb
+CapEff:	00000000a80425fb
+CapBnd:	00000000a80425fb
+CapAmb:	0000000000000000
+`
+
+var testDefaultCapabilities = []string{
+	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_FOWNER", "CAP_FSETID", "CAP_KILL",
+	"CAP_SETGID", "CAP_SETUID", "CAP_SETPCAP", "CAP_NET_BIND_SERVICE",
+	"CAP_NET_RAW", "CAP_SYS_CHROOT", "CAP_MKNOD", "CAP_AUDIT_WRITE", "CAP_SETFCAP",
+}
+
+func TestCapabilityNames(t *testing.T) {
+	table := []struct {
+		set      uint64
+		expected []string
+	}{
+		{0, []string{}},
+		{0xa80425fb, testDefaultCapabilities},
+		{1<<21 | 1<<39, []string{"CAP_SYS_ADMIN", "CAP_BPF"}},
+		// Unknown
+		{1 << 41, []string{"CAP_41"}},
+	}
+	for _, entry := range table {
+		if names := CapabilityNames(entry.set); !reflect.DeepEqual(names, entry.expected) {
+			t.Errorf("%x: got %q, expected %q", entry.set, names, entry.expected)
+		}
+	}
+	if len(CapabilityNames(0x1ffffffffff)) != len(capabilityNames) {
+		t.Errorf("all capabilities should be named")
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	c, err := parseCapabilities(testCapStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Capabilities{
+		Comm:      "nginx",
+		Effective: testDefaultCapabilities,
+		Permitted: testDefaultCapabilities,
+		Bounding:  testDefaultCapabilities,
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("got %+v, expected %+v", c, expected)
+	}
+
+	// Dropped after the start, as with the user of the container set
+	c, err = parseCapabilities("Name:\tapp\nCapPrm:\t0000000000000000\nCapEff:\t0000000000000000\nCapBnd:\t0000003fffffffff\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Effective) != 0 || len(c.Permitted) != 0 || len(c.Bounding) != 38 {
+		t.Fatalf("unexpected capabilities %+v", c)
+	}
+
+	for _, status := range []string{"Name:\tapp\nCapPrm:\t0\nCapEff:\t0\n", "CapPrm:\t0\nCapEff:\tzz\nCapBnd:\t0\n"} {
+		if _, err := parseCapabilities(status); err == nil {
+			t.Errorf("%q: expected an error", status)
+		}
+	}
+}
+
+func TestContainerCapabilities(t *testing.T) {
+	root, remove := newTestProc(t, testProcesses)
+	defer remove()
+	for _, pid := range []string{"1", "42", "43"} {
+		if err := ioutil.WriteFile(filepath.Join(root, pid, "status"), []byte(testCapStatus), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The host is skipped, and the container is read from its first
+	// process
+	capabilities, err := ContainerCapabilities(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(capabilities) != 1 {
+		t.Fatalf("unexpected capabilities %+v", capabilities)
+	}
+	c := capabilities[0]
+	if c.ContainerID != testContainerID || c.Pid != 42 || c.Comm != "nginx" || !reflect.DeepEqual(c.Effective, testDefaultCapabilities) {
+		t.Fatalf("unexpected capabilities %+v", c)
+	}
+}
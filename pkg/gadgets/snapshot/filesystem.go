@@ -0,0 +1,172 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Filesystem is a filesystem mounted in a container, with its usage as
+// printed by df. Pid is the first process of the container, whose mount
+// namespace is read. The usage is not set when the filesystem could not be
+// read, Error tells why.
+type Filesystem struct {
+	Container
+	Pid        int    `json:"pid"`
+	MountPoint string `json:"mount_point"`
+	Source     string `json:"source"`
+	FsType     string `json:"fstype"`
+	ReadOnly   bool   `json:"read_only"`
+
+	Size       uint64 `json:"size"`
+	Used       uint64 `json:"used"`
+	Avail      uint64 `json:"avail"`
+	Inodes     uint64 `json:"inodes"`
+	InodesUsed uint64 `json:"inodes_used"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// fsUsage is the usage of a filesystem, in bytes and inodes
+type fsUsage struct {
+	size, used, avail  uint64
+	inodes, inodesUsed uint64
+}
+
+// mount is a mount of /proc/<pid>/mountinfo
+type mount struct {
+	mountPoint string
+	source     string
+	fsType     string
+	readOnly   bool
+}
+
+// skippedMountPoints are the directories whose mounts are not printed: the
+// runtimes mask some of their files by mounting /dev/null or an empty tmpfs
+// over them
+var skippedMountPoints = []string{"/proc", "/sys"}
+
+// Filesystems returns the filesystems mounted in the containers of the node
+// whose /proc is procRoot, in the order they were mounted. The
+// filesystems without blocks, like proc or cgroup, are skipped, as df does.
+// The host is skipped too: it holds the mounts of all the containers.
+func Filesystems(procRoot string) ([]Filesystem, error) {
+	all, err := pids(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	var filesystems []Filesystem
+	seen := map[string]bool{}
+	for _, pid := range all {
+		id := containerID(procRoot, pid)
+		if id == "" || seen[id] {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "mountinfo"))
+		if err != nil {
+			// Exited
+			continue
+		}
+		seen[id] = true
+		root := filepath.Join(procRoot, strconv.Itoa(pid), "root")
+		for _, m := range parseMountinfo(string(content)) {
+			if skippedMountPoint(m.mountPoint) {
+				continue
+			}
+			fs := Filesystem{
+				Container:  Container{ContainerID: id},
+				Pid:        pid,
+				MountPoint: m.mountPoint,
+				Source:     m.source,
+				FsType:     m.fsType,
+				ReadOnly:   m.readOnly,
+			}
+			// The mount points are relative to the root of the process
+			usage, err := statfs(filepath.Join(root, m.mountPoint))
+			if err != nil {
+				fs.Error = err.Error()
+				filesystems = append(filesystems, fs)
+				continue
+			}
+			if usage.size == 0 {
+				continue
+			}
+			fs.Size, fs.Used, fs.Avail = usage.size, usage.used, usage.avail
+			fs.Inodes, fs.InodesUsed = usage.inodes, usage.inodesUsed
+			filesystems = append(filesystems, fs)
+		}
+	}
+	return filesystems, nil
+}
+
+func skippedMountPoint(mountPoint string) bool {
+	for _, dir := range skippedMountPoints {
+		if mountPoint == dir || strings.HasPrefix(mountPoint, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMountinfo parses /proc/<pid>/mountinfo, like:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// When a mount point is mounted over, only the last mount is returned, at
+// the place of the first one.
+func parseMountinfo(content string) []mount {
+	var mounts []mount
+	index := map[string]int{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		// The optional fields end with a "-" field
+		separator := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				separator = i
+				break
+			}
+		}
+		if separator < 0 || len(fields) < separator+3 {
+			continue
+		}
+		m := mount{
+			mountPoint: unescapeMountinfo(fields[4]),
+			fsType:     fields[separator+1],
+			source:     unescapeMountinfo(fields[separator+2]),
+		}
+		for _, option := range strings.Split(fields[5], ",") {
+			if option == "ro" {
+				m.readOnly = true
+			}
+		}
+		if i, ok := index[m.mountPoint]; ok {
+			mounts[i] = m
+			continue
+		}
+		index[m.mountPoint] = len(mounts)
+		mounts = append(mounts, m)
+	}
+	return mounts
+}
+
+// unescapeMountinfo decodes the characters escaped in octal in mountinfo,
+// like the spaces as \040
+func unescapeMountinfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
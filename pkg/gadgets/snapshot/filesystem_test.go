@@ -0,0 +1,80 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testMountinfo = `1000 900 0:52 / / rw,relatime master:300 - overlay overlay rw,lowerdir=/var/lib/docker/overlay2/l/A:/var/lib/docker/overlay2/l/B
+1001 1000 0:55 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw
+1002 1000 0:56 / /dev rw,nosuid - tmpfs tmpfs rw,size=65536k,mode=755
+1003 1001 0:5 /null /proc/kcore rw,nosuid - devtmpfs udev rw,size=4014656k
+1004 1000 8:1 /var/lib/kubelet/pods/1/volumes/kubernetes.io~empty-dir/cache /cache\040dir rw,relatime - ext4 /dev/sda1 rw
+1005 1000 8:1 /var/lib/kubelet/pods/1/etc-hosts /etc/hosts ro,relatime - ext4 /dev/sda1 rw
+1006 1000 0:57 / /cache\040dir rw - tmpfs tmpfs rw
+`
+
+func TestParseMountinfo(t *testing.T) {
+	expected := []mount{
+		{mountPoint: "/", source: "overlay", fsType: "overlay"},
+		{mountPoint: "/proc", source: "proc", fsType: "proc"},
+		{mountPoint: "/dev", source: "tmpfs", fsType: "tmpfs"},
+		{mountPoint: "/proc/kcore", source: "udev", fsType: "devtmpfs"},
+		// Mounted over
+		{mountPoint: "/cache dir", source: "tmpfs", fsType: "tmpfs"},
+		{mountPoint: "/etc/hosts", source: "/dev/sda1", fsType: "ext4", readOnly: true},
+	}
+	if mounts := parseMountinfo(testMountinfo); !reflect.DeepEqual(mounts, expected) {
+		t.Fatalf("unexpected mounts:\n%+v\nexpected:\n%+v", mounts, expected)
+	}
+}
+
+func TestFilesystems(t *testing.T) {
+	root, remove := newTestProc(t, testProcesses)
+	defer remove()
+
+	// The root of the container, the other mount points are missing
+	containerRoot := filepath.Join(root, "42", "root")
+	if err := os.MkdirAll(filepath.Join(containerRoot, "cache dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, pid := range []string{"1", "42", "43"} {
+		if err := ioutil.WriteFile(filepath.Join(root, pid, "mountinfo"), []byte(testMountinfo), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filesystems, err := Filesystems(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The host is skipped, and the container is read once, from its first
+	// process
+	var mountPoints []string
+	for _, fs := range filesystems {
+		if fs.ContainerID != testContainerID || fs.Pid != 42 {
+			t.Fatalf("unexpected filesystem %+v", fs)
+		}
+		mountPoints = append(mountPoints, fs.MountPoint)
+	}
+	if expected := []string{"/", "/dev", "/cache dir", "/etc/hosts"}; !reflect.DeepEqual(mountPoints, expected) {
+		t.Fatalf("unexpected mount points %q, expected %q", mountPoints, expected)
+	}
+
+	for _, fs := range filesystems {
+		switch fs.MountPoint {
+		case "/", "/cache dir":
+			if fs.Error != "" || fs.Size == 0 || fs.Used > fs.Size || fs.Avail > fs.Size {
+				t.Errorf("unexpected usage %+v", fs)
+			}
+		default:
+			// Not readable
+			if fs.Error == "" || fs.Size != 0 {
+				t.Errorf("expected an error for %+v", fs)
+			}
+		}
+	}
+}
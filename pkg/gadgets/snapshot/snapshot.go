@@ -0,0 +1,422 @@
+// Package snapshot collects the state of a node at one point in time, for
+// the resources of "kubectl gadget snapshot": the processes, the sockets, the
+// namespaces, the filesystems and the capabilities of the containers. The
+// collectors read /proc: the gadget pod shares the pid namespace of the host.
+package snapshot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Container identifies the container of an entry. The collectors only set
+// ContainerID, the other fields are set by kubectl-gadget.
+type Container struct {
+	Node        string `json:"node,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Pod         string `json:"pod,omitempty"`
+	Container   string `json:"container,omitempty"`
+	ContainerID string `json:"containerid,omitempty"`
+}
+
+// Process is a process of the node
+type Process struct {
+	Container
+	Pid  int    `json:"pid"`
+	Ppid int    `json:"ppid"`
+	Uid  int    `json:"uid"`
+	Comm string `json:"comm"`
+}
+
+// Socket is a TCP or UDP socket of a process of the node
+type Socket struct {
+	Container
+	Protocol      string `json:"protocol"`
+	LocalAddress  string `json:"local_address"`
+	LocalPort     uint16 `json:"local_port"`
+	RemoteAddress string `json:"remote_address"`
+	RemotePort    uint16 `json:"remote_port"`
+	State         string `json:"state"`
+	Pid           int    `json:"pid"`
+	Comm          string `json:"comm"`
+}
+
+// Namespaces are the Linux namespaces of a container, as the inode numbers
+// of the files of /proc/<pid>/ns. Pid is the first process of the container.
+type Namespaces struct {
+	Container
+	Pid    int    `json:"pid"`
+	Mnt    uint64 `json:"mnt"`
+	Net    uint64 `json:"net"`
+	PidNs  uint64 `json:"pidns"`
+	Uts    uint64 `json:"uts"`
+	Ipc    uint64 `json:"ipc"`
+	User   uint64 `json:"user"`
+	Cgroup uint64 `json:"cgroup,omitempty"`
+}
+
+// Resources are the resources that can be collected
+var Resources = []string{"process", "socket", "namespace", "filesystem", "capability"}
+
+// Collect returns the entries of resource on the node whose /proc is
+// procRoot, sorted by pid
+func Collect(procRoot, resource string) ([]interface{}, error) {
+	var entries []interface{}
+	switch resource {
+	case "process":
+		processes, err := Processes(procRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range processes {
+			entries = append(entries, p)
+		}
+	case "socket":
+		sockets, err := Sockets(procRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sockets {
+			entries = append(entries, s)
+		}
+	case "namespace":
+		namespaces, err := ContainerNamespaces(procRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range namespaces {
+			entries = append(entries, n)
+		}
+	case "filesystem":
+		filesystems, err := Filesystems(procRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range filesystems {
+			entries = append(entries, f)
+		}
+	case "capability":
+		capabilities, err := ContainerCapabilities(procRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range capabilities {
+			entries = append(entries, c)
+		}
+	default:
+		return nil, fmt.Errorf("unknown resource %q, expected one of %s", resource, strings.Join(Resources, ", "))
+	}
+	return entries, nil
+}
+
+// pids returns the pids of the processes of procRoot, sorted
+func pids(procRoot string) ([]int, error) {
+	dirs, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, dir := range dirs {
+		if pid, err := strconv.Atoi(dir.Name()); err == nil && dir.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+	return pids, nil
+}
+
+var containerIDRegexp = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerID returns the id of the container of a process from its
+// cgroups, like docker-<id>.scope, crio-<id>.scope or <id>, "" when it is not
+// in a container
+func containerID(procRoot string, pid int) string {
+	content, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return ""
+	}
+	return containerIDRegexp.FindString(string(content))
+}
+
+// Processes returns the processes of the node whose /proc is procRoot.
+// Processes exiting during the collection are skipped.
+func Processes(procRoot string) ([]Process, error) {
+	all, err := pids(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	var processes []Process
+	for _, pid := range all {
+		p, err := readProcess(procRoot, pid)
+		if err != nil {
+			continue
+		}
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+func readProcess(procRoot string, pid int) (Process, error) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	status, err := ioutil.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return Process{}, err
+	}
+	p := Process{Pid: pid}
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "Name:":
+			p.Comm = strings.TrimSpace(strings.TrimPrefix(line, "Name:"))
+		case "PPid:":
+			p.Ppid, _ = strconv.Atoi(fields[1])
+		case "Uid:":
+			// Real, effective, saved and filesystem uid: the effective
+			// one is the one checked for permissions
+			if len(fields) > 2 {
+				p.Uid, _ = strconv.Atoi(fields[2])
+			}
+		}
+	}
+	p.ContainerID = containerID(procRoot, pid)
+	return p, nil
+}
+
+// namespaceInode returns the inode number of the namespace ns of a process,
+// from the target of the /proc/<pid>/ns/<ns> link, like net:[4026531993]
+func namespaceInode(procRoot string, pid int, ns string) (uint64, error) {
+	target, err := os.Readlink(filepath.Join(procRoot, strconv.Itoa(pid), "ns", ns))
+	if err != nil {
+		return 0, err
+	}
+	start := strings.Index(target, "[")
+	if start == -1 || !strings.HasSuffix(target, "]") {
+		return 0, fmt.Errorf("unexpected namespace %q", target)
+	}
+	return strconv.ParseUint(target[start+1:len(target)-1], 10, 64)
+}
+
+// ContainerNamespaces returns the namespaces of the containers of the node,
+// and of the host, with an empty ContainerID
+func ContainerNamespaces(procRoot string) ([]Namespaces, error) {
+	all, err := pids(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []Namespaces
+	seen := map[string]bool{}
+	for _, pid := range all {
+		id := containerID(procRoot, pid)
+		if seen[id] {
+			continue
+		}
+		n := Namespaces{Container: Container{ContainerID: id}, Pid: pid}
+		n.Mnt, err = namespaceInode(procRoot, pid, "mnt")
+		if err != nil {
+			// Exited
+			continue
+		}
+		n.Net, _ = namespaceInode(procRoot, pid, "net")
+		n.PidNs, _ = namespaceInode(procRoot, pid, "pid")
+		n.Uts, _ = namespaceInode(procRoot, pid, "uts")
+		n.Ipc, _ = namespaceInode(procRoot, pid, "ipc")
+		n.User, _ = namespaceInode(procRoot, pid, "user")
+		// Linux 4.6 or later
+		n.Cgroup, _ = namespaceInode(procRoot, pid, "cgroup")
+		seen[id] = true
+		namespaces = append(namespaces, n)
+	}
+	return namespaces, nil
+}
+
+// tcpStates are the states of the TCP sockets in /proc/net/tcp, see
+// include/net/tcp_states.h
+var tcpStates = map[uint64]string{
+	1:  "ESTABLISHED",
+	2:  "SYN_SENT",
+	3:  "SYN_RECV",
+	4:  "FIN_WAIT1",
+	5:  "FIN_WAIT2",
+	6:  "TIME_WAIT",
+	7:  "CLOSE",
+	8:  "CLOSE_WAIT",
+	9:  "LAST_ACK",
+	10: "LISTEN",
+	11: "CLOSING",
+}
+
+// Sockets returns the TCP and UDP sockets of the processes of the node whose
+// /proc is procRoot. The sockets are read once per network namespace, and
+// attributed to the first process holding them: sockets held by no process,
+// like the ones in TIME_WAIT, are skipped.
+func Sockets(procRoot string) ([]Socket, error) {
+	all, err := pids(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	// The process holding each socket inode, per network namespace
+	type owner struct {
+		pid  int
+		comm string
+	}
+	owners := map[uint64]map[uint64]owner{}
+	netnsPid := map[uint64]int{}
+	var netnsOrder []uint64
+	for _, pid := range all {
+		netns, err := namespaceInode(procRoot, pid, "net")
+		if err != nil {
+			continue
+		}
+		if _, ok := owners[netns]; !ok {
+			owners[netns] = map[uint64]owner{}
+			netnsPid[netns] = pid
+			netnsOrder = append(netnsOrder, netns)
+		}
+		fdDir := filepath.Join(procRoot, strconv.Itoa(pid), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		var comm string
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, ok := owners[netns][inode]; ok {
+				continue
+			}
+			if comm == "" {
+				comm = readComm(procRoot, pid)
+			}
+			owners[netns][inode] = owner{pid: pid, comm: comm}
+		}
+	}
+
+	var sockets []Socket
+	for _, netns := range netnsOrder {
+		for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+			path := filepath.Join(procRoot, strconv.Itoa(netnsPid[netns]), "net", protocol)
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				// IPv6 disabled, or the process exited
+				continue
+			}
+			entries, err := parseSockets(string(content), protocol)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", path, err)
+			}
+			for _, e := range entries {
+				o, ok := owners[netns][e.inode]
+				if !ok {
+					continue
+				}
+				e.socket.Pid = o.pid
+				e.socket.Comm = o.comm
+				e.socket.ContainerID = containerID(procRoot, o.pid)
+				sockets = append(sockets, e.socket)
+			}
+		}
+	}
+	sort.SliceStable(sockets, func(i, j int) bool {
+		return sockets[i].Pid < sockets[j].Pid
+	})
+	return sockets, nil
+}
+
+func readComm(procRoot string, pid int) string {
+	comm, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+type socketEntry struct {
+	socket Socket
+	inode  uint64
+}
+
+// parseSockets parses a socket table of /proc/net, like tcp or udp6
+func parseSockets(content, protocol string) ([]socketEntry, error) {
+	var entries []socketEntry
+	lines := strings.Split(content, "\n")
+	// The first line is the header
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		localAddress, localPort, err := parseAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		remoteAddress, remotePort, err := parseAddress(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		state, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state %q", fields[3])
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode %q", fields[9])
+		}
+		s := Socket{
+			Protocol:      strings.ToUpper(strings.TrimSuffix(protocol, "6")),
+			LocalAddress:  localAddress,
+			LocalPort:     localPort,
+			RemoteAddress: remoteAddress,
+			RemotePort:    remotePort,
+			State:         tcpStates[state],
+		}
+		// The UDP sockets use the same values but aren't connections
+		if s.Protocol == "UDP" {
+			s.State = ""
+			if state == 1 {
+				s.State = "ESTABLISHED"
+			}
+		}
+		entries = append(entries, socketEntry{socket: s, inode: inode})
+	}
+	return entries, nil
+}
+
+// parseAddress parses an address of /proc/net, like 0100007F:0277: the
+// address in hexadecimal, as 32-bit words in the byte order of the host,
+// assumed little endian, then the port
+func parseAddress(s string) (string, uint16, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 || (len(parts[0]) != 8 && len(parts[0]) != 32) {
+		return "", 0, fmt.Errorf("invalid address %q", s)
+	}
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in %q", s)
+	}
+	var ip []byte
+	for i := 0; i < len(parts[0]); i += 8 {
+		word, err := strconv.ParseUint(parts[0][i:i+8], 16, 32)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid address %q", s)
+		}
+		ip = append(ip, byte(word), byte(word>>8), byte(word>>16), byte(word>>24))
+	}
+	return net.IP(ip).String(), uint16(port), nil
+}
@@ -0,0 +1,148 @@
+package snapshot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const testContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+const testTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:A2C4 01 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:1F90 0100007F:A2C6 06 00000000:00000000 03:00000F6F 00000000     0        0 0 3 0000000000000000
+`
+
+const testUDP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  0: 00000000000000000000000001000000:0035 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1003 2 0000000000000000 0
+`
+
+type testProcess struct {
+	pid     int
+	ppid    int
+	comm    string
+	cgroup  string
+	netns   uint64
+	sockets []uint64
+}
+
+// newTestProc returns a fake /proc with the processes, removed by the
+// returned function
+func newTestProc(t *testing.T, processes []testProcess) (string, func()) {
+	root, err := ioutil.TempDir("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(path, target string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range processes {
+		dir := filepath.Join(root, strconv.Itoa(p.pid))
+		write(filepath.Join(dir, "status"), fmt.Sprintf("Name:\t%s\nState:\tS (sleeping)\nPPid:\t%d\nUid:\t1000\t1001\t1001\t1001\n", p.comm, p.ppid))
+		write(filepath.Join(dir, "comm"), p.comm+"\n")
+		write(filepath.Join(dir, "cgroup"), p.cgroup)
+		write(filepath.Join(dir, "net", "tcp"), testTCP)
+		write(filepath.Join(dir, "net", "udp6"), testUDP6)
+		for _, ns := range []string{"mnt", "pid", "uts", "ipc", "user", "cgroup"} {
+			link(filepath.Join(dir, "ns", ns), fmt.Sprintf("%s:[%d]", ns, p.netns+1))
+		}
+		link(filepath.Join(dir, "ns", "net"), fmt.Sprintf("net:[%d]", p.netns))
+		if err := os.MkdirAll(filepath.Join(dir, "fd"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		link(filepath.Join(dir, "fd", "0"), "/dev/null")
+		for i, inode := range p.sockets {
+			link(filepath.Join(dir, "fd", strconv.Itoa(i+3)), fmt.Sprintf("socket:[%d]", inode))
+		}
+	}
+	// Not a process
+	write(filepath.Join(root, "uptime"), "1.00 1.00\n")
+	return root, func() { os.RemoveAll(root) }
+}
+
+var testProcesses = []testProcess{
+	{pid: 1, comm: "systemd", cgroup: "0::/init.scope\n", netns: 100},
+	{pid: 42, ppid: 1, comm: "nginx", cgroup: "4:memory:/kubepods/besteffort/pod1/" + testContainerID + "\n", netns: 200, sockets: []uint64{1001, 1002}},
+	{pid: 43, ppid: 42, comm: "nginx worker", cgroup: "4:memory:/kubepods/besteffort/pod1/" + testContainerID + "\n", netns: 200, sockets: []uint64{1001}},
+	{pid: 50, ppid: 1, comm: "dnsmasq", cgroup: "0::/system.slice/dnsmasq.service\n", netns: 100, sockets: []uint64{1003}},
+}
+
+func TestProcesses(t *testing.T) {
+	root, remove := newTestProc(t, testProcesses)
+	defer remove()
+
+	processes, err := Processes(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Process{
+		{Pid: 1, Ppid: 0, Uid: 1001, Comm: "systemd"},
+		{Container: Container{ContainerID: testContainerID}, Pid: 42, Ppid: 1, Uid: 1001, Comm: "nginx"},
+		{Container: Container{ContainerID: testContainerID}, Pid: 43, Ppid: 42, Uid: 1001, Comm: "nginx worker"},
+		{Pid: 50, Ppid: 1, Uid: 1001, Comm: "dnsmasq"},
+	}
+	if !reflect.DeepEqual(processes, expected) {
+		t.Fatalf("unexpected processes:\n%+v\nexpected:\n%+v", processes, expected)
+	}
+}
+
+func TestContainerNamespaces(t *testing.T) {
+	root, remove := newTestProc(t, testProcesses)
+	defer remove()
+
+	namespaces, err := ContainerNamespaces(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Namespaces{
+		{Pid: 1, Mnt: 101, Net: 100, PidNs: 101, Uts: 101, Ipc: 101, User: 101, Cgroup: 101},
+		{Container: Container{ContainerID: testContainerID}, Pid: 42, Mnt: 201, Net: 200, PidNs: 201, Uts: 201, Ipc: 201, User: 201, Cgroup: 201},
+	}
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Fatalf("unexpected namespaces:\n%+v\nexpected:\n%+v", namespaces, expected)
+	}
+}
+
+func TestSockets(t *testing.T) {
+	root, remove := newTestProc(t, testProcesses)
+	defer remove()
+
+	sockets, err := Sockets(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := Container{ContainerID: testContainerID}
+	expected := []Socket{
+		// The socket in TIME_WAIT is held by no process
+		{Container: container, Protocol: "TCP", LocalAddress: "0.0.0.0", LocalPort: 8080, RemoteAddress: "0.0.0.0", State: "LISTEN", Pid: 42, Comm: "nginx"},
+		{Container: container, Protocol: "TCP", LocalAddress: "127.0.0.1", LocalPort: 8080, RemoteAddress: "127.0.0.1", RemotePort: 41668, State: "ESTABLISHED", Pid: 42, Comm: "nginx"},
+		{Protocol: "UDP", LocalAddress: "::1", LocalPort: 53, RemoteAddress: "::", Pid: 50, Comm: "dnsmasq"},
+	}
+	if !reflect.DeepEqual(sockets, expected) {
+		t.Fatalf("unexpected sockets:\n%+v\nexpected:\n%+v", sockets, expected)
+	}
+}
+
+func TestCollectUnknownResource(t *testing.T) {
+	if _, err := Collect("/proc", "files"); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
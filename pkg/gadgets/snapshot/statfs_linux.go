@@ -0,0 +1,28 @@
+package snapshot
+
+import (
+	"syscall"
+)
+
+// statfs returns the usage of the filesystem of path
+func statfs(path string) (fsUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return fsUsage{}, err
+	}
+	// The blocks are counted in fragments, when they are supported
+	size := uint64(st.Frsize)
+	if size == 0 {
+		size = uint64(st.Bsize)
+	}
+	usage := fsUsage{
+		size:   st.Blocks * size,
+		used:   (st.Blocks - st.Bfree) * size,
+		avail:  st.Bavail * size,
+		inodes: st.Files,
+	}
+	if st.Files >= st.Ffree {
+		usage.inodesUsed = st.Files - st.Ffree
+	}
+	return usage, nil
+}
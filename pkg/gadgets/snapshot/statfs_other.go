@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package snapshot
+
+import (
+	"errors"
+)
+
+// statfs is only used on the nodes, on Linux
+func statfs(path string) (fsUsage, error) {
+	return fsUsage{}, errors.New("filesystem usage not available on this system")
+}
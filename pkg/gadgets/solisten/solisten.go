@@ -0,0 +1,79 @@
+// Package solisten describes the listening sockets printed by the solisten
+// gadget.
+package solisten
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Event is a call to listen() as printed by the solisten gadget, completed
+// with the pod of the container by kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* "TCP" */
+	Proto string `json:"proto"`
+	/* 4 or 6 */
+	IPVersion int `json:"ipversion"`
+
+	/* Local address and port of the socket. The port was chosen by the
+	 * kernel if the socket was not bound. */
+	Addr    string `json:"addr"`
+	Port    uint16 `json:"port"`
+	Backlog int    `json:"backlog"`
+
+	/* 0 on success, or minus the error number */
+	Ret int `json:"ret"`
+
+	/* Set by kubectl-gadget */
+	Error string `json:"error,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Result returns "OK" if the call succeeded, or the name of the error, like
+// EADDRINUSE, for the errors of listen()
+func Result(ret int) string {
+	if ret == 0 {
+		return "OK"
+	}
+	switch syscall.Errno(-ret) {
+	case syscall.EADDRINUSE:
+		return "EADDRINUSE"
+	case syscall.EINVAL:
+		return "EINVAL"
+	case syscall.EACCES:
+		return "EACCES"
+	case syscall.EPERM:
+		return "EPERM"
+	case syscall.ENOBUFS:
+		return "ENOBUFS"
+	case syscall.ENOMEM:
+		return "ENOMEM"
+	}
+	return fmt.Sprintf("errno %d", -ret)
+}
+
+// ErrorMessage returns the message of the error of a failed call, like
+// "address already in use", or "" if it succeeded
+func ErrorMessage(ret int) string {
+	if ret == 0 {
+		return ""
+	}
+	return syscall.Errno(-ret).Error()
+}
+
+// FlowKey returns the flow of a call to listen(), to suppress the calls
+// repeating it, like a server retrying to listen on a port in use
+func FlowKey(e Event) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%d|%d", e.ContainerID, e.Comm, e.Proto, e.Addr, e.Port, e.Ret)
+}
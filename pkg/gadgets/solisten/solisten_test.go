@@ -0,0 +1,27 @@
+package solisten
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	table := []struct {
+		ret     int
+		result  string
+		message string
+	}{
+		{0, "OK", ""},
+		{-int(syscall.EADDRINUSE), "EADDRINUSE", "address already in use"},
+		{-int(syscall.EINVAL), "EINVAL", "invalid argument"},
+		{-int(syscall.EIO), "errno 5", "input/output error"},
+	}
+	for _, entry := range table {
+		if result := Result(entry.ret); result != entry.result {
+			t.Errorf("%d: %q != %q", entry.ret, result, entry.result)
+		}
+		if message := ErrorMessage(entry.ret); message != entry.message {
+			t.Errorf("%d: %q != %q", entry.ret, message, entry.message)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package swapin
+
+// Event is a page fault served from swap, as printed by the swapin gadget,
+// completed with the pod of the container by kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* Whether the page was read from the swap device, instead of being
+	 * found in the swap cache */
+	Major bool `json:"major"`
+
+	/* Time spent handling the page fault */
+	LatencyUs uint64 `json:"latency_us"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
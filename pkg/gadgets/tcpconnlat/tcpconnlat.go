@@ -0,0 +1,41 @@
+package tcpconnlat
+
+import (
+	"fmt"
+)
+
+// Event is a connection as printed by the tcpconnlat gadget, completed with
+// the pod of the container by kubectl-gadget
+type Event struct {
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* With --pod-status, the status of the pod when the event was enriched */
+	PodPhase       string `json:"podphase,omitempty"`
+	ContainerReady *bool  `json:"containerready,omitempty"`
+
+	/* 4 or 6 */
+	IPVersion int `json:"ipversion"`
+
+	Saddr string `json:"saddr"`
+	Daddr string `json:"daddr"`
+	Dport uint16 `json:"dport"`
+
+	/* Time from connect() to the reception of the SYN-ACK */
+	LatencyUs uint64 `json:"latency_us"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// FlowKey returns the flow of a connection, to suppress the connections
+// repeating it: the container, the command and the addresses, without the
+// pid nor the latency
+func FlowKey(e Event) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%d", e.ContainerID, e.Comm, e.Saddr, e.Daddr, e.Dport)
+}
@@ -0,0 +1,118 @@
+package tcpping
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+const (
+	// RoleClient is the role of the side of the connection sending the
+	// requests
+	RoleClient = "client"
+	// RoleServer is the role of the side of the connection answering them
+	RoleServer = "server"
+)
+
+// Event is a request as printed by the tcpping gadget, completed with the pod
+// of the container by kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* RoleClient or RoleServer, given by the first data sent or read on
+	 * the connection */
+	Role string `json:"role"`
+
+	/* 4 or 6 */
+	IPVersion int `json:"ipversion"`
+
+	Saddr string `json:"saddr"`
+	Sport uint16 `json:"sport"`
+	Daddr string `json:"daddr"`
+	Dport uint16 `json:"dport"`
+
+	/* For a client, time from the request sent to the response read. For a
+	 * server, time from the request read to the response sent. */
+	LatencyUs uint64 `json:"latency_us"`
+
+	/* With --trace-header, the value of this header in the HTTP/1.x
+	 * request, and the trace id it carries, see TraceID */
+	TraceHeader string `json:"traceheader,omitempty"`
+	TraceID     string `json:"traceid,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Connection returns the local and remote addresses of the connection, like
+// "10.2.1.5:43412 -> 10.2.3.7:8080"
+func (e Event) Connection() string {
+	return fmt.Sprintf("%s -> %s",
+		net.JoinHostPort(e.Saddr, fmt.Sprint(e.Sport)),
+		net.JoinHostPort(e.Daddr, fmt.Sprint(e.Dport)))
+}
+
+// headerNameRe matches the names of the HTTP headers, tokens of RFC 7230
+var headerNameRe = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// ParseTraceHeader checks the name of the header given with --trace-header
+// and returns it in lower case, as the gadget expects it
+func ParseTraceHeader(name string) (string, error) {
+	if !headerNameRe.MatchString(name) {
+		return "", fmt.Errorf("%q is not a HTTP header name", name)
+	}
+	return strings.ToLower(name), nil
+}
+
+var (
+	traceIDRe = regexp.MustCompile("^([0-9a-f]{16}|[0-9a-f]{32})$")
+	zeroRe    = regexp.MustCompile("^0+$")
+)
+
+// TraceID returns the trace id carried by the value of the header name, in
+// lower case:
+//
+// - traceparent, of W3C Trace Context: version-traceid-parentid-flags
+// - b3, the single header of Zipkin: traceid-spanid[-sampled[-parentid]]
+// - uber-trace-id, of Jaeger: traceid:spanid:parentid:flags
+//
+// It is empty when the value is not valid, or carries no trace id. The value
+// of the other headers, like x-b3-traceid or x-request-id, is the trace id.
+func TraceID(name, value string) string {
+	value = strings.TrimSpace(value)
+	var id string
+	switch name {
+	case "traceparent":
+		fields := strings.Split(value, "-")
+		if len(fields) < 4 || len(fields[0]) != 2 || fields[0] == "ff" || len(fields[1]) != 32 {
+			return ""
+		}
+		id = fields[1]
+	case "b3":
+		id = strings.Split(value, "-")[0]
+	case "uber-trace-id":
+		value = strings.Replace(value, "%3A", ":", -1)
+		id = strings.Split(value, ":")[0]
+		// Jaeger drops the leading zeros
+		if len(id) < 16 {
+			id = strings.Repeat("0", 16-len(id)) + id
+		} else if len(id) > 16 && len(id) < 32 {
+			id = strings.Repeat("0", 32-len(id)) + id
+		}
+	default:
+		return value
+	}
+	id = strings.ToLower(id)
+	if !traceIDRe.MatchString(id) || zeroRe.MatchString(id) {
+		return ""
+	}
+	return id
+}
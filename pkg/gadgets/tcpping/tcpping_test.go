@@ -0,0 +1,65 @@
+package tcpping
+
+import (
+	"testing"
+)
+
+func TestConnection(t *testing.T) {
+	table := []struct {
+		event    Event
+		expected string
+	}{
+		{
+			Event{IPVersion: 4, Saddr: "10.2.1.5", Sport: 43412, Daddr: "10.2.3.7", Dport: 8080},
+			"10.2.1.5:43412 -> 10.2.3.7:8080",
+		},
+		{
+			Event{IPVersion: 6, Saddr: "fd00::5", Sport: 43412, Daddr: "fd00::7", Dport: 443},
+			"[fd00::5]:43412 -> [fd00::7]:443",
+		},
+	}
+	for _, entry := range table {
+		if got := entry.event.Connection(); got != entry.expected {
+			t.Errorf("%+v: %q != %q", entry.event, got, entry.expected)
+		}
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	table := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		// Future versions can add fields
+		{"traceparent", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"traceparent", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+		{"traceparent", "00-4bf92f3577b34da6-00f067aa0ba902b7-01", ""},
+		{"traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E473X-00f067aa0ba902b7-01", ""},
+		{"b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90", "80f198ee56343ba864fe8b2a57d3eff7"},
+		{"b3", "a3ce929d0e0e4736-00f067aa0ba902b7", "a3ce929d0e0e4736"},
+		// Only the sampling decision
+		{"b3", "0", ""},
+		{"uber-trace-id", "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"uber-trace-id", "abc123%3A00f067aa0ba902b7%3A0%3A1", "0000000000abc123"},
+		{"x-request-id", " 8d3c4f1a-6c1e-4b8e-9a3b-2f6d2c1e7a90 ", "8d3c4f1a-6c1e-4b8e-9a3b-2f6d2c1e7a90"},
+	}
+	for _, entry := range table {
+		if got := TraceID(entry.name, entry.value); got != entry.expected {
+			t.Errorf("TraceID(%q, %q) = %q, expected %q", entry.name, entry.value, got, entry.expected)
+		}
+	}
+}
+
+func TestParseTraceHeader(t *testing.T) {
+	if name, err := ParseTraceHeader("X-B3-TraceId"); err != nil || name != "x-b3-traceid" {
+		t.Fatalf("unexpected %q, %v", name, err)
+	}
+	for _, name := range []string{"", "trace parent", "traceparent:", "trace\nparent"} {
+		if _, err := ParseTraceHeader(name); err == nil {
+			t.Fatalf("%q accepted", name)
+		}
+	}
+}
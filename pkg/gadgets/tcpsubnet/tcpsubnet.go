@@ -0,0 +1,64 @@
+package tcpsubnet
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MaxSubnets is the maximum number of subnets supported by the gadget
+const MaxSubnets = 16
+
+// Event is a summary of the TCP traffic of a container to a subnet over an
+// interval, as printed by the tcpsubnet gadget, completed with the pod of the
+// container by kubectl-gadget
+type Event struct {
+	Timestamp string `json:"timestamp"`
+
+	/* Empty for processes that are not in a container */
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	Subnet   string `json:"subnet"`
+	Sent     uint64 `json:"sent"`
+	Received uint64 `json:"received"`
+
+	/* Summary of the incomplete last interval, printed when the gadget
+	 * is stopped */
+	Partial bool `json:"partial,omitempty"`
+
+	/* Summary recorded on the node before the gadget was started, with
+	 * kubectl gadget deploy --history */
+	History bool `json:"history,omitempty"`
+}
+
+// ParseSubnets parses a comma-separated list of IPv4 subnets in CIDR
+// notation, like "10.0.0.0/8,0.0.0.0/0". The subnets are returned in their
+// canonical form, e.g. "10.1.2.3/8" is returned as "10.0.0.0/8".
+func ParseSubnets(s string) ([]string, error) {
+	var subnets []string
+	for _, cidr := range strings.Split(s, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet %q: %w", cidr, err)
+		}
+		if subnet.IP.To4() == nil {
+			return nil, fmt.Errorf("invalid subnet %q: only IPv4 is supported", cidr)
+		}
+		subnets = append(subnets, subnet.String())
+	}
+	if len(subnets) == 0 {
+		return nil, fmt.Errorf("no subnets")
+	}
+	if len(subnets) > MaxSubnets {
+		return nil, fmt.Errorf("too many subnets: %d, at most %d are supported", len(subnets), MaxSubnets)
+	}
+	return subnets, nil
+}
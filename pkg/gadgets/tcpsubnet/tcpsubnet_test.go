@@ -0,0 +1,34 @@
+package tcpsubnet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSubnets(t *testing.T) {
+	table := []struct {
+		input    string
+		expected string
+	}{
+		{"10.0.0.0/8,0.0.0.0/0", "10.0.0.0/8,0.0.0.0/0"},
+		{" 10.1.2.3/8, 192.168.0.0/16 ,", "10.0.0.0/8,192.168.0.0/16"},
+		{"127.0.0.1/32", "127.0.0.1/32"},
+	}
+	for _, entry := range table {
+		subnets, err := ParseSubnets(entry.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", entry.input, err)
+			continue
+		}
+		if strings.Join(subnets, ",") != entry.expected {
+			t.Errorf("%q: got %v, expected %s", entry.input, subnets, entry.expected)
+		}
+	}
+
+	tooMany := strings.Repeat("10.0.0.0/8,", MaxSubnets+1)
+	for _, input := range []string{"", ",", "10.0.0.0", "10.0.0.0/33", "example.com/8", "fd00::/8", tooMany} {
+		if _, err := ParseSubnets(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+const (
+	DirectionOutbound = "outbound"
+	DirectionInbound  = "inbound"
+
+	// MaxConnections is the number of open connections whose direction
+	// is remembered until their close
+	MaxConnections = 65536
+)
+
+// Connection identifies a connection by its version and 4-tuple
+type Connection struct {
+	IPVersion int
+	Saddr     string
+	Sport     uint16
+	Daddr     string
+	Dport     uint16
+}
+
+// Directions remembers the direction of the open connections, to give it to
+// their close events, which don't tell which side opened the connection
+type Directions struct {
+	open map[Connection]string
+}
+
+func NewDirections() *Directions {
+	return &Directions{open: map[Connection]string{}}
+}
+
+// Direction returns the direction of the connection of an event of the given
+// type: "connect", "accept" or "close". It is empty for the close of a
+// connection not seen opened, or when too many connections were open.
+func (d *Directions) Direction(eventType string, c Connection) string {
+	switch eventType {
+	case "connect":
+		d.remember(c, DirectionOutbound)
+		return DirectionOutbound
+	case "accept":
+		d.remember(c, DirectionInbound)
+		return DirectionInbound
+	case "close":
+		direction := d.open[c]
+		delete(d.open, c)
+		return direction
+	}
+	return ""
+}
+
+func (d *Directions) remember(c Connection, direction string) {
+	if _, ok := d.open[c]; ok || len(d.open) < MaxConnections {
+		d.open[c] = direction
+	}
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestDirections(t *testing.T) {
+	d := NewDirections()
+	client := Connection{4, "10.2.232.47", 45866, "10.2.232.1", 80}
+	server := Connection{6, "fd00::1", 8080, "fd00::2", 51234}
+	old := Connection{4, "10.2.232.47", 40000, "10.2.232.1", 443}
+
+	table := []struct {
+		eventType string
+		conn      Connection
+		expected  string
+	}{
+		{"connect", client, DirectionOutbound},
+		{"accept", server, DirectionInbound},
+		{"close", old, ""},
+		{"close", server, DirectionInbound},
+		{"close", client, DirectionOutbound},
+		// Forgotten once closed
+		{"close", client, ""},
+	}
+	for _, entry := range table {
+		if direction := d.Direction(entry.eventType, entry.conn); direction != entry.expected {
+			t.Fatalf("%s %v: %q != %q", entry.eventType, entry.conn, direction, entry.expected)
+		}
+	}
+	if len(d.open) != 0 {
+		t.Fatalf("connections not forgotten: %v", d.open)
+	}
+}
+
+func TestDirectionsLimit(t *testing.T) {
+	d := NewDirections()
+	for i := 0; i < MaxConnections+1; i++ {
+		d.Direction("connect", Connection{4, "10.0.0.1", uint16(i), "10.0.0.2", uint16(i >> 16)})
+	}
+	if len(d.open) != MaxConnections {
+		t.Fatalf("%d connections remembered", len(d.open))
+	}
+	// The connection after the limit: port 0, wrapping, and 1
+	last := Connection{4, "10.0.0.1", 0, "10.0.0.2", 1}
+	if direction := d.Direction("close", last); direction != "" {
+		t.Fatalf("unexpected %q", direction)
+	}
+}
@@ -0,0 +1,41 @@
+package types
+
+type Event struct {
+	/* "connect", "accept" or "close" */
+	Type string `json:"type"`
+
+	/* "outbound" for a connect, "inbound" for an accept, and the one of
+	 * the connection for a close, omitted when the connection was opened
+	 * before the gadget started */
+	Direction string `json:"direction,omitempty"`
+
+	Node string `json:"node,omitempty"`
+
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+
+	/* With --pod-status, the status of the pod when the event was enriched */
+	PodPhase       string `json:"podphase,omitempty"`
+	ContainerReady *bool  `json:"containerready,omitempty"`
+
+	Pid  uint32 `json:"pid"`
+	Comm string `json:"comm"`
+
+	/* With --show-age, when the process started, in RFC3339 format, and
+	 * the time it had been running for at the event */
+	ProcessStart string `json:"processstart,omitempty"`
+	ProcessAgeNs *int64 `json:"processage_ns,omitempty"`
+
+	/* With --show-cgroup, the cgroup of the process, like
+	 * /kubepods/burstable/pod<uid>/<container id> */
+	CgroupPath string `json:"cgrouppath,omitempty"`
+
+	/* 4 or 6 */
+	IPVersion int `json:"ipversion"`
+
+	Saddr string `json:"saddr"`
+	Sport uint16 `json:"sport"`
+	Daddr string `json:"daddr"`
+	Dport uint16 `json:"dport"`
+}
@@ -0,0 +1,185 @@
+// Package traceloop compares the traces dumped by "kubectl gadget traceloop
+// show", to spot behavioral changes between two runs of the same workload.
+package traceloop
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Key identifies similar syscalls in a capture. Timestamps, pids, file
+// descriptors and pointers change between two runs of the same workload, so
+// they are not part of the key.
+type Key struct {
+	Comm    string
+	Syscall string
+
+	// Path is the first string argument starting with "/", if any
+	Path string
+
+	// Errno is the error returned by the syscall, like "no such file or
+	// directory", or empty on success or when the result is not in the
+	// capture
+	Errno string
+}
+
+// Capture counts the syscalls of a trace by key
+type Capture map[Key]int
+
+var (
+	// 00:00.070713699 cpu#0 pid 14465 [sh] open(...) = 3
+	eventRegexp = regexp.MustCompile(`^\S+ cpu#\d+ pid (\d+) \[(.*?)\] (.*)$`)
+	// -1 (no such file or directory) [CAP_SYS_ADMIN]
+	errnoRegexp  = regexp.MustCompile(`^-1 \((.*?)\)`)
+	stringRegexp = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+)
+
+// Parse reads a trace in the text format of traceloop. Lines that are not
+// syscalls, like the parameters of the syscalls or the truncation marker of
+// --limit-bytes, are ignored.
+func Parse(r io.Reader) (Capture, error) {
+	c := Capture{}
+	// Syscalls whose result is on a later line, by pid
+	pending := map[string]Key{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := eventRegexp.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		pid, comm, call := m[1], m[2], m[3]
+
+		// ...write() = 20
+		if strings.HasPrefix(call, "...") {
+			name, ret := splitCall(strings.TrimPrefix(call, "..."))
+			key, ok := pending[pid]
+			if ok && key.Syscall == name {
+				delete(pending, pid)
+				key.Errno = parseErrno(ret)
+				c[key]++
+			}
+			continue
+		}
+
+		if key, ok := pending[pid]; ok {
+			delete(pending, pid)
+			c[key]++
+		}
+		name, ret := splitCall(call)
+		key := Key{
+			Comm:    comm,
+			Syscall: name,
+			Path:    parsePath(call),
+		}
+		// write(4, "0", 1)...
+		if strings.HasSuffix(call, "...") {
+			pending[pid] = key
+			continue
+		}
+		key.Errno = parseErrno(ret)
+		c[key]++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, key := range pending {
+		c[key]++
+	}
+	return c, nil
+}
+
+// splitCall returns the name and the result of a syscall like
+// open("/tmp/file", 0, 0) = 3
+func splitCall(call string) (name, ret string) {
+	name = call
+	if i := strings.IndexByte(call, '('); i >= 0 {
+		name = call[:i]
+	}
+	if i := strings.LastIndex(call, ") = "); i >= 0 {
+		ret = call[i+len(") = "):]
+	}
+	return name, ret
+}
+
+func parseErrno(ret string) string {
+	m := errnoRegexp.FindStringSubmatch(ret)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func parsePath(call string) string {
+	for _, s := range stringRegexp.FindAllString(call, -1) {
+		path, err := strconv.Unquote(s)
+		if err == nil && strings.HasPrefix(path, "/") {
+			return path
+		}
+	}
+	return ""
+}
+
+// Change is a key whose count differs between two captures
+type Change struct {
+	Key
+	CountA int
+	CountB int
+}
+
+// Status returns "-" if the key is only in the first capture, "+" if it is
+// only in the second one, and "~" if its count changed
+func (c Change) Status() string {
+	switch {
+	case c.CountB == 0:
+		return "-"
+	case c.CountA == 0:
+		return "+"
+	default:
+		return "~"
+	}
+}
+
+// Diff returns the keys whose count differs between a and b. The keys only
+// in one of the captures come first, then the ones whose count changed.
+func Diff(a, b Capture) []Change {
+	var changes []Change
+	for key, countA := range a {
+		if countB := b[key]; countB != countA {
+			changes = append(changes, Change{key, countA, countB})
+		}
+	}
+	for key, countB := range b {
+		if _, ok := a[key]; !ok {
+			changes = append(changes, Change{key, 0, countB})
+		}
+	}
+
+	order := map[string]int{"-": 0, "+": 1, "~": 2}
+	sort.Slice(changes, func(i, j int) bool {
+		ci, cj := changes[i], changes[j]
+		if oi, oj := order[ci.Status()], order[cj.Status()]; oi != oj {
+			return oi < oj
+		}
+		return ci.Key.less(cj.Key)
+	})
+	return changes
+}
+
+func (k Key) less(o Key) bool {
+	if k.Comm != o.Comm {
+		return k.Comm < o.Comm
+	}
+	if k.Syscall != o.Syscall {
+		return k.Syscall < o.Syscall
+	}
+	if k.Path != o.Path {
+		return k.Path < o.Path
+	}
+	return k.Errno < o.Errno
+}
@@ -0,0 +1,68 @@
+package traceloop
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseFile(t *testing.T, path string) Capture {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse(strings.NewReader(
+		`00:00.000 cpu#0 pid 1 [runc:[2:INIT]] write(3, "0", 1)...
+00:00.001 cpu#1 pid 2 [cat] open("/etc/shadow", 0, 0) = -1 (permission denied) [CAP_DAC_OVERRIDE]
+00:00.002 cpu#0 pid 1 [runc:[2:INIT]] ...write() = 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Capture{
+		{Comm: "runc:[2:INIT]", Syscall: "write"}:                                       1,
+		{Comm: "cat", Syscall: "open", Path: "/etc/shadow", Errno: "permission denied"}: 1,
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("Unexpected capture: %v, expected %v", c, expected)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := parseFile(t, "testdata/before.txt")
+	after := parseFile(t, "testdata/after.txt")
+
+	expected := []Change{
+		{Key{"cat", "close", "", ""}, 1, 0},
+		{Key{"cat", "open", "/tmp/file-1889", ""}, 1, 0},
+		{Key{"cat", "read", "", ""}, 2, 0},
+		{Key{"cat", "exit_group", "", ""}, 0, 1},
+		{Key{"cat", "open", "/tmp/file-3240", "no such file or directory"}, 0, 2},
+		{Key{"cat", "write", "", ""}, 0, 2},
+		{Key{"bc", "write", "", ""}, 1, 2},
+	}
+	changes := Diff(before, after)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Unexpected changes:\n%v\nexpected:\n%v", changes, expected)
+	}
+
+	for i, status := range []string{"-", "-", "-", "+", "+", "+", "~"} {
+		if changes[i].Status() != status {
+			t.Errorf("%v: status %q, expected %q", changes[i], changes[i].Status(), status)
+		}
+	}
+
+	if changes := Diff(before, before); len(changes) != 0 {
+		t.Errorf("Unexpected changes between identical captures: %v", changes)
+	}
+}
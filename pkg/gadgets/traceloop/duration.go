@@ -0,0 +1,144 @@
+package traceloop
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseTimestamp parses the timestamp of an event, the time since the start
+// of the trace as printed by traceloop, like "00:00.070713699"
+func ParseTimestamp(s string) (time.Duration, error) {
+	parts := strings.SplitN(s, ".", 2)
+	var d time.Duration
+	for _, field := range strings.Split(parts[0], ":") {
+		v, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		d = d*60 + time.Duration(v)
+	}
+	d *= time.Second
+	if len(parts) == 2 {
+		fraction := parts[1]
+		if len(fraction) > 9 {
+			fraction = fraction[:9]
+		}
+		ns, err := strconv.ParseUint(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		d += time.Duration(ns)
+	}
+	return d, nil
+}
+
+// syscallDuration returns the time between the entry and the exit of a
+// syscall, from the timestamps of their lines
+func syscallDuration(entry, exit string) (time.Duration, bool) {
+	start, err := ParseTimestamp(entry)
+	if err != nil {
+		return 0, false
+	}
+	end, err := ParseTimestamp(exit)
+	if err != nil || end < start {
+		return 0, false
+	}
+	return end - start, true
+}
+
+// FormatDuration formats the duration of a syscall in seconds, as strace -T
+// does, like "0.000016158"
+func FormatDuration(d time.Duration) string {
+	return fmt.Sprintf("%d.%09d", d/time.Second, d%time.Second)
+}
+
+// pendingEntry is a syscall whose result is on a later line
+type pendingEntry struct {
+	syscall   string
+	timestamp string
+}
+
+// DurationWriter copies a trace in the text format of traceloop to w,
+// adding the duration of the syscalls whose entry and exit are on two lines,
+// like blocking reads, at the end of their exit line:
+//
+//	00:00.001808990 cpu#1 pid 2201 [sh] ...read() = 20 <0.000016158>
+//
+// The syscalls whose entry and exit are on the same line have a single
+// timestamp: their duration is unknown.
+type DurationWriter struct {
+	w      io.Writer
+	buffer []byte
+	// Syscalls whose result is on a later line, by pid
+	pending map[string]pendingEntry
+}
+
+// NewDurationWriter returns a DurationWriter writing to w
+func NewDurationWriter(w io.Writer) *DurationWriter {
+	return &DurationWriter{
+		w:       w,
+		pending: map[string]pendingEntry{},
+	}
+}
+
+func (d *DurationWriter) Write(p []byte) (int, error) {
+	d.buffer = append(d.buffer, p...)
+	for {
+		i := bytes.IndexByte(d.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(d.buffer[:i])
+		d.buffer = d.buffer[i+1:]
+		if _, err := io.WriteString(d.w, d.annotate(line)+"\n"); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (d *DurationWriter) annotate(line string) string {
+	m := eventRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return line
+	}
+	pid, call := m[1], m[3]
+	timestamp := strings.Fields(line)[0]
+
+	// ...read() = 20
+	if strings.HasPrefix(call, "...") {
+		name, _ := splitCall(strings.TrimPrefix(call, "..."))
+		entry, ok := d.pending[pid]
+		if !ok || entry.syscall != name {
+			return line
+		}
+		delete(d.pending, pid)
+		if duration, ok := syscallDuration(entry.timestamp, timestamp); ok {
+			return fmt.Sprintf("%s <%s>", line, FormatDuration(duration))
+		}
+		return line
+	}
+
+	delete(d.pending, pid)
+	// read(3, "", 4096)...
+	if strings.HasSuffix(call, "...") {
+		name, _ := splitCall(call)
+		d.pending[pid] = pendingEntry{syscall: name, timestamp: timestamp}
+	}
+	return line
+}
+
+// Close writes the last line if it was not terminated by a newline
+func (d *DurationWriter) Close() error {
+	if len(d.buffer) == 0 {
+		return nil
+	}
+	line := string(d.buffer)
+	d.buffer = nil
+	_, err := io.WriteString(d.w, d.annotate(line))
+	return err
+}
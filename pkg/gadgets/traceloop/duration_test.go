@@ -0,0 +1,62 @@
+package traceloop
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	table := []struct {
+		timestamp string
+		expected  time.Duration
+	}{
+		{"00:00.070713699", 70713699 * time.Nanosecond},
+		{"01:02.5", time.Minute + 2500*time.Millisecond},
+		{"1:00:00.000000001", time.Hour + time.Nanosecond},
+	}
+	for _, entry := range table {
+		d, err := ParseTimestamp(entry.timestamp)
+		if err != nil {
+			t.Fatalf("%q: %s", entry.timestamp, err)
+		}
+		if d != entry.expected {
+			t.Errorf("%q: %s != %s", entry.timestamp, d, entry.expected)
+		}
+	}
+	if _, err := ParseTimestamp("cpu#0"); err == nil {
+		t.Errorf("invalid timestamp parsed")
+	}
+}
+
+func TestDurationWriter(t *testing.T) {
+	trace := `00:00.001792832 cpu#1 pid 2201 [sh] read(0, "", 4096)...
+00:00.001794832 "param"
+00:00.001808990 cpu#1 pid 2201 [sh] ...read() = 20
+00:00.003000000 cpu#0 pid 2202 [bc] futex(140723923041877, 128, 0)...
+00:00.003100000 cpu#0 pid 2203 [cat] write(1, "42\n", 3) = 3
+00:01.503000010 cpu#0 pid 2202 [bc] ...futex() = 0
+00:01.600000000 cpu#1 pid 2203 [cat] ...write() = 3
+00:01.700000000 cpu#1 pid 2203 [cat] poll(140723923041877, 1, -1)...`
+
+	var out bytes.Buffer
+	w := NewDurationWriter(&out)
+	r := bytes.NewReader([]byte(trace))
+	if _, err := io.CopyBuffer(w, struct{ io.Reader }{r}, make([]byte, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The exit of write() has no entry on its own line: its duration is
+	// unknown, and so is the one of poll() that did not return
+	expected := strings.Replace(strings.Replace(trace,
+		"...futex() = 0", "...futex() = 0 <1.500000010>", 1),
+		"...read() = 20", "...read() = 20 <0.000016158>", 1)
+	if out.String() != expected {
+		t.Fatalf("unexpected trace:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
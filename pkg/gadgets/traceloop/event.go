@@ -0,0 +1,264 @@
+package traceloop
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Event is a syscall of a trace, as printed with "kubectl gadget traceloop
+// show -o json"
+type Event struct {
+	// TraceID is the trace of the syscall, set only when several traces
+	// are shown at once
+	TraceID string `json:"traceid,omitempty"`
+
+	// Timestamp is the time of the syscall since the start of the trace, as
+	// printed by traceloop, like "00:00.070713699"
+	Timestamp string `json:"timestamp"`
+	CPU       int    `json:"cpu"`
+	Pid       int    `json:"pid"`
+	Comm      string `json:"comm"`
+	Syscall   string `json:"syscall"`
+
+	// Args are the arguments of the syscall: numbers and strings when
+	// decoded, or the text printed by traceloop for each argument with
+	// --raw
+	Args []interface{} `json:"args"`
+	// Truncated is set when a string argument was cut to MaxJSONArgLen
+	// bytes
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Ret is the result of the syscall, nil when not in the trace
+	Ret *int64 `json:"ret"`
+	// Errno is the message of the error, like "no such file or directory"
+	Errno string `json:"errno,omitempty"`
+
+	// DurationNs is the time between the entry and the exit of the
+	// syscall, known only when they are on two lines of the trace
+	DurationNs *int64 `json:"duration_ns,omitempty"`
+}
+
+// EventWriter converts a trace in the text format of traceloop, written to
+// it, to one JSON object per syscall written to w. The syscalls whose result
+// is printed on a later line are written with it. The lines that are not
+// syscalls, like the marker of --limit-bytes, are copied to other.
+type EventWriter struct {
+	// TraceID, when set, is given to all the events written
+	TraceID string
+
+	w     io.Writer
+	other io.Writer
+	raw   bool
+
+	buffer []byte
+	// Syscalls whose result is on a later line, by pid
+	pending map[int]*Event
+	// Pids of pending, in the order of their syscalls
+	order []int
+}
+
+// NewEventWriter returns an EventWriter decoding the arguments of the
+// syscalls, unless raw is set
+func NewEventWriter(w, other io.Writer, raw bool) *EventWriter {
+	return &EventWriter{
+		w:       w,
+		other:   other,
+		raw:     raw,
+		pending: map[int]*Event{},
+	}
+}
+
+func (e *EventWriter) Write(p []byte) (int, error) {
+	e.buffer = append(e.buffer, p...)
+	for {
+		i := bytes.IndexByte(e.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(e.buffer[:i])
+		e.buffer = e.buffer[i+1:]
+		if err := e.writeLine(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (e *EventWriter) writeLine(line string) error {
+	m := eventRegexp.FindStringSubmatch(line)
+	if m == nil {
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+		_, err := io.WriteString(e.other, line+"\n")
+		return err
+	}
+	pid, _ := strconv.Atoi(m[1])
+	comm, call := m[2], m[3]
+
+	// ...write() = 20
+	if strings.HasPrefix(call, "...") {
+		name, ret := splitCall(strings.TrimPrefix(call, "..."))
+		event, ok := e.pending[pid]
+		if !ok || event.Syscall != name {
+			return nil
+		}
+		e.setResult(event, ret)
+		if duration, ok := syscallDuration(event.Timestamp, strings.Fields(line)[0]); ok {
+			ns := int64(duration)
+			event.DurationNs = &ns
+		}
+		return e.flush(pid)
+	}
+
+	if _, ok := e.pending[pid]; ok {
+		if err := e.flush(pid); err != nil {
+			return err
+		}
+	}
+	event := &Event{
+		TraceID:   e.TraceID,
+		Timestamp: strings.Fields(line)[0],
+		CPU:       parseCPU(line),
+		Pid:       pid,
+		Comm:      comm,
+		Args:      []interface{}{},
+	}
+	// write(4, "0", 1)...
+	unfinished := strings.HasSuffix(call, "...")
+	if unfinished {
+		call = strings.TrimSuffix(call, "...")
+	}
+	name, ret := splitCall(call)
+	event.Syscall = name
+	for _, arg := range splitArgs(call[len(name):]) {
+		if e.raw {
+			event.Args = append(event.Args, arg)
+		} else {
+			event.Args = append(event.Args, decodeArg(arg))
+		}
+	}
+	if unfinished {
+		e.pending[pid] = event
+		e.order = append(e.order, pid)
+		return nil
+	}
+	e.setResult(event, ret)
+	return e.writeEvent(event)
+}
+
+func (e *EventWriter) setResult(event *Event, ret string) {
+	fields := strings.Fields(ret)
+	if len(fields) == 0 {
+		return
+	}
+	if v, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+		event.Ret = &v
+	}
+	event.Errno = parseErrno(ret)
+}
+
+// flush writes the pending syscall of pid
+func (e *EventWriter) flush(pid int) error {
+	event := e.pending[pid]
+	delete(e.pending, pid)
+	for i, p := range e.order {
+		if p == pid {
+			e.order = append(e.order[:i], e.order[i+1:]...)
+			break
+		}
+	}
+	return e.writeEvent(event)
+}
+
+func (e *EventWriter) writeEvent(event *Event) error {
+	truncateJSONArgs(event)
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(buf, '\n'))
+	return err
+}
+
+// Close converts the last line if it was not terminated by a newline, and
+// writes the syscalls whose result was not in the trace.
+func (e *EventWriter) Close() error {
+	if len(e.buffer) != 0 {
+		line := string(e.buffer)
+		e.buffer = nil
+		if err := e.writeLine(line); err != nil {
+			return err
+		}
+	}
+	for len(e.order) != 0 {
+		if err := e.flush(e.order[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCPU returns the cpu of an event line, like 1 for
+// "00:00.070713699 cpu#1 pid 2202 [sh] ..."
+func parseCPU(line string) int {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return 0
+	}
+	cpu, _ := strconv.Atoi(strings.TrimPrefix(fields[1], "cpu#"))
+	return cpu
+}
+
+// splitArgs returns the arguments of a syscall, as printed by traceloop,
+// from its parameters like (4, "a, b", 20) = 20
+func splitArgs(params string) []string {
+	if !strings.HasPrefix(params, "(") {
+		return nil
+	}
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+		escaped bool
+	)
+	for _, r := range params[1:] {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ',' || r == ')'):
+			if arg := strings.TrimSpace(current.String()); arg != "" {
+				args = append(args, arg)
+			}
+			current.Reset()
+			if r == ')' {
+				return args
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	return args
+}
+
+// decodeArg returns an argument as a number or a string when it can be
+// decoded, or as printed by traceloop otherwise
+func decodeArg(arg string) interface{} {
+	if v, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseUint(arg, 10, 64); err == nil {
+		return v
+	}
+	if s, err := strconv.Unquote(arg); err == nil {
+		return s
+	}
+	return arg
+}
@@ -0,0 +1,74 @@
+package traceloop
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestEventWriter(t *testing.T) {
+	trace := `00:00.000 cpu#0 pid 1 [runc:[2:INIT]] write(3, "a, \"b\")", 7)...
+00:00.001 "param"
+00:00.001 cpu#1 pid 2 [cat] open("/etc/shadow", 0, 0) = -1 (permission denied) [CAP_DAC_OVERRIDE]
+00:00.002 cpu#0 pid 1 [runc:[2:INIT]] ...write() = 7
+00:00.003 cpu#1 pid 2 [cat] munmap(140723923041877) = 0
+00:00.004 cpu#1 pid 2 [cat] exit_group(1)...
+[trace truncated after 1024 bytes]`
+
+	table := []struct {
+		raw      bool
+		expected string
+	}{
+		{
+			false,
+			`{"timestamp":"00:00.001","cpu":1,"pid":2,"comm":"cat","syscall":"open","args":["/etc/shadow",0,0],"ret":-1,"errno":"permission denied"}
+{"timestamp":"00:00.000","cpu":0,"pid":1,"comm":"runc:[2:INIT]","syscall":"write","args":[3,"a, \"b\")",7],"ret":7,"duration_ns":2000000}
+{"timestamp":"00:00.003","cpu":1,"pid":2,"comm":"cat","syscall":"munmap","args":[140723923041877],"ret":0}
+{"timestamp":"00:00.004","cpu":1,"pid":2,"comm":"cat","syscall":"exit_group","args":[1],"ret":null}
+`,
+		},
+		{
+			true,
+			`{"timestamp":"00:00.001","cpu":1,"pid":2,"comm":"cat","syscall":"open","args":["\"/etc/shadow\"","0","0"],"ret":-1,"errno":"permission denied"}
+{"timestamp":"00:00.000","cpu":0,"pid":1,"comm":"runc:[2:INIT]","syscall":"write","args":["3","\"a, \\\"b\\\")\"","7"],"ret":7,"duration_ns":2000000}
+{"timestamp":"00:00.003","cpu":1,"pid":2,"comm":"cat","syscall":"munmap","args":["140723923041877"],"ret":0}
+{"timestamp":"00:00.004","cpu":1,"pid":2,"comm":"cat","syscall":"exit_group","args":["1"],"ret":null}
+`,
+		},
+	}
+	for _, entry := range table {
+		var out, other bytes.Buffer
+		w := NewEventWriter(&out, &other, entry.raw)
+		// Written in small chunks, as received from the gadget pod
+		r := bytes.NewReader([]byte(trace))
+		if _, err := io.CopyBuffer(w, struct{ io.Reader }{r}, make([]byte, 7)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != entry.expected {
+			t.Errorf("raw=%v: unexpected events:\n%s\nexpected:\n%s", entry.raw, out.String(), entry.expected)
+		}
+		if expected := "00:00.001 \"param\"\n[trace truncated after 1024 bytes]\n"; other.String() != expected {
+			t.Errorf("raw=%v: unexpected other lines %q, expected %q", entry.raw, other.String(), expected)
+		}
+	}
+}
+
+func TestEventWriterTraceID(t *testing.T) {
+	var out, other bytes.Buffer
+	w := NewEventWriter(&out, &other, false)
+	w.TraceID = "00000000000000c0"
+	if _, err := io.WriteString(w, "00:00.003 cpu#1 pid 2 [cat] munmap(140723923041877) = 0\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"traceid":"00000000000000c0","timestamp":"00:00.003","cpu":1,"pid":2,"comm":"cat","syscall":"munmap","args":[140723923041877],"ret":0}
+`
+	if out.String() != expected {
+		t.Errorf("unexpected events:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
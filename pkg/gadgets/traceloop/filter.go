@@ -0,0 +1,118 @@
+package traceloop
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SyscallFilter selects the syscalls of a trace by name, like "openat"
+type SyscallFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// NewSyscallFilter returns a SyscallFilter keeping only the syscalls in
+// include, unless it is empty, and dropping the ones in exclude. It returns
+// an error listing the names that are not syscalls printed by traceloop.
+func NewSyscallFilter(include, exclude []string) (*SyscallFilter, error) {
+	known := map[string]bool{}
+	for _, name := range syscallNames {
+		known[name] = true
+	}
+	var unknown []string
+	set := func(names []string) map[string]bool {
+		m := map[string]bool{}
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !known[name] {
+				unknown = append(unknown, fmt.Sprintf("%q", name))
+				continue
+			}
+			m[name] = true
+		}
+		return m
+	}
+	f := &SyscallFilter{
+		include: set(include),
+		exclude: set(exclude),
+	}
+	if len(unknown) != 0 {
+		return nil, fmt.Errorf("unknown syscalls %s", strings.Join(unknown, ", "))
+	}
+	return f, nil
+}
+
+// Match returns whether the syscall name is selected by the filter
+func (f *SyscallFilter) Match(name string) bool {
+	if len(f.include) != 0 && !f.include[name] {
+		return false
+	}
+	return !f.exclude[name]
+}
+
+// FilterWriter copies a trace in the text format of traceloop to w, only
+// with the syscalls selected by a SyscallFilter. The lines that are not
+// syscalls, like the parameters of a syscall, are kept with the syscall they
+// follow, and the ones before the first syscall are always kept. The exit of
+// a syscall printed on its own line, like "...read() = 20", has the name of
+// the syscall and is kept with its entry.
+type FilterWriter struct {
+	w      io.Writer
+	filter *SyscallFilter
+	buffer []byte
+	// Whether the lines of the current syscall are kept
+	keep bool
+}
+
+// NewFilterWriter returns a FilterWriter writing the syscalls of w selected
+// by filter
+func NewFilterWriter(w io.Writer, filter *SyscallFilter) *FilterWriter {
+	return &FilterWriter{
+		w:      w,
+		filter: filter,
+		keep:   true,
+	}
+}
+
+func (f *FilterWriter) Write(p []byte) (int, error) {
+	f.buffer = append(f.buffer, p...)
+	for {
+		i := bytes.IndexByte(f.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(f.buffer[:i+1])
+		f.buffer = f.buffer[i+1:]
+		if err := f.writeLine(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (f *FilterWriter) writeLine(line string) error {
+	if m := eventRegexp.FindStringSubmatch(strings.TrimSuffix(line, "\n")); m != nil {
+		name, _ := splitCall(strings.TrimPrefix(m[3], "..."))
+		f.keep = f.filter.Match(name)
+	}
+	if !f.keep {
+		return nil
+	}
+	_, err := io.WriteString(f.w, line)
+	return err
+}
+
+// Close writes the last line if it was not terminated by a newline
+func (f *FilterWriter) Close() error {
+	if len(f.buffer) == 0 {
+		return nil
+	}
+	line := string(f.buffer)
+	f.buffer = nil
+	return f.writeLine(line)
+}
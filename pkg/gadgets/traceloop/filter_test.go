@@ -0,0 +1,80 @@
+package traceloop
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestNewSyscallFilter(t *testing.T) {
+	if _, err := NewSyscallFilter([]string{"write", "openat"}, []string{"futex"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewSyscallFilter([]string{"write", "opnat"}, []string{"SYS_futex"})
+	if err == nil {
+		t.Fatal("unknown syscalls accepted")
+	}
+	if expected := `unknown syscalls "opnat", "SYS_futex"`; err.Error() != expected {
+		t.Errorf("%q != %q", err, expected)
+	}
+}
+
+func TestSyscallFilterMatch(t *testing.T) {
+	table := []struct {
+		include, exclude []string
+		name             string
+		expected         bool
+	}{
+		{nil, nil, "read", true},
+		{[]string{"write", "openat"}, nil, "openat", true},
+		{[]string{"write", "openat"}, nil, "read", false},
+		{nil, []string{"futex"}, "futex", false},
+		{nil, []string{"futex"}, "unknown", true},
+		{[]string{"write"}, []string{"write"}, "write", false},
+	}
+	for _, entry := range table {
+		f, err := NewSyscallFilter(entry.include, entry.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Match(entry.name); got != entry.expected {
+			t.Errorf("%v %v %q: %t != %t", entry.include, entry.exclude, entry.name, got, entry.expected)
+		}
+	}
+}
+
+func TestFilterWriter(t *testing.T) {
+	trace := `Lost 3 events
+00:00.001792832 cpu#1 pid 2201 [sh] read(0, "", 4096)...
+00:00.001794832 "param"
+00:00.003000000 cpu#0 pid 2202 [bc] futex(140723923041877, 128, 0)...
+00:00.003000010 "param"
+00:00.003100000 cpu#0 pid 2203 [cat] write(1, "42\n", 3) = 3
+00:00.001808990 cpu#1 pid 2201 [sh] ...read() = 20
+00:01.503000010 cpu#0 pid 2202 [bc] ...futex() = 0
+00:01.700000000 cpu#1 pid 2203 [cat] write(1, "\n", 1) = 1`
+
+	f, err := NewSyscallFilter([]string{"read", "write"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w := NewFilterWriter(&out, f)
+	r := bytes.NewReader([]byte(trace))
+	if _, err := io.CopyBuffer(w, struct{ io.Reader }{r}, make([]byte, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `Lost 3 events
+00:00.001792832 cpu#1 pid 2201 [sh] read(0, "", 4096)...
+00:00.001794832 "param"
+00:00.003100000 cpu#0 pid 2203 [cat] write(1, "42\n", 3) = 3
+00:00.001808990 cpu#1 pid 2201 [sh] ...read() = 20
+00:01.700000000 cpu#1 pid 2203 [cat] write(1, "\n", 1) = 1`
+	if out.String() != expected {
+		t.Fatalf("unexpected trace:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
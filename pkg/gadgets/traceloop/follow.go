@@ -0,0 +1,137 @@
+package traceloop
+
+import (
+	"strings"
+	"time"
+)
+
+// Traceloop prints the timestamps as minutes and seconds: they wrap every
+// hour
+const timestampPeriod = time.Hour
+
+// followEvent is an event of a dump, with the lines that follow it, like
+// the parameters of the syscall
+type followEvent struct {
+	lines []string
+	// text is the event line without its timestamp, empty for the lines
+	// before the first event
+	text string
+	// delta is the time since the previous event, -1 for the first one
+	delta time.Duration
+}
+
+// Follower returns the new events of a trace, dumped again and again, as
+// "kubectl gadget traceloop show --follow" does.
+//
+// Each dump holds the last events recorded, with timestamps relative to the
+// first of them: once the oldest events are dropped, the timestamps of the
+// same events change from a dump to the next. The last event returned is
+// found again in the next dump by its text and the time since the previous
+// event, that don't change.
+//
+// The last event of a dump is held back until the next one: traceloop prints
+// a syscall and its result on the same line only when the result follows
+// it, which it may do in the next dump.
+type Follower struct {
+	started bool
+	// Last event returned
+	lastText  string
+	lastDelta time.Duration
+	// Last event of the last dump, not returned yet
+	tail []string
+}
+
+// Next returns the lines of dump following the ones returned by the
+// previous calls, and whether some events were lost in between because they
+// were dropped by traceloop before being dumped.
+func (f *Follower) Next(dump string) (lines []string, lost bool) {
+	events := splitDump(dump)
+	if len(events) == 0 {
+		return nil, false
+	}
+	body := events[:len(events)-1]
+	f.tail = events[len(events)-1].lines
+
+	start := 0
+	if f.started && f.lastText != "" {
+		found := -1
+		for i := len(body) - 1; i >= 0; i-- {
+			e := body[i]
+			if e.text != f.lastText {
+				continue
+			}
+			if f.lastDelta < 0 || e.delta < 0 || e.delta == f.lastDelta {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			lost = true
+		} else {
+			start = found + 1
+		}
+	} else if f.started {
+		// The first dump had only one event, held back: skip the lines
+		// before it
+		for start < len(body) && body[start].text == "" {
+			start++
+		}
+	}
+	f.started = true
+
+	for _, e := range body[start:] {
+		lines = append(lines, e.lines...)
+		if e.text != "" {
+			f.lastText = e.text
+			f.lastDelta = e.delta
+		}
+	}
+	return lines, lost
+}
+
+// Flush returns the last event held back, when no more dumps are expected
+func (f *Follower) Flush() []string {
+	tail := f.tail
+	f.tail = nil
+	return tail
+}
+
+// splitDump splits a dump in events. Empty lines are dropped.
+func splitDump(dump string) []followEvent {
+	var (
+		events []followEvent
+		last   time.Duration
+		seen   bool
+	)
+	for _, line := range strings.Split(dump, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !eventRegexp.MatchString(line) {
+			if len(events) == 0 {
+				events = append(events, followEvent{delta: -1})
+			}
+			events[len(events)-1].lines = append(events[len(events)-1].lines, line)
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		e := followEvent{
+			lines: []string{line},
+			text:  fields[1],
+			delta: -1,
+		}
+		ts, err := ParseTimestamp(fields[0])
+		if err == nil {
+			if seen {
+				e.delta = ts - last
+				if e.delta < 0 {
+					e.delta += timestampPeriod
+				}
+			}
+			last = ts
+			seen = true
+		}
+		events = append(events, e)
+	}
+	return events
+}
@@ -0,0 +1,100 @@
+package traceloop
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFollower(t *testing.T) {
+	table := []struct {
+		dump     string
+		expected []string
+		lost     bool
+	}{
+		{
+			`00:00.000000000 cpu#0 pid 1 [sh] execve("/bin/sh") = 0
+00:00.001000000 cpu#0 pid 1 [sh] read(0, "", 4096)...
+`,
+			[]string{
+				`00:00.000000000 cpu#0 pid 1 [sh] execve("/bin/sh") = 0`,
+			},
+			false,
+		},
+		// The read held back is now printed with its result
+		{
+			`00:00.000000000 cpu#0 pid 1 [sh] execve("/bin/sh") = 0
+00:00.001000000 cpu#0 pid 1 [sh] read(0, "ls\n", 4096) = 3
+00:00.002000000 cpu#0 pid 2 [ls] open("/etc", 0, 0) = 3
+00:00.002000100 "param"
+00:00.003000000 cpu#0 pid 2 [ls] exit_group(0)...
+`,
+			[]string{
+				`00:00.001000000 cpu#0 pid 1 [sh] read(0, "ls\n", 4096) = 3`,
+				`00:00.002000000 cpu#0 pid 2 [ls] open("/etc", 0, 0) = 3`,
+				`00:00.002000100 "param"`,
+			},
+			false,
+		},
+		// The oldest events were dropped: the timestamps changed
+		{
+			`00:00.000000000 cpu#0 pid 2 [ls] open("/etc", 0, 0) = 3
+00:00.000000100 "param"
+00:00.001000000 cpu#0 pid 2 [ls] exit_group(0)...
+00:00.001500000 cpu#1 pid 1 [sh] write(1, "$ ", 2) = 2
+`,
+			[]string{
+				`00:00.001000000 cpu#0 pid 2 [ls] exit_group(0)...`,
+			},
+			false,
+		},
+		// Nothing new
+		{
+			`00:00.000000000 cpu#0 pid 2 [ls] open("/etc", 0, 0) = 3
+00:00.001000000 cpu#0 pid 2 [ls] exit_group(0)...
+00:00.001500000 cpu#1 pid 1 [sh] write(1, "$ ", 2) = 2
+`,
+			nil,
+			false,
+		},
+		// More events than traceloop keeps were recorded since the last dump
+		{
+			`00:00.000000000 cpu#1 pid 3 [cat] read(3, "", 4096) = 0
+00:00.000100000 cpu#1 pid 3 [cat] close(3) = 0
+`,
+			[]string{
+				`00:00.000000000 cpu#1 pid 3 [cat] read(3, "", 4096) = 0`,
+			},
+			true,
+		},
+	}
+
+	f := &Follower{}
+	for i, entry := range table {
+		lines, lost := f.Next(entry.dump)
+		if !reflect.DeepEqual(lines, entry.expected) || lost != entry.lost {
+			t.Fatalf("dump %d: unexpected lines %q (lost %v), expected %q (lost %v)", i, lines, lost, entry.expected, entry.lost)
+		}
+	}
+	expected := []string{`00:00.000100000 cpu#1 pid 3 [cat] close(3) = 0`}
+	if tail := f.Flush(); !reflect.DeepEqual(tail, expected) {
+		t.Fatalf("unexpected tail %q, expected %q", tail, expected)
+	}
+}
+
+func TestFollowerRepeatedEvents(t *testing.T) {
+	f := &Follower{}
+	f.Next(`00:00.000000000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2
+00:00.001000000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2
+00:00.003000000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2
+`)
+	// The last event returned is the second one, 1ms after the first
+	lines, lost := f.Next(`00:00.000000000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2
+00:00.001000000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2
+00:00.003000000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2
+00:00.003500000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2
+`)
+	expected := []string{`00:00.003000000 cpu#0 pid 1 [yes] write(1, "y\n", 2) = 2`}
+	if !reflect.DeepEqual(lines, expected) || lost {
+		t.Fatalf("unexpected lines %q (lost %v), expected %q", lines, lost, expected)
+	}
+}
@@ -0,0 +1,40 @@
+package traceloop
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// [ready] 3: default/mypod #0
+// [deleted] 4: default/oldpod #1 (deleted)
+var progRegexp = regexp.MustCompile(`^\[(\S+)\] (\d+): (\S+)/(\S+) #(-?\d+)( \(deleted\))?$`)
+
+// ProgIndex returns the index of the program of traceloop recording a trace,
+// needed to close it, from the list of the programs printed by traceloop. The
+// list does not print the trace IDs: the trace is found by the container it
+// records, as given by its metadata, and by its status, so that the trace of
+// a container restarted is not mistaken for the trace of the container that
+// terminated.
+func ProgIndex(list, namespace, pod string, containerIdx int, status string) (int, error) {
+	index := -1
+	for _, line := range strings.Split(list, "\n") {
+		m := progRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		idx, _ := strconv.Atoi(m[5])
+		if m[1] != status || m[3] != namespace || m[4] != pod || idx != containerIdx {
+			continue
+		}
+		if index != -1 {
+			return -1, fmt.Errorf("several programs record %s/%s #%d", namespace, pod, containerIdx)
+		}
+		index, _ = strconv.Atoi(m[2])
+	}
+	if index == -1 {
+		return -1, fmt.Errorf("no program records %s/%s #%d", namespace, pod, containerIdx)
+	}
+	return index, nil
+}
@@ -0,0 +1,42 @@
+package traceloop
+
+import (
+	"testing"
+)
+
+const testProgs = `[ready] 0: default/mypod #0
+[ready] 1: default/mypod #1
+[unused] 2: trace not assigned to any container ("", pid 0)
+[deleted] 3: default/mypod #1 (deleted)
+[ready] 4: kube-system/coredns-5c98 #0
+[ready] 5: default/twin #0
+[ready] 6: default/twin #0
+7: [manual] /sys/fs/cgroup/unified/system.slice
+`
+
+func TestProgIndex(t *testing.T) {
+	table := []struct {
+		namespace    string
+		pod          string
+		containerIdx int
+		status       string
+		expected     int
+	}{
+		{"default", "mypod", 1, "ready", 1},
+		// The container terminated, and restarted as the trace above
+		{"default", "mypod", 1, "deleted", 3},
+		{"kube-system", "coredns-5c98", 0, "ready", 4},
+	}
+	for _, entry := range table {
+		index, err := ProgIndex(testProgs, entry.namespace, entry.pod, entry.containerIdx, entry.status)
+		if err != nil || index != entry.expected {
+			t.Errorf("%s/%s #%d %s: got %d (%v), expected %d", entry.namespace, entry.pod, entry.containerIdx, entry.status, index, err, entry.expected)
+		}
+	}
+
+	for _, pod := range []string{"gone", "twin"} {
+		if index, err := ProgIndex(testProgs, "default", pod, 0, "ready"); err == nil {
+			t.Errorf("%s: expected an error, got %d", pod, index)
+		}
+	}
+}
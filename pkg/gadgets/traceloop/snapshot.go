@@ -0,0 +1,224 @@
+package traceloop
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// AnyErrno is the errno of a trigger matching all the failed syscalls
+const AnyErrno = "any"
+
+// errnos are the errors that can be given by name in a trigger. Traceloop
+// prints their message, like "no such file or directory".
+var errnos = map[string]syscall.Errno{
+	"EPERM":        syscall.EPERM,
+	"ENOENT":       syscall.ENOENT,
+	"ESRCH":        syscall.ESRCH,
+	"EINTR":        syscall.EINTR,
+	"EIO":          syscall.EIO,
+	"EBADF":        syscall.EBADF,
+	"EAGAIN":       syscall.EAGAIN,
+	"ENOMEM":       syscall.ENOMEM,
+	"EACCES":       syscall.EACCES,
+	"EFAULT":       syscall.EFAULT,
+	"EBUSY":        syscall.EBUSY,
+	"EEXIST":       syscall.EEXIST,
+	"ENOTDIR":      syscall.ENOTDIR,
+	"EISDIR":       syscall.EISDIR,
+	"EINVAL":       syscall.EINVAL,
+	"EMFILE":       syscall.EMFILE,
+	"ENOSPC":       syscall.ENOSPC,
+	"EROFS":        syscall.EROFS,
+	"EPIPE":        syscall.EPIPE,
+	"ENOSYS":       syscall.ENOSYS,
+	"EADDRINUSE":   syscall.EADDRINUSE,
+	"ECONNRESET":   syscall.ECONNRESET,
+	"ETIMEDOUT":    syscall.ETIMEDOUT,
+	"ECONNREFUSED": syscall.ECONNREFUSED,
+}
+
+// Trigger selects the syscalls around which a snapshot of a trace is taken.
+// The empty fields match all the syscalls.
+type Trigger struct {
+	Syscall string
+	Comm    string
+
+	// Errno is the message of the error, like "no such file or directory",
+	// or AnyErrno
+	Errno string
+}
+
+// ParseTrigger parses a trigger expression: a comma-separated list of
+// conditions, all of which must match, among syscall=NAME, comm=NAME and
+// errno=NAME, where NAME is like ENOENT, or "any" for all the errors.
+func ParseTrigger(expr string) (Trigger, error) {
+	t := Trigger{}
+	for _, cond := range strings.Split(expr, ",") {
+		cond = strings.TrimSpace(cond)
+		if cond == "" {
+			continue
+		}
+		kv := strings.SplitN(cond, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return Trigger{}, fmt.Errorf("invalid condition %q, expected key=value", cond)
+		}
+		switch kv[0] {
+		case "syscall":
+			t.Syscall = kv[1]
+		case "comm":
+			t.Comm = kv[1]
+		case "errno":
+			if kv[1] == AnyErrno {
+				t.Errno = AnyErrno
+				break
+			}
+			errno, ok := errnos[strings.ToUpper(kv[1])]
+			if !ok {
+				return Trigger{}, fmt.Errorf("unknown errno %q, expected one of %s or %q", kv[1], errnoNames(), AnyErrno)
+			}
+			t.Errno = errno.Error()
+		default:
+			return Trigger{}, fmt.Errorf("unknown key %q in condition %q, expected syscall, comm or errno", kv[0], cond)
+		}
+	}
+	if t == (Trigger{}) {
+		return Trigger{}, fmt.Errorf("empty trigger")
+	}
+	return t, nil
+}
+
+func errnoNames() string {
+	var names []string
+	for name := range errnos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// match returns whether an event line of a trace, without its timestamp,
+// cpu and pid, matches the trigger
+func (t Trigger) match(comm, call string) bool {
+	if t.Comm != "" && t.Comm != comm {
+		return false
+	}
+	// ...write() = 20: the result of a syscall started on an earlier line
+	name, ret := splitCall(strings.TrimPrefix(call, "..."))
+	if t.Syscall != "" && t.Syscall != name {
+		return false
+	}
+	if t.Errno != "" {
+		errno := parseErrno(ret)
+		if errno == "" || (t.Errno != AnyErrno && t.Errno != errno) {
+			return false
+		}
+	}
+	return true
+}
+
+// SnapshotSeparator separates two windows of events that don't overlap
+const SnapshotSeparator = "--"
+
+// Snapshot copies from r to w the windows of events around the events
+// matching trigger: the before events preceding it, the event itself and
+// the after events following it, as a flight recorder would. The lines that
+// are not events, like the parameters of a syscall, are kept with the event
+// they follow. Windows that overlap are merged, the others are separated by
+// SnapshotSeparator. Snapshot returns the number of events matching trigger.
+func Snapshot(r io.Reader, w io.Writer, trigger Trigger, before, after int) (int, error) {
+	var (
+		// Last events not written yet, at most before, each with its lines
+		pending [][]string
+		// Number of events still to write after the last trigger
+		remaining int
+		// Whether some events were written, and some were skipped since
+		written, skipped bool
+		triggers         int
+	)
+
+	write := func(lines []string) error {
+		if skipped && written {
+			if _, err := fmt.Fprintln(w, SnapshotSeparator); err != nil {
+				return err
+			}
+		}
+		skipped = false
+		written = true
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	// Lines of the current event, with the lines before the first event
+	var event []string
+	started := false
+	// Whether the current event must be written
+	keep := false
+	flush := func() error {
+		if event == nil {
+			return nil
+		}
+		defer func() { event = nil }()
+		if keep {
+			return write(event)
+		}
+		if before == 0 {
+			skipped = true
+			return nil
+		}
+		if len(pending) == before {
+			pending = pending[1:]
+			skipped = true
+		}
+		pending = append(pending, event)
+		return nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		m := eventRegexp.FindStringSubmatch(line)
+		if m == nil {
+			event = append(event, line)
+			continue
+		}
+		if started {
+			if err := flush(); err != nil {
+				return triggers, err
+			}
+		}
+
+		keep = false
+		if trigger.match(m[2], m[3]) {
+			triggers++
+			for _, e := range pending {
+				if err := write(e); err != nil {
+					return triggers, err
+				}
+			}
+			pending = nil
+			keep = true
+			remaining = after
+		} else if remaining > 0 {
+			keep = true
+			remaining--
+		}
+		event = append(event, line)
+		started = true
+	}
+	if err := scanner.Err(); err != nil {
+		return triggers, err
+	}
+	if err := flush(); err != nil {
+		return triggers, err
+	}
+	return triggers, nil
+}
@@ -0,0 +1,143 @@
+package traceloop
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseTrigger(t *testing.T) {
+	table := []struct {
+		expr     string
+		expected Trigger
+		err      string
+	}{
+		{
+			expr:     "syscall=open,errno=ENOENT",
+			expected: Trigger{Syscall: "open", Errno: "no such file or directory"},
+		},
+		{
+			expr:     "comm=cat, errno=any",
+			expected: Trigger{Comm: "cat", Errno: AnyErrno},
+		},
+		{
+			expr: "errno=ENOPE",
+			err:  `unknown errno "ENOPE"`,
+		},
+		{
+			expr: "pid=42",
+			err:  `unknown key "pid" in condition "pid=42", expected syscall, comm or errno`,
+		},
+		{
+			expr: "open",
+			err:  `invalid condition "open", expected key=value`,
+		},
+		{
+			expr: " , ",
+			err:  "empty trigger",
+		},
+	}
+	for _, entry := range table {
+		trigger, err := ParseTrigger(entry.expr)
+		if entry.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), entry.err) {
+				t.Fatalf("%q: expected error %q, got %v", entry.expr, entry.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: %s", entry.expr, err)
+		}
+		if trigger != entry.expected {
+			t.Fatalf("%q: %+v != %+v", entry.expr, trigger, entry.expected)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	table := []struct {
+		description string
+		trigger     Trigger
+		before      int
+		after       int
+		triggers    int
+		expected    string
+	}{
+		{
+			description: "window around each failed open",
+			trigger:     Trigger{Syscall: "open", Errno: "no such file or directory"},
+			before:      1,
+			after:       1,
+			triggers:    2,
+			expected: `00:00.071198694 cpu#0 pid 2202 [bc] write(1, "42\n", 3) = 3
+00:00.071546191 cpu#0 pid 2203 [cat] open("/tmp/file-3240", 0, 0) = -1 (no such file or directory)
+00:00.071566973 cpu#0 pid 2203 [cat] write(2, "cat: can't open '/tmp/file-3240': No such file or directory\n", 60) = 60
+00:00.071576973 cpu#0 pid 2203 [cat] write(2, "retrying\n", 9) = 9
+00:00.071586973 cpu#0 pid 2203 [cat] open("/tmp/file-3240", 0, 0) = -1 (no such file or directory)
+00:00.071596973 cpu#0 pid 2203 [cat] exit_group(1)...
+[trace truncated after 1024 bytes]
+`,
+		},
+		{
+			description: "windows not overlapping, lines of the events kept",
+			trigger:     Trigger{Syscall: "write", Comm: "sh"},
+			before:      1,
+			after:       0,
+			triggers:    2,
+			expected: `00:00.000000000 cpu#1 pid 2201 [sh] execve("/bin/sh", 140723923041877, 140723923041900) = 0
+00:00.001792832 cpu#1 pid 2201 [sh] write(4, "{\"type\":\"procReady\"}", 20)...
+00:00.001794832 "param"
+00:00.001808990 cpu#1 pid 2201 [sh] ...write() = 20
+`,
+		},
+		{
+			description: "adjacent windows merged",
+			trigger:     Trigger{Comm: "bc"},
+			before:      0,
+			after:       0,
+			triggers:    2,
+			expected: `00:00.071188694 cpu#0 pid 2202 [bc] write(1, "42\n", 3) = 3
+00:00.071198694 cpu#0 pid 2202 [bc] write(1, "42\n", 3) = 3
+`,
+		},
+		{
+			description: "any error, separator between windows",
+			trigger:     Trigger{Errno: AnyErrno},
+			before:      0,
+			after:       0,
+			triggers:    2,
+			expected: `00:00.071546191 cpu#0 pid 2203 [cat] open("/tmp/file-3240", 0, 0) = -1 (no such file or directory)
+--
+00:00.071586973 cpu#0 pid 2203 [cat] open("/tmp/file-3240", 0, 0) = -1 (no such file or directory)
+`,
+		},
+		{
+			description: "not triggered",
+			trigger:     Trigger{Syscall: "unlink"},
+			before:      10,
+			after:       10,
+			triggers:    0,
+			expected:    "",
+		},
+	}
+
+	for _, entry := range table {
+		f, err := os.Open("testdata/after.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		triggers, err := Snapshot(f, &out, entry.trigger, entry.before, entry.after)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", entry.description, err)
+		}
+		if triggers != entry.triggers {
+			t.Errorf("%s: %d triggers != %d", entry.description, triggers, entry.triggers)
+		}
+		if out.String() != entry.expected {
+			t.Errorf("%s: %q != %q", entry.description, out.String(), entry.expected)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package traceloop
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DefaultMaxArgDisplay is the default number of bytes of each string
+// argument printed in the text output
+const DefaultMaxArgDisplay = 256
+
+// MaxJSONArgLen is the number of bytes of each string argument kept in the
+// JSON events: the events with longer arguments are marked as truncated
+const MaxJSONArgLen = 1 << 20
+
+// TruncateArgs returns a line of a trace with the strings longer than max
+// bytes, like the buffer of a large write, cut to their first max bytes and
+// followed by an ellipsis and their length:
+//
+//	00:00.071188694 cpu#1 pid 14465 [bc] write(1, "aaaa"...(4096 bytes), 4096) = 4096
+//
+// The shorter strings are left as printed by traceloop. Max 0 keeps all
+// the strings.
+func TruncateArgs(line string, max int) string {
+	if max <= 0 {
+		return line
+	}
+	return stringRegexp.ReplaceAllStringFunc(line, func(quoted string) string {
+		s, err := strconv.Unquote(quoted)
+		if err != nil || len(s) <= max {
+			return quoted
+		}
+		return fmt.Sprintf("%s...(%d bytes)", strconv.Quote(s[:max]), len(s))
+	})
+}
+
+// truncateJSONArgs cuts the string arguments of event longer than
+// MaxJSONArgLen and marks event as truncated if any was
+func truncateJSONArgs(event *Event) {
+	for i, arg := range event.Args {
+		if s, ok := arg.(string); ok && len(s) > MaxJSONArgLen {
+			event.Args[i] = s[:MaxJSONArgLen]
+			event.Truncated = true
+		}
+	}
+}
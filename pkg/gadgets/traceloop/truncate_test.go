@@ -0,0 +1,63 @@
+package traceloop
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTruncateArgs(t *testing.T) {
+	line := `00:00.071188694 cpu#1 pid 14465 [bc] write(1, "%s", 9) = 9`
+	table := []struct {
+		arg      string
+		max      int
+		expected string
+	}{
+		// Up to the boundary, the strings are left as printed
+		{`aaaaaaaa`, 8, `"aaaaaaaa"`},
+		{`aaaaaaa\n`, 8, `"aaaaaaa\n"`},
+		{`aaaaaaaaa`, 8, `"aaaaaaaa"...(9 bytes)`},
+		{`aaaaaaa\n`, 7, `"aaaaaaa"...(8 bytes)`},
+		{`a\"b\"cdef`, 4, `"a\"b\""...(8 bytes)`},
+		{`aaaaaaaaa`, 0, `"aaaaaaaaa"`},
+	}
+	for _, entry := range table {
+		got := TruncateArgs(strings.Replace(line, "%s", entry.arg, 1), entry.max)
+		expected := strings.Replace(line, `"%s"`, entry.expected, 1)
+		if got != expected {
+			t.Errorf("%q, %d:\n%s\nexpected:\n%s", entry.arg, entry.max, got, expected)
+		}
+	}
+
+	// The parameters printed on their own line too
+	if got, expected := TruncateArgs(`00:00.001794832 "/tmp/file-1889"`, 4), `00:00.001794832 "/tmp"...(14 bytes)`; got != expected {
+		t.Errorf("%s != %s", got, expected)
+	}
+}
+
+func TestEventWriterTruncated(t *testing.T) {
+	arg := strings.Repeat("a", MaxJSONArgLen)
+	trace := `00:00.001 cpu#1 pid 2 [cat] write(1, "` + arg + `", 1048576) = 1048576
+00:00.002 cpu#1 pid 2 [cat] write(1, "` + arg + `b", 1048577) = 1048577
+`
+	var out, other bytes.Buffer
+	w := NewEventWriter(&out, &other, false)
+	if _, err := io.WriteString(w, trace); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	decoder := json.NewDecoder(&out)
+	for i, truncated := range []bool{false, true} {
+		event := Event{}
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Truncated != truncated || event.Args[1] != arg {
+			t.Errorf("%d: unexpected truncated %t and argument of %d bytes", i, event.Truncated, len(event.Args[1].(string)))
+		}
+	}
+}
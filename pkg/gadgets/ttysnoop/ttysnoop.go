@@ -0,0 +1,58 @@
+// Package ttysnoop describes the writes to terminals printed by the ttysnoop
+// gadget, the output of the interactive sessions in containers.
+package ttysnoop
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+// Event is a write to a terminal, as printed by the ttysnoop gadget,
+// completed with the pod of the container of the writer by kubectl-gadget
+type Event struct {
+	Timestamp   string `json:"timestamp"`
+	Pid         uint32 `json:"pid"`
+	Comm        string `json:"comm"`
+	ContainerID string `json:"containerid,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+
+	/* Bytes written */
+	Count uint32 `json:"count"`
+
+	/* The beginning of the bytes written, as text when they are valid
+	 * UTF-8 and encoded in base64 otherwise, see Output */
+	Data       string `json:"data,omitempty"`
+	DataBase64 string `json:"data_base64,omitempty"`
+	/* Only the beginning of the bytes written was copied */
+	Truncated bool `json:"truncated,omitempty"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// Output returns the bytes written copied by the gadget
+func (e Event) Output() ([]byte, error) {
+	if e.DataBase64 != "" {
+		return base64.StdEncoding.DecodeString(e.DataBase64)
+	}
+	return []byte(e.Data), nil
+}
+
+// Quote returns the bytes written as a Go string literal, like
+// "ls\r\nbin  etc\r\n": the control characters are escaped so that the
+// output of the containers can't drive the terminal of the user, with
+// escape sequences changing its title or its clipboard
+func (e Event) Quote() string {
+	output, err := e.Output()
+	if err != nil {
+		return strconv.Quote(e.DataBase64)
+	}
+	s := strconv.Quote(string(output))
+	if e.Truncated {
+		s += "..."
+	}
+	return s
+}
@@ -0,0 +1,34 @@
+package ttysnoop
+
+import (
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	table := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Data: "ls\r\nbin  etc\r\n"}, `"ls\r\nbin  etc\r\n"`},
+		// Setting the title of the terminal
+		{Event{Data: "\x1b]0;pwned\x07$ "}, `"\x1b]0;pwned\a$ "`},
+		{Event{DataBase64: "/2xz"}, `"\xffls"`},
+		{Event{Data: "aaaa", Truncated: true}, `"aaaa"...`},
+		{Event{DataBase64: "!!"}, `"!!"`},
+	}
+	for _, entry := range table {
+		if got := entry.event.Quote(); got != entry.expected {
+			t.Errorf("%+v: %s != %s", entry.event, got, entry.expected)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	output, err := Event{DataBase64: "/2xz"}.Output()
+	if err != nil || string(output) != "\xffls" {
+		t.Fatalf("unexpected %q, %v", output, err)
+	}
+	if _, err := (Event{DataBase64: "!!"}).Output(); err == nil {
+		t.Fatalf("invalid base64 decoded")
+	}
+}
@@ -0,0 +1,139 @@
+package ugidsnoop
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syndtr/gocapability/capability"
+)
+
+// Credentials as printed by the ugidsnoop gadget
+type Credentials struct {
+	Uid          uint32 `json:"uid"`
+	Gid          uint32 `json:"gid"`
+	Euid         uint32 `json:"euid"`
+	Egid         uint32 `json:"egid"`
+	CapEffective uint64 `json:"cap_effective"`
+	CapPermitted uint64 `json:"cap_permitted"`
+}
+
+// Event is a credential change as printed by the ugidsnoop gadget
+type Event struct {
+	Pid     uint32      `json:"pid"`
+	Comm    string      `json:"comm"`
+	Syscall string      `json:"syscall"`
+	Old     Credentials `json:"old"`
+	New     Credentials `json:"new"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// DecodedCredentials are Credentials with symbolic capability sets
+type DecodedCredentials struct {
+	Uid          uint32   `json:"uid"`
+	Gid          uint32   `json:"gid"`
+	Euid         uint32   `json:"euid"`
+	Egid         uint32   `json:"egid"`
+	CapEffective []string `json:"cap_effective"`
+	CapPermitted []string `json:"cap_permitted"`
+}
+
+// DecodedEvent is an Event with symbolic capability sets
+type DecodedEvent struct {
+	Pid     uint32             `json:"pid"`
+	Comm    string             `json:"comm"`
+	Syscall string             `json:"syscall"`
+	Old     DecodedCredentials `json:"old"`
+	New     DecodedCredentials `json:"new"`
+
+	/* Sequence number of the event on its node, see package eventseq */
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// CapNames returns the names of the capabilities in a capability set, in
+// the order of their numbers. Unknown bits are named by number.
+func CapNames(caps uint64) []string {
+	names := []string{}
+	known := uint64(0)
+	for _, c := range capability.List() {
+		bit := uint64(1) << uint(c)
+		known |= bit
+		if caps&bit != 0 {
+			names = append(names, c.String())
+		}
+	}
+	for i := uint(0); i < 64; i++ {
+		bit := uint64(1) << i
+		if caps&bit != 0 && known&bit == 0 {
+			names = append(names, fmt.Sprintf("cap_%d", i))
+		}
+	}
+	return names
+}
+
+func decodeCredentials(c Credentials) DecodedCredentials {
+	return DecodedCredentials{
+		Uid:          c.Uid,
+		Gid:          c.Gid,
+		Euid:         c.Euid,
+		Egid:         c.Egid,
+		CapEffective: CapNames(c.CapEffective),
+		CapPermitted: CapNames(c.CapPermitted),
+	}
+}
+
+// Decode returns the event with symbolic capability sets
+func Decode(e Event) DecodedEvent {
+	return DecodedEvent{
+		Pid:     e.Pid,
+		Comm:    e.Comm,
+		Syscall: e.Syscall,
+		Old:     decodeCredentials(e.Old),
+		New:     decodeCredentials(e.New),
+		Seq:     e.Seq,
+	}
+}
+
+// capDiff returns the capabilities removed from and added to a set, as
+// "-name" and "+name"
+func capDiff(oldCaps, newCaps uint64) string {
+	var out []string
+	for _, name := range CapNames(oldCaps &^ newCaps) {
+		out = append(out, "-"+name)
+	}
+	for _, name := range CapNames(newCaps &^ oldCaps) {
+		out = append(out, "+"+name)
+	}
+	return strings.Join(out, ",")
+}
+
+// FormatChanges returns a one line description of the credentials that
+// changed, like "uid=0->1000 euid=0->1000 cap_effective=-chown,-kill".
+func FormatChanges(e Event) string {
+	var out []string
+	ids := []struct {
+		name     string
+		old, new uint32
+	}{
+		{"uid", e.Old.Uid, e.New.Uid},
+		{"gid", e.Old.Gid, e.New.Gid},
+		{"euid", e.Old.Euid, e.New.Euid},
+		{"egid", e.Old.Egid, e.New.Egid},
+	}
+	for _, id := range ids {
+		if id.old != id.new {
+			out = append(out, fmt.Sprintf("%s=%d->%d", id.name, id.old, id.new))
+		}
+	}
+	if e.Old.CapEffective != e.New.CapEffective {
+		out = append(out, "cap_effective="+capDiff(e.Old.CapEffective, e.New.CapEffective))
+	}
+	if e.Old.CapPermitted != e.New.CapPermitted {
+		out = append(out, "cap_permitted="+capDiff(e.Old.CapPermitted, e.New.CapPermitted))
+	}
+	if len(out) == 0 {
+		return "unchanged"
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,71 @@
+package ugidsnoop
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCapNames(t *testing.T) {
+	table := []struct {
+		caps     uint64
+		expected []string
+	}{
+		{0, []string{}},
+		{1<<0 | 1<<5 | 1<<21, []string{"chown", "kill", "sys_admin"}},
+		{1 << 63, []string{"cap_63"}},
+	}
+	for _, entry := range table {
+		names := CapNames(entry.caps)
+		if !reflect.DeepEqual(names, entry.expected) {
+			t.Errorf("CapNames(%#x) = %v, expected %v", entry.caps, names, entry.expected)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	input := `{"pid":4242,"comm":"su","syscall":"setresuid",` +
+		`"old":{"uid":0,"gid":0,"euid":0,"egid":0,"cap_effective":1057,"cap_permitted":1057},` +
+		`"new":{"uid":1000,"gid":0,"euid":1000,"egid":0,"cap_effective":0,"cap_permitted":1024}}`
+	event := Event{}
+	if err := json.Unmarshal([]byte(input), &event); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := Decode(event)
+	expected := DecodedEvent{
+		Pid:     4242,
+		Comm:    "su",
+		Syscall: "setresuid",
+		Old: DecodedCredentials{
+			CapEffective: []string{"chown", "kill", "net_bind_service"},
+			CapPermitted: []string{"chown", "kill", "net_bind_service"},
+		},
+		New: DecodedCredentials{
+			Uid:          1000,
+			Euid:         1000,
+			CapEffective: []string{},
+			CapPermitted: []string{"net_bind_service"},
+		},
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Fatalf("%+v != %+v", decoded, expected)
+	}
+
+	changes := FormatChanges(event)
+	expectedChanges := "uid=0->1000 euid=0->1000 cap_effective=-chown,-kill,-net_bind_service cap_permitted=-chown,-kill"
+	if changes != expectedChanges {
+		t.Fatalf("%q != %q", changes, expectedChanges)
+	}
+}
+
+func TestFormatChangesUnchanged(t *testing.T) {
+	event := Event{
+		Syscall: "setuid",
+		Old:     Credentials{Uid: 1000, Euid: 1000},
+		New:     Credentials{Uid: 1000, Euid: 1000},
+	}
+	if changes := FormatChanges(event); changes != "unchanged" {
+		t.Fatalf("%q != %q", changes, "unchanged")
+	}
+}
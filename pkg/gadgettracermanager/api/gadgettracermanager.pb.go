@@ -138,10 +138,14 @@ func (m *RemoveContainerResponse) GetDebug() string {
 }
 
 type ContainerSelector struct {
+	// Comma-separated list of namespaces, all namespaces if empty
 	Namespace      string   `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
 	Podname        string   `protobuf:"bytes,2,opt,name=podname" json:"podname,omitempty"`
 	Labels         []*Label `protobuf:"bytes,3,rep,name=labels" json:"labels,omitempty"`
 	ContainerIndex int32    `protobuf:"varint,4,opt,name=container_index,json=containerIndex" json:"container_index,omitempty"`
+	PodUid         string   `protobuf:"bytes,5,opt,name=pod_uid,json=podUid" json:"pod_uid,omitempty"`
+	// Also trace the container of the gadget itself
+	IncludeSelf bool `protobuf:"varint,6,opt,name=include_self,json=includeSelf" json:"include_self,omitempty"`
 }
 
 func (m *ContainerSelector) Reset()                    { *m = ContainerSelector{} }
@@ -177,6 +181,20 @@ func (m *ContainerSelector) GetContainerIndex() int32 {
 	return 0
 }
 
+func (m *ContainerSelector) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+func (m *ContainerSelector) GetIncludeSelf() bool {
+	if m != nil {
+		return m.IncludeSelf
+	}
+	return false
+}
+
 type TracerID struct {
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 }
@@ -202,6 +220,7 @@ type ContainerDefinition struct {
 	Podname        string   `protobuf:"bytes,6,opt,name=podname" json:"podname,omitempty"`
 	ContainerIndex int32    `protobuf:"varint,7,opt,name=container_index,json=containerIndex" json:"container_index,omitempty"`
 	Labels         []*Label `protobuf:"bytes,8,rep,name=labels" json:"labels,omitempty"`
+	PodUid         string   `protobuf:"bytes,9,opt,name=pod_uid,json=podUid" json:"pod_uid,omitempty"`
 }
 
 func (m *ContainerDefinition) Reset()                    { *m = ContainerDefinition{} }
@@ -265,6 +284,13 @@ func (m *ContainerDefinition) GetLabels() []*Label {
 	return nil
 }
 
+func (m *ContainerDefinition) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
 type DumpStateRequest struct {
 }
 
@@ -509,38 +535,40 @@ var _GadgetTracerManager_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("gadgettracermanager.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 515 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x54, 0xdb, 0x6e, 0xd3, 0x40,
-	0x10, 0xcd, 0xbd, 0xc9, 0x24, 0x6a, 0xc3, 0xa4, 0x12, 0x6e, 0x28, 0x22, 0xac, 0x04, 0x18, 0xa9,
-	0x6a, 0xa5, 0xf0, 0x05, 0x85, 0x48, 0x28, 0x12, 0x08, 0xe4, 0xf0, 0xc4, 0x4b, 0xb5, 0xf1, 0x4e,
-	0x5d, 0xab, 0xf1, 0xda, 0xd8, 0xeb, 0x0a, 0x3e, 0x87, 0x37, 0xfe, 0x8d, 0x9f, 0x40, 0xeb, 0x5b,
-	0xd2, 0xb0, 0x09, 0xe5, 0x2d, 0x73, 0x7c, 0xe6, 0x76, 0x66, 0x4f, 0xe0, 0xc4, 0xe3, 0xc2, 0x23,
-	0xa5, 0x62, 0xee, 0x52, 0x1c, 0x70, 0xc9, 0x3d, 0x8a, 0xcf, 0xa3, 0x38, 0x54, 0x21, 0x8e, 0x0c,
-	0x9f, 0xd8, 0x05, 0xb4, 0x3f, 0xf0, 0x25, 0xad, 0x70, 0x08, 0xcd, 0x5b, 0xfa, 0x61, 0xd5, 0x27,
-	0x75, 0xbb, 0xe7, 0xe8, 0x9f, 0x78, 0x0c, 0xed, 0x3b, 0xbe, 0x4a, 0xc9, 0x6a, 0x64, 0x58, 0x1e,
-	0xb0, 0x6b, 0x18, 0x5e, 0x0a, 0xf1, 0x25, 0x2b, 0xe2, 0xd0, 0xb7, 0x94, 0x12, 0x85, 0x87, 0xd0,
-	0xf0, 0x45, 0x91, 0xda, 0xf0, 0x05, 0xbe, 0x85, 0x6e, 0x42, 0x2b, 0x72, 0x55, 0x18, 0x67, 0xc9,
-	0xfd, 0xe9, 0xcb, 0x73, 0xd3, 0x5c, 0xef, 0x42, 0xa9, 0xb8, 0x2f, 0x29, 0x5e, 0x14, 0x6c, 0xa7,
-	0xca, 0x63, 0x67, 0x70, 0xec, 0x50, 0x10, 0xde, 0x51, 0xd9, 0x2a, 0x89, 0x42, 0x99, 0x90, 0x9e,
-	0x4a, 0xd0, 0x32, 0xf5, 0x8a, 0x76, 0x79, 0xa0, 0xd9, 0x97, 0x42, 0x54, 0xf5, 0xfe, 0xc1, 0xbe,
-	0x80, 0xc7, 0x79, 0xed, 0x87, 0x26, 0xfc, 0xaa, 0xc3, 0xa3, 0xbf, 0x86, 0xc5, 0x53, 0xe8, 0x49,
-	0x1e, 0x50, 0x12, 0x71, 0x97, 0x0a, 0xfe, 0x1a, 0x40, 0x0b, 0x0e, 0xa2, 0x50, 0xe8, 0xb8, 0x10,
-	0xb0, 0x0c, 0x71, 0x0a, 0x9d, 0x95, 0xd6, 0x3c, 0xb1, 0x9a, 0x93, 0xa6, 0xdd, 0x9f, 0x8e, 0x8d,
-	0xe2, 0x64, 0x67, 0x71, 0x0a, 0x26, 0xbe, 0x82, 0x23, 0xb7, 0x1c, 0xe0, 0xca, 0x97, 0x82, 0xbe,
-	0x5b, 0xad, 0x49, 0xdd, 0x6e, 0x3b, 0x87, 0x15, 0x3c, 0xd7, 0x28, 0x1b, 0x43, 0x37, 0x57, 0x6c,
-	0x3e, 0xdb, 0xbe, 0x0b, 0xfb, 0xd9, 0x80, 0x51, 0xb5, 0xc6, 0x8c, 0xae, 0x7d, 0xe9, 0x2b, 0x3f,
-	0x94, 0xf8, 0x1c, 0x06, 0x1b, 0xc5, 0xcb, 0x8c, 0xfe, 0xba, 0xb2, 0xc0, 0x67, 0xd0, 0x77, 0xbd,
-	0x38, 0x4c, 0xa3, 0xab, 0x88, 0xab, 0x9b, 0x62, 0x23, 0xc8, 0xa1, 0xcf, 0x5c, 0xdd, 0xe0, 0x13,
-	0xe8, 0x15, 0x04, 0x5f, 0x58, 0xcd, 0x49, 0xdd, 0x6e, 0x39, 0xdd, 0x1c, 0x98, 0x0b, 0xad, 0x6a,
-	0x20, 0x95, 0x4c, 0xb2, 0x99, 0x5b, 0x4e, 0x1e, 0xdc, 0xd7, 0xaf, 0xbd, 0x47, 0xbf, 0xce, 0x7d,
-	0xfd, 0x0c, 0x5a, 0x1c, 0x98, 0xb4, 0xd8, 0x10, 0xba, 0xfb, 0x50, 0xa1, 0x19, 0xc2, 0x70, 0x96,
-	0x06, 0xd1, 0x42, 0x71, 0x45, 0xc5, 0xfb, 0x66, 0xa7, 0xd0, 0xd2, 0x98, 0x5e, 0x23, 0xd1, 0x78,
-	0xf9, 0x38, 0xb2, 0x60, 0xfa, 0xbb, 0x09, 0xa3, 0xf7, 0x59, 0xdd, 0x5c, 0xf8, 0x8f, 0x79, 0x5d,
-	0x5c, 0x40, 0xaf, 0x72, 0x0a, 0xbe, 0x30, 0xb6, 0xde, 0x76, 0xd2, 0xf8, 0xa9, 0x91, 0x56, 0x1e,
-	0x94, 0xd5, 0xf0, 0x2b, 0x0c, 0x36, 0x6d, 0x81, 0xfb, 0x13, 0xc6, 0xaf, 0x8d, 0x9f, 0x4d, 0xc6,
-	0x62, 0x35, 0x24, 0x18, 0x6c, 0x9a, 0x08, 0xed, 0xfd, 0xa6, 0x5d, 0x3f, 0xa0, 0x1d, 0x6d, 0x4c,
-	0x8e, 0x64, 0x35, 0xbc, 0x85, 0xa3, 0x2d, 0xf7, 0xfd, 0x47, 0xa7, 0xb3, 0x3d, 0x0b, 0x99, 0x9a,
-	0x7d, 0x82, 0x5e, 0x75, 0xce, 0x1d, 0x47, 0xd8, 0x3e, 0xf7, 0xf8, 0x64, 0x27, 0x8d, 0xd5, 0x96,
-	0x9d, 0xec, 0xcf, 0xf4, 0xcd, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xbf, 0xee, 0x4b, 0xf9, 0x69,
-	0x05, 0x00, 0x00,
+	// 557 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xdb, 0x6e, 0xd3, 0x40,
+	0x10, 0x8d, 0x73, 0x6b, 0x3c, 0xa9, 0xda, 0xb0, 0xa9, 0x54, 0x37, 0x14, 0x11, 0x56, 0x02, 0x8c,
+	0x54, 0xb5, 0x52, 0xf8, 0x82, 0x42, 0x24, 0x14, 0x09, 0x04, 0x72, 0xe0, 0x85, 0x97, 0x68, 0xe3,
+	0x9d, 0xa4, 0xab, 0x3a, 0x6b, 0x63, 0xaf, 0x2b, 0xf8, 0x35, 0xbe, 0x87, 0x37, 0x7e, 0x02, 0xad,
+	0x6f, 0x49, 0xc3, 0x26, 0x94, 0x37, 0xcf, 0xf1, 0xcc, 0x9e, 0xd9, 0x73, 0x7c, 0x0c, 0x67, 0x4b,
+	0xc6, 0x97, 0xa8, 0x54, 0xcc, 0x7c, 0x8c, 0x57, 0x4c, 0xb2, 0x25, 0xc6, 0x97, 0x51, 0x1c, 0xaa,
+	0x90, 0xf4, 0x0d, 0xaf, 0xe8, 0x15, 0xb4, 0xde, 0xb3, 0x39, 0x06, 0xa4, 0x07, 0x8d, 0x5b, 0xfc,
+	0xe1, 0x58, 0x43, 0xcb, 0xb5, 0x3d, 0xfd, 0x48, 0x4e, 0xa0, 0x75, 0xc7, 0x82, 0x14, 0x9d, 0x7a,
+	0x86, 0xe5, 0x05, 0x5d, 0x40, 0xef, 0x9a, 0xf3, 0xcf, 0xd9, 0x21, 0x1e, 0x7e, 0x4b, 0x31, 0x51,
+	0xe4, 0x08, 0xea, 0x82, 0x17, 0xa3, 0x75, 0xc1, 0xc9, 0x1b, 0xe8, 0x24, 0x18, 0xa0, 0xaf, 0xc2,
+	0x38, 0x1b, 0xee, 0x8e, 0x5e, 0x5c, 0x9a, 0xf6, 0x7a, 0x1b, 0x4a, 0xc5, 0x84, 0xc4, 0x78, 0x5a,
+	0x74, 0x7b, 0xd5, 0x1c, 0xbd, 0x80, 0x13, 0x0f, 0x57, 0xe1, 0x1d, 0x96, 0x54, 0x49, 0x14, 0xca,
+	0x04, 0xf5, 0x56, 0x1c, 0xe7, 0xe9, 0xb2, 0xa0, 0xcb, 0x0b, 0xdd, 0x7d, 0xcd, 0x79, 0x75, 0xde,
+	0x3f, 0xba, 0xaf, 0xe0, 0x34, 0x3f, 0xfb, 0xa1, 0x03, 0xbf, 0x2c, 0x78, 0xf4, 0xd7, 0xb2, 0xe4,
+	0x1c, 0x6c, 0xc9, 0x56, 0x98, 0x44, 0xcc, 0xc7, 0xa2, 0x7f, 0x0d, 0x10, 0x07, 0x0e, 0xa2, 0x90,
+	0xeb, 0xba, 0x10, 0xb0, 0x2c, 0xc9, 0x08, 0xda, 0x81, 0xd6, 0x3c, 0x71, 0x1a, 0xc3, 0x86, 0xdb,
+	0x1d, 0x0d, 0x8c, 0xe2, 0x64, 0xb6, 0x78, 0x45, 0x27, 0x79, 0x09, 0xc7, 0x7e, 0xb9, 0xc0, 0x4c,
+	0x48, 0x8e, 0xdf, 0x9d, 0xe6, 0xd0, 0x72, 0x5b, 0xde, 0x51, 0x05, 0x4f, 0x34, 0x4a, 0x4e, 0x33,
+	0xda, 0x59, 0x2a, 0xb8, 0xd3, 0xca, 0x68, 0xdb, 0x51, 0xc8, 0xbf, 0x08, 0x4e, 0x9e, 0xc1, 0xa1,
+	0x90, 0x7e, 0x90, 0x72, 0x9c, 0x25, 0x18, 0x2c, 0x9c, 0xf6, 0xd0, 0x72, 0x3b, 0x5e, 0xb7, 0xc0,
+	0xa6, 0x18, 0x2c, 0xe8, 0x00, 0x3a, 0xb9, 0xda, 0x93, 0xf1, 0xb6, 0xa7, 0xf4, 0x67, 0x1d, 0xfa,
+	0x95, 0x04, 0x63, 0x5c, 0x08, 0x29, 0x94, 0x08, 0xa5, 0x3e, 0x76, 0x63, 0xb1, 0x72, 0xa2, 0xbb,
+	0xde, 0x8a, 0x93, 0xa7, 0xd0, 0xf5, 0x97, 0x71, 0x98, 0x46, 0xb3, 0x88, 0xa9, 0x9b, 0x42, 0x0d,
+	0xc8, 0xa1, 0x4f, 0x4c, 0xdd, 0x90, 0xc7, 0x60, 0x17, 0x0d, 0x82, 0x3b, 0x8d, 0xa1, 0xe5, 0x36,
+	0xbd, 0x4e, 0x0e, 0x4c, 0xb8, 0x76, 0x64, 0x25, 0x95, 0x4c, 0xb2, 0xfb, 0x36, 0xbd, 0xbc, 0xb8,
+	0xaf, 0x7d, 0x6b, 0x8f, 0xf6, 0xed, 0xfb, 0xda, 0x1b, 0x74, 0x3c, 0x30, 0xea, 0xb8, 0x36, 0xa9,
+	0xf3, 0x60, 0x93, 0x36, 0xb4, 0xb7, 0x37, 0xb5, 0xa7, 0x04, 0x7a, 0xe3, 0x74, 0x15, 0x4d, 0x15,
+	0x53, 0x58, 0x84, 0x86, 0x9e, 0x43, 0x53, 0x63, 0xfa, 0x7e, 0x89, 0xc6, 0xcb, 0x2f, 0x2e, 0x2b,
+	0x46, 0xbf, 0x1b, 0xd0, 0x7f, 0x97, 0x11, 0xe6, 0x8e, 0x7c, 0xc8, 0x09, 0xc9, 0x14, 0xec, 0x2a,
+	0x7e, 0xe4, 0xb9, 0x71, 0xa7, 0xed, 0x78, 0x0e, 0x9e, 0x18, 0xdb, 0x4a, 0xa7, 0x69, 0x8d, 0x7c,
+	0x85, 0xc3, 0xcd, 0xac, 0x91, 0xfd, 0x03, 0x83, 0x57, 0xc6, 0xd7, 0xa6, 0xb4, 0xd2, 0x1a, 0x41,
+	0x38, 0xdc, 0x4c, 0x26, 0x71, 0xf7, 0xff, 0x09, 0xd6, 0x5f, 0xd6, 0x0e, 0x1a, 0x53, 0xcc, 0x69,
+	0x8d, 0xdc, 0xc2, 0xf1, 0x56, 0xa4, 0xff, 0x83, 0xe9, 0x62, 0xcf, 0x85, 0x4c, 0x64, 0x1f, 0xc1,
+	0xae, 0xec, 0xdc, 0x61, 0xc2, 0xb6, 0xdd, 0x83, 0xb3, 0x9d, 0x6d, 0xb4, 0x36, 0x6f, 0x67, 0x7f,
+	0xe8, 0xd7, 0x7f, 0x06, 0x00, 0x80, 0x7d, 0x61, 0x72, 0xbe, 0x05, 0x00, 0x00,
 }
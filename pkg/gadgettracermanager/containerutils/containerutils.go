@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"syscall"
 	"unsafe"
 
@@ -58,13 +60,82 @@ uint64_t get_cgroupid(char *path) {
 */
 import "C"
 
+// CgroupMode describes which cgroup hierarchies are mounted on the host.
+type CgroupMode int
+
+const (
+	// CgroupModeLegacy: only cgroup-v1 hierarchies.
+	CgroupModeLegacy CgroupMode = iota
+	// CgroupModeHybrid: cgroup-v1 hierarchies, and the cgroup-v2 unified
+	// hierarchy in /sys/fs/cgroup/unified.
+	CgroupModeHybrid
+	// CgroupModeUnified: only the cgroup-v2 unified hierarchy, in
+	// /sys/fs/cgroup.
+	CgroupModeUnified
+)
+
+func (m CgroupMode) String() string {
+	switch m {
+	case CgroupModeLegacy:
+		return "legacy"
+	case CgroupModeHybrid:
+		return "hybrid"
+	case CgroupModeUnified:
+		return "unified"
+	}
+	return "unknown"
+}
+
+// CGROUP2_SUPER_MAGIC from linux/magic.h
+const cgroup2SuperMagic = 0x63677270
+
+var (
+	cgroupModeOnce sync.Once
+	cgroupMode     CgroupMode
+	cgroupModeErr  error
+)
+
+// GetCgroupMode detects how cgroups are mounted on the host. The detection
+// is done only once and cached.
+func GetCgroupMode() (CgroupMode, error) {
+	cgroupModeOnce.Do(func() {
+		cgroupMode, cgroupModeErr = detectCgroupMode()
+	})
+	return cgroupMode, cgroupModeErr
+}
+
+func detectCgroupMode() (CgroupMode, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs("/sys/fs/cgroup", &st); err != nil {
+		return CgroupModeLegacy, fmt.Errorf("cannot statfs /sys/fs/cgroup: %v", err)
+	}
+	if st.Type == cgroup2SuperMagic {
+		return CgroupModeUnified, nil
+	}
+	if err := syscall.Statfs("/sys/fs/cgroup/unified", &st); err == nil && st.Type == cgroup2SuperMagic {
+		return CgroupModeHybrid, nil
+	}
+	return CgroupModeLegacy, nil
+}
+
+// CgroupPathV2AddMountpoint adds the mountpoint of the cgroup-v2 unified
+// hierarchy to a cgroup-v2 path, depending on the detected cgroup mode.
 func CgroupPathV2AddMountpoint(path string) (string, error) {
-	pathWithMountpoint := filepath.Join("/sys/fs/cgroup/unified", path)
-	if _, err := os.Stat(pathWithMountpoint); os.IsNotExist(err) {
+	mode, err := GetCgroupMode()
+	if err != nil {
+		return "", err
+	}
+	var pathWithMountpoint string
+	switch mode {
+	case CgroupModeUnified:
 		pathWithMountpoint = filepath.Join("/sys/fs/cgroup", path)
-		if _, err := os.Stat(pathWithMountpoint); os.IsNotExist(err) {
-			return "", fmt.Errorf("cannot access cgroup %q: %v", path, err)
-		}
+	case CgroupModeHybrid:
+		pathWithMountpoint = filepath.Join("/sys/fs/cgroup/unified", path)
+	default:
+		return "", fmt.Errorf("cannot access cgroup %q: cgroup-v2 not mounted", path)
+	}
+	if _, err := os.Stat(pathWithMountpoint); os.IsNotExist(err) {
+		return "", fmt.Errorf("cannot access cgroup %q: %v", path, err)
 	}
 	return pathWithMountpoint, nil
 }
@@ -80,32 +151,46 @@ func GetCgroupID(pathWithMountpoint string) (uint64, error) {
 	return ret, nil
 }
 
-// GetCgroup2Path returns the cgroup1 and cgroup2 paths of a process.
+// GetCgroupPaths returns the cgroup1 and cgroup2 paths of a process.
 // It does not include the "/sys/fs/cgroup/{unified,systemd,}" prefix.
 func GetCgroupPaths(pid int) (string, string, error) {
+	cgroupFile, err := os.Open(filepath.Join("/proc", fmt.Sprintf("%d", pid), "cgroup"))
+	if err != nil {
+		return "", "", fmt.Errorf("cannot parse cgroup: %v", err)
+	}
+	defer cgroupFile.Close()
+	return ParseCgroupPaths(cgroupFile)
+}
+
+// ParseCgroupPaths returns the cgroup1 and cgroup2 paths from the content of
+// a /proc/PID/cgroup file. The cgroup1 path is the one of the name=systemd
+// hierarchy, whatever its hierarchy ID. On hosts with only the cgroup-v2
+// unified hierarchy, the cgroup1 path is empty.
+func ParseCgroupPaths(r io.Reader) (string, string, error) {
 	cgroupPathV1 := ""
 	cgroupPathV2 := ""
-	if cgroupFile, err := os.Open(filepath.Join("/proc", fmt.Sprintf("%d", pid), "cgroup")); err == nil {
-		defer cgroupFile.Close()
-		reader := bufio.NewReader(cgroupFile)
-		for {
-			line, err := reader.ReadString('\n')
-			if err != nil {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			break
+		}
+		line = strings.TrimSuffix(line, "\n")
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			cgroupPathV2 = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "name=systemd" {
+				cgroupPathV1 = fields[2]
 				break
 			}
-			if strings.HasPrefix(line, "1:name=systemd:") {
-				cgroupPathV1 = strings.TrimPrefix(line, "1:name=systemd:")
-				cgroupPathV1 = strings.TrimSuffix(cgroupPathV1, "\n")
-				continue
-			}
-			if strings.HasPrefix(line, "0::") {
-				cgroupPathV2 = strings.TrimPrefix(line, "0::")
-				cgroupPathV2 = strings.TrimSuffix(cgroupPathV2, "\n")
-				continue
-			}
 		}
-	} else {
-		return "", "", fmt.Errorf("cannot parse cgroup: %v", err)
 	}
 
 	if cgroupPathV1 == "/" {
@@ -123,6 +208,55 @@ func GetCgroupPaths(pid int) (string, string, error) {
 	return cgroupPathV1, cgroupPathV2, nil
 }
 
+// CgroupPath returns the cgroup path of a process shown to the users, from
+// the paths returned by GetCgroupPaths: the cgroup2 one when the process has
+// one, as on hosts with only the unified hierarchy, and the name=systemd one
+// otherwise. It is empty for the processes in the root cgroup.
+func CgroupPath(cgroupPathV1, cgroupPathV2 string) string {
+	if cgroupPathV2 != "" {
+		return cgroupPathV2
+	}
+	return cgroupPathV1
+}
+
+// SelfCgroup is the cgroup of the current process. The gadgets read it at
+// startup to recognize their own container and exclude its activity.
+type SelfCgroup struct {
+	PathV1 string
+	PathV2 string
+}
+
+// GetSelfCgroup returns the cgroup of the current process
+func GetSelfCgroup() (SelfCgroup, error) {
+	cgroupPathV1, cgroupPathV2, err := GetCgroupPaths(os.Getpid())
+	if err != nil {
+		return SelfCgroup{}, err
+	}
+	return SelfCgroup{cgroupPathV1, cgroupPathV2}, nil
+}
+
+// IsContainer returns whether the current process is in a container, by
+// looking for the container id in the cgroup paths. The container id can have
+// a runtime prefix, like docker://.
+func (s SelfCgroup) IsContainer(containerID string) bool {
+	if i := strings.Index(containerID, "://"); i >= 0 {
+		containerID = containerID[i+3:]
+	}
+	if containerID == "" {
+		return false
+	}
+	return strings.Contains(s.PathV1, containerID) || strings.Contains(s.PathV2, containerID)
+}
+
+// Matches returns whether the cgroup paths of a process, as returned by
+// GetCgroupPaths, are the ones of the current process
+func (s SelfCgroup) Matches(cgroupPathV1, cgroupPathV2 string) bool {
+	if s.PathV1 == "" && s.PathV2 == "" {
+		return false
+	}
+	return cgroupPathV1 == s.PathV1 && cgroupPathV2 == s.PathV2
+}
+
 func GetMntNs(pid int) (uint64, error) {
 	fileinfo, err := os.Stat(filepath.Join("/proc", fmt.Sprintf("%d", pid), "ns/mnt"))
 	if err != nil {
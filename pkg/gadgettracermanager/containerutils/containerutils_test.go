@@ -3,6 +3,7 @@ package containerutils
 import (
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -30,3 +31,115 @@ func TestParseOCIState(t *testing.T) {
 		}
 	}
 }
+
+func TestParseCgroupPaths(t *testing.T) {
+	table := []struct {
+		description string
+		cgroupFile  string
+		expectedV1  string
+		expectedV2  string
+	}{
+		{
+			description: "cgroup-v1 only",
+			cgroupFile: `12:pids:/kubepods/besteffort/pod3c5c1d26-7e5a-4f2b-9d0e-2a1c7bb0f9a4/5c8a1e3f
+4:memory:/kubepods/besteffort/pod3c5c1d26-7e5a-4f2b-9d0e-2a1c7bb0f9a4/5c8a1e3f
+1:name=systemd:/kubepods/besteffort/pod3c5c1d26-7e5a-4f2b-9d0e-2a1c7bb0f9a4/5c8a1e3f
+`,
+			expectedV1: "/kubepods/besteffort/pod3c5c1d26-7e5a-4f2b-9d0e-2a1c7bb0f9a4/5c8a1e3f",
+			expectedV2: "",
+		},
+		{
+			description: "cgroup-v1 with name=systemd not on hierarchy 1",
+			cgroupFile: `11:cpu,cpuacct:/kubepods.slice/crio-5c8a1e3f.scope
+9:name=systemd:/kubepods.slice/crio-5c8a1e3f.scope
+`,
+			expectedV1: "/kubepods.slice/crio-5c8a1e3f.scope",
+			expectedV2: "",
+		},
+		{
+			description: "hybrid",
+			cgroupFile: `4:memory:/kubepods/pod3c5c1d26/5c8a1e3f
+1:name=systemd:/kubepods/pod3c5c1d26/5c8a1e3f
+0::/kubepods/pod3c5c1d26/5c8a1e3f
+`,
+			expectedV1: "/kubepods/pod3c5c1d26/5c8a1e3f",
+			expectedV2: "/kubepods/pod3c5c1d26/5c8a1e3f",
+		},
+		{
+			description: "hybrid with cgroup-v2 not delegated",
+			cgroupFile: `1:name=systemd:/kubepods/pod3c5c1d26/5c8a1e3f
+0::/
+`,
+			expectedV1: "/kubepods/pod3c5c1d26/5c8a1e3f",
+			expectedV2: "",
+		},
+		{
+			description: "cgroup-v2 unified only, without trailing newline",
+			cgroupFile:  `0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod3c5c1d26_7e5a_4f2b_9d0e_2a1c7bb0f9a4.slice/cri-containerd-5c8a1e3f.scope`,
+			expectedV1:  "",
+			expectedV2:  "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod3c5c1d26_7e5a_4f2b_9d0e_2a1c7bb0f9a4.slice/cri-containerd-5c8a1e3f.scope",
+		},
+	}
+
+	for _, entry := range table {
+		v1, v2, err := ParseCgroupPaths(strings.NewReader(entry.cgroupFile))
+		if err != nil {
+			t.Errorf("%s: %s", entry.description, err)
+			continue
+		}
+		if v1 != entry.expectedV1 {
+			t.Errorf("%s: cgroup-v1 path %q, expected %q", entry.description, v1, entry.expectedV1)
+		}
+		if v2 != entry.expectedV2 {
+			t.Errorf("%s: cgroup-v2 path %q, expected %q", entry.description, v2, entry.expectedV2)
+		}
+	}
+
+	_, _, err := ParseCgroupPaths(strings.NewReader("0::/\n"))
+	if err == nil {
+		t.Errorf("root cgroup only: expected an error")
+	}
+}
+
+func TestCgroupPath(t *testing.T) {
+	table := []struct {
+		v1, v2, expected string
+	}{
+		{"/kubepods/burstable/pod3c5c1d26/5c8a1e3f", "", "/kubepods/burstable/pod3c5c1d26/5c8a1e3f"},
+		{"/kubepods/burstable/pod3c5c1d26/5c8a1e3f", "/kubepods/burstable/pod3c5c1d26/5c8a1e3f", "/kubepods/burstable/pod3c5c1d26/5c8a1e3f"},
+		{"", "/kubepods.slice/cri-containerd-5c8a1e3f.scope", "/kubepods.slice/cri-containerd-5c8a1e3f.scope"},
+		{"", "", ""},
+	}
+	for _, entry := range table {
+		if got := CgroupPath(entry.v1, entry.v2); got != entry.expected {
+			t.Errorf("CgroupPath(%q, %q) = %q, expected %q", entry.v1, entry.v2, got, entry.expected)
+		}
+	}
+}
+
+func TestSelfCgroup(t *testing.T) {
+	self := SelfCgroup{
+		PathV1: "/kubepods/besteffort/pod3c5c1d26/5c8a1e3f",
+		PathV2: "/kubepods/besteffort/pod3c5c1d26/5c8a1e3f",
+	}
+	for _, id := range []string{"5c8a1e3f", "docker://5c8a1e3f", "cri-o://5c8a1e3f"} {
+		if !self.IsContainer(id) {
+			t.Errorf("IsContainer(%q) should be true", id)
+		}
+	}
+	for _, id := range []string{"", "docker://", "9b0d2c4a"} {
+		if self.IsContainer(id) {
+			t.Errorf("IsContainer(%q) should be false", id)
+		}
+	}
+
+	if !self.Matches(self.PathV1, self.PathV2) {
+		t.Errorf("Matches should be true for the same paths")
+	}
+	if self.Matches(self.PathV1, "") || self.Matches("/kubepods/besteffort/pod9b0d2c4a/9b0d2c4a", self.PathV2) {
+		t.Errorf("Matches should be false for other paths")
+	}
+	if (SelfCgroup{}).Matches("", "") {
+		t.Errorf("an unknown cgroup should not match processes without cgroup paths")
+	}
+}
@@ -6,14 +6,20 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"unsafe"
 
 	bpflib "github.com/iovisor/gobpf/elf"
 	_ "github.com/iovisor/gobpf/pkg/bpffs"
 	_ "github.com/iovisor/gobpf/pkg/cpuonline"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/api"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/containerutils"
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
 )
 
 type GadgetTracerManager struct {
@@ -24,8 +30,25 @@ type GadgetTracerManager struct {
 
 	// tracers by tracerId
 	tracers map[string]tracer
+
+	// containers outside of these namespaces are never traced
+	allowlist nsallowlist.Allowlist
+
+	// cgroup of the gadget, whose container is only traced by tracers
+	// with IncludeSelf
+	self containerutils.SelfCgroup
+
+	// bpffs where the maps of the tracers are pinned
+	bpfDir string
+
+	// set by Close: no tracer can be added anymore
+	closed bool
 }
 
+// DefaultBPFDir is the bpffs where the maps of the tracers are pinned, in
+// the gadget directory
+const DefaultBPFDir = "/sys/fs/bpf"
+
 type tracer struct {
 	tracerId string
 
@@ -38,13 +61,41 @@ type tracer struct {
 	mntnsSetMapPath    string
 }
 
+// close closes the maps of the tracer and removes them from the bpffs
+// bpfDir. The maps are freed by the kernel once the gadgets using them
+// closed them too.
+func (t *tracer) close(bpfDir string) {
+	if t.mapHolder != nil {
+		t.mapHolder.Close()
+	}
+	os.Remove(filepath.Join(bpfDir, t.cgroupIdSetMapPath))
+	os.Remove(filepath.Join(bpfDir, t.mntnsSetMapPath))
+}
+
+// namespaceMatches returns whether namespace is in the comma-separated list
+// of namespaces of a selector, an empty list selecting all namespaces
+func namespaceMatches(list, namespace string) bool {
+	if list == "" {
+		return true
+	}
+	for _, ns := range strings.Split(list, ",") {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
 func containerSelectorMatches(s *pb.ContainerSelector, c *pb.ContainerDefinition) bool {
-	if s.Namespace != "" && s.Namespace != c.Namespace {
+	if !namespaceMatches(s.Namespace, c.Namespace) {
 		return false
 	}
 	if s.Podname != "" && s.Podname != c.Podname {
 		return false
 	}
+	if s.PodUid != "" && s.PodUid != c.PodUid {
+		return false
+	}
 	if s.ContainerIndex != -1 && s.ContainerIndex != c.ContainerIndex {
 		return false
 	}
@@ -64,7 +115,21 @@ func containerSelectorMatches(s *pb.ContainerSelector, c *pb.ContainerDefinition
 	return true
 }
 
+// selected returns whether a tracer with selector s traces container c
+func (g *GadgetTracerManager) selected(s *pb.ContainerSelector, c *pb.ContainerDefinition) bool {
+	if !s.IncludeSelf && g.self.IsContainer(c.ContainerId) {
+		return false
+	}
+	return g.allowlist.Allowed(c.Namespace) && containerSelectorMatches(s, c)
+}
+
 func (g *GadgetTracerManager) AddTracer(ctx context.Context, req *pb.AddTracerRequest) (*pb.TracerID, error) {
+	if err := g.allowlist.Check(req.Selector.Namespace); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if g.closed {
+		return nil, status.Error(codes.Unavailable, "the gadget tracer manager is terminating")
+	}
 	tracerId := ""
 	if req.Id == "" {
 		b := make([]byte, 6)
@@ -109,7 +174,7 @@ func (g *GadgetTracerManager) AddTracer(ctx context.Context, req *pb.AddTracerRe
 	mntnsSetMap := m.Map("mntns_set")
 
 	for _, c := range g.containers {
-		if containerSelectorMatches(req.Selector, &c) {
+		if g.selected(req.Selector, &c) {
 			zero := uint32(0)
 			cgroupIdC := uint64(c.CgroupId)
 			if cgroupIdC != 0 {
@@ -144,9 +209,7 @@ func (g *GadgetTracerManager) RemoveTracer(ctx context.Context, tracerID *pb.Tra
 		return nil, fmt.Errorf("cannot remove tracer: unknown tracer %q", tracerID.Id)
 	}
 
-	t.mapHolder.Close()
-	os.Remove("/sys/fs/bpf/" + t.cgroupIdSetMapPath)
-	os.Remove("/sys/fs/bpf/" + t.mntnsSetMapPath)
+	t.close(g.bpfDir)
 
 	delete(g.tracers, tracerID.Id)
 	return &pb.RemoveTracerResponse{}, nil
@@ -161,7 +224,7 @@ func (g *GadgetTracerManager) AddContainer(ctx context.Context, containerDefinit
 	}
 
 	for _, t := range g.tracers {
-		if containerSelectorMatches(&t.containerSelector, containerDefinition) {
+		if g.selected(&t.containerSelector, containerDefinition) {
 			cgroupIdC := uint64(containerDefinition.CgroupId)
 			mntnsC := uint64(containerDefinition.Mntns)
 			zero := uint32(0)
@@ -189,7 +252,7 @@ func (g *GadgetTracerManager) RemoveContainer(ctx context.Context, containerDefi
 	}
 
 	for _, t := range g.tracers {
-		if containerSelectorMatches(&t.containerSelector, &c) {
+		if g.selected(&t.containerSelector, &c) {
 			cgroupIdC := uint64(c.CgroupId)
 			mntnsC := uint64(c.Mntns)
 			t.mapHolder.DeleteElement(t.cgroupIdSetMap, unsafe.Pointer(&cgroupIdC))
@@ -202,23 +265,30 @@ func (g *GadgetTracerManager) RemoveContainer(ctx context.Context, containerDefi
 }
 
 func (g *GadgetTracerManager) DumpState(ctx context.Context, req *pb.DumpStateRequest) (*pb.Dump, error) {
-	out := "List of containers:\n"
+	out := ""
+	if g.allowlist.Enabled() {
+		out += fmt.Sprintf("Allowed namespaces: %s\n", g.allowlist)
+	}
+	out += fmt.Sprintf("Cgroup of the gadget: %+v\n", g.self)
+	out += "List of containers:\n"
 	for i, c := range g.containers {
 		out += fmt.Sprintf("%v -> %+v\n", i, c)
 	}
 	out += "List of tracers:\n"
 	for i, t := range g.tracers {
-		out += fmt.Sprintf("%v -> %q/%q (#%d) Labels: \n",
+		out += fmt.Sprintf("%v -> %q/%q (#%d) PodUID: %q IncludeSelf: %t Labels: \n",
 			i,
 			t.containerSelector.Namespace,
 			t.containerSelector.Podname,
-			t.containerSelector.ContainerIndex)
+			t.containerSelector.ContainerIndex,
+			t.containerSelector.PodUid,
+			t.containerSelector.IncludeSelf)
 		for _, l := range t.containerSelector.Labels {
 			out += fmt.Sprintf("                  %v: %v\n", l.Key, l.Value)
 		}
 		out += fmt.Sprintf("        Matches:\n")
 		for _, c := range g.containers {
-			if containerSelectorMatches(&t.containerSelector, &c) {
+			if g.selected(&t.containerSelector, &c) {
 				out += fmt.Sprintf("        - %s/%s [Mntns=%v CgroupId=%v]\n", c.Namespace, c.Podname, c.Mntns, c.CgroupId)
 			}
 		}
@@ -226,13 +296,49 @@ func (g *GadgetTracerManager) DumpState(ctx context.Context, req *pb.DumpStateRe
 	return &pb.Dump{State: out}, nil
 }
 
-func NewServer(initialContainers []pb.ContainerDefinition) *GadgetTracerManager {
+func NewServer(initialContainers []pb.ContainerDefinition, allowlist nsallowlist.Allowlist, self containerutils.SelfCgroup) *GadgetTracerManager {
 	g := &GadgetTracerManager{
 		containers: make(map[string]pb.ContainerDefinition),
 		tracers:    make(map[string]tracer),
+		allowlist:  allowlist,
+		self:       self,
+		bpfDir:     DefaultBPFDir,
 	}
 	for _, containerDefinition := range initialContainers {
 		g.containers[containerDefinition.ContainerId] = containerDefinition
 	}
 	return g
 }
+
+// Close removes all the tracers, when the gadget pod terminates, so that
+// their maps pinned in the bpffs of the node are not leaked across restarts
+// of the gadget pod. The maps left by a gadget tracer manager that could not
+// terminate cleanly are removed as well. No tracer can be added afterwards.
+// The gRPC server must be stopped first.
+func (g *GadgetTracerManager) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	for id, t := range g.tracers {
+		t.close(g.bpfDir)
+		delete(g.tracers, id)
+	}
+
+	var errs []string
+	for _, pattern := range []string{"gadget/cgroupidset-*", "gadget/mntnsset-*"} {
+		leaked, err := filepath.Glob(filepath.Join(g.bpfDir, pattern))
+		if err != nil {
+			return err
+		}
+		for _, path := range leaked {
+			if err := os.Remove(path); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("cannot remove the maps of the tracers: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package gadgettracermanager
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/api"
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgettracermanager/containerutils"
+	"github.com/kinvolk/inspektor-gadget/pkg/nsallowlist"
+)
+
+// TestContainerSelectorPodUID tests that a selector with a pod UID does not
+// match a container of a different pod with the same name
+func TestContainerSelectorPodUID(t *testing.T) {
+	selector := &pb.ContainerSelector{
+		Namespace:      "default",
+		Podname:        "mypod",
+		PodUid:         "7f8c1a3e-0d2b-4c55-9e1a-3b6f2d0c9a10",
+		ContainerIndex: -1,
+	}
+
+	oldPod := &pb.ContainerDefinition{
+		ContainerId: "docker://0001",
+		Namespace:   "default",
+		Podname:     "mypod",
+		PodUid:      "7f8c1a3e-0d2b-4c55-9e1a-3b6f2d0c9a10",
+	}
+	newPod := &pb.ContainerDefinition{
+		ContainerId: "docker://0002",
+		Namespace:   "default",
+		Podname:     "mypod",
+		PodUid:      "c41d99b2-65f0-4b0e-8d6a-1f3e0a7b5c22",
+	}
+
+	if !containerSelectorMatches(selector, oldPod) {
+		t.Fatalf("selector %+v should match container %+v", selector, oldPod)
+	}
+	if containerSelectorMatches(selector, newPod) {
+		t.Fatalf("selector %+v should not match container %+v", selector, newPod)
+	}
+
+	// Without the pod UID, both pods are selected by name
+	selector.PodUid = ""
+	if !containerSelectorMatches(selector, newPod) {
+		t.Fatalf("selector %+v should match container %+v", selector, newPod)
+	}
+}
+
+// TestContainerSelectorNamespaces tests that a selector with several
+// namespaces matches the containers of each of them
+func TestContainerSelectorNamespaces(t *testing.T) {
+	selector := &pb.ContainerSelector{
+		Namespace:      "team-a,team-b",
+		ContainerIndex: -1,
+	}
+	table := []struct {
+		namespace string
+		matches   bool
+	}{
+		{"team-a", true},
+		{"team-b", true},
+		{"team", false},
+		{"team-a,team-b", false},
+		{"kube-system", false},
+	}
+	for _, entry := range table {
+		c := &pb.ContainerDefinition{ContainerId: "docker://0001", Namespace: entry.namespace}
+		if containerSelectorMatches(selector, c) != entry.matches {
+			t.Errorf("selector %+v matching container %+v: expected %v", selector, c, entry.matches)
+		}
+	}
+}
+
+// TestAllowedNamespaces tests that containers outside of the allowed
+// namespaces are never traced and that tracers selecting them are refused
+func TestAllowedNamespaces(t *testing.T) {
+	allowed := pb.ContainerDefinition{
+		ContainerId: "docker://0001",
+		Namespace:   "team-a",
+		Podname:     "web",
+	}
+	disallowed := pb.ContainerDefinition{
+		ContainerId: "docker://0002",
+		Namespace:   "kube-system",
+		Podname:     "coredns",
+	}
+	g := NewServer([]pb.ContainerDefinition{allowed, disallowed}, nsallowlist.Parse("team-a"), containerutils.SelfCgroup{})
+
+	all := &pb.ContainerSelector{ContainerIndex: -1}
+	if !g.selected(all, &allowed) {
+		t.Fatalf("container %+v in an allowed namespace should be selected", allowed)
+	}
+	if g.selected(all, &disallowed) {
+		t.Fatalf("container %+v outside of the allowed namespaces should not be selected", disallowed)
+	}
+	byName := &pb.ContainerSelector{Podname: "coredns", ContainerIndex: -1}
+	if g.selected(byName, &disallowed) {
+		t.Fatalf("container %+v outside of the allowed namespaces should not be selected by name", disallowed)
+	}
+
+	_, err := g.AddTracer(context.Background(), &pb.AddTracerRequest{
+		Id:       "test",
+		Selector: &pb.ContainerSelector{Namespace: "kube-system", ContainerIndex: -1},
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("tracer on a namespace outside of the allowlist: got %v, expected PermissionDenied", err)
+	}
+
+	// Without allowlist, all namespaces are traced
+	g = NewServer([]pb.ContainerDefinition{disallowed}, nil, containerutils.SelfCgroup{})
+	if !g.selected(all, &disallowed) {
+		t.Fatalf("container %+v should be selected without allowlist", disallowed)
+	}
+}
+
+// TestSelfExcluded tests that the container of the gadget is only traced by
+// tracers with IncludeSelf
+func TestSelfExcluded(t *testing.T) {
+	gadget := pb.ContainerDefinition{
+		ContainerId: "docker://5c8a1e3f",
+		Namespace:   "kube-system",
+		Podname:     "gadget-x7k2p",
+	}
+	other := pb.ContainerDefinition{
+		ContainerId: "docker://9b0d2c4a",
+		Namespace:   "kube-system",
+		Podname:     "coredns",
+	}
+	self := containerutils.SelfCgroup{
+		PathV1: "/kubepods/besteffort/pod3c5c1d26/5c8a1e3f",
+	}
+	g := NewServer([]pb.ContainerDefinition{gadget, other}, nil, self)
+
+	all := &pb.ContainerSelector{ContainerIndex: -1}
+	if g.selected(all, &gadget) {
+		t.Fatalf("container of the gadget %+v should not be selected by default", gadget)
+	}
+	if !g.selected(all, &other) {
+		t.Fatalf("container %+v should be selected", other)
+	}
+	byName := &pb.ContainerSelector{Podname: "gadget-x7k2p", ContainerIndex: -1}
+	if g.selected(byName, &gadget) {
+		t.Fatalf("container of the gadget %+v should not be selected by name", gadget)
+	}
+
+	all.IncludeSelf = true
+	if !g.selected(all, &gadget) {
+		t.Fatalf("container of the gadget %+v should be selected with IncludeSelf", gadget)
+	}
+}
+
+// TestClose tests that closing the gadget tracer manager removes all the
+// tracers and their pinned maps, including the ones leaked by a previous
+// gadget pod, and refuses new tracers
+func TestClose(t *testing.T) {
+	bpfDir, err := ioutil.TempDir("", "gadgettracermanager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bpfDir)
+	if err := os.Mkdir(filepath.Join(bpfDir, "gadget"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	pinned := []string{
+		"gadget/cgroupidset-1a2b", "gadget/mntnsset-1a2b",
+		"gadget/cgroupidset-3c4d", "gadget/mntnsset-3c4d",
+		// leaked by a previous gadget pod
+		"gadget/cgroupidset-5e6f", "gadget/mntnsset-5e6f",
+	}
+	for _, path := range pinned {
+		if err := ioutil.WriteFile(filepath.Join(bpfDir, path), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// not a map of a tracer
+	other := filepath.Join(bpfDir, "gadget", "other")
+	if err := ioutil.WriteFile(other, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewServer(nil, nil, containerutils.SelfCgroup{})
+	g.bpfDir = bpfDir
+	for _, id := range []string{"1a2b", "3c4d"} {
+		g.tracers[id] = tracer{
+			tracerId:           id,
+			cgroupIdSetMapPath: "gadget/cgroupidset-" + id,
+			mntnsSetMapPath:    "gadget/mntnsset-" + id,
+		}
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatalf("cannot close: %s", err)
+	}
+	if len(g.tracers) != 0 {
+		t.Fatalf("tracers %v not removed", g.tracers)
+	}
+	for _, path := range pinned {
+		if _, err := os.Stat(filepath.Join(bpfDir, path)); !os.IsNotExist(err) {
+			t.Fatalf("map %s not removed", path)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("file %s should not be removed: %s", other, err)
+	}
+
+	_, err = g.AddTracer(context.Background(), &pb.AddTracerRequest{
+		Id:       "7a8b",
+		Selector: &pb.ContainerSelector{ContainerIndex: -1},
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("adding a tracer after closing should fail with Unavailable, got %v", err)
+	}
+}
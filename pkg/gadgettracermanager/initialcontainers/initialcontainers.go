@@ -74,6 +74,7 @@ func InitialContainers() (arr []pb.ContainerDefinition, err error) {
 				Podname:        pod.GetName(),
 				ContainerIndex: int32(i),
 				Labels:         labels,
+				PodUid:         string(pod.GetUID()),
 			}
 			arr = append(arr, containerDef)
 		}
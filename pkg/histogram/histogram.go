@@ -0,0 +1,107 @@
+// Package histogram aggregates values in power-of-2 buckets and prints them
+// like the bcc tools do.
+package histogram
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+)
+
+// Histogram counts values in power-of-2 buckets: bucket 0 counts 0 and 1,
+// bucket i counts [2^i, 2^(i+1)-1]. It is safe for concurrent use.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets [64]uint64
+	count   uint64
+	sum     uint64
+}
+
+// Bucket is a range of values and the number of values added in it
+type Bucket struct {
+	Min   uint64 `json:"min"`
+	Max   uint64 `json:"max"`
+	Count uint64 `json:"count"`
+}
+
+// Add adds a value to the histogram
+func (h *Histogram) Add(value uint64) {
+	i := 0
+	if value > 1 {
+		i = bits.Len64(value) - 1
+	}
+	h.mu.Lock()
+	h.buckets[i]++
+	h.count++
+	h.sum += value
+	h.mu.Unlock()
+}
+
+// Count returns the number of values added
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the sum of the values added
+func (h *Histogram) Sum() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// Buckets returns the buckets from 0 to the highest non-empty one
+func (h *Histogram) Buckets() []Bucket {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	last := -1
+	for i, count := range h.buckets {
+		if count != 0 {
+			last = i
+		}
+	}
+	buckets := []Bucket{}
+	for i := 0; i <= last; i++ {
+		b := Bucket{Count: h.buckets[i]}
+		if i == 0 {
+			b.Max = 1
+		} else {
+			b.Min = 1 << uint(i)
+			b.Max = b.Min<<1 - 1
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+const barWidth = 40
+
+// String formats the histogram as bcc's print_log2_hist() does, with unit as
+// the label of the values (e.g. "usecs")
+func (h *Histogram) String(unit string) string {
+	buckets := h.Buckets()
+	if len(buckets) == 0 {
+		return ""
+	}
+	maxCount := uint64(0)
+	for _, b := range buckets {
+		if b.Count > maxCount {
+			maxCount = b.Count
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "     %-19s : count     distribution\n", unit)
+	for _, b := range buckets {
+		stars := 0
+		if maxCount > 0 {
+			stars = int(b.Count * barWidth / maxCount)
+		}
+		fmt.Fprintf(&sb, "%10d -> %-10d : %-8d |%-*s|\n",
+			b.Min, b.Max, b.Count, barWidth, strings.Repeat("*", stars))
+	}
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package histogram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuckets(t *testing.T) {
+	h := &Histogram{}
+	if s := h.String("usecs"); s != "" {
+		t.Fatalf("empty histogram printed as %q", s)
+	}
+
+	for _, v := range []uint64{0, 1, 5, 6, 7, 100} {
+		h.Add(v)
+	}
+	expected := []Bucket{
+		{0, 1, 2},
+		{2, 3, 0},
+		{4, 7, 3},
+		{8, 15, 0},
+		{16, 31, 0},
+		{32, 63, 0},
+		{64, 127, 1},
+	}
+	if buckets := h.Buckets(); !reflect.DeepEqual(buckets, expected) {
+		t.Fatalf("%v != %v", buckets, expected)
+	}
+	if h.Count() != 6 || h.Sum() != 119 {
+		t.Fatalf("count %d sum %d, expected 6 and 119", h.Count(), h.Sum())
+	}
+
+	expectedString := "" +
+		"     usecs               : count     distribution\n" +
+		"         0 -> 1          : 2        |**************************              |\n" +
+		"         2 -> 3          : 0        |                                        |\n" +
+		"         4 -> 7          : 3        |****************************************|\n" +
+		"         8 -> 15         : 0        |                                        |\n" +
+		"        16 -> 31         : 0        |                                        |\n" +
+		"        32 -> 63         : 0        |                                        |\n" +
+		"        64 -> 127        : 1        |*************                           |\n"
+	if s := h.String("usecs"); s != expectedString {
+		t.Fatalf("histogram:\n%s\nexpected:\n%s", s, expectedString)
+	}
+}
@@ -0,0 +1,115 @@
+// Package history keeps the summaries printed by the aggregate gadgets over
+// the last intervals, so that a client starting a gadget late can get the
+// recent history of the node instead of starting from zero.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBytes is the default maximum size of the records kept for one
+// gadget
+const DefaultMaxBytes = 4 << 20
+
+// SocketFile is the socket the history of the gadgets is served on, in the
+// gadget pod
+const SocketFile = "/run/gadgethistory.socket"
+
+type record struct {
+	time time.Time
+	line string
+}
+
+// Store is a rolling store of the records printed by a gadget. The records
+// older than maxAge are evicted, and the oldest records are evicted as well
+// when the records kept take more than maxBytes. It is safe for concurrent
+// use.
+type Store struct {
+	maxAge   time.Duration
+	maxBytes int
+
+	// now can be replaced in tests
+	now func() time.Time
+
+	mu      sync.Mutex
+	records []record
+	bytes   int
+}
+
+// NewStore returns a Store keeping the records of the last maxAge, in at
+// most maxBytes
+func NewStore(maxAge time.Duration, maxBytes int) *Store {
+	return &Store{
+		maxAge:   maxAge,
+		maxBytes: maxBytes,
+		now:      time.Now,
+	}
+}
+
+// Add adds a record printed now. A record larger than maxBytes is not kept.
+func (s *Store) Add(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(line) > s.maxBytes {
+		return
+	}
+	s.records = append(s.records, record{time: s.now(), line: line})
+	s.bytes += len(line)
+	s.evict()
+}
+
+// evict removes the records that are too old or that don't fit in maxBytes
+func (s *Store) evict() {
+	oldest := s.now().Add(-s.maxAge)
+	n := 0
+	for n < len(s.records) && (s.records[n].time.Before(oldest) || s.bytes > s.maxBytes) {
+		s.bytes -= len(s.records[n].line)
+		n++
+	}
+	s.records = s.records[n:]
+}
+
+// Since returns the records printed during the last d, oldest first
+func (s *Store) Since(d time.Duration) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict()
+	since := s.now().Add(-d)
+	var lines []string
+	for _, r := range s.records {
+		if r.time.Before(since) {
+			continue
+		}
+		lines = append(lines, r.line)
+	}
+	return lines
+}
+
+// Len returns the number of records kept
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// Bytes returns the size of the records kept
+func (s *Store) Bytes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bytes
+}
+
+// Mark adds "history": true to a record, a JSON object, so that kubectl-gadget
+// can tell the records of the history from the ones of the running gadget
+func Mark(line string) (string, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return "", fmt.Errorf("invalid record %q: %s", line, err)
+	}
+	fields["history"] = json.RawMessage("true")
+	buf, err := json.Marshal(fields)
+	return string(buf), err
+}
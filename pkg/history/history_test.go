@@ -0,0 +1,76 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStoreEvictsOldRecords(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(5*time.Minute, DefaultMaxBytes)
+	s.now = func() time.Time { return now }
+
+	for _, line := range []string{"a", "b", "c", "d"} {
+		s.Add(line)
+		now = now.Add(2 * time.Minute)
+	}
+	// a was added 8 minutes ago, b 6 minutes ago
+	if lines := s.Since(5 * time.Minute); !reflect.DeepEqual(lines, []string{"c", "d"}) {
+		t.Fatalf("unexpected records %v", lines)
+	}
+	if s.Len() != 2 || s.Bytes() != 2 {
+		t.Fatalf("old records not evicted: %d records, %d bytes", s.Len(), s.Bytes())
+	}
+	if lines := s.Since(3 * time.Minute); !reflect.DeepEqual(lines, []string{"d"}) {
+		t.Fatalf("unexpected records %v", lines)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if lines := s.Since(5 * time.Minute); len(lines) != 0 {
+		t.Fatalf("unexpected records %v", lines)
+	}
+	if s.Len() != 0 || s.Bytes() != 0 {
+		t.Fatalf("old records not evicted: %d records, %d bytes", s.Len(), s.Bytes())
+	}
+}
+
+func TestStoreEvictsOverMaxBytes(t *testing.T) {
+	s := NewStore(time.Hour, 10)
+
+	s.Add("0123")
+	s.Add("4567")
+	s.Add("89ab")
+	if lines := s.Since(time.Hour); !reflect.DeepEqual(lines, []string{"4567", "89ab"}) {
+		t.Fatalf("unexpected records %v", lines)
+	}
+	if s.Bytes() != 8 {
+		t.Fatalf("unexpected size %d", s.Bytes())
+	}
+
+	// Too large to be kept, the other records are not evicted for it
+	s.Add("0123456789abcdef")
+	if lines := s.Since(time.Hour); !reflect.DeepEqual(lines, []string{"4567", "89ab"}) {
+		t.Fatalf("unexpected records %v", lines)
+	}
+
+	s.Add("0123456789")
+	if lines := s.Since(time.Hour); !reflect.DeepEqual(lines, []string{"0123456789"}) {
+		t.Fatalf("unexpected records %v", lines)
+	}
+}
+
+func TestMark(t *testing.T) {
+	line, err := Mark(`{"timestamp":"2020-06-01T12:00:01Z","scope":"node","hits":900}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"history":true,"hits":900,"scope":"node","timestamp":"2020-06-01T12:00:01Z"}`
+	if line != expected {
+		t.Fatalf("%s != %s", line, expected)
+	}
+
+	if _, err := Mark("Tracing... Hit Ctrl-C to end."); err == nil {
+		t.Fatal("expected an error")
+	}
+}
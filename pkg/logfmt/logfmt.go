@@ -0,0 +1,239 @@
+// Package logfmt encodes gadget events as single key=value lines.
+package logfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Marshal returns the logfmt encoding of v, without trailing newline.
+//
+// v must be a struct or a pointer to a struct. Keys are taken from the json
+// struct tags, so that the same names are used in JSON and logfmt output.
+// Fields tagged with omitempty are skipped when empty. Maps and nested
+// structs are flattened with dotted keys ("local_pod_labels.app=web").
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("logfmt: cannot marshal nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("logfmt: cannot marshal %s", rv.Type())
+	}
+
+	var buf bytes.Buffer
+	encodeStruct(&buf, "", rv)
+	return buf.Bytes(), nil
+}
+
+func encodeStruct(buf *bytes.Buffer, prefix string, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name, omitEmpty := parseTag(field)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if omitEmpty && isEmpty(fv) {
+			continue
+		}
+		encodeValue(buf, prefix+name, fv)
+	}
+}
+
+func encodeValue(buf *bytes.Buffer, key string, rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			writePair(buf, key, "")
+			return
+		}
+		encodeValue(buf, key, rv.Elem())
+	case reflect.Struct:
+		encodeStruct(buf, key+".", rv)
+	case reflect.Map:
+		encodeMap(buf, key+".", rv)
+	case reflect.Slice, reflect.Array:
+		items := make([]string, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			items[i] = fmt.Sprint(rv.Index(i).Interface())
+		}
+		writePair(buf, key, strings.Join(items, ","))
+	default:
+		writePair(buf, key, fmt.Sprint(rv.Interface()))
+	}
+}
+
+func encodeMap(buf *bytes.Buffer, prefix string, rv reflect.Value) {
+	keys := make([]string, 0, rv.Len())
+	values := map[string]reflect.Value{}
+	for _, k := range rv.MapKeys() {
+		ks := fmt.Sprint(k.Interface())
+		keys = append(keys, ks)
+		values[ks] = rv.MapIndex(k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		encodeValue(buf, prefix+k, values[k])
+	}
+}
+
+func writePair(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if needsQuoting(value) {
+		buf.WriteString(strconv.Quote(value))
+	} else {
+		buf.WriteString(value)
+	}
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTag(field reflect.StructField) (name string, omitEmpty bool) {
+	name = field.Name
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return
+}
+
+func isEmpty(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
+
+type writer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	event  reflect.Type
+	fields map[string]bool // fields of event, true if always encoded
+	buffer []byte          // incomplete line
+}
+
+// NewWriter returns a writer encoding each JSON object written, one per line,
+// as logfmt before writing it to w. Objects with the fields of event, a
+// struct, are encoded in the order of its fields; the other objects, like the
+// errors, with their keys sorted. Lines that are not JSON objects are written
+// as is.
+func NewWriter(w io.Writer, event interface{}) io.Writer {
+	t := reflect.TypeOf(event)
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, omitEmpty := parseTag(field)
+		if name != "-" {
+			fields[name] = !omitEmpty
+		}
+	}
+	return &writer{w: w, event: t, fields: fields}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := w.buffer[:i]
+		if encoded, err := w.encode(line); err == nil {
+			line = encoded
+		}
+		if _, err := fmt.Fprintf(w.w, "%s\n", line); err != nil {
+			return 0, err
+		}
+		w.buffer = w.buffer[i+1:]
+	}
+}
+
+func (w *writer) encode(line []byte) ([]byte, error) {
+	var object map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&object); err != nil {
+		return nil, err
+	}
+	if w.isEvent(object) {
+		event := reflect.New(w.event)
+		if err := json.Unmarshal(line, event.Interface()); err == nil {
+			return Marshal(event.Interface())
+		}
+	}
+	var buf bytes.Buffer
+	encodeMap(&buf, "", reflect.ValueOf(object))
+	return buf.Bytes(), nil
+}
+
+// isEvent returns whether object has all the fields of the event always
+// encoded, and no other field than those of the event
+func (w *writer) isEvent(object map[string]interface{}) bool {
+	for key := range object {
+		if _, ok := w.fields[key]; !ok {
+			return false
+		}
+	}
+	for name, always := range w.fields {
+		if _, ok := object[name]; always && !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package logfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/kinvolk/inspektor-gadget/pkg/gadgets/networkpolicy/types"
+)
+
+func TestMarshal(t *testing.T) {
+	match, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inputFile := range match {
+		inputBytes, err := ioutil.ReadFile(inputFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := types.KubernetesConnectionEvent{}
+		err = json.Unmarshal(inputBytes, &event)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := Marshal(&event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		generatedOutput := string(out) + "\n"
+
+		goldenFile := inputFile[:len(inputFile)-len(".input")] + ".golden"
+		goldenOutputBytes, err := ioutil.ReadFile(goldenFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goldenOutput := string(goldenOutputBytes)
+
+		if generatedOutput != goldenOutput {
+			t.Errorf("Unexpected logfmt from %s:\n%s\nExpected:\n%s\n", inputFile, generatedOutput, goldenOutput)
+		}
+	}
+}
+
+func TestMarshalNotStruct(t *testing.T) {
+	if _, err := Marshal("connect"); err == nil {
+		t.Fatalf("Marshal of a string should fail")
+	}
+}
+
+type testEvent struct {
+	Type      string `json:"type"`
+	Node      string `json:"node,omitempty"`
+	Pid       uint32 `json:"pid"`
+	Comm      string `json:"comm"`
+	Container string `json:"container,omitempty"`
+}
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, testEvent{})
+	input := []string{
+		`{"comm":"curl","pid":4242,"type":"connect","node":"node1"}`,
+		`{"type":"error","message":"gadget failed","node":"node1"}`,
+		`{"type":"ready"}`,
+		`{"type":"count","count":18874368,"key":{"comm":"curl"}}`,
+		`not json`,
+	}
+	for _, line := range input {
+		// written in two parts to check that incomplete lines are kept
+		w.Write([]byte(line[:3]))
+		w.Write([]byte(line[3:] + "\n"))
+	}
+	expected := `type=connect node=node1 pid=4242 comm=curl
+message="gadget failed" node=node1 type=error
+type=ready
+count=18874368 key.comm=curl type=count
+not json
+`
+	if out.String() != expected {
+		t.Fatalf("Unexpected logfmt output:\n%s\nExpected:\n%s", out.String(), expected)
+	}
+}
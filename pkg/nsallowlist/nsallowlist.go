@@ -0,0 +1,79 @@
+// Package nsallowlist implements the --allowed-namespaces option of
+// "kubectl gadget deploy": the gadgets refuse to trace or to enrich events
+// with the containers of the other namespaces, whoever queries them.
+package nsallowlist
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvVar is the environment variable of the gadget pods with the
+// comma-separated list of allowed namespaces
+const EnvVar = "INSPEKTOR_GADGET_OPTION_ALLOWED_NAMESPACES"
+
+// Allowlist is a set of namespaces. A nil Allowlist allows all namespaces.
+type Allowlist map[string]struct{}
+
+// Parse parses a comma-separated list of namespaces. It returns nil, allowing
+// all namespaces, for an empty list.
+func Parse(list string) Allowlist {
+	var a Allowlist
+	for _, ns := range strings.Split(list, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+		if a == nil {
+			a = Allowlist{}
+		}
+		a[ns] = struct{}{}
+	}
+	return a
+}
+
+// FromEnv returns the allowlist the gadget pod was deployed with
+func FromEnv() Allowlist {
+	return Parse(os.Getenv(EnvVar))
+}
+
+// Enabled returns whether namespaces are restricted
+func (a Allowlist) Enabled() bool {
+	return a != nil
+}
+
+// Allowed returns whether the containers of namespace can be traced
+func (a Allowlist) Allowed(namespace string) bool {
+	if a == nil {
+		return true
+	}
+	_, ok := a[namespace]
+	return ok
+}
+
+// Check returns an error if a query selecting namespaces, a comma-separated
+// list, is not allowed. An empty list selects all namespaces: it is allowed
+// and only the allowed namespaces are traced.
+func (a Allowlist) Check(namespaces string) error {
+	if namespaces == "" {
+		return nil
+	}
+	for _, ns := range strings.Split(namespaces, ",") {
+		if !a.Allowed(ns) {
+			return fmt.Errorf("namespace %q is not allowed: Inspektor Gadget was deployed with --allowed-namespaces=%s", ns, a)
+		}
+	}
+	return nil
+}
+
+// String returns the comma-separated list of namespaces
+func (a Allowlist) String() string {
+	namespaces := make([]string, 0, len(a))
+	for ns := range a {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return strings.Join(namespaces, ",")
+}
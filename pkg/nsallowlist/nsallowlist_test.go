@@ -0,0 +1,41 @@
+package nsallowlist
+
+import (
+	"testing"
+)
+
+func TestAllowlist(t *testing.T) {
+	all := Parse("")
+	if all.Enabled() || !all.Allowed("kube-system") || all.Check("kube-system") != nil {
+		t.Fatalf("empty allowlist must allow all namespaces")
+	}
+
+	a := Parse("team-b, team-a,")
+	if !a.Enabled() {
+		t.Fatalf("allowlist %v not enabled", a)
+	}
+	if a.String() != "team-a,team-b" {
+		t.Fatalf("unexpected allowlist %q", a.String())
+	}
+	table := []struct {
+		namespace string
+		allowed   bool
+		checked   bool
+	}{
+		{"team-a", true, true},
+		{"team-b", true, true},
+		{"kube-system", false, false},
+		{"team-a,team-b", false, true},
+		{"team-a,kube-system", false, false},
+		// Selecting all namespaces is allowed, but no container is in ""
+		{"", false, true},
+	}
+	for _, entry := range table {
+		if a.Allowed(entry.namespace) != entry.allowed {
+			t.Errorf("Allowed(%q) = %v, expected %v", entry.namespace, !entry.allowed, entry.allowed)
+		}
+		if err := a.Check(entry.namespace); (err == nil) != entry.checked {
+			t.Errorf("Check(%q) = %v", entry.namespace, err)
+		}
+	}
+}
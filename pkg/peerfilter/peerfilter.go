@@ -0,0 +1,88 @@
+// Package peerfilter implements the --internal-only and --external-only
+// options of the network gadgets, printing only the connections whose peer
+// is in the cluster, a pod or a service, or outside of it. The peers are
+// classified by their IP address, against the pod and service CIDRs of the
+// cluster and the cluster IPs of the services.
+package peerfilter
+
+import (
+	"fmt"
+	"net"
+)
+
+// Class is the class of the peer of a connection
+type Class int
+
+const (
+	// Unknown is the class of an address that can't be parsed, like the
+	// missing address of an event
+	Unknown Class = iota
+	// Local is the class of the loopback and unspecified addresses: the
+	// connection does not leave the container
+	Local
+	// Internal is the class of the pods and the services of the cluster
+	Internal
+	// External is the class of the other addresses
+	External
+)
+
+func (c Class) String() string {
+	switch c {
+	case Local:
+		return "local"
+	case Internal:
+		return "internal"
+	case External:
+		return "external"
+	}
+	return "unknown"
+}
+
+// Classifier classifies the peers of the connections
+type Classifier struct {
+	nets []*net.IPNet
+	ips  map[string]bool
+}
+
+// New returns a Classifier classifying as Internal the addresses in one of
+// cidrs, like "10.2.0.0/16", or equal to one of ips, like the cluster IPs of
+// the services when the service CIDR is not known
+func New(cidrs []string, ips []string) (*Classifier, error) {
+	c := &Classifier{ips: map[string]bool{}}
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		c.nets = append(c.nets, n)
+	}
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", s)
+		}
+		c.ips[ip.String()] = true
+	}
+	return c, nil
+}
+
+// Classify returns the class of the peer with the address addr, like
+// "10.2.1.5" or "fd00::5"
+func (c *Classifier) Classify(addr string) Class {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return Unknown
+	}
+	if ip.IsLoopback() || ip.IsUnspecified() {
+		return Local
+	}
+	if c.ips[ip.String()] {
+		return Internal
+	}
+	for _, n := range c.nets {
+		if n.Contains(ip) {
+			return Internal
+		}
+	}
+	return External
+}
@@ -0,0 +1,49 @@
+package peerfilter
+
+import (
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	// Pod CIDRs of two nodes, a service CIDR, and a cluster IP out of them
+	c, err := New([]string{"10.2.0.0/24", "10.2.1.0/24", "10.96.0.0/12", "fd00:10:2::/64"}, []string{"172.17.0.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := []struct {
+		addr     string
+		expected Class
+	}{
+		{"10.2.0.5", Internal},
+		{"10.2.1.255", Internal},
+		{"10.96.0.1", Internal},
+		{"10.111.4.2", Internal},
+		{"172.17.0.10", Internal},
+		{"fd00:10:2::8", Internal},
+		// IPv4-mapped IPv6 address of a pod
+		{"::ffff:10.2.0.5", Internal},
+		{"10.2.2.5", External},
+		{"172.17.0.11", External},
+		{"8.8.8.8", External},
+		{"2001:4860:4860::8888", External},
+		{"127.0.0.1", Local},
+		{"::1", Local},
+		{"0.0.0.0", Local},
+		{"", Unknown},
+		{"example.com", Unknown},
+	}
+	for _, entry := range table {
+		if class := c.Classify(entry.addr); class != entry.expected {
+			t.Errorf("%q: got %s, expected %s", entry.addr, class, entry.expected)
+		}
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New([]string{"10.2.0.0"}, nil); err == nil {
+		t.Errorf("expected an error for a CIDR without prefix length")
+	}
+	if _, err := New(nil, []string{"10.2.0.0/24"}); err == nil {
+		t.Errorf("expected an error for a CIDR given as IP address")
+	}
+}
@@ -0,0 +1,93 @@
+// Package perfbuffer validates the size of the perf buffers through which
+// the gadgets send their events from the kernel to userspace. A buffer is
+// allocated for each CPU: a gadget with a buffer of N pages uses N+1 pages
+// of locked memory per CPU of the node. When a buffer is full, the kernel
+// drops the events and the gadget reports them as lost.
+package perfbuffer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvVar is the environment variable of the gadget pods with the
+	// default sizes set with "kubectl gadget deploy --perf-buffer-pages",
+	// like "execsnoop=128,swapin=256". The gadgets are named after their
+	// program in the gadget pod.
+	EnvVar = "INSPEKTOR_GADGET_OPTION_PERF_BUFFER_PAGES"
+
+	// DefaultPages is the size of the buffers of bcc and gobpf, used by
+	// the gadgets unless they set another default
+	DefaultPages = 8
+
+	// MaxPages is the largest size accepted, 4 MiB per CPU with 4 KiB
+	// pages
+	MaxPages = 1024
+)
+
+// CheckPages returns an error if the perf buffers cannot have this number
+// of pages. The kernel requires a power of 2.
+func CheckPages(pages int) error {
+	if pages < 1 || pages > MaxPages {
+		return fmt.Errorf("the number of pages must be between 1 and %d, not %d", MaxPages, pages)
+	}
+	if pages&(pages-1) != 0 {
+		return fmt.Errorf("the number of pages must be a power of 2, not %d", pages)
+	}
+	return nil
+}
+
+// ParseDefaults parses a comma-separated list of gadget=pages, like
+// "execsnoop=128,swapin=256". The gadgets must be in known.
+func ParseDefaults(list string, known []string) (map[string]int, error) {
+	defaults := map[string]int{}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.Split(entry, "=")
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%q is not gadget=pages", entry)
+		}
+		gadget := strings.TrimSpace(kv[0])
+		if !contains(known, gadget) {
+			return nil, fmt.Errorf("%q does not support setting the size of its perf buffers, only %s do", gadget, strings.Join(known, ", "))
+		}
+		pages, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%q: invalid number of pages", entry)
+		}
+		if err := CheckPages(pages); err != nil {
+			return nil, fmt.Errorf("%s: %s", gadget, err)
+		}
+		if _, ok := defaults[gadget]; ok {
+			return nil, fmt.Errorf("%s is given twice", gadget)
+		}
+		defaults[gadget] = pages
+	}
+	return defaults, nil
+}
+
+// FormatDefaults formats defaults as ParseDefaults parses them, sorted by
+// gadget
+func FormatDefaults(defaults map[string]int) string {
+	var entries []string
+	for gadget, pages := range defaults {
+		entries = append(entries, fmt.Sprintf("%s=%d", gadget, pages))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package perfbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckPages(t *testing.T) {
+	for _, pages := range []int{1, 8, 64, MaxPages} {
+		if err := CheckPages(pages); err != nil {
+			t.Errorf("%d: unexpected error: %s", pages, err)
+		}
+	}
+	for _, pages := range []int{-8, 0, 3, 100, MaxPages * 2} {
+		if err := CheckPages(pages); err == nil {
+			t.Errorf("%d: expected an error", pages)
+		}
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	known := []string{"execsnoop", "swapin", "rungadget"}
+	table := []struct {
+		description string
+		list        string
+		expected    map[string]int
+		err         string
+	}{
+		{
+			description: "empty",
+			list:        "",
+			expected:    map[string]int{},
+		},
+		{
+			description: "several gadgets",
+			list:        "execsnoop=128, swapin = 256",
+			expected:    map[string]int{"execsnoop": 128, "swapin": 256},
+		},
+		{
+			description: "unknown gadget",
+			list:        "opensnoop=64",
+			err:         `"opensnoop" does not support setting the size of its perf buffers, only execsnoop, swapin, rungadget do`,
+		},
+		{
+			description: "not a power of 2",
+			list:        "execsnoop=100",
+			err:         "execsnoop: the number of pages must be a power of 2, not 100",
+		},
+		{
+			description: "not a number",
+			list:        "execsnoop=1M",
+			err:         `"execsnoop=1M": invalid number of pages`,
+		},
+		{
+			description: "missing size",
+			list:        "execsnoop",
+			err:         `"execsnoop" is not gadget=pages`,
+		},
+		{
+			description: "duplicate",
+			list:        "swapin=64,swapin=128",
+			err:         "swapin is given twice",
+		},
+	}
+	for _, entry := range table {
+		defaults, err := ParseDefaults(entry.list, known)
+		if entry.err != "" {
+			if err == nil || err.Error() != entry.err {
+				t.Fatalf("%s: expected error %q, got %v", entry.description, entry.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", entry.description, err)
+		}
+		if !reflect.DeepEqual(defaults, entry.expected) {
+			t.Fatalf("%s: %v != %v", entry.description, defaults, entry.expected)
+		}
+		if s := FormatDefaults(defaults); entry.list != "" && s != "execsnoop=128,swapin=256" {
+			t.Fatalf("%s: formatted as %q", entry.description, s)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package podinformer keeps a local copy of the pods with an informer, so
+// that gadgets can enrich events with the status of the pods without
+// querying the API server for each event.
+package podinformer
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Status is the status of a pod and one of its containers
+type Status struct {
+	// Phase is the phase of the pod: Pending, Running, Succeeded, Failed
+	// or Unknown
+	Phase string
+
+	// Ready is whether the container passes its readiness probe
+	Ready bool
+}
+
+// Store is a local copy of the pods, kept up to date by an informer
+type Store struct {
+	lister   listersv1.PodLister
+	informer cache.SharedIndexInformer
+}
+
+// NewStore starts an informer on the pods of namespace, or of all namespaces
+// if empty, and of node if not empty. It returns once the informer is synced.
+// The informer runs until stop is closed.
+func NewStore(client kubernetes.Interface, namespace, node string, stop <-chan struct{}) (*Store, error) {
+	options := []informers.SharedInformerOption{
+		informers.WithNamespace(namespace),
+	}
+	if node != "" {
+		options = append(options, informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = "spec.nodeName=" + node
+		}))
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 10*time.Minute, options...)
+	pods := factory.Core().V1().Pods()
+	// Register the informer before starting the factory
+	informer := pods.Informer()
+	factory.Start(stop)
+	if !cacheSynced(stop, informer.HasSynced) {
+		return nil, fmt.Errorf("cannot sync pod informer")
+	}
+	return &Store{lister: pods.Lister(), informer: informer}, nil
+}
+
+func cacheSynced(stop <-chan struct{}, hasSynced func() bool) bool {
+	for !hasSynced() {
+		select {
+		case <-stop:
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return true
+}
+
+// PodStatus returns the status of a pod and one of its containers
+func PodStatus(pod *corev1.Pod, container string) Status {
+	s := Status{Phase: string(pod.Status.Phase)}
+	for _, c := range pod.Status.ContainerStatuses {
+		if c.Name == container {
+			s.Ready = c.Ready
+			break
+		}
+	}
+	return s
+}
+
+// Status returns the status of a pod and one of its containers. ok is false
+// if the pod is unknown.
+func (s *Store) Status(namespace, pod, container string) (status Status, ok bool) {
+	p, err := s.lister.Pods(namespace).Get(pod)
+	if err != nil {
+		return Status{}, false
+	}
+	return PodStatus(p, container), true
+}
+
+// OnUpdate calls f with the old and the new version of each pod updated from
+// now on. With the periodic resync, f is also called with unchanged pods.
+func (s *Store) OnUpdate(f func(old, new *corev1.Pod)) {
+	s.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, ok1 := oldObj.(*corev1.Pod)
+			new, ok2 := newObj.(*corev1.Pod)
+			if ok1 && ok2 {
+				f(old, new)
+			}
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package podinformer
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func fakePod(name string, phase corev1.PodPhase, ready bool) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: name},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "web", Ready: ready},
+				{Name: "sidecar", Ready: true},
+			},
+		},
+	}
+}
+
+func TestStatus(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		fakePod("running", corev1.PodRunning, true),
+		fakePod("starting", corev1.PodRunning, false),
+		fakePod("pending", corev1.PodPending, false),
+		fakePod("failed", corev1.PodFailed, false),
+	)
+	stop := make(chan struct{})
+	defer close(stop)
+	store, err := NewStore(client, "demo", "", stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		pod, container string
+		expected       Status
+		ok             bool
+	}{
+		{"running", "web", Status{"Running", true}, true},
+		{"starting", "web", Status{"Running", false}, true},
+		{"starting", "sidecar", Status{"Running", true}, true},
+		{"pending", "web", Status{"Pending", false}, true},
+		{"failed", "web", Status{"Failed", false}, true},
+		{"deleted", "web", Status{}, false},
+	}
+	for _, entry := range table {
+		status, ok := store.Status("demo", entry.pod, entry.container)
+		if status != entry.expected || ok != entry.ok {
+			t.Errorf("%s/%s: %+v %v, expected %+v %v", entry.pod, entry.container,
+				status, ok, entry.expected, entry.ok)
+		}
+	}
+}
+
+func TestOnUpdate(t *testing.T) {
+	client := fake.NewSimpleClientset(fakePod("web", corev1.PodRunning, true))
+	stop := make(chan struct{})
+	defer close(stop)
+	store, err := NewStore(client, "demo", "", stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates := make(chan string, 1)
+	store.OnUpdate(func(old, new *corev1.Pod) {
+		updates <- string(old.Status.Phase) + "->" + string(new.Status.Phase)
+	})
+	_, err = client.CoreV1().Pods("demo").UpdateStatus(fakePod("web", corev1.PodFailed, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case update := <-updates:
+		if update != "Running->Failed" {
+			t.Fatalf("unexpected update %q", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no update")
+	}
+}
@@ -0,0 +1,84 @@
+// Package procage finds when a process started, from the start time of its
+// task in /proc, to tell the events of a process starting up from the ones
+// of a process running for long.
+package procage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClockTicks is the unit of the start time in /proc/<pid>/stat, USER_HZ,
+// fixed to 100 by the kernel ABI on all architectures supported
+const ClockTicks = 100
+
+// ParseStartTime returns the start time of a process since the boot, from
+// the content of its /proc/<pid>/stat, like "1 (systemd) S 0 1 1 ...": the
+// 22nd field, counted after the command name that can contain spaces and
+// parentheses
+func ParseStartTime(stat string) (time.Duration, error) {
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("invalid stat %q", stat)
+	}
+	// Fields from the 3rd, the state
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("invalid stat %q", stat)
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid start time %q: %v", fields[19], err)
+	}
+	return time.Duration(ticks) * time.Second / ClockTicks, nil
+}
+
+// ParseUptime returns the time since the boot from the content of
+// /proc/uptime, like "350735.47 234388.90"
+func ParseUptime(uptime string) (time.Duration, error) {
+	fields := strings.Fields(uptime)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("invalid uptime %q", uptime)
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid uptime %q: %v", uptime, err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Age returns the time a process started startTime after the boot has been
+// running at uptime. The uptime has a precision of 10ms like the start time:
+// the age is never negative.
+func Age(startTime, uptime time.Duration) time.Duration {
+	if uptime < startTime {
+		return 0
+	}
+	return uptime - startTime
+}
+
+// Get returns the age of the process pid and the time it started, as now
+// minus its age
+func Get(pid int, now time.Time) (time.Duration, time.Time, error) {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	startTime, err := ParseStartTime(string(stat))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	uptime, err := ioutil.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	up, err := ParseUptime(string(uptime))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	age := Age(startTime, up)
+	return age, now.Add(-age), nil
+}
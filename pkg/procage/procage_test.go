@@ -0,0 +1,81 @@
+package procage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseStartTime(t *testing.T) {
+	table := []struct {
+		stat     string
+		expected time.Duration
+	}{
+		{
+			"1 (systemd) S 0 1 1 0 -1 4194560 47426 1462318 107 1222 148 211 5432 1893 20 0 1 0 12 175546368 2887 18446744073709551615",
+			120 * time.Millisecond,
+		},
+		// Spaces and parentheses in the name of the command
+		{
+			"4242 (a) b (c)) R 1 4242 4242 0 -1 4194304 93 0 0 0 0 0 0 0 20 0 1 0 35073547 7200768 201 18446744073709551615",
+			350735*time.Second + 470*time.Millisecond,
+		},
+	}
+	for _, entry := range table {
+		start, err := ParseStartTime(entry.stat)
+		if err != nil {
+			t.Fatalf("%q: %s", entry.stat, err)
+		}
+		if start != entry.expected {
+			t.Errorf("%q: %s != %s", entry.stat, start, entry.expected)
+		}
+	}
+	for _, stat := range []string{"", "1 (systemd", "1 (systemd) S 0 1", "1 (systemd) S 0 1 1 0 -1 4194560 47426 1462318 107 1222 148 211 5432 1893 20 0 1 0 x 175546368"} {
+		if _, err := ParseStartTime(stat); err == nil {
+			t.Errorf("invalid stat %q parsed", stat)
+		}
+	}
+}
+
+func TestParseUptime(t *testing.T) {
+	uptime, err := ParseUptime("350735.47 234388.90\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 350735*time.Second + 470*time.Millisecond; uptime.Round(time.Millisecond) != expected {
+		t.Errorf("%s != %s", uptime, expected)
+	}
+	if _, err := ParseUptime(""); err == nil {
+		t.Errorf("empty uptime parsed")
+	}
+}
+
+func TestAge(t *testing.T) {
+	table := []struct {
+		start, uptime, expected time.Duration
+	}{
+		{120 * time.Millisecond, 10 * time.Second, 9880 * time.Millisecond},
+		{10 * time.Second, 10 * time.Second, 0},
+		// Started within the precision of the uptime
+		{10*time.Second + 10*time.Millisecond, 10 * time.Second, 0},
+	}
+	for _, entry := range table {
+		if age := Age(entry.start, entry.uptime); age != entry.expected {
+			t.Errorf("Age(%s, %s) = %s != %s", entry.start, entry.uptime, age, entry.expected)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no /proc")
+	}
+	now := time.Now()
+	age, start, err := Get(os.Getpid(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age < 0 || !start.Equal(now.Add(-age)) {
+		t.Errorf("unexpected age %s and start %s", age, start)
+	}
+}